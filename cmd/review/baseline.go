@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/juparave/codereviewer/internal/app"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/juparave/codereviewer/internal/state"
+	"github.com/juparave/codereviewer/internal/suppress"
+	"github.com/spf13/cobra"
+)
+
+var (
+	baselineCommits     bool
+	baselineAppend      bool
+	baselineFromHistory bool
+)
+
+func newBaselineCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "baseline",
+		Short: "Record existing findings so later runs only report new ones",
+		Long: `Reviews the current state of every repository under root_path and records all findings in the baseline file (review.baseline_file, by default baseline.yaml next to the config file). Later runs leave out findings that match the baseline by repository, files and title.
+
+Reviewing whole repositories sends every supported file to the LLM, which can be slow and expensive on large codebases. Use --commits to baseline only the commits in the review window (see --since), or --from-history to record the findings of the stored reports without calling the LLM.
+
+The baseline is replaced unless --append is given.`,
+		Args: cobra.NoArgs,
+		RunE: runBaseline,
+	}
+
+	cmd.Flags().BoolVar(&baselineCommits, "commits", false, "Review only the commits in the review window instead of whole repositories")
+	cmd.Flags().BoolVar(&baselineFromHistory, "from-history", false, "Record the findings of the stored reports instead of reviewing")
+	cmd.Flags().BoolVar(&baselineAppend, "append", false, "Add to the existing baseline instead of replacing it")
+
+	return cmd
+}
+
+func runBaseline(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+	path := cfg.Review.BaselineFile
+	if path == "" {
+		return fmt.Errorf("review.baseline_file is not set")
+	}
+
+	list, err := suppress.Load(state.Files, path)
+	if err != nil {
+		return err
+	}
+	if !baselineAppend {
+		list.Entries = nil
+	}
+
+	var findings []domain.Finding
+	if baselineFromHistory {
+		records, err := report.NewFormatter(cfg.Reports).Findings(report.Query{})
+		if err != nil {
+			return err
+		}
+		for _, rec := range records {
+			findings = append(findings, rec.Finding)
+		}
+	} else {
+		// The old baseline must not hide the findings being re-recorded
+		cfg.Review.BaselineFile = ""
+		if findings, err = app.NewRunner(cfg).Baseline(cmd.Context(), baselineCommits); err != nil {
+			return err
+		}
+	}
+
+	added := 0
+	for _, f := range findings {
+		if list.Add(f, "baseline") {
+			added++
+		}
+	}
+	if err := list.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Recorded %d findings in %s (%d total)\n", added, path, len(list.Entries))
+	return nil
+}
@@ -0,0 +1,598 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/juparave/codereviewer/internal/app"
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/git"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/juparave/codereviewer/internal/suppress"
+	"github.com/spf13/cobra"
+)
+
+func newBrowseCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "browse [date]",
+		Short: "Interactively browse the findings of the latest run",
+		Long: `Opens a terminal UI listing the findings of the latest run (or the report for date, YYYY-MM-DD) grouped by repository and severity. Open a finding to read it, view the diff it was raised on, mark it as a false positive or accepted, or open its file in $VISUAL / $EDITOR.
+
+The diff is the one reviewed, stored with the report (see reports.appendix); for reports stored without it, the commits to the finding's files in the day before the run are shown instead.
+
+Marked findings are added to the suppressions file (review.suppressions_file) and left out of future reports; "review suppress --list" shows them. s opens the repository settings of "review config repos".`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeReportDate(report.ArtifactReport),
+		RunE:              runBrowse,
+	}
+}
+
+func runBrowse(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+	formatter := report.NewFormatter(cfg.Reports)
+
+	var rpt *domain.Report
+	if len(args) == 1 {
+		if rpt, err = formatter.Load(args[0]); err != nil {
+			return fmt.Errorf("no report data for %s: %w", args[0], err)
+		}
+	} else if rpt, err = latestRun(formatter); err != nil {
+		return err
+	}
+	if !rpt.HasFindings() {
+		fmt.Printf("No findings in the run for %s\n", rpt.Date.Format(report.DateLayout))
+		return nil
+	}
+	if err := formatter.LoadDiffs(rpt); err != nil {
+		return err
+	}
+
+	var suppressions *suppress.List
+	if cfg.Review.SuppressionsFile != "" {
+		if suppressions, err = suppress.Load(formatter.State(), cfg.Review.SuppressionsFile); err != nil {
+			return err
+		}
+	}
+
+	// Repository paths for diffs and the editor; browsing works without them
+	paths := make(map[string]string)
+	repos, err := app.NewRunner(cfg).Discover()
+	if err == nil {
+		for _, repo := range repos {
+			paths[repo.Name] = repo.Path
+		}
+	}
+
+	b := &browser{
+		ctx:          cmd.Context(),
+		config:       cfg,
+		repos:        repos,
+		git:          git.NewClient(nil),
+		report:       rpt,
+		findings:     groupFindings(rpt.Findings),
+		suppressions: suppressions,
+		paths:        paths,
+	}
+	program := tea.NewProgram(b, tea.WithAltScreen(), tea.WithContext(cmd.Context()),
+		tea.WithInput(cmd.InOrStdin()), tea.WithOutput(cmd.OutOrStdout()))
+	_, err = program.Run()
+	return err
+}
+
+// groupFindings orders findings by repository, then severity, highest first
+func groupFindings(findings []domain.Finding) []domain.Finding {
+	sorted := append([]domain.Finding(nil), findings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].RepoName != sorted[j].RepoName {
+			return sorted[i].RepoName < sorted[j].RepoName
+		}
+		return sorted[i].Severity.Rank() > sorted[j].Severity.Rank()
+	})
+	return sorted
+}
+
+// browserScreen is what the browser shows
+type browserScreen int
+
+const (
+	screenList    browserScreen = iota // The findings
+	screenFinding                      // One finding
+	screenDiff                         // The diff of a finding
+	screenFiles                        // Which file of a finding to edit
+	screenReason                       // The reason a finding is accepted
+)
+
+// browser is the terminal UI behind `browse`, a bubbletea model
+type browser struct {
+	ctx          context.Context
+	config       *config.Config
+	repos        []domain.Repository // For the settings screen
+	git          *git.Client
+	report       *domain.Report
+	findings     []domain.Finding
+	suppressions *suppress.List    // nil when review.suppressions_file is unset
+	paths        map[string]string // Repository name to path
+
+	screen  browserScreen
+	cursor  int      // Selected finding
+	file    int      // Selected file on screenFiles
+	lines   []string // Shown on screenFinding and screenDiff
+	scroll  int      // First line shown
+	reason  string   // Typed on screenReason
+	message string   // Outcome of the last action
+	width   int
+	height  int
+
+	settings *settingsScreen // Open settings screen, see config repos
+}
+
+// editorDone reports that the editor opened by edit exited
+type editorDone struct{ err error }
+
+func (b *browser) Init() tea.Cmd { return nil }
+
+func (b *browser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if b.settings != nil {
+		if size, ok := msg.(tea.WindowSizeMsg); ok {
+			b.width, b.height = size.Width, size.Height
+		}
+		_, cmd := b.settings.Update(msg)
+		if b.settings.done {
+			if b.settings.saved {
+				b.message = "Saved overrides to " + b.settings.path
+			}
+			b.settings = nil
+		}
+		return b, cmd
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.width, b.height = msg.Width, msg.Height
+		if b.screen == screenFinding {
+			b.lines = b.detail(b.findings[b.cursor])
+		}
+		return b, nil
+	case editorDone:
+		if msg.err != nil {
+			b.message = "Editor: " + msg.err.Error()
+		}
+		return b, nil
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return b, tea.Quit
+		}
+		switch b.screen {
+		case screenList:
+			return b.updateList(msg)
+		case screenFinding, screenDiff:
+			return b.updateText(msg)
+		case screenFiles:
+			return b.updateFiles(msg)
+		case screenReason:
+			return b.updateReason(msg)
+		}
+	}
+	return b, nil
+}
+
+func (b *browser) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		return b, tea.Quit
+	case "up", "k":
+		b.cursor = max(b.cursor-1, 0)
+	case "down", "j":
+		b.cursor = min(b.cursor+1, len(b.findings)-1)
+	case "pgup":
+		b.cursor = max(b.cursor-b.pageSize(), 0)
+	case "pgdown":
+		b.cursor = min(b.cursor+b.pageSize(), len(b.findings)-1)
+	case "home", "g":
+		b.cursor = 0
+	case "end", "G":
+		b.cursor = len(b.findings) - 1
+	case "enter", "right", "l":
+		b.openFinding()
+	case "s":
+		b.openSettings()
+	default:
+		return b.act(msg.String())
+	}
+	return b, nil
+}
+
+// openSettings shows the settings screen of the repositories
+func (b *browser) openSettings() {
+	if len(b.repos) == 0 {
+		b.message = "No repositories found under root_path"
+		return
+	}
+	settings, err := newSettingsScreen(b.config, b.repos)
+	if err != nil {
+		b.message = err.Error()
+		return
+	}
+	settings.height = b.height
+	b.settings = settings
+}
+
+func (b *browser) updateText(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	last := max(len(b.lines)-b.pageSize(), 0)
+	switch msg.String() {
+	case "q":
+		return b, tea.Quit
+	case "esc", "left", "h", "b":
+		if b.screen == screenDiff {
+			b.openFinding()
+		} else {
+			b.screen = screenList
+		}
+	case "up", "k":
+		b.scroll = max(b.scroll-1, 0)
+	case "down", "j":
+		b.scroll = min(b.scroll+1, last)
+	case "pgup":
+		b.scroll = max(b.scroll-b.pageSize(), 0)
+	case "pgdown", " ":
+		b.scroll = min(b.scroll+b.pageSize(), last)
+	case "home", "g":
+		b.scroll = 0
+	case "end", "G":
+		b.scroll = last
+	case "n":
+		if b.screen == screenFinding && b.cursor < len(b.findings)-1 {
+			b.cursor++
+			b.openFinding()
+		}
+	case "p":
+		if b.screen == screenFinding && b.cursor > 0 {
+			b.cursor--
+			b.openFinding()
+		}
+	default:
+		return b.act(msg.String())
+	}
+	return b, nil
+}
+
+// act runs the finding actions available on the list and finding screens
+func (b *browser) act(key string) (tea.Model, tea.Cmd) {
+	f := b.findings[b.cursor]
+	switch key {
+	case "d":
+		b.lines, b.scroll, b.screen = b.diff(f), 0, screenDiff
+	case "f":
+		b.message = b.mark(f, "false positive")
+	case "a":
+		b.reason, b.screen = "", screenReason
+	case "u":
+		b.message = b.unmark(f)
+	case "e":
+		if len(f.Files) > 1 {
+			b.file, b.screen = 0, screenFiles
+			return b, nil
+		}
+		return b, b.edit(f, 0)
+	}
+	if b.screen == screenFinding {
+		b.lines = b.detail(f)
+	}
+	return b, nil
+}
+
+func (b *browser) updateFiles(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	f := b.findings[b.cursor]
+	switch msg.String() {
+	case "esc":
+		b.openFinding()
+	case "up", "k":
+		b.file = max(b.file-1, 0)
+	case "down", "j":
+		b.file = min(b.file+1, len(f.Files)-1)
+	case "enter":
+		b.openFinding()
+		return b, b.edit(f, b.file)
+	}
+	return b, nil
+}
+
+func (b *browser) updateReason(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		b.openFinding()
+	case tea.KeyEnter:
+		reason := "accepted"
+		if why := strings.TrimSpace(b.reason); why != "" {
+			reason += ": " + why
+		}
+		b.message = b.mark(b.findings[b.cursor], reason)
+		b.openFinding()
+	case tea.KeyBackspace:
+		if r := []rune(b.reason); len(r) > 0 {
+			b.reason = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		b.reason += string(msg.Runes)
+	}
+	return b, nil
+}
+
+// openFinding shows the selected finding
+func (b *browser) openFinding() {
+	b.screen, b.scroll = screenFinding, 0
+	b.lines = b.detail(b.findings[b.cursor])
+}
+
+// pageSize is how many lines fit between the header and the footer
+func (b *browser) pageSize() int {
+	if b.height == 0 {
+		// Size not known yet
+		return 20
+	}
+	return max(b.height-4, 1)
+}
+
+func (b *browser) View() string {
+	if b.settings != nil {
+		return b.settings.View()
+	}
+	var sb strings.Builder
+	switch b.screen {
+	case screenList:
+		fmt.Fprintf(&sb, "Findings of %s (%d)\n\n", b.report.Date.Format(report.DateLayout), len(b.findings))
+		b.viewList(&sb)
+		b.footer(&sb, "↑/↓ move  enter open  d diff  f false positive  a accept  u unmark  e edit  s settings  q quit")
+	case screenFinding:
+		fmt.Fprintf(&sb, "Finding %d of %d\n\n", b.cursor+1, len(b.findings))
+		b.viewLines(&sb)
+		b.footer(&sb, "↑/↓ scroll  n/p next/prev  d diff  f false positive  a accept  u unmark  e edit  esc back  q quit")
+	case screenDiff:
+		fmt.Fprintf(&sb, "Diff of %s\n\n", b.findings[b.cursor].Title)
+		b.viewLines(&sb)
+		b.footer(&sb, "↑/↓ scroll  pgup/pgdown page  esc back  q quit")
+	case screenFiles:
+		f := b.findings[b.cursor]
+		sb.WriteString("Open which file?\n\n")
+		for i, file := range f.Files {
+			marker := "  "
+			if i == b.file {
+				marker = "> "
+			}
+			sb.WriteString(marker + file + "\n")
+		}
+		b.footer(&sb, "↑/↓ move  enter open  esc back")
+	case screenReason:
+		fmt.Fprintf(&sb, "Accept %s\n\nReason (optional): %s█\n", b.findings[b.cursor].Title, b.reason)
+		b.footer(&sb, "enter save  esc cancel")
+	}
+	return sb.String()
+}
+
+// viewList renders the page of the finding list around the cursor, with
+// a heading for each repository
+func (b *browser) viewList(sb *strings.Builder) {
+	var rows []string
+	selected := 0
+	repo := ""
+	for i, f := range b.findings {
+		if f.RepoName != repo {
+			repo = f.RepoName
+			rows = append(rows, "\x1b[1m"+repo+"\x1b[0m")
+		}
+		marker := "  "
+		if i == b.cursor {
+			marker, selected = "> ", len(rows)
+		}
+		rows = append(rows, b.truncate(fmt.Sprintf("%s%-6s %s  (%s)", marker, f.Severity, f.Title, b.status(f))))
+	}
+
+	page := b.pageSize()
+	first := max(min(selected-page/2, len(rows)-page), 0)
+	for _, row := range rows[first:min(first+page, len(rows))] {
+		sb.WriteString(row + "\n")
+	}
+}
+
+// viewLines renders the page of b.lines from b.scroll
+func (b *browser) viewLines(sb *strings.Builder) {
+	end := min(b.scroll+b.pageSize(), len(b.lines))
+	for _, line := range b.lines[b.scroll:end] {
+		sb.WriteString(line + "\n")
+	}
+}
+
+// footer ends a screen with the last action's outcome and the keys
+func (b *browser) footer(sb *strings.Builder, keys string) {
+	sb.WriteString("\n")
+	if b.message != "" {
+		sb.WriteString(b.message + "\n")
+	}
+	sb.WriteString("\x1b[2m" + keys + "\x1b[0m")
+}
+
+// truncate cuts line to the terminal width
+func (b *browser) truncate(line string) string {
+	if r := []rune(line); b.width > 0 && len(r) > b.width {
+		return string(r[:b.width-1]) + "…"
+	}
+	return line
+}
+
+// detail returns the lines describing f, wrapped to the terminal width
+func (b *browser) detail(f domain.Finding) []string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[%s] %s\n", f.Severity, f.Title)
+	fmt.Fprintf(&sb, "Repository: %s  ID: %s  Status: %s\n", f.RepoName, f.Fingerprint(), b.status(f))
+	if len(f.Files) > 0 {
+		fmt.Fprintf(&sb, "Files: %s\n", strings.Join(f.Files, ", "))
+	}
+	if f.PRNumber > 0 {
+		fmt.Fprintf(&sb, "Pull request: #%d %s\n", f.PRNumber, f.PRURL)
+	}
+	if f.RemovedIn != "" {
+		fmt.Fprintf(&sb, "Code removed in %s\n", domain.ShortHash(f.RemovedIn))
+	}
+	fmt.Fprintf(&sb, "\nIssue: %s\n\nFix: %s\n", f.Explanation, f.Action)
+	for _, name := range f.Extensions.Names() {
+		fmt.Fprintf(&sb, "\n%s: %s\n", name, f.Extensions[name])
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(sb.String(), "\n"), "\n") {
+		lines = append(lines, wrap(line, b.width)...)
+	}
+	return lines
+}
+
+// wrap breaks line at spaces into lines of at most width runes
+func wrap(line string, width int) []string {
+	if width <= 0 || len([]rune(line)) <= width {
+		return []string{line}
+	}
+	var lines []string
+	current := ""
+	for _, word := range strings.Fields(line) {
+		if current != "" && len([]rune(current))+1+len([]rune(word)) > width {
+			lines = append(lines, current)
+			current = ""
+		}
+		if current != "" {
+			current += " "
+		}
+		current += word
+	}
+	return append(lines, current)
+}
+
+// status describes whether a finding is suppressed, and why
+func (b *browser) status(f domain.Finding) string {
+	if b.suppressions == nil {
+		return "open"
+	}
+	id := f.Fingerprint()
+	for _, e := range b.suppressions.Entries {
+		if e.ID == id {
+			if e.Reason == "" {
+				return "suppressed"
+			}
+			return e.Reason
+		}
+	}
+	return "open"
+}
+
+// diff returns the colored diff f was raised on: the reviewed diffs of
+// its files stored with the report, or else the commits to them in the
+// day up to the run
+func (b *browser) diff(f domain.Finding) []string {
+	var out []string
+	for _, file := range f.Files {
+		found := false
+		for _, d := range b.report.Diffs {
+			if d.Repo == f.RepoName && (d.Path == file || d.OldPath == file) {
+				out = append(out, fmt.Sprintf("\x1b[1m%s @ %s\x1b[0m", d.Path, domain.ShortHash(d.Commit)))
+				out = append(out, strings.Split(strings.TrimRight(d.Content, "\n"), "\n")...)
+				out = append(out, "")
+				found = true
+			}
+		}
+		if !found {
+			out = append(out, b.gitChanges(f.RepoName, file)...)
+		}
+	}
+	for i, line := range out {
+		switch {
+		case strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++"):
+			out[i] = "\x1b[32m" + line + "\x1b[0m"
+		case strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---"):
+			out[i] = "\x1b[31m" + line + "\x1b[0m"
+		case strings.HasPrefix(line, "@@"):
+			out[i] = "\x1b[36m" + line + "\x1b[0m"
+		}
+	}
+	return out
+}
+
+// gitChanges returns the commits to file in the day up to the run, for
+// reports stored without their reviewed diffs
+func (b *browser) gitChanges(repo, file string) []string {
+	path, ok := b.paths[repo]
+	if !ok {
+		return []string{fmt.Sprintf("%s: the reviewed diff wasn't stored and repository %s isn't under root_path", file, repo)}
+	}
+	until := b.report.Date
+	changes, err := b.git.FileChanges(b.ctx, path, file, until.Add(-24*time.Hour), until)
+	switch {
+	case err != nil:
+		return []string{fmt.Sprintf("%s: %v", file, err)}
+	case changes == "":
+		return []string{file + ": the reviewed diff wasn't stored and no commits touched the file in the day before the run"}
+	}
+	return strings.Split(strings.TrimRight(changes, "\n"), "\n")
+}
+
+// mark suppresses f with reason, returning the outcome
+func (b *browser) mark(f domain.Finding, reason string) string {
+	if b.suppressions == nil {
+		return "review.suppressions_file is not set, can't mark findings"
+	}
+	b.suppressions.Remove(f.Fingerprint())
+	b.suppressions.Add(f, reason)
+	if err := b.suppressions.Save(); err != nil {
+		return fmt.Sprintf("Saving suppressions: %v", err)
+	}
+	return fmt.Sprintf("Marked %s: %s", f.Fingerprint(), reason)
+}
+
+// unmark lifts the suppression of f, returning the outcome
+func (b *browser) unmark(f domain.Finding) string {
+	if b.suppressions == nil || !b.suppressions.Remove(f.Fingerprint()) {
+		return "The finding isn't marked"
+	}
+	if err := b.suppressions.Save(); err != nil {
+		return fmt.Sprintf("Saving suppressions: %v", err)
+	}
+	return fmt.Sprintf("Unmarked %s", f.Fingerprint())
+}
+
+// edit opens file i of f in $VISUAL or $EDITOR, suspending the UI. The
+// path comes from the model's answer, so one leaving the repository is
+// refused.
+func (b *browser) edit(f domain.Finding, i int) tea.Cmd {
+	path, ok := b.paths[f.RepoName]
+	if !ok || len(f.Files) == 0 {
+		b.message = fmt.Sprintf("No file of %s found under root_path", f.RepoName)
+		return nil
+	}
+	file := f.Files[i]
+	if !filepath.IsLocal(filepath.FromSlash(file)) {
+		b.message = fmt.Sprintf("Not opening %s: it is outside the repository", file)
+		return nil
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// The editor may carry arguments, e.g. "code --wait"
+	argv := append(strings.Fields(editor), filepath.Join(path, filepath.FromSlash(file)))
+	cmd := exec.CommandContext(b.ctx, argv[0], argv[1:]...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg { return editorDone{err} })
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+func TestBrowserEditRefusesPathsOutsideRepository(t *testing.T) {
+	b := &browser{ctx: context.Background(), paths: map[string]string{"api": t.TempDir()}}
+	for _, file := range []string{"../secrets.env", "/etc/passwd", "a/../../b"} {
+		b.message = ""
+		if cmd := b.edit(domain.Finding{RepoName: "api", Files: []string{file}}, 0); cmd != nil {
+			t.Errorf("edit(%q) opened the editor", file)
+		}
+		if !strings.Contains(b.message, "outside the repository") {
+			t.Errorf("edit(%q) message = %q", file, b.message)
+		}
+	}
+}
+
+func TestBrowserDiffShowsReviewedDiff(t *testing.T) {
+	f := domain.Finding{RepoName: "api", Title: "Leak", Files: []string{"main.go"}}
+	b := &browser{
+		ctx:      context.Background(),
+		report:   &domain.Report{Diffs: []domain.ReportDiff{{Repo: "api", Path: "main.go", Commit: "abc1234", Content: "+leak()\n"}}},
+		findings: []domain.Finding{f},
+	}
+	b.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+	if b.screen != screenDiff {
+		t.Fatalf("screen = %d, want the diff", b.screen)
+	}
+	if !strings.Contains(strings.Join(b.lines, "\n"), "+leak()") {
+		t.Errorf("diff = %q, want the reviewed diff", b.lines)
+	}
+}
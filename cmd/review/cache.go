@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/juparave/codereviewer/internal/cache"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/spf13/cobra"
+)
+
+func newCacheCmd() *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Inspect and trim the cache",
+		Long: `CRA caches what it can work out again in one directory, cache.dir (default cra in the user cache directory, e.g. ~/.cache/cra), shared by every CRA process on the machine: scheduled runs, review serve and review watch can use it at once.
+
+  scan       repositories found under each root path, see scanner.cache
+  responses  the model's answers by prompt, see cache.responses
+  policy     the last policy fetched from policy.url, used when it can't be reached
+
+Once scan and responses grow past cache.max_mb (default 512), the least recently used entries are removed. The policy is never evicted.`,
+		Args: cobra.NoArgs,
+		RunE: runCacheInfo,
+	}
+
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:   "info",
+		Short: "Show the cache directory and the size of each namespace",
+		Args:  cobra.NoArgs,
+		RunE:  runCacheInfo,
+	})
+
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:   "prune",
+		Short: "Remove the least recently used entries over cache.max_mb now",
+		Args:  cobra.NoArgs,
+		RunE:  runCachePrune,
+	})
+
+	cacheCmd.AddCommand(&cobra.Command{
+		Use:   "clear [namespace]",
+		Short: "Remove every cached entry, or those of one namespace",
+		Long: `Removes every entry of the scan and responses namespaces, or of the one given, so the next run walks the root path and asks the model again. Other CRA processes finish their reads and writes first.
+
+The cached policy is only removed when named: review cache clear policy.`,
+		Example: `  review cache clear
+  review cache clear responses`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: cobra.FixedCompletions(append(slices.Clone(cache.Evictable), cache.Policy), cobra.ShellCompDirectiveNoFileComp),
+		RunE:              runCacheClear,
+	})
+
+	return cacheCmd
+}
+
+func openCache(cmd *cobra.Command) (*cache.Store, error) {
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return nil, err
+	}
+	store, err := cache.Open(cfg.Cache, nil)
+	if err != nil {
+		return nil, errs.Config(err, "set cache.dir")
+	}
+	return store, nil
+}
+
+func runCacheInfo(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	store, err := openCache(cmd)
+	if err != nil {
+		return err
+	}
+	usage, err := store.Usage()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Cache directory: %s\n", store.Dir())
+	fmt.Printf("Size limit: %s for %v\n\n", formatBytes(store.MaxSize()), cache.Evictable)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tENTRIES\tSIZE")
+	for _, u := range usage {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", u.Namespace, u.Entries, formatBytes(u.Bytes))
+	}
+	return w.Flush()
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	store, err := openCache(cmd)
+	if err != nil {
+		return err
+	}
+	ev, err := store.Evict(false)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d entries (%s), %s left\n", ev.Entries, formatBytes(ev.Bytes), formatBytes(ev.Left))
+	return nil
+}
+
+func runCacheClear(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	store, err := openCache(cmd)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		ev, err := store.Evict(true)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %d entries (%s)\n", ev.Entries, formatBytes(ev.Bytes))
+		return nil
+	}
+
+	namespace := args[0]
+	if namespace != cache.Policy && !slices.Contains(cache.Evictable, namespace) {
+		return errs.Config(fmt.Errorf("unknown cache namespace %q", namespace), "use scan, responses or policy")
+	}
+	removed, err := store.Clear(namespace)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d %s entries from %s\n", removed, namespace, filepath.Join(store.Dir(), namespace))
+	return nil
+}
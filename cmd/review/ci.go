@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/app"
+	"github.com/juparave/codereviewer/internal/ci"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/juparave/codereviewer/internal/review"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ciBase        string
+	ciHead        string
+	ciFailOn      string
+	ciCodeQuality string
+)
+
+func newCICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ci",
+		Short: "Review the change of a CI job (pull request, merge request or push)",
+		Long: `Reviews only the commits of the pull request, merge request or push that triggered the CI job, reads the repository and commits from the CI environment, and reports the findings where the CI system shows them:
+
+  GitHub Actions  an annotation per finding and a section in the job summary
+  GitLab CI       a code quality report (--codequality) to publish as
+                  artifacts:reports:codequality
+
+The job fails when a finding is at or above --fail-on. Nothing is written to the reports directory and no email is sent. The base commit must be in the checkout, e.g. actions/checkout with fetch-depth: 0 or GIT_DEPTH: 0.
+
+Outside a supported CI system, pass --head (and --base) to review any change the same way.`,
+		Example: `  review ci
+  review ci --fail-on Medium
+  review ci --base origin/main --head HEAD`,
+		Args: cobra.NoArgs,
+		RunE: runCI,
+	}
+
+	cmd.Flags().StringVar(&ciBase, "base", "", "Commit the change is compared against (default: from the CI environment)")
+	cmd.Flags().StringVar(&ciHead, "head", "", "Last commit of the change (default: from the CI environment)")
+	cmd.Flags().StringVar(&ciFailOn, "fail-on", "High", "Fail the job when a finding at or above this severity is found (High, Medium, Low; empty to never fail)")
+	cmd.Flags().StringVar(&ciCodeQuality, "codequality", "gl-code-quality-report.json", "Code quality report written on GitLab CI")
+
+	return cmd
+}
+
+func runCI(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	gate, err := parseFailOn(ciFailOn)
+	if err != nil {
+		return err
+	}
+
+	env, err := ci.Detect(os.Getenv)
+	if err != nil {
+		return err
+	}
+	if env == nil {
+		if ciHead == "" {
+			return errs.Config(fmt.Errorf("not running in GitHub Actions or GitLab CI"), "pass --head, and --base, to choose the change to review")
+		}
+		env = &ci.Env{Dir: "."}
+	}
+	if ciBase != "" {
+		env.Base = ciBase
+	}
+	if ciHead != "" {
+		env.Head = ciHead
+	}
+	if env.Head == "" {
+		return errs.Config(fmt.Errorf("no commit to review in the CI environment"), "pass --head")
+	}
+	if env.Dir == "" {
+		env.Dir = "."
+	}
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	revs := env.Revisions()
+	result, err := app.NewRunner(cfg).ReviewRange(cmd.Context(), env.Dir, revs...)
+	if err != nil {
+		return err
+	}
+
+	formatter := report.NewFormatter(cfg.Reports)
+	printFindings(formatter, result.Summary, result.Findings)
+	for _, failure := range result.Failures {
+		fmt.Fprintf(os.Stderr, "Warning: %s were not reviewed: %s\n", strings.Join(failure.Files, ", "), failure.Error)
+	}
+
+	switch env.Provider {
+	case ci.GitHub:
+		ci.WriteAnnotations(os.Stdout, result.Findings)
+		if env.SummaryPath != "" {
+			if err := ci.AppendSummary(env.SummaryPath, jobSummary(formatter, strings.Join(revs, " "), result)); err != nil {
+				return err
+			}
+		}
+	case ci.GitLab:
+		if ciCodeQuality != "" {
+			if err := ci.WriteCodeQuality(ciCodeQuality, result.Findings); err != nil {
+				return err
+			}
+		}
+	}
+
+	return checkFailOn(gate, result.Findings)
+}
+
+// jobSummary renders the review of a CI job as Markdown
+func jobSummary(formatter *report.Formatter, change string, result *review.Result) string {
+	var sb strings.Builder
+	sb.WriteString("## Code review\n\n")
+	fmt.Fprintf(&sb, "Reviewed `%s`.\n\n", change)
+	if result.Summary != "" {
+		sb.WriteString(result.Summary + "\n\n")
+	}
+	if len(result.Findings) == 0 {
+		sb.WriteString("✅ No issues found.\n")
+	}
+	for _, f := range result.Findings {
+		sb.WriteString(formatter.FormatFinding(f))
+	}
+	for _, failure := range result.Failures {
+		fmt.Fprintf(&sb, "\n⚠️ Not reviewed: %s (%s)\n", strings.Join(failure.Files, ", "), failure.Error)
+	}
+	return sb.String()
+}
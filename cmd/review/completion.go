@@ -0,0 +1,49 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/spf13/cobra"
+)
+
+// severityNames lists the accepted --fail-on values
+var severityNames = []string{"High", "Medium", "Low"}
+
+// completeProfile completes --profile with the profiles of the config file
+func completeProfile(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	cfg, err := config.Load(cfgFile)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return slices.Sorted(maps.Keys(cfg.Profiles)), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeReportDate completes the first argument with the extra values,
+// then the dates of the runs that stored a kind artifact, newest first
+func completeReportDate(kind string, extra ...string) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		cfg, err := config.Load(cfgFile)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		dates, err := report.NewFormatter(cfg.Reports).Store().Dates(kind)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		completions := append([]string(nil), extra...)
+		for i := len(dates) - 1; i >= 0; i-- {
+			if strings.HasPrefix(dates[i], toComplete) {
+				completions = append(completions, dates[i])
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+	}
+}
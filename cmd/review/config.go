@@ -0,0 +1,516 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/diff"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/netproxy"
+	"github.com/juparave/codereviewer/internal/notify"
+	"github.com/juparave/codereviewer/internal/scanner"
+	"github.com/juparave/codereviewer/internal/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	schemaOutput   string
+	schemaRepoFile bool
+	encryptPass    bool
+)
+
+func newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect and validate the configuration",
+	}
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "Validate the configuration without running a review",
+		Long:  `Loads the config file, runs every validation check (unknown keys, provider, API key, strictness, SMTP reachability) and prints a per-field pass/fail table with suggested fixes.`,
+		Args:  cobra.NoArgs,
+		RunE:  runConfigValidate,
+	})
+
+	encryptCmd := &cobra.Command{
+		Use:   "encrypt [value]",
+		Short: "Encrypt a secret for use as a !vault value in the config file",
+		Long: `Encrypts a value (read from stdin when not given, so it stays out of your shell history) with the vault key and prints it as a !vault value for config.yaml, e.g. for email.smtp_password or review.api_key.
+
+The key is read from $CRA_VAULT_KEY or ~/.config/cra/vault.key; the key file is created on first use. Values are decrypted when the config is loaded.
+
+With --passphrase, the key is derived from the passphrase in $CRA_VAULT_PASSPHRASE instead, and loading the config needs the same variable: nothing but the passphrase has to follow the config file to a new machine, e.g. from a dotfiles repository.`,
+		Example: `  printf '%s' "$SMTP_PASSWORD" | review config encrypt
+  printf '%s' "$OPENAI_API_KEY" | CRA_VAULT_PASSPHRASE=... review config encrypt --passphrase`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runConfigEncrypt,
+	}
+	encryptCmd.Flags().BoolVar(&encryptPass, "passphrase", false, "Derive the key from $"+config.VaultPassphraseEnv+" instead of using the vault key")
+	configCmd.AddCommand(encryptCmd)
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "keyring <service>/<account> [value]",
+		Short: "Store a secret in the OS keyring for use as a keyring:// value in the config file",
+		Long: `Stores a value (read from stdin when not given, so it stays out of your shell history) in the OS keyring, macOS Keychain, Secret Service on Linux or Windows Credential Manager, and prints the keyring:// value that reads it back, e.g. for review.api_key or email.smtp_password.
+
+Any string in the config file or a CRA_* variable can be a keyring:// value; it is read when the config is loaded, so the secret never sits in plaintext on disk.`,
+		Example: `  printf '%s' "$OPENAI_API_KEY" | review config keyring cra/openai
+  # then in config.yaml:  api_key: keyring://cra/openai`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: runConfigKeyring,
+	})
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "env",
+		Short: "List the CRA_* environment variables that override config fields",
+		Long: `Lists the environment variable for every config field that can be set from the environment, e.g. CRA_ROOT_PATH, CRA_REVIEW_PROVIDER or CRA_EMAIL_SMTP_PASSWORD, and whether it is set. Values are not printed.
+
+Precedence is command-line flags, then environment variables, then the config file, then the defaults. Lists take comma-separated values; maps and lists of entries such as overrides can only be set in the file.`,
+		Args: cobra.NoArgs,
+		RunE: runConfigEnv,
+	})
+
+	configCmd.AddCommand(&cobra.Command{
+		Use:   "languages",
+		Short: "List the reviewed file extensions and their language labels",
+		Long: `Lists every reviewed file extension with the language label sent to the model, and whether it is built in or set by languages in the config or the org policy.
+
+Extensions are matched case-insensitively. A .cra.yaml can add or remove extensions for its repository.
+
+Files whose extension isn't listed are reviewed as the extension their name or first line implies, when that one is listed: Dockerfile.prod as .dockerfile, Makefile as .mk, Jenkinsfile as .groovy, and extensionless scripts by their shebang (a bash script as .sh, python3 as .py, node as .js).`,
+		Args: cobra.NoArgs,
+		RunE: runConfigLanguages,
+	})
+
+	schemaCmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Write a JSON Schema of the config file for editor completion and validation",
+		Long: `Writes a JSON Schema of the config file to ` + config.SchemaFileName + ` next to it, and adds a modeline pointing at it to the top of the config file, so editors using yaml-language-server (VS Code's YAML extension, Neovim, Helix and others) complete and validate every field.
+
+With --repo-file, writes the schema of .cra.yaml to ` + config.RepoSchemaFileName + ` instead; point a repository's .cra.yaml at it with a "# yaml-language-server: $schema=<path>" modeline.
+
+Editors flag !vault values unless told about the tag: add "!vault scalar" to the yaml.customTags setting. Run the command again after upgrading to pick up new fields.`,
+		Example: `  review config schema
+  review config schema --output - > cra.schema.json`,
+		Args: cobra.NoArgs,
+		RunE: runConfigSchema,
+	}
+	schemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "Write the schema to this file, or - for stdout, without touching the config file")
+	schemaCmd.Flags().BoolVar(&schemaRepoFile, "repo-file", false, "Write the schema of .cra.yaml instead")
+	configCmd.AddCommand(schemaCmd)
+
+	configCmd.AddCommand(newConfigReposCmd())
+
+	return configCmd
+}
+
+func runConfigEncrypt(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	var value string
+	if len(args) == 1 {
+		value = args[0]
+	} else {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		value = strings.TrimRight(string(data), "\r\n")
+	}
+	if value == "" {
+		return fmt.Errorf("nothing to encrypt")
+	}
+
+	if encryptPass {
+		passphrase := os.Getenv(config.VaultPassphraseEnv)
+		if passphrase == "" {
+			return errs.Config(fmt.Errorf("no passphrase: %s is empty", config.VaultPassphraseEnv), "export "+config.VaultPassphraseEnv+" with the passphrase, the same one loading the config will use")
+		}
+		encrypted, err := config.EncryptPassphrase(passphrase, value)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s %s\n", config.VaultTag, encrypted)
+		return nil
+	}
+
+	key, err := config.LoadVaultKey()
+	if err != nil && os.Getenv(config.VaultKeyEnv) == "" {
+		if _, statErr := os.Stat(config.VaultKeyPath()); os.IsNotExist(statErr) {
+			if key, err = config.GenerateVaultKey(config.VaultKeyPath()); err == nil {
+				fmt.Fprintf(os.Stderr, "Created vault key %s; keep it private and back it up\n", config.VaultKeyPath())
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := config.Encrypt(key, value)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s\n", config.VaultTag, encrypted)
+	return nil
+}
+
+func runConfigKeyring(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	service, account, ok := config.ParseKeyringRef(config.KeyringScheme + strings.TrimPrefix(args[0], config.KeyringScheme))
+	if !ok {
+		return errs.Config(fmt.Errorf("invalid keyring entry %q", args[0]), "name it <service>/<account>, e.g. cra/openai")
+	}
+
+	var value string
+	if len(args) == 2 {
+		value = args[1]
+	} else {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		value = strings.TrimRight(string(data), "\r\n")
+	}
+	if value == "" {
+		return fmt.Errorf("nothing to store")
+	}
+
+	if err := config.SetKeyring(service, account, value); err != nil {
+		return err
+	}
+	fmt.Println(config.KeyringRef(service, account))
+	return nil
+}
+
+func runConfigEnv(cmd *cobra.Command, args []string) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VARIABLE\tFIELD\tTYPE\tSET")
+	for _, v := range config.EnvVars() {
+		set := ""
+		if _, ok := os.LookupEnv(v.Name); ok {
+			set = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", v.Name, v.Field, v.Type, set)
+	}
+	return w.Flush()
+}
+
+func runConfigSchema(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	schema, name := config.Schema, config.SchemaFileName
+	if schemaRepoFile {
+		schema, name = config.RepoFileSchema, config.RepoSchemaFileName
+	}
+	data, err := schema()
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if schemaOutput == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	configPath := config.ResolvePath(cfgFile)
+	path := schemaOutput
+	if path == "" {
+		path = filepath.Join(filepath.Dir(configPath), name)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", path)
+
+	if schemaOutput != "" || schemaRepoFile {
+		return nil
+	}
+	added, err := config.AddSchemaModeline(configPath, "./"+name)
+	if err != nil {
+		return err
+	}
+	if added {
+		fmt.Printf("Pointed %s at it\n", configPath)
+	}
+	return nil
+}
+
+func runConfigLanguages(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	languages := diff.Languages(cfg.Languages)
+	exts := make([]string, 0, len(languages))
+	for ext := range languages {
+		exts = append(exts, ext)
+	}
+	slices.Sort(exts)
+
+	configured := make(map[string]bool, len(cfg.Languages))
+	for ext := range cfg.Languages {
+		configured[diff.NormalizeExtension(ext)] = true
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "EXTENSION\tLANGUAGE\tSOURCE")
+	for _, ext := range exts {
+		source := "built-in"
+		if configured[ext] {
+			source = "languages"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", ext, languages[ext], source)
+	}
+	return w.Flush()
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cfg, checks := config.Diagnose(cfgFile)
+
+	if cfg != nil && len(cfg.Scanner.Repos) > 0 {
+		checks = append(checks, checkRepoFilter(cfg.Scanner.Repos))
+	}
+	if cfg != nil && (len(cfg.Repos.Include) > 0 || len(cfg.Repos.Exclude) > 0) {
+		checks = append(checks, checkRepoList(cfg.Repos))
+	}
+	if cfg != nil && len(cfg.Exclude) > 0 {
+		checks = append(checks, checkExclude(cfg.Exclude))
+	}
+	if cfg != nil && len(cfg.Authors) > 0 {
+		checks = append(checks, checkAuthors(cfg.Authors))
+	}
+	if cfg != nil && (!cfg.Branches.IsZero() || len(cfg.Branches.Repos) > 0) {
+		checks = append(checks, checkBranches(cfg.Branches)...)
+	}
+	if cfg != nil {
+		for i, o := range cfg.Overrides {
+			checks = append(checks, checkOverride(i, o))
+		}
+	}
+
+	// Probe the SMTP server only when there is a host to dial, through
+	// proxy.smtp as runs would; a bad proxy setting already failed its check
+	if cfg != nil && cfg.Email.Enabled && cfg.Email.SMTPHost != "" && netproxy.Configure(cfg.Proxy) == nil {
+		checks = append(checks, checkSMTP(cfg.Email))
+	}
+
+	if failures := printChecks(checks); failures > 0 {
+		return fmt.Errorf("configuration has %d problem(s)", failures)
+	}
+	fmt.Println("\nConfiguration is valid.")
+	return nil
+}
+
+// printChecks renders check results as a table and returns the failure count
+func printChecks(checks []config.Check) int {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STATUS\tFIELD\tDETAIL\tFIX")
+	failures := 0
+	for _, c := range checks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Status, c.Field, c.Message, c.Fix)
+		if c.Status == config.CheckFail {
+			failures++
+		}
+	}
+	w.Flush()
+	return failures
+}
+
+func checkRepoFilter(patterns []string) config.Check {
+	if _, err := scanner.NewFilter(patterns); err != nil {
+		return config.Check{
+			Field:   "scanner.repos",
+			Status:  config.CheckFail,
+			Message: err.Error(),
+			Fix:     "fix the glob, or the regular expression between slashes",
+		}
+	}
+	return config.Check{
+		Field:   "scanner.repos",
+		Status:  config.CheckPass,
+		Message: strings.Join(patterns, ", "),
+	}
+}
+
+func checkRepoList(list config.RepoListConfig) config.Check {
+	if _, err := scanner.NewListFilter(list.Include, list.Exclude); err != nil {
+		return config.Check{
+			Field:   "repos",
+			Status:  config.CheckFail,
+			Message: err.Error(),
+			Fix:     "fix the glob, or the regular expression between slashes",
+		}
+	}
+	return config.Check{
+		Field:   "repos",
+		Status:  config.CheckPass,
+		Message: fmt.Sprintf("%d included, %d excluded patterns", len(list.Include), len(list.Exclude)),
+	}
+}
+
+func checkExclude(patterns []string) config.Check {
+	if _, err := util.CompileIgnore(patterns); err != nil {
+		return config.Check{
+			Field:   "exclude",
+			Status:  config.CheckFail,
+			Message: err.Error(),
+			Fix:     "fix the gitignore-style pattern",
+		}
+	}
+	return config.Check{
+		Field:   "exclude",
+		Status:  config.CheckPass,
+		Message: strings.Join(patterns, ", "),
+	}
+}
+
+func checkAuthors(patterns []string) config.Check {
+	if _, err := util.CompilePatterns(patterns, true); err != nil {
+		return config.Check{
+			Field:   "authors",
+			Status:  config.CheckFail,
+			Message: err.Error(),
+			Fix:     "fix the glob, or the regular expression between slashes",
+		}
+	}
+	return config.Check{
+		Field:   "authors",
+		Status:  config.CheckPass,
+		Message: strings.Join(patterns, ", "),
+	}
+}
+
+// checkBranches validates the branch patterns and per-repo overrides
+func checkBranches(branches config.BranchConfig) []config.Check {
+	check := func(field string, selection config.BranchSelection) config.Check {
+		if _, err := util.CompilePatterns(selection.Include, false); err != nil {
+			return config.Check{
+				Field:   field,
+				Status:  config.CheckFail,
+				Message: err.Error(),
+				Fix:     "fix the glob, or the regular expression between slashes",
+			}
+		}
+		detail := strings.Join(selection.Include, ", ")
+		switch {
+		case selection.IsZero():
+			detail = "every ref"
+		case selection.DefaultBranch && detail == "":
+			detail = "default branch"
+		case selection.DefaultBranch:
+			detail = "default branch, " + detail
+		}
+		return config.Check{Field: field, Status: config.CheckPass, Message: detail}
+	}
+
+	checks := []config.Check{check("branches", branches.BranchSelection)}
+	for i, override := range branches.Repos {
+		field := fmt.Sprintf("branches.repos[%d]", i)
+		if override.Repo == "" {
+			checks = append(checks, config.Check{
+				Field:   field,
+				Status:  config.CheckFail,
+				Message: "repo is not set",
+				Fix:     "set repo to a repository glob or /regexp/",
+			})
+			continue
+		}
+		if c := checkRepoFilter([]string{override.Repo}); c.Status == config.CheckFail {
+			c.Field = field
+			checks = append(checks, c)
+			continue
+		}
+		c := check(field, override.BranchSelection)
+		if c.Status == config.CheckPass {
+			c.Message = override.Repo + " -> " + c.Message
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+// checkOverride validates one overrides entry
+func checkOverride(i int, o config.RepoOverride) config.Check {
+	field := fmt.Sprintf("overrides[%d]", i)
+	failed := func(message, fix string) config.Check {
+		return config.Check{Field: field, Status: config.CheckFail, Message: message, Fix: fix}
+	}
+
+	if o.Repo == "" {
+		return failed("repo is not set", "set repo to a repository glob or /regexp/")
+	}
+	if _, err := scanner.NewFilter([]string{o.Repo}); err != nil {
+		return failed(err.Error(), "fix the glob, or the regular expression between slashes")
+	}
+	if o.Strictness != "" && !slices.Contains(config.StrictnessLevels, o.Strictness) {
+		return failed(fmt.Sprintf("invalid strictness %q", o.Strictness), "use one of: "+strings.Join(config.StrictnessLevels, ", "))
+	}
+	if o.Risk != "" && !slices.Contains(config.RiskProfiles, o.Risk) {
+		return failed(fmt.Sprintf("invalid risk %q", o.Risk), "use one of: "+strings.Join(config.RiskProfiles, ", "))
+	}
+	if _, err := util.CompilePatterns(o.Paths, false); err != nil {
+		return failed("paths: "+err.Error(), "fix the glob, or the regular expression between slashes")
+	}
+	if _, err := util.CompileIgnore(o.Exclude); err != nil {
+		return failed("exclude: "+err.Error(), "fix the gitignore-style pattern")
+	}
+
+	var settings []string
+	if o.Skip {
+		settings = append(settings, "skipped")
+	}
+	if o.Strictness != "" {
+		settings = append(settings, "strictness "+o.Strictness)
+	}
+	if o.Risk != "" {
+		settings = append(settings, "risk "+o.Risk)
+	}
+	if o.Backend != "" {
+		settings = append(settings, "backend "+o.Backend)
+	}
+	if len(o.Paths) > 0 {
+		settings = append(settings, "paths "+strings.Join(o.Paths, ", "))
+	}
+	if len(o.Exclude) > 0 {
+		settings = append(settings, "exclude "+strings.Join(o.Exclude, ", "))
+	}
+	if len(settings) == 0 {
+		settings = append(settings, "no changes")
+	}
+	return config.Check{Field: field, Status: config.CheckPass, Message: o.Repo + " -> " + strings.Join(settings, "; ")}
+}
+
+func checkSMTP(cfg config.EmailConfig) config.Check {
+	svc, err := notify.NewService(cfg, nil, nil)
+	if err == nil {
+		err = svc.CheckConnection()
+	}
+	if err != nil {
+		return config.Check{
+			Field:   "email.smtp_connection",
+			Status:  config.CheckFail,
+			Message: err.Error(),
+			Fix:     "check smtp_host/smtp_port, proxy.smtp and your network connection",
+		}
+	}
+	return config.Check{
+		Field:   "email.smtp_connection",
+		Status:  config.CheckPass,
+		Message: fmt.Sprintf("reachable at %s:%d", cfg.SMTPHost, cfg.SMTPPort),
+	}
+}
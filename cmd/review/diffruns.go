@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/juparave/codereviewer/internal/state"
+	"github.com/juparave/codereviewer/internal/suppress"
+	"github.com/spf13/cobra"
+)
+
+var diffRunsHTML string
+
+func newDiffRunsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff-runs <run-a> <run-b>",
+		Short: "Compare the findings of two stored reports",
+		Long: `Compares the findings of two stored reports, given by date (YYYY-MM-DD) or "latest", and lists which are new in run-b, which were resolved since run-a, which persist and which were suppressed or baselined since, e.g. to measure a cleanup sprint.
+
+Findings match by ID (same repository, title and files, following files renamed since). Each run only reviews the changes of its window, so a finding of run-a counts as resolved once a later run up to run-b reviewed a change to its files without reporting it again (which needs the reviewed diffs kept with reports.appendix); a finding whose code nobody touched since persists. Persisting findings are shown as last reported.`,
+		Example: `  review diff-runs 2026-03-02 latest
+  review diff-runs 2026-03-02 2026-03-16 --html sprint.html`,
+		Args: cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if len(args) >= 2 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeReportDate(report.ArtifactReport, "latest")(cmd, nil, toComplete)
+		},
+		RunE: runDiffRuns,
+	}
+
+	cmd.Flags().StringVar(&diffRunsHTML, "html", "", "Also write the comparison as an HTML page to this path")
+	cmd.MarkFlagFilename("html", "html")
+
+	return cmd
+}
+
+func runDiffRuns(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+	formatter := report.NewFormatter(cfg.Reports)
+
+	from, err := loadRun(formatter, args[0])
+	if err != nil {
+		return err
+	}
+	to, err := loadRun(formatter, args[1])
+	if err != nil {
+		return err
+	}
+	if to.Date.Before(from.Date) {
+		from, to = to, from
+	}
+
+	between, err := runsBetween(formatter, from, to)
+	if err != nil {
+		return err
+	}
+	suppressed, err := suppressedIDs(formatter, cfg)
+	if err != nil {
+		return err
+	}
+
+	comparison := report.Compare(from, to, between, suppressed)
+	fmt.Print(formatter.FormatComparison(comparison))
+	if diffRunsHTML == "" {
+		return nil
+	}
+	if err := os.WriteFile(diffRunsHTML, []byte(formatter.ComparisonHTML(comparison)), 0o644); err != nil {
+		return fmt.Errorf("writing comparison: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Comparison written to %s\n", diffRunsHTML)
+	return nil
+}
+
+// runsBetween loads the reports of the runs after from up to to, with
+// the diffs each reviewed, which tell Compare what was resolved
+func runsBetween(formatter *report.Formatter, from, to *domain.Report) ([]*domain.Report, error) {
+	history, err := formatter.History()
+	if err != nil {
+		return nil, err
+	}
+	var between []*domain.Report
+	for _, rpt := range history {
+		if rpt.Date.After(from.Date) && rpt.Date.Before(to.Date) {
+			between = append(between, rpt)
+		}
+	}
+	for _, rpt := range append(between, to) {
+		if err := formatter.LoadDiffs(rpt); err != nil {
+			return nil, err
+		}
+	}
+	return between, nil
+}
+
+// suppressedIDs returns whether a finding ID is in the suppressions or
+// the baseline, which runs leave out of their reports
+func suppressedIDs(formatter *report.Formatter, cfg *config.Config) (func(string) bool, error) {
+	var lists []*suppress.List
+	if path := cfg.Review.SuppressionsFile; path != "" {
+		list, err := suppress.Load(formatter.State(), path)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	if path := cfg.Review.BaselineFile; path != "" {
+		list, err := suppress.Load(state.Files, path)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	return func(id string) bool {
+		for _, list := range lists {
+			if list.Contains(id) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// loadRun loads the stored report of date, or the latest run for "latest"
+func loadRun(formatter *report.Formatter, date string) (*domain.Report, error) {
+	if date == "latest" {
+		return latestRun(formatter)
+	}
+	rpt, err := formatter.Load(date)
+	if err != nil {
+		return nil, fmt.Errorf("no report data for %s: %w", date, err)
+	}
+	return rpt, nil
+}
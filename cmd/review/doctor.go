@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/juparave/codereviewer/internal/app"
+	"github.com/spf13/cobra"
+)
+
+func newDoctorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check that git, the LLM provider, SMTP and the reports directory work",
+		Long:  `Runs environment health checks up front (git version, a tiny test generation against the LLM provider, SMTP connection and authentication, reports directory permissions) so failures don't surface mid-run.`,
+		Args:  cobra.NoArgs,
+		RunE:  runDoctor,
+	}
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	runner := app.NewRunner(cfg)
+	if failures := printChecks(runner.Doctor(cmd.Context())); failures > 0 {
+		return fmt.Errorf("%d health check(s) failed", failures)
+	}
+	fmt.Println("\nAll checks passed.")
+	return nil
+}
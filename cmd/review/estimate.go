@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/juparave/codereviewer/internal/app"
+	"github.com/juparave/codereviewer/internal/review"
+	"github.com/spf13/cobra"
+)
+
+func newEstimateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "estimate",
+		Short: "Estimate chunks, tokens and cost of a review without calling the LLM",
+		Long:  `Runs scanning, commit discovery and diff extraction, then prints the estimated number of LLM calls, tokens and cost per provider so you can sanity-check before an expensive run.`,
+		Args:  cobra.NoArgs,
+		RunE:  runEstimate,
+	}
+}
+
+func runEstimate(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	est, err := app.NewRunner(cfg).Estimate(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Repositories: %d\n", est.Repositories)
+	fmt.Printf("Commits:      %d\n", est.Commits)
+	fmt.Printf("Files:        %d\n", est.Files)
+	if cfg.Review.LLMDisabled() {
+		fmt.Println("\nThe LLM is disabled (no_llm or provider: none): no LLM calls, no cost.")
+		return nil
+	}
+	fmt.Printf("Chunks:       %d\n", est.Chunks)
+	fmt.Printf("Tokens:       ~%d input, ~%d output\n\n", est.InputTokens, est.OutputTokens)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tPROVIDER\tMODEL\tINPUT $/1M\tOUTPUT $/1M\tEST. COST")
+	for _, price := range review.Pricing {
+		marker := ""
+		if price.Provider == cfg.Review.Provider && price.Model == cfg.Review.Model {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.2f\t%.2f\t$%.4f\n",
+			marker, price.Provider, price.Model, price.Input, price.Output, est.Cost(price))
+	}
+	w.Flush()
+	fmt.Println("\n* configured model. Prices are approximate list prices.")
+
+	return nil
+}
+
+// printPrompts prints each prompt a review would send, headed by the files
+// it covers and its estimated size
+func printPrompts(ctx context.Context, runner *app.Runner) error {
+	prompts, err := runner.Prompts(ctx)
+	if err != nil {
+		return err
+	}
+	if len(prompts) == 0 {
+		fmt.Println("No diffs to review, no prompt would be sent")
+		return nil
+	}
+
+	total := 0
+	for i, p := range prompts {
+		files := make([]string, len(p.Diffs))
+		for j, d := range p.Diffs {
+			files[j] = d.RepoName + "/" + d.FilePath
+		}
+		fmt.Printf("===== Prompt %d/%d: %d files, ~%d tokens =====\n", i+1, len(prompts), len(p.Diffs), p.Tokens)
+		fmt.Printf("Files: %s\n\n", strings.Join(files, ", "))
+		fmt.Println(p.Text)
+		total += p.Tokens
+	}
+
+	fmt.Printf("===== Total: ~%d input tokens =====\n", total)
+	if len(prompts) > 1 {
+		fmt.Println("Prompts after the first also carry the summary and findings of earlier prompts, which depend on the LLM's answers and are left out here.")
+	}
+	return nil
+}
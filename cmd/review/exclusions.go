@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var exclusionsJSON bool
+
+func newExplainExclusionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain-exclusions [date]",
+		Short: "List the repositories and files the latest run left out, and why",
+		Long: `Prints every repository and file left out of the latest run (or the report for date, YYYY-MM-DD) with the reason:
+
+  repo_list        the repository is outside repos.include or matches repos.exclude
+  repo_filter      the repository doesn't match scanner.repos / --repos
+  repo_skip        skip is set for the repository in overrides or a .cra.yaml (its own or a parent directory's)
+  in_progress      a rebase or similar was in progress and scanner.in_progress is skip
+  git_error        the repository's history couldn't be read
+  extension        the file type isn't reviewed (see languages)
+  exclude_pattern  the path matches an exclude pattern: built in, such as vendor/, or from exclude, overrides or .cra.yaml
+  override_paths   the path is outside the paths of the repository's override or settings files
+  size             the diff was reviewed only in part, truncated to review.limits.max_diff_lines or max_file_bytes
+  sampled          the file was left out of the sample on a day over review.sampling.max_lines
+  budget           the file didn't fit review.limits.token_budget
+
+Repositories with no commits in the window aren't listed; see "review repo list".`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeReportDate(report.ArtifactReport),
+		RunE:              runExplainExclusions,
+	}
+
+	cmd.Flags().BoolVar(&exclusionsJSON, "json", false, "Print the exclusions as JSON")
+
+	return cmd
+}
+
+func runExplainExclusions(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+	formatter := report.NewFormatter(cfg.Reports)
+
+	var rpt *domain.Report
+	if len(args) == 1 {
+		if rpt, err = formatter.Load(args[0]); err != nil {
+			return fmt.Errorf("no report data for %s: %w", args[0], err)
+		}
+	} else if rpt, err = latestRun(formatter); err != nil {
+		return err
+	}
+
+	exclusions := append([]domain.Exclusion(nil), rpt.Exclusions...)
+	sort.SliceStable(exclusions, func(i, j int) bool {
+		if exclusions[i].Repo != exclusions[j].Repo {
+			return exclusions[i].Repo < exclusions[j].Repo
+		}
+		return exclusions[i].Path < exclusions[j].Path
+	})
+
+	if exclusionsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(exclusions)
+	}
+
+	date := rpt.Date.Format(report.DateLayout)
+	if len(exclusions) == 0 {
+		fmt.Printf("Nothing was left out of the run for %s\n", date)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tPATH\tREASON\tDETAIL")
+	counts := make(map[string]int)
+	for _, e := range exclusions {
+		path := e.Path
+		if path == "" {
+			path = "(whole repository)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Repo, path, e.Reason, e.Detail)
+		counts[e.Reason]++
+	}
+	w.Flush()
+
+	reasons := make([]string, 0, len(counts))
+	for reason := range counts {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	summary := make([]string, len(reasons))
+	for i, reason := range reasons {
+		summary[i] = fmt.Sprintf("%s %d", reason, counts[reason])
+	}
+	fmt.Printf("\n%d exclusions in the run for %s (%s)\n", len(exclusions), date, strings.Join(summary, ", "))
+	return nil
+}
+
+// latestRun returns the most recently generated stored report, which is
+// not the latest date when a past window was rerun with --until
+func latestRun(formatter *report.Formatter) (*domain.Report, error) {
+	reports, err := formatter.History()
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, fmt.Errorf("no reports found, run a review first")
+	}
+
+	latest := reports[len(reports)-1]
+	for _, rpt := range reports {
+		if rpt.Provenance.GeneratedAt.After(latest.Provenance.GeneratedAt) {
+			latest = rpt
+		}
+	}
+	return latest, nil
+}
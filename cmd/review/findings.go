@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	findingsRepo     string
+	findingsSeverity string
+	findingsFrom     string
+	findingsTo       string
+	findingsJSON     bool
+)
+
+func newFindingsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "findings [search text]",
+		Short: "Search findings across stored reports",
+		Long:  `Lists findings from the stored report data, filtered by repository, minimum severity, date range and free text (matched against the title, explanation, suggested action and file paths).`,
+		Example: `  review findings --repo services/api --severity High
+  review findings --from 2025-01-01 --to 2025-01-31 "sql injection"
+  review findings --json | jq '.[].title'`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runFindings,
+	}
+
+	cmd.Flags().StringVar(&findingsRepo, "repo", "", "Only findings in this repository")
+	cmd.Flags().StringVar(&findingsSeverity, "severity", "", "Only findings at or above this severity (High, Medium, Low)")
+	cmd.Flags().StringVar(&findingsFrom, "from", "", "First report date to include (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&findingsTo, "to", "", "Last report date to include (YYYY-MM-DD)")
+	cmd.Flags().BoolVar(&findingsJSON, "json", false, "Print the findings as JSON")
+
+	return cmd
+}
+
+func runFindings(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	q := report.Query{Repo: findingsRepo}
+	if len(args) == 1 {
+		q.Text = args[0]
+	}
+	if findingsSeverity != "" {
+		severity, ok := domain.ParseSeverity(findingsSeverity)
+		if !ok {
+			return fmt.Errorf("invalid --severity %q, use High, Medium or Low", findingsSeverity)
+		}
+		q.MinSeverity = severity
+	}
+	var err error
+	if q.From, err = parseDateFlag("from", findingsFrom); err != nil {
+		return err
+	}
+	if q.To, err = parseDateFlag("to", findingsTo); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	formatter := report.NewFormatter(cfg.Reports)
+	records, err := formatter.Findings(q)
+	if err != nil {
+		return err
+	}
+
+	if findingsJSON {
+		if records == nil {
+			records = []report.Record{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+
+	if len(records) == 0 {
+		fmt.Println("No matching findings.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDATE\tSEVERITY\tREPOSITORY\tTITLE\tFILES")
+	for _, rec := range records {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			rec.ID, rec.Date.Format(report.DateLayout), formatter.Label(rec.Severity),
+			rec.RepoName, rec.Title, strings.Join(rec.Files, ", "))
+	}
+	return w.Flush()
+}
+
+// parseDateFlag parses an optional YYYY-MM-DD flag value
+func parseDateFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(report.DateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s %q, use YYYY-MM-DD", name, value)
+	}
+	return t, nil
+}
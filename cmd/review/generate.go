@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	generateFrom   string
+	generateFormat string
+	generateView   string
+	generateOutput string
+)
+
+// renderFormats lists the accepted generate-report --format values
+var renderFormats = []string{"html", "md", "terminal", "pdf"}
+
+func newGenerateReportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate-report <file>",
+		Short: "Render an exported JSON report as HTML, Markdown or PDF",
+		Long: `Renders a report exported elsewhere, such as on a CI runner, without scanning or calling the LLM, so the review can run where the code is and the report be presented where it's read.
+
+The file (or - for stdin) is either the report printed by --format json or the run manifest written by --output; a manifest carries no summary. Severity labels and colors come from the local reports.severity, and the manager view's trend from the local report history, when there is one.
+
+PDF is printed with a headless Chrome or Chromium found on PATH.`,
+		Example: `  review --format json > report.json                # on the CI runner
+  review generate-report --from json report.json -o report.html
+  review generate-report --from json report.json --format pdf --view manager -o summary.pdf`,
+		Args: cobra.ExactArgs(1),
+		RunE: runGenerateReport,
+	}
+
+	cmd.Flags().StringVar(&generateFrom, "from", "json", "Format of the input file; json is the only one")
+	cmd.Flags().StringVarP(&generateFormat, "format", "f", "html", "Output format: "+strings.Join(renderFormats, ", "))
+	cmd.Flags().StringVar(&generateView, "view", string(domain.ViewEngineer), "Report view: engineer or manager")
+	cmd.Flags().StringVarP(&generateOutput, "output", "o", "", "Write to this file instead of stdout (required for pdf)")
+
+	cmd.RegisterFlagCompletionFunc("from", cobra.FixedCompletions([]string{"json"}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(renderFormats, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("view", cobra.FixedCompletions([]string{string(domain.ViewEngineer), string(domain.ViewManager)}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.MarkFlagFilename("output", "html", "md", "pdf")
+
+	return cmd
+}
+
+func runGenerateReport(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if generateFrom != "json" {
+		return errs.Config(fmt.Errorf("invalid --from %q", generateFrom), "export the report with --format json and pass --from json")
+	}
+	if !slices.Contains(renderFormats, generateFormat) {
+		return errs.Config(fmt.Errorf("invalid --format %q", generateFormat), "use one of: "+strings.Join(renderFormats, ", "))
+	}
+	view, ok := domain.ParseView(generateView)
+	if !ok {
+		return errs.Config(fmt.Errorf("invalid --view %q", generateView), "use engineer or manager")
+	}
+	if generateFormat == "pdf" && generateOutput == "" {
+		return errs.Config(fmt.Errorf("--format pdf needs --output"), "pass --output report.pdf")
+	}
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if args[0] == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(args[0])
+	}
+	if err != nil {
+		return err
+	}
+	rpt, err := report.DecodeJSON(data)
+	if err != nil {
+		return err
+	}
+
+	formatter := report.NewFormatter(cfg.Reports)
+	var previous *domain.Report
+	if view == domain.ViewManager {
+		previous = formatter.Previous(rpt.Date)
+	}
+
+	var out []byte
+	switch generateFormat {
+	case "md":
+		if view == domain.ViewManager {
+			out = []byte(formatter.FormatManager(rpt, previous))
+		} else {
+			out = []byte(formatter.Markdown(rpt))
+		}
+	case "terminal":
+		out = []byte(formatter.Text(rpt))
+	default:
+		page := formatter.ToHTML(rpt)
+		if view == domain.ViewManager {
+			page = formatter.ManagerHTML(rpt, previous)
+		}
+		out = []byte(page)
+		if generateFormat == "pdf" {
+			if out, err = report.PDF(cmd.Context(), page); err != nil {
+				return errs.Config(err, "install Chrome or Chromium, or render --format html and print it from a browser")
+			}
+		}
+	}
+
+	if generateOutput == "" {
+		_, err := os.Stdout.Write(out)
+		return err
+	}
+	if err := os.WriteFile(generateOutput, out, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", generateOutput)
+	return nil
+}
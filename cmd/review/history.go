@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var historyView string
+
+func newHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:               "history [date|latest]",
+		Short:             "List past reports or print one",
+		Long:              `Without arguments, lists stored reports with their finding counts and the repositories covered. With a date (YYYY-MM-DD) or "latest", prints that day's report.`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeReportDate(report.ArtifactReport, "latest"),
+		RunE:              runHistory,
+	}
+
+	cmd.Flags().StringVar(&historyView, "view", "engineer", "Report view to print: engineer (full) or manager (condensed summary)")
+
+	return cmd
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+	formatter := report.NewFormatter(cfg.Reports)
+
+	if len(args) == 1 {
+		view, ok := domain.ParseView(historyView)
+		if !ok {
+			return fmt.Errorf("invalid --view %q, use engineer or manager", historyView)
+		}
+		return printReport(formatter, args[0], view)
+	}
+
+	reports, err := formatter.History()
+	if err != nil {
+		return err
+	}
+	if len(reports) == 0 {
+		fmt.Printf("No reports in %s\n", cfg.Reports.OutputDir)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tFINDINGS\tHIGH\tMEDIUM\tLOW\tCOMMITS\tREPOSITORIES")
+	for _, rpt := range reports {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			rpt.Date.Format(report.DateLayout), rpt.TotalFindings(),
+			rpt.HighCount(), rpt.MediumCount(), rpt.LowCount(),
+			rpt.CommitCount, strings.Join(rpt.Repositories, ", "))
+	}
+	return w.Flush()
+}
+
+func printReport(formatter *report.Formatter, date string, view domain.View) error {
+	if date == "latest" {
+		latest, err := formatter.LatestDate()
+		if err != nil {
+			return err
+		}
+		date = latest
+	}
+
+	if view == domain.ViewManager {
+		rpt, err := formatter.Load(date)
+		if err != nil {
+			return fmt.Errorf("no report data for %s: %w", date, err)
+		}
+		fmt.Print(formatter.FormatManager(rpt, formatter.Previous(rpt.Date)))
+		return nil
+	}
+
+	content, err := formatter.LoadMarkdown(date)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("no report for %s", date)
+		}
+		return err
+	}
+
+	_, err = os.Stdout.Write(content)
+	return err
+}
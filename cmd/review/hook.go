@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/hooks"
+	"github.com/spf13/cobra"
+)
+
+var (
+	hookType      string
+	hookFailOn    string
+	hookUninstall bool
+)
+
+func newInstallHookCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "install-hook [repo-path]",
+		Short: "Install a git hook that reviews changes before they are pushed or committed",
+		Long: `Installs a pre-push hook (runs "review range" on the pushed commits) or a pre-commit hook (runs "review staged") that blocks when a finding at or above the --fail-on severity is produced.
+
+An existing hook that wasn't installed by CRA is preserved as <hook>.pre-cra and still runs first. Use --uninstall to remove the CRA hook and restore the previous one.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runInstallHook,
+	}
+
+	cmd.Flags().StringVar(&hookType, "hook", "pre-push", "Hook to install: pre-push or pre-commit")
+	cmd.Flags().StringVar(&hookFailOn, "fail-on", "High", "Block when a finding at or above this severity is found")
+	cmd.Flags().BoolVar(&hookUninstall, "uninstall", false, "Remove the CRA hook and restore any previous hook")
+
+	return cmd
+}
+
+func runInstallHook(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+
+	if hookUninstall {
+		path, err := hooks.Uninstall(cmd.Context(), dir, hookType)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Removed %s\n", path)
+		return nil
+	}
+
+	severity, ok := domain.ParseSeverity(hookFailOn)
+	if !ok {
+		return fmt.Errorf("invalid --fail-on %q, use High, Medium or Low", hookFailOn)
+	}
+
+	// Use the absolute path of this binary so the hook works without PATH
+	command, err := os.Executable()
+	if err != nil {
+		command = "review"
+	}
+
+	path, err := hooks.Install(cmd.Context(), dir, hooks.Options{
+		Hook:    hookType,
+		FailOn:  string(severity),
+		Command: command,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Installed %s (blocks on %s findings)\n", path, severity)
+	return nil
+}
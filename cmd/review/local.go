@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/app"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/patch"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/juparave/codereviewer/internal/review"
+	"github.com/spf13/cobra"
+)
+
+var (
+	localRepo   string
+	localFailOn string
+	mboxName    string
+)
+
+func newRangeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "range <revision>...",
+		Short: "Review the commits in a revision range of the current repository",
+		Long: `Reviews the commits selected by git log revision arguments (e.g. "origin/main..HEAD") and prints the findings. Nothing is written to the reports directory and no email is sent.
+
+Use "--" before revisions that start with a dash:
+  review range -- abc123 --not --remotes`,
+		Example: "  review range origin/main..HEAD --fail-on High",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLocal(cmd, func(runner *app.Runner) (*review.Result, error) {
+				return runner.ReviewRange(cmd.Context(), localRepo, args...)
+			})
+		},
+	}
+	addLocalFlags(cmd)
+	return cmd
+}
+
+func newStagedCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "staged",
+		Short: "Review the changes staged for commit in the current repository",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLocal(cmd, func(runner *app.Runner) (*review.Result, error) {
+				return runner.ReviewStaged(cmd.Context(), localRepo)
+			})
+		},
+	}
+	addLocalFlags(cmd)
+	return cmd
+}
+
+func newURLCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "url <link>",
+		Short: "Review a patch or gist from a URL",
+		Long: `Downloads a patch and prints the findings for its changes, e.g. code shared by someone else. Nothing is written to the reports directory and no email is sent.
+
+Links to GitHub commits, pull requests and compares are fetched as their .patch, and a gist link reviews each of its files as new code. Any other link must serve a unified diff, such as the output of git diff or git format-patch. GitHub requests use forge.token or $GITHUB_TOKEN when set.`,
+		Example: `  review url https://github.com/owner/repo/commit/1a2b3c4
+  review url https://gist.github.com/someone/0123abcd`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLocal(cmd, func(runner *app.Runner) (*review.Result, error) {
+				return runner.ReviewURL(cmd.Context(), args[0])
+			})
+		},
+	}
+	cmd.Flags().StringVar(&localFailOn, "fail-on", "", "Exit non-zero if a finding at or above this severity is found (High, Medium, Low)")
+	return cmd
+}
+
+func newMboxCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mbox <file>",
+		Short: "Review a patch series saved as an mbox, e.g. from a mailing list",
+		Long: `Reviews the patches in a mailbox of git format-patch mails, such as a series saved from a mailing list, fetched with b4 or from lore, or written by git format-patch --stdout, and prints each patch with its author and subject, then the findings. Use - to read stdin. Nothing is written to the reports directory and no email is sent.
+
+Cover letters are skipped, and the authors setting (or --authors) leaves out the patches of other authors. A plain unified diff works too, as one patch.`,
+		Example: `  review mbox v2-series.mbox --fail-on High
+  git format-patch --stdout origin/main | review mbox -
+  b4 am -o - <message-id> | review mbox - --name linux`,
+		Args: cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if len(args) >= 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveDefault
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLocal(cmd, func(runner *app.Runner) (*review.Result, error) {
+				src, err := patch.Read(args[0], mboxName)
+				if err != nil {
+					return nil, errs.Config(err, "pass the path of an mbox file, or - for stdin")
+				}
+				result, commits, err := runner.ReviewPatches(cmd.Context(), src)
+				if err == nil {
+					printPatches(commits)
+				}
+				return result, err
+			})
+		},
+	}
+	cmd.Flags().StringVar(&mboxName, "name", "", "Repository name to file the findings under (default: the file name)")
+	cmd.Flags().StringVar(&localFailOn, "fail-on", "", "Exit non-zero if a finding at or above this severity is found (High, Medium, Low)")
+	return cmd
+}
+
+// printPatches lists the patches of a series with their author and
+// subject; a plain diff has none to list
+func printPatches(commits []domain.Commit) {
+	if len(commits) == 0 || commits[0].Hash == "" {
+		return
+	}
+	fmt.Println("Patches:")
+	for i, c := range commits {
+		subject, _, _ := strings.Cut(c.Message, "\n")
+		line := fmt.Sprintf("  %d. %s", i+1, subject)
+		if c.Author != "" || c.Email != "" {
+			line += fmt.Sprintf(" (%s <%s>)", c.Author, c.Email)
+		}
+		if len(c.Hash) >= 12 {
+			line += " " + c.Hash[:12]
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+}
+
+func addLocalFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&localRepo, "repo", ".", "Path inside the repository to review")
+	cmd.Flags().StringVar(&localFailOn, "fail-on", "", "Exit non-zero if a finding at or above this severity is found (High, Medium, Low)")
+}
+
+// runLocal runs a single-repository review and prints its findings
+func runLocal(cmd *cobra.Command, reviewRepo func(*app.Runner) (*review.Result, error)) error {
+	cmd.SilenceUsage = true
+
+	gate, err := parseFailOn(localFailOn)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	result, err := reviewRepo(app.NewRunner(cfg))
+	if err != nil {
+		return err
+	}
+
+	printFindings(report.NewFormatter(cfg.Reports), result.Summary, result.Findings)
+	for _, failure := range result.Failures {
+		fmt.Fprintf(os.Stderr, "Warning: %s were not reviewed: %s\n", strings.Join(failure.Files, ", "), failure.Error)
+	}
+
+	return checkFailOn(gate, result.Findings)
+}
+
+// parseFailOn parses a --fail-on severity, empty when the gate is off
+func parseFailOn(value string) (domain.Severity, error) {
+	if value == "" {
+		return "", nil
+	}
+	gate, ok := domain.ParseSeverity(value)
+	if !ok {
+		return "", errs.Config(fmt.Errorf("invalid --fail-on %q", value), "use High, Medium or Low")
+	}
+	return gate, nil
+}
+
+// checkFailOn fails when a finding is at or above the gate severity
+func checkFailOn(gate domain.Severity, findings []domain.Finding) error {
+	if gate == "" {
+		return nil
+	}
+	if n := domain.CountAtOrAbove(findings, gate); n > 0 {
+		return fmt.Errorf("%d finding(s) at or above %s severity", n, gate)
+	}
+	return nil
+}
+
+// printFindings writes findings to the terminal as Markdown
+func printFindings(formatter *report.Formatter, summary string, findings []domain.Finding) {
+	fmt.Println(summary)
+	fmt.Println()
+	if len(findings) == 0 {
+		fmt.Println("✅ No issues found.")
+		return
+	}
+
+	for _, f := range findings {
+		fmt.Print(formatter.FormatFinding(f))
+	}
+}
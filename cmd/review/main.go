@@ -1,67 +1,298 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
+	"time"
 
-	"github.com/juparave/codereviewer/internal/app"
+	"github.com/juparave/codereviewer/internal/buildinfo"
 	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/logging"
+	"github.com/juparave/codereviewer/internal/netproxy"
+	"github.com/juparave/codereviewer/internal/policy"
 	"github.com/spf13/cobra"
 )
 
 var (
-	version  = "0.1.0"
 	rootPath string
 	cfgFile  string
+	profile  string
 	dryRun   bool
 	verbose  bool
+	since    string
+	until    string
+	repos    string
+	authors  string
+	branch   string
+	format   string
+	// progressFormat is --progress; progress names the package
+	progressFormat string
+	failOn         string
+	output         string
+	noLLM          bool
+	focus          string
+	rescan         bool
+	noCache        bool
+
+	logLevel  string
+	logFormat string
+	logFile   string
+
+	showPrompt bool
+	quiet      bool
+
+	watch         bool
+	watchInterval time.Duration
+
+	defaultBranchOnly bool
+)
+
+// Command groups shown in the help output
+const (
+	groupReview  = "review"
+	groupReports = "reports"
+	groupSetup   = "setup"
 )
 
 func main() {
 	rootCmd := &cobra.Command{
-		Use:     "review",
-		Short:   "Code Review Agent - Your personal senior engineer",
-		Long:    `CRA performs nightly code reviews across Git repositories, identifying meaningful issues and delivering a concise daily report.`,
-		Version: version,
+		Use:   "review",
+		Short: "Code Review Agent - Your personal senior engineer",
+		Long: `CRA performs nightly code reviews across Git repositories, identifying meaningful issues and delivering a concise daily report.
+
+Without a command, review does the same as "review run".
+
+Exit codes:
+  1  other failure, or findings over --fail-on
+  2  configuration error
+  3  git error
+  4  LLM provider error (e.g. rejected API key, quota)
+  5  email delivery error (e.g. SMTP unreachable or login refused)`,
+		Version: buildinfo.Get().String(),
+		Args:    cobra.NoArgs,
 		RunE:    run,
+		// Errors are printed by main
+		SilenceErrors: true,
+		// --profile goes through the environment, so every way of loading
+		// the config applies it
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if profile != "" {
+				return os.Setenv(config.ProfileEnv, profile)
+			}
+			return nil
+		},
 	}
+	addRunFlags(rootCmd)
+
+	rootCmd.PersistentFlags().StringVarP(&rootPath, "root", "r", "", "Root path to scan for repositories (default: ~/projects)")
+	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "Path to config file (default: ~/.config/cra/config.yaml)")
+	rootCmd.PersistentFlags().StringVarP(&profile, "profile", "p", "", "Apply this profile of the config file (default: profile in the file, or $CRA_PROFILE)")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output (same as --log-level debug)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "Minimum level logged: debug, info, warn or error (default: info)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "", "Log format: text or json (default: text)")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Append logs to this file instead of stderr")
+	rootCmd.PersistentFlags().StringVar(&since, "since", "", "Start of the review window (e.g. '24h', '3d', 'yesterday', '2024-05-01', 'last_run'; default: today)")
+	rootCmd.PersistentFlags().StringVar(&repos, "repos", "", "Only review repositories matching these comma-separated globs or /regexps/ (prefix ! to exclude)")
+	rootCmd.PersistentFlags().StringVar(&authors, "authors", "", "Only review commits whose author name or email matches these comma-separated globs or /regexps/ (prefix ! to exclude)")
+	rootCmd.PersistentFlags().StringVar(&branch, "branch", "", "Only read commits on branches matching these comma-separated globs or /regexps/, local or remote (default: every ref)")
+	rootCmd.PersistentFlags().BoolVar(&defaultBranchOnly, "default-branch-only", false, "Only read commits on each repository's default branch")
+	rootCmd.PersistentFlags().BoolVar(&noLLM, "no-llm", false, "Skip the LLM and review with built-in heuristics (credentials, nil dereferences, error wrapping, TODOs)")
+	rootCmd.PersistentFlags().StringVar(&focus, "focus", "", "Only review these comma-separated areas: "+strings.Join(config.FocusAreas, ", ")+" (default: all)")
+	rootCmd.PersistentFlags().BoolVar(&rescan, "rescan", false, "Walk the whole root path for repositories, ignoring and refreshing the scan cache")
+	rootCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "Ask the model again instead of reusing cached answers, and --rescan")
+	rootCmd.PersistentFlags().StringVar(&until, "until", "", "End of the review window (e.g. '2024-05-07', inclusive; default: now)")
+
+	rootCmd.MarkPersistentFlagFilename("config", "yaml", "yml")
+	rootCmd.MarkPersistentFlagDirname("root")
+	rootCmd.MarkPersistentFlagFilename("log-file")
+	rootCmd.RegisterFlagCompletionFunc("profile", completeProfile)
+	rootCmd.RegisterFlagCompletionFunc("log-level", cobra.FixedCompletions([]string{"debug", "info", "warn", "error"}, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("focus", cobra.FixedCompletions(config.FocusAreas, cobra.ShellCompDirectiveNoFileComp))
+	rootCmd.RegisterFlagCompletionFunc("log-format", cobra.FixedCompletions([]string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp))
 
-	rootCmd.Flags().StringVarP(&rootPath, "root", "r", "", "Root path to scan for repositories (default: ~/projects)")
-	rootCmd.Flags().StringVarP(&cfgFile, "config", "c", "", "Path to config file (default: ~/.config/cra/config.yaml)")
-	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Scan repositories but don't send email")
-	rootCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Enable verbose output")
+	rootCmd.AddGroup(
+		&cobra.Group{ID: groupReview, Title: "Reviewing:"},
+		&cobra.Group{ID: groupReports, Title: "Reports and findings:"},
+		&cobra.Group{ID: groupSetup, Title: "Setup and maintenance:"},
+	)
 
-	var since string
-	rootCmd.Flags().StringVar(&since, "since", "", "Time window for review (e.g. '24h', '7d', 'today')")
+	addGroup(rootCmd, groupReview,
+		newRunCmd(),
+		newRangeCmd(),
+		newStagedCmd(),
+		newURLCmd(),
+		newMboxCmd(),
+		newCICmd(),
+		newBaselineCmd(),
+		newEstimateCmd(),
+	)
+	addGroup(rootCmd, groupReports,
+		newHistoryCmd(),
+		newStatsCmd(),
+		newDiffRunsCmd(),
+		newFindingsCmd(),
+		newBrowseCmd(),
+		newSuppressCmd(),
+		newNoteCmd(),
+		newReplayCmd(),
+		newGenerateReportCmd(),
+		newSendCmd(),
+		newServeCmd(),
+	)
+	addGroup(rootCmd, groupSetup,
+		newConfigCmd(),
+		newRepoCmd(),
+		newDoctorCmd(),
+		newPauseCmd(),
+		newResumeCmd(),
+		newStorageCmd(),
+		newCacheCmd(),
+		newUserCmd(),
+		newInstallHookCmd(),
+		newVersionCmd(),
+	)
+	rootCmd.SetCompletionCommandGroupID(groupSetup)
+	rootCmd.SetHelpCommandGroupID(groupSetup)
+
+	// Former top-level names, kept so existing scripts and cron lines work
+	rootCmd.AddCommand(deprecated(newListReposCmd(), "use \"review repo list\""))
+	rootCmd.AddCommand(deprecated(newExplainExclusionsCmd(), "use \"review repo exclusions\""))
 
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		if hint := errs.Hint(err); hint != "" {
+			fmt.Fprintln(os.Stderr, "Hint:", hint)
+		}
+		os.Exit(errs.ExitCode(err))
 	}
 }
 
-func run(cmd *cobra.Command, args []string) error {
-	// Load configuration
+// addGroup adds the commands to root under the help group id
+func addGroup(root *cobra.Command, id string, cmds ...*cobra.Command) {
+	for _, cmd := range cmds {
+		cmd.GroupID = id
+		root.AddCommand(cmd)
+	}
+}
+
+// deprecated marks cmd as a hidden alias that prints message when used
+func deprecated(cmd *cobra.Command, message string) *cobra.Command {
+	cmd.Deprecated = message
+	return cmd
+}
+
+// loadConfig loads the config file and applies the shared CLI flag
+// overrides, then sets up logging and the proxy and merges the central
+// policy
+func loadConfig(ctx context.Context) (*config.Config, error) {
+	cfg, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// The log file stays open until the process exits
+	logger, _, err := logging.New(cfg.Log, os.Stderr)
+	if err != nil {
+		return nil, errs.Config(err, "fix log.level, log.format or log.file, or the matching --log-* flag")
+	}
+	slog.SetDefault(logger)
+
+	if err := netproxy.Configure(cfg.Proxy); err != nil {
+		return nil, errs.Config(err, "fix proxy.url, e.g. http://proxy.corp.example:3128, or $HTTPS_PROXY")
+	}
+	if err := applyPolicy(ctx, cfg, logger); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// reloadConfig loads the config file again for a long-running command,
+// keeping the logging set up by loadConfig
+func reloadConfig(ctx context.Context) (*config.Config, error) {
+	cfg, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+	if err := netproxy.Configure(cfg.Proxy); err != nil {
+		return nil, errs.Config(err, "fix proxy.url, e.g. http://proxy.corp.example:3128, or $HTTPS_PROXY")
+	}
+	if err := applyPolicy(ctx, cfg, slog.Default()); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// readConfig loads the config file and applies the shared CLI flag overrides
+func readConfig() (*config.Config, error) {
 	cfg, err := config.Load(cfgFile)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return nil, errs.Config(fmt.Errorf("failed to load config: %w", err), "run `review config validate` for details")
 	}
 
 	// Override config with CLI flags
 	if rootPath != "" {
 		cfg.RootPath = rootPath
 	}
-	if dryRun {
-		cfg.Email.Enabled = false
-	}
-	// Get --since flag value
-	since, _ := cmd.Flags().GetString("since")
 	if since != "" {
 		cfg.Since = since
 	}
-	cfg.Verbose = verbose
+	if until != "" {
+		cfg.Until = until
+	}
+	if repos != "" {
+		cfg.Scanner.Repos = strings.Split(repos, ",")
+	}
+	if authors != "" {
+		cfg.Authors = strings.Split(authors, ",")
+	}
+	// Branch flags replace the configured selection, per-repo entries included
+	if branch != "" || defaultBranchOnly {
+		cfg.Branches = config.BranchConfig{BranchSelection: config.BranchSelection{
+			Include:       strings.Split(branch, ","),
+			DefaultBranch: defaultBranchOnly,
+		}}
+	}
+	if noLLM {
+		cfg.Review.NoLLM = true
+	}
+	if rescan || noCache {
+		cfg.Scanner.Rescan = true
+	}
+	if noCache {
+		cfg.Cache.Responses = false
+	}
+	if focus != "" {
+		cfg.Review.Focus = strings.Split(focus, ",")
+	}
+	if verbose {
+		cfg.Log.Level = "debug"
+	}
+	if quiet {
+		cfg.Log.Level = "error"
+	}
+	if logLevel != "" {
+		cfg.Log.Level = logLevel
+	}
+	if logFormat != "" {
+		cfg.Log.Format = logFormat
+	}
+	if logFile != "" {
+		cfg.Log.File = logFile
+	}
+	return cfg, nil
+}
 
-	// Run the review
-	runner := app.NewRunner(cfg)
-	return runner.Run(cmd.Context())
+// applyPolicy merges the centrally managed policy, if any
+func applyPolicy(ctx context.Context, cfg *config.Config, logger *slog.Logger) error {
+	if cfg.Policy.URL == "" {
+		return nil
+	}
+	if err := policy.Apply(ctx, cfg, logger); err != nil {
+		return errs.Config(fmt.Errorf("loading policy: %w", err), "check policy.url and policy.public_key, or remove the policy section")
+	}
+	return nil
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/juparave/codereviewer/internal/annotate"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	noteList  bool
+	noteClear bool
+)
+
+func newNoteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "note <text>",
+		Short: "Leave a note for the next review run",
+		Long: `Stores a note (review.notes_file, by default notes.yaml next to the config file) that the next review run passes to the LLM and prints in the report header, so the review can account for known circumstances such as a migration or a large refactor.
+
+Notes are used once: after a run, they are kept for reference with the date of the report they went into. Use --list to see them and --clear to drop the notes still waiting for a run.`,
+		Example: `  review note "migrating auth service today, expect churn"
+  review note --list`,
+		RunE: runNote,
+	}
+
+	cmd.Flags().BoolVar(&noteList, "list", false, "List notes, pending and used")
+	cmd.Flags().BoolVar(&noteClear, "clear", false, "Drop the notes waiting for the next run")
+
+	return cmd
+}
+
+func runNote(cmd *cobra.Command, args []string) error {
+	if !noteList && !noteClear && len(args) == 0 {
+		return fmt.Errorf("requires the note text")
+	}
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if cfg.Review.NotesFile == "" {
+		return fmt.Errorf("review.notes_file is not set")
+	}
+
+	list, err := annotate.Load(report.OpenState(cfg.Reports), cfg.Review.NotesFile)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case noteList:
+		if len(list.Notes) == 0 {
+			fmt.Println("No notes.")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ADDED\tUSED IN\tNOTE")
+		for _, n := range list.Notes {
+			used := n.Used
+			if used == "" {
+				used = "next run"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", n.Added.Format(report.DateLayout), used, n.Text)
+		}
+		return w.Flush()
+	case noteClear:
+		fmt.Printf("Dropped %d pending notes\n", list.ClearPending())
+		return list.Save()
+	}
+
+	text := strings.TrimSpace(strings.Join(args, " "))
+	list.Add(text)
+	if err := list.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Noted for the next run: %s\n", text)
+	return nil
+}
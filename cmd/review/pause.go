@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/app"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/pause"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pauseUntil  string
+	pauseHold   bool
+	pauseStatus bool
+)
+
+func newPauseCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Pause scheduled reviews, e.g. during a vacation",
+		Long: `Stores a pause window (pause_file, by default pause.yaml next to the config file) that every run honors, including runs from cron, the server and --watch:
+
+  skip (default)  runs do nothing until the pause ends
+  --hold          runs review and write reports as usual but hold the email;
+                  the first run after the pause, or "review resume", emails
+                  one catch-up digest of the held reports`,
+		Example: `  review pause --until 2025-01-06
+  review pause --until 2025-01-06 --hold
+  review pause --status`,
+		Args: cobra.NoArgs,
+		RunE: runPause,
+	}
+
+	cmd.Flags().StringVar(&pauseUntil, "until", "", "First day reviews run normally again (YYYY-MM-DD)")
+	cmd.Flags().BoolVar(&pauseHold, "hold", false, "Keep reviewing but hold email for a catch-up digest")
+	cmd.Flags().BoolVar(&pauseStatus, "status", false, "Show the current pause")
+
+	return cmd
+}
+
+func newResumeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume",
+		Short: "End a pause now and email the catch-up digest of held reports",
+		Args:  cobra.NoArgs,
+		RunE:  runResume,
+	}
+}
+
+func runPause(cmd *cobra.Command, args []string) error {
+	if !pauseStatus && pauseUntil == "" {
+		return fmt.Errorf("requires --until or --status")
+	}
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if cfg.PauseFile == "" {
+		return fmt.Errorf("pause_file is not set")
+	}
+	state, err := pause.Load(report.OpenState(cfg.Reports), cfg.PauseFile)
+	if err != nil {
+		return err
+	}
+
+	if pauseStatus {
+		if !state.Active(time.Now()) {
+			fmt.Println("Not paused.")
+			if len(state.Held) > 0 {
+				fmt.Printf("%d held reports will be emailed by the next run or \"review resume\"\n", len(state.Held))
+			}
+			return nil
+		}
+		fmt.Printf("Paused until %s (%s), %d reports held\n", state.Until.Format(report.DateLayout), state.Mode, len(state.Held))
+		return nil
+	}
+
+	until, err := time.ParseInLocation(report.DateLayout, pauseUntil, time.Local)
+	if err != nil {
+		return errs.Config(fmt.Errorf("invalid --until %q", pauseUntil), "use a date such as 2025-01-06")
+	}
+	if !until.After(time.Now()) {
+		return errs.Config(fmt.Errorf("--until %s is not in the future", pauseUntil), "pass the first day reviews should run again")
+	}
+
+	state.Until = until
+	state.Mode = pause.ModeSkip
+	if pauseHold {
+		state.Mode = pause.ModeHold
+	}
+	if err := state.Save(); err != nil {
+		return err
+	}
+	if pauseHold {
+		fmt.Printf("Paused until %s: reviews run, email is held for a catch-up digest\n", pauseUntil)
+	} else {
+		fmt.Printf("Paused until %s: reviews are skipped\n", pauseUntil)
+	}
+	return nil
+}
+
+func runResume(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if cfg.PauseFile == "" {
+		return fmt.Errorf("pause_file is not set")
+	}
+	if cfg.Email.Enabled {
+		if err := cfg.Validate(); err != nil {
+			return errs.Config(fmt.Errorf("invalid configuration: %w", err), "run `review config validate` to see every problem and its fix")
+		}
+	}
+
+	digest, err := app.NewRunner(cfg).Resume(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if digest == nil {
+		fmt.Println("Resumed, no reports were held.")
+		return nil
+	}
+	fmt.Printf("Resumed, catch-up digest of %d findings", digest.TotalFindings())
+	if cfg.Email.Enabled {
+		fmt.Print(" emailed")
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/juparave/codereviewer/internal/config"
+)
+
+// watchConfig reloads the config file whenever it changes, until ctx is
+// cancelled, and hands the new configuration to apply. A configuration
+// that fails to load, validate or apply is logged and the running one is
+// kept, so a typo saved mid-edit doesn't take the process down.
+func watchConfig(ctx context.Context, apply func(*config.Config) error) {
+	path := config.ResolvePath(cfgFile)
+	if path == "" {
+		return
+	}
+	config.WatchFile(ctx, path, config.ReloadInterval, func() {
+		cfg, err := reloadConfig(ctx)
+		if err == nil {
+			err = apply(cfg)
+		}
+		if err != nil {
+			slog.Error("config changed but can't be applied, keeping the running configuration", "path", path, "err", err)
+			return
+		}
+		slog.Info("config reloaded", "path", path)
+	})
+}
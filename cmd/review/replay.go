@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/juparave/codereviewer/internal/app"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var replaySend bool
+
+func newReplayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay <date>",
+		Short: "Rebuild a report from saved model responses without calling the LLM",
+		Long: `Rebuilds the report for date (YYYY-MM-DD) from the raw model answers saved when review.save_responses is set, without calling the LLM. The answers are parsed again and the current severity and suppression settings apply, so you can iterate on report templates or debug parsing failures for free.
+
+The Markdown and JSON reports for the date are rewritten. Email is sent only with --send.`,
+		Example:           "  review replay 2024-05-07 --send",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeReportDate(report.ArtifactResponses),
+		RunE:              runReplay,
+	}
+
+	cmd.Flags().BoolVar(&replaySend, "send", false, "Email the rebuilt report")
+
+	return cmd
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	formatter := report.NewFormatter(cfg.Reports)
+	rpt, err := app.NewRunner(cfg).Replay(cmd.Context(), args[0], replaySend)
+	if rpt != nil {
+		fmt.Printf("Rebuilt %s: %d findings (%d High, %d Medium, %d Low)", formatter.MarkdownPath(args[0]),
+			rpt.TotalFindings(), rpt.HighCount(), rpt.MediumCount(), rpt.LowCount())
+		if len(rpt.Failures) > 0 {
+			fmt.Printf(", %d chunks not reviewed", len(rpt.Failures))
+		}
+		fmt.Println()
+	}
+	return err
+}
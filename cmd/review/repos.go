@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/juparave/codereviewer/internal/app"
+	"github.com/spf13/cobra"
+)
+
+func newRepoCmd() *cobra.Command {
+	repoCmd := &cobra.Command{
+		Use:   "repo",
+		Short: "Inspect the discovered repositories and their settings",
+	}
+
+	list := newListReposCmd()
+	list.Use = "list"
+	exclusions := newExplainExclusionsCmd()
+	exclusions.Use = "exclusions [date]"
+	settings := newConfigReposCmd()
+	settings.Use = "settings"
+
+	repoCmd.AddCommand(list, exclusions, settings)
+	return repoCmd
+}
+
+func newListReposCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list-repos",
+		Short: "List discovered repositories with their recent activity",
+		Long:  `Runs the scanner and prints each repository with its last commit date, the number of commits in the review window and whether it would be included in a review.`,
+		Args:  cobra.NoArgs,
+		RunE:  runListRepos,
+	}
+}
+
+func runListRepos(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	statuses, err := app.NewRunner(cfg).ListRepositories(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPOSITORY\tLAST COMMIT\tCOMMITS\tFILES\tINCLUDED")
+	for _, s := range statuses {
+		last := "never"
+		if !s.LastCommit.IsZero() {
+			last = s.LastCommit.Local().Format("2006-01-02 15:04")
+		}
+
+		included := "yes"
+		if !s.Included {
+			included = "no (" + s.Reason + ")"
+		}
+		if s.Err != nil {
+			included += ": " + s.Err.Error()
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", s.Name, last, s.Commits, s.Files, included)
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d repositories under %s\n", len(statuses), cfg.RootPath)
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/app"
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/progress"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/spf13/cobra"
+)
+
+func newRunCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run",
+		Short: "Review the day's commits and deliver the report (the default command)",
+		Long:  `Scans the repositories under the root path, reviews the commits in the window and writes and emails the report. Running review without a command does the same.`,
+		Args:  cobra.NoArgs,
+		RunE:  run,
+	}
+	addRunFlags(cmd)
+	return cmd
+}
+
+// addRunFlags registers the flags of the review run. They are added to both
+// the run command and the root command, so `review --dry-run` keeps working.
+func addRunFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Scan repositories but don't send email")
+	cmd.Flags().BoolVar(&showPrompt, "show-prompt", false, "Print the prompts that would be sent to the LLM, with estimated tokens, without calling it")
+	cmd.Flags().BoolVar(&watch, "watch", false, "Keep running and review new commits as they land, printing and emailing each batch")
+	cmd.Flags().DurationVar(&watchInterval, "watch-interval", time.Minute, "How often --watch checks the repositories for new commits")
+	cmd.Flags().StringVar(&failOn, "fail-on", "", "Exit non-zero if a finding at or above this severity is reported (High, Medium, Low), e.g. as a CI gate")
+	cmd.Flags().StringVar(&output, "output", "", "Write a JSON manifest of the run (repositories, commits, diffs, findings, token usage, timings) to this path")
+	cmd.Flags().StringVar(&format, "format", "", "Also print the report to stdout: terminal, md or json (logs go to stderr)")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Only log errors and print the final summary line, without the per-repository badges")
+	cmd.Flags().StringVar(&progressFormat, "progress", "", "Emit progress events (stage, repo, percent, message) on stderr: json for one JSON object per line")
+
+	cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(outputFormats, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("progress", cobra.FixedCompletions([]string{"json"}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("fail-on", cobra.FixedCompletions(severityNames, cobra.ShellCompDirectiveNoFileComp))
+	cmd.MarkFlagFilename("output", "json")
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if format != "" && !slices.Contains(outputFormats, format) {
+		return errs.Config(fmt.Errorf("invalid --format %q", format), "use one of: "+strings.Join(outputFormats, ", "))
+	}
+	if progressFormat != "" && progressFormat != "json" {
+		return errs.Config(fmt.Errorf("invalid --progress %q", progressFormat), "use json, or leave --progress out")
+	}
+	if quiet && verbose {
+		return errs.Config(fmt.Errorf("--quiet and --verbose both set"), "use one of them")
+	}
+	gate, err := parseFailOn(failOn)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	applyRunFlags(cfg)
+
+	// Run the review
+	runner := app.NewRunner(cfg)
+	if progressFormat == "json" {
+		runner.SetProgress(progress.NewJSON(os.Stderr))
+	}
+	if showPrompt {
+		return printPrompts(cmd.Context(), runner)
+	}
+	if watch {
+		return runWatch(runner, report.NewFormatter(cfg.Reports))
+	}
+	rpt, err := runner.Run(cmd.Context())
+	formatter := report.NewFormatter(cfg.Reports)
+	if rpt != nil && format != "" {
+		if printErr := writeReport(formatter, rpt, format); printErr != nil && err == nil {
+			err = printErr
+		}
+	}
+	if rpt != nil {
+		printSummary(formatter, rpt)
+	}
+	if err != nil || rpt == nil {
+		return err
+	}
+	return checkFailOn(gate, rpt.Findings)
+}
+
+// applyRunFlags applies the flags of the review run to cfg
+func applyRunFlags(cfg *config.Config) {
+	if dryRun {
+		cfg.Email.Enabled = false
+	}
+	if output != "" {
+		cfg.Reports.ManifestPath = output
+	}
+}
+
+// runWatch reviews new commits until interrupted, printing each batch in
+// --format (terminal by default)
+func runWatch(runner *app.Runner, formatter *report.Formatter) error {
+	if watchInterval <= 0 {
+		return errs.Config(fmt.Errorf("invalid --watch-interval %s", watchInterval), "use a positive duration such as 30s or 5m")
+	}
+	out := format
+	if out == "" {
+		out = "terminal"
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go watchConfig(ctx, func(cfg *config.Config) error {
+		applyRunFlags(cfg)
+		return runner.Reload(cfg)
+	})
+	return runner.Watch(ctx, watchInterval, func(rpt *domain.Report) {
+		if err := writeReport(formatter, rpt, out); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+	})
+}
+
+// printSummary ends a run with the severity badges of each repository and
+// a summary line on stderr, keeping stdout for --format; with --quiet only
+// the line is printed
+func printSummary(formatter *report.Formatter, rpt *domain.Report) {
+	if quiet {
+		fmt.Fprintln(os.Stderr, formatter.SummaryLine(rpt))
+		return
+	}
+	fmt.Fprint(os.Stderr, "\n"+formatter.TerminalSummary(rpt, colorOutput(os.Stderr)))
+}
+
+// colorOutput reports whether f is a terminal that takes ANSI colors:
+// not redirected, not a dumb terminal, and NO_COLOR (no-color.org) unset
+func colorOutput(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// outputFormats lists the accepted --format values
+var outputFormats = []string{"terminal", "md", "json"}
+
+// writeReport writes the report to stdout in the given --format
+func writeReport(formatter *report.Formatter, rpt *domain.Report, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rpt)
+	case "md":
+		fmt.Print(formatter.Markdown(rpt))
+	default:
+		fmt.Print(formatter.Text(rpt))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/juparave/codereviewer/internal/app"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sendRun     string
+	sendChannel string
+	sendTo      string
+)
+
+func newSendCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "send",
+		Short: "Send a stored report again without reviewing",
+		Long: `Delivers the report of the latest run (or of --run, YYYY-MM-DD) through a channel, even when it has no findings, without scanning or calling the LLM. Use it to retry a failed delivery or to test the delivery settings.
+
+The only channel is email.`,
+		Example: `  review send --run 2025-01-10
+  review send --to me@example.com`,
+		Args: cobra.NoArgs,
+		RunE: runSend,
+	}
+
+	cmd.Flags().StringVar(&sendRun, "run", "", "Date of the report to send, YYYY-MM-DD (default: the latest run)")
+	cmd.Flags().StringVar(&sendChannel, "channel", "email", "Channel to deliver the report through")
+	cmd.Flags().StringVar(&sendTo, "to", "", "Send only the engineer report to this address instead of to_address and routes")
+
+	cmd.RegisterFlagCompletionFunc("run", completeReportDate(report.ArtifactReport))
+	cmd.RegisterFlagCompletionFunc("channel", cobra.FixedCompletions(app.Channels, cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+func runSend(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if sendTo != "" {
+		cfg.Email.ToAddress = sendTo
+		cfg.Email.Routes = nil
+	}
+	formatter := report.NewFormatter(cfg.Reports)
+
+	var rpt *domain.Report
+	if sendRun != "" {
+		if rpt, err = formatter.Load(sendRun); err != nil {
+			return fmt.Errorf("no report data for %s: %w", sendRun, err)
+		}
+	} else if rpt, err = latestRun(formatter); err != nil {
+		return err
+	}
+	if err := formatter.LoadDiffs(rpt); err != nil {
+		return err
+	}
+
+	sent, err := app.NewRunner(cfg).Send(cmd.Context(), rpt, sendChannel)
+	if err != nil {
+		return err
+	}
+	if !sent {
+		fmt.Printf("Paused: the report of %s is held for the catch-up digest\n", rpt.Date.Format("2006-01-02"))
+		return nil
+	}
+	fmt.Printf("Sent the report of %s by %s\n", rpt.Date.Format("2006-01-02"), sendChannel)
+	return nil
+}
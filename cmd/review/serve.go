@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/server"
+	"github.com/spf13/cobra"
+)
+
+var serveAddr string
+
+func newServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run an HTTP server with a REST API and dashboard",
+		Long: `Starts a long-running server that can trigger reviews and serves stored reports.
+
+Endpoints:
+  GET  /                              HTML dashboard
+  GET  /reports/{date}                HTML report
+  POST /api/runs                      Trigger a review
+  GET  /api/runs/current              Status of the latest review
+  GET  /api/reports                   List stored reports
+  GET  /api/reports/{date}            Report as JSON
+  GET  /api/reports/{date}/findings   Findings as JSON
+
+POST /api/runs needs the X-CSRF-Token header returned by
+GET /api/runs/current, unless it carries a bearer token.
+
+With server.auth, every request needs an API token, sent as
+"Authorization: Bearer <token>" or entered on the /login page, and
+engineers see only the repositories of their account; see review user.
+Only leads can trigger reviews.
+
+  GET  /api/me                        The signed-in user
+
+With email.manage_url pointing at the server, report emails link each
+recipient to a page where they tune their own emails; the link's email
+and token parameters authorize these, with or without server.auth:
+
+  GET  /manage                        Email preferences page
+  GET  /api/preferences               Preferences as JSON
+  PUT  /api/preferences               Replace the preferences
+
+The server also runs reviews by itself at the times the config's schedule
+lists as cron expressions, e.g. schedule: "0 7 * * 1-5" for 7:00 on
+weekdays. A schedule due while a review runs is skipped.
+
+Changes to the config file are applied without a restart, except to
+server.addr and server.auth; an invalid config is logged and ignored.`,
+		Args: cobra.NoArgs,
+		RunE: runServe,
+	}
+
+	cmd.Flags().StringVar(&serveAddr, "addr", "", "Listen address (default: server.addr from config)")
+
+	return cmd
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if serveAddr != "" {
+		cfg.Server.Addr = serveAddr
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv, err := server.New(cfg, slog.Default())
+	if err != nil {
+		return errs.Config(err, "set reports.backend to postgres or sqlite, or turn server.auth off")
+	}
+	go srv.RunSchedules(ctx)
+	go watchConfig(ctx, func(cfg *config.Config) error {
+		if serveAddr != "" {
+			cfg.Server.Addr = serveAddr
+		}
+		return srv.Reload(cfg)
+	})
+	err = srv.ListenAndServe(ctx)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/juparave/codereviewer/internal/app"
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/scanner"
+	"github.com/juparave/codereviewer/internal/util"
+	"github.com/spf13/cobra"
+)
+
+func newConfigReposCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "repos",
+		Short: "Interactively set per-repository inclusion, strictness and paths",
+		Long: `Opens a terminal UI listing the discovered repositories with their settings, to toggle whether each one is reviewed, cycle its strictness and limit the reviewed paths. "review browse" opens the same screen with s.
+
+Changes are saved to the overrides section of the config file; the rest of the file and the comments of the entries kept are left as is.`,
+		Args: cobra.NoArgs,
+		RunE: runConfigRepos,
+	}
+}
+
+func runConfigRepos(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+	repos, err := app.NewRunner(cfg).Discover()
+	if err != nil {
+		return err
+	}
+	if len(repos) == 0 {
+		return fmt.Errorf("no repositories found under %s", cfg.RootPath)
+	}
+	s, err := newSettingsScreen(cfg, repos)
+	if err != nil {
+		return err
+	}
+	s.standalone = true
+
+	program := tea.NewProgram(s, tea.WithAltScreen(), tea.WithContext(cmd.Context()),
+		tea.WithInput(cmd.InOrStdin()), tea.WithOutput(cmd.OutOrStdout()))
+	if _, err := program.Run(); err != nil {
+		return err
+	}
+	if s.saved {
+		fmt.Fprintf(cmd.OutOrStdout(), "Saved overrides to %s\n", s.path)
+	} else if s.changed {
+		fmt.Fprintln(cmd.OutOrStdout(), "Changes discarded")
+	}
+	return nil
+}
+
+// settingsScreen is the terminal UI editing the overrides, behind
+// `config repos` and the settings screen of `browse`; a bubbletea model
+type settingsScreen struct {
+	repos      []domain.Repository
+	filter     *scanner.Filter
+	repoList   *scanner.ListFilter
+	overrides  []config.RepoOverride
+	defaults   string // review.strictness
+	path       string // The config file
+	standalone bool   // Quit the program when closed
+
+	cursor  int    // Selected repository
+	editing bool   // Typing the paths of the selected repository
+	input   string // Typed paths
+	confirm bool   // Asking whether to discard unsaved changes
+	changed bool   // Since the last save
+	saved   bool   // At least once
+	message string // Outcome of the last action
+	height  int
+	done    bool // Closed
+}
+
+// newSettingsScreen returns the overrides editor for the repositories
+// found with cfg
+func newSettingsScreen(cfg *config.Config, repos []domain.Repository) (*settingsScreen, error) {
+	filter, err := scanner.NewFilter(cfg.Scanner.Repos)
+	if err != nil {
+		return nil, err
+	}
+	list, err := scanner.NewListFilter(cfg.Repos.Include, cfg.Repos.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	return &settingsScreen{
+		repos:     repos,
+		filter:    filter,
+		repoList:  list,
+		overrides: append([]config.RepoOverride(nil), cfg.Overrides...),
+		defaults:  cfg.Review.Strictness,
+		path:      config.ResolvePath(cfgFile),
+	}, nil
+}
+
+// strictnessCycle is the order the strictness of a repository goes
+// through, "" meaning review.strictness
+var strictnessCycle = append([]string{""}, config.StrictnessLevels...)
+
+func (s *settingsScreen) Init() tea.Cmd { return nil }
+
+func (s *settingsScreen) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.height = msg.Height
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return s, tea.Quit
+		}
+		switch {
+		case s.editing:
+			s.updatePaths(msg)
+		case s.confirm:
+			switch msg.String() {
+			case "y":
+				return s, s.close()
+			case "n", "esc":
+				s.confirm = false
+			}
+		default:
+			return s, s.updateList(msg)
+		}
+	}
+	return s, nil
+}
+
+func (s *settingsScreen) updateList(msg tea.KeyMsg) tea.Cmd {
+	repo := s.repos[s.cursor]
+	switch msg.String() {
+	case "up", "k":
+		s.cursor = max(s.cursor-1, 0)
+	case "down", "j":
+		s.cursor = min(s.cursor+1, len(s.repos)-1)
+	case "home", "g":
+		s.cursor = 0
+	case "end", "G":
+		s.cursor = len(s.repos) - 1
+	case " ", "x":
+		entry := s.entry(repo)
+		entry.Skip = !entry.Skip
+		s.changed = true
+	case "s":
+		entry := s.entry(repo)
+		i := slices.Index(strictnessCycle, entry.Strictness)
+		entry.Strictness = strictnessCycle[(i+1)%len(strictnessCycle)]
+		s.changed = true
+	case "p":
+		s.editing, s.message = true, ""
+		s.input = ""
+		if o := effectiveOverride(s.overrides, repo); o != nil {
+			s.input = strings.Join(o.Paths, ", ")
+		}
+	case "w":
+		if err := config.SaveOverrides(s.path, pruneOverrides(s.overrides)); err != nil {
+			s.message = "Saving: " + err.Error()
+			return nil
+		}
+		s.changed, s.saved = false, true
+		s.message = "Saved overrides to " + s.path
+	case "q", "esc":
+		if s.changed {
+			s.confirm = true
+			return nil
+		}
+		return s.close()
+	}
+	return nil
+}
+
+// updatePaths edits the paths of the selected repository
+func (s *settingsScreen) updatePaths(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		s.editing = false
+	case tea.KeyEnter:
+		list := splitList(s.input)
+		if _, err := util.CompilePatterns(list, false); err != nil {
+			s.message = "Invalid paths: " + err.Error()
+			return
+		}
+		s.entry(s.repos[s.cursor]).Paths = list
+		s.editing, s.changed, s.message = false, true, ""
+	case tea.KeyBackspace:
+		if r := []rune(s.input); len(r) > 0 {
+			s.input = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		s.input += string(msg.Runes)
+	}
+}
+
+// close ends the screen, and the program when it runs alone
+func (s *settingsScreen) close() tea.Cmd {
+	s.done = true
+	if s.standalone {
+		return tea.Quit
+	}
+	return nil
+}
+
+func (s *settingsScreen) View() string {
+	var sb strings.Builder
+	sb.WriteString("Repository settings, saved to the overrides of " + s.path + "\n\n")
+
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  REPOSITORY\tREVIEWED\tSTRICTNESS\tPATHS")
+	for i, repo := range s.repos {
+		o := effectiveOverride(s.overrides, repo)
+		marker := "  "
+		if i == s.cursor {
+			marker = "> "
+		}
+		fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\n", marker, repo.Name, s.reviewed(repo, o), s.strictness(o), paths(o))
+	}
+	w.Flush()
+	rows := strings.Split(strings.TrimRight(table.String(), "\n"), "\n")
+
+	// The header, then the page of rows around the cursor
+	sb.WriteString(rows[0] + "\n")
+	rows = rows[1:]
+	page := len(rows)
+	if s.height > 0 {
+		page = max(s.height-8, 1)
+	}
+	first := max(min(s.cursor-page/2, len(rows)-page), 0)
+	for _, row := range rows[first:min(first+page, len(rows))] {
+		sb.WriteString(row + "\n")
+	}
+
+	sb.WriteString("\n")
+	switch {
+	case s.editing:
+		fmt.Fprintf(&sb, "Paths of %s (comma-separated globs or /regexps/, ! excludes; empty for all): %s█\n", s.repos[s.cursor].Name, s.input)
+	case s.confirm:
+		sb.WriteString("Discard unsaved changes? [y/N]\n")
+	}
+	if s.message != "" {
+		sb.WriteString(s.message + "\n")
+	}
+	keys := "↑/↓ move  space reviewed  s strictness  p paths  w save  q close"
+	if s.editing {
+		keys = "enter set  esc cancel"
+	}
+	sb.WriteString("\x1b[2m" + keys + "\x1b[0m")
+	return sb.String()
+}
+
+// entry returns the override named exactly after repo, adding one in
+// front of any glob entries (so it takes precedence) seeded with the
+// settings the repository has now
+func (s *settingsScreen) entry(repo domain.Repository) *config.RepoOverride {
+	for i := range s.overrides {
+		if s.overrides[i].Repo == repo.Name {
+			return &s.overrides[i]
+		}
+	}
+
+	entry := config.RepoOverride{Repo: repo.Name}
+	if o := effectiveOverride(s.overrides, repo); o != nil {
+		entry.Skip = o.Skip
+		entry.Strictness = o.Strictness
+		entry.Paths = append([]string(nil), o.Paths...)
+		entry.Exclude = append([]string(nil), o.Exclude...)
+	}
+	s.overrides = append([]config.RepoOverride{entry}, s.overrides...)
+	return &s.overrides[0]
+}
+
+func (s *settingsScreen) reviewed(repo domain.Repository, o *config.RepoOverride) string {
+	if reason := s.repoList.Reason(repo); reason != "" {
+		return "no (" + reason + ")"
+	}
+	switch {
+	case !s.filter.Match(repo):
+		return "no (scanner.repos)"
+	case o != nil && o.Skip:
+		return "no"
+	default:
+		return "yes"
+	}
+}
+
+func (s *settingsScreen) strictness(o *config.RepoOverride) string {
+	if o != nil && o.Strictness != "" {
+		return o.Strictness
+	}
+	return s.defaults + " (default)"
+}
+
+func paths(o *config.RepoOverride) string {
+	if o == nil || len(o.Paths) == 0 {
+		return "all"
+	}
+	return strings.Join(o.Paths, ", ")
+}
+
+// effectiveOverride returns the first override matching repo, or nil
+func effectiveOverride(overrides []config.RepoOverride, repo domain.Repository) *config.RepoOverride {
+	for i, o := range overrides {
+		if o.Repo == "" {
+			continue
+		}
+		if filter, err := scanner.NewFilter([]string{o.Repo}); err == nil && filter.Match(repo) {
+			return &overrides[i]
+		}
+	}
+	return nil
+}
+
+// pruneOverrides drops entries that change nothing and don't shadow a
+// later entry that would otherwise apply
+func pruneOverrides(overrides []config.RepoOverride) []config.RepoOverride {
+	var kept []config.RepoOverride
+	for i, o := range overrides {
+		if o.IsZero() {
+			next := effectiveOverride(overrides[i+1:], domain.Repository{Name: o.Repo})
+			if next == nil || next.IsZero() {
+				continue
+			}
+		}
+		kept = append(kept, o)
+	}
+	return kept
+}
+
+// splitList splits a comma-separated list, dropping blank entries
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsBy   string
+	statsJSON bool
+)
+
+func newStatsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show finding trends across stored reports",
+		Long: `Aggregates the stored report data into findings per day, week or month, the severity distribution, the repositories with the most findings, and the average time until a finding stops being reported.
+
+The monthly rollup (--by month) adds a heat map of the files and directories that collected the most findings, month by month, to point refactoring at the hottest spots.
+
+A finding counts as resolved on the first later report that reviewed commits and no longer contains it (same repository, title and files, following files renamed since).`,
+		Args: cobra.NoArgs,
+		RunE: runStats,
+	}
+
+	cmd.Flags().StringVar(&statsBy, "by", "day", "Group findings by day, week or month")
+	cmd.Flags().BoolVar(&statsJSON, "json", false, "Print the statistics as JSON")
+
+	return cmd
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	period := report.Period(statsBy)
+	if period != report.PeriodDay && period != report.PeriodWeek && period != report.PeriodMonth {
+		return fmt.Errorf("invalid --by %q, use day, week or month", statsBy)
+	}
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	formatter := report.NewFormatter(cfg.Reports)
+	reports, err := formatter.History()
+	if err != nil {
+		return err
+	}
+	if len(reports) == 0 {
+		fmt.Printf("No reports in %s\n", cfg.Reports.OutputDir)
+		return nil
+	}
+
+	failed, err := formatter.FailedRuns()
+	if err != nil {
+		return err
+	}
+	stats := report.ComputeStats(reports, failed, period)
+	if statsJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	}
+
+	fmt.Printf("%d reports, %d findings (%d High, %d Medium, %d Low)\n\n",
+		stats.Reports, stats.Severity.Total, stats.Severity.High, stats.Severity.Medium, stats.Severity.Low)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tFINDINGS\tHIGH\tMEDIUM\tLOW\n", strings.ToUpper(string(period)))
+	for _, b := range stats.Buckets {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", b.Label, b.Total, b.High, b.Medium, b.Low)
+	}
+	w.Flush()
+
+	if len(stats.HotSpots) > 0 {
+		fmt.Println()
+		fmt.Fprintln(w, "REPOSITORY\tFINDINGS\tHIGH\tMEDIUM\tLOW")
+		for _, h := range stats.HotSpots {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", h.Repo, h.Total, h.High, h.Medium, h.Low)
+		}
+		w.Flush()
+	}
+
+	if period == report.PeriodMonth && len(stats.HeatMap) > 0 {
+		fmt.Println()
+		printHeatMap(w, stats)
+	}
+
+	fmt.Println()
+	fmt.Printf("Resolved: %d, still open: %d", stats.Resolved, stats.Open)
+	if stats.Resolved > 0 {
+		fmt.Printf(", average time to resolution: %s", formatDays(stats.AvgTimeToResolve))
+	}
+	fmt.Println()
+
+	if len(stats.Providers) > 0 {
+		fmt.Println()
+		fmt.Fprintf(w, "PROVIDER\tRUNS\tFAILED RUNS\tREQUESTS\tERRORS\tAVG LATENCY\tLAST %d DAYS\tLAST USED\n", report.HealthWindow)
+		for _, p := range stats.Providers {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\n", p.Provider, p.Runs, p.Failed, p.Requests, errorRate(p.CallStats),
+				p.AvgLatency().Round(time.Millisecond), recentCalls(p.Recent), p.LastUsed.Format(report.DateLayout))
+			for _, m := range p.ByModel {
+				fmt.Fprintf(w, "  %s\t\t\t%d\t%s\t%s\t\t\n", m.Model, m.Requests, errorRate(m.CallStats), m.AvgLatency().Round(time.Millisecond))
+			}
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// errorRate shows failed calls with their share of the requests
+func errorRate(c report.CallStats) string {
+	return fmt.Sprintf("%d (%.1f%%)", c.Errors, 100*c.ErrorRate())
+}
+
+// recentCalls summarizes a provider's recent calls in one cell
+func recentCalls(c report.CallStats) string {
+	return fmt.Sprintf("%d req, %.1f%% err, %s", c.Requests, 100*c.ErrorRate(), c.AvgLatency().Round(time.Millisecond))
+}
+
+// heatShades shade heat map cells from cold to hot
+var heatShades = []string{"░", "▒", "▓", "█"}
+
+// printHeatMap prints the hottest files and directories with a shaded
+// count per month, relative to the hottest cell
+func printHeatMap(w *tabwriter.Writer, stats *report.Stats) {
+	peak := 0
+	for _, row := range stats.HeatMap {
+		for _, n := range row.Buckets {
+			peak = max(peak, n)
+		}
+	}
+
+	header := []string{"HOT SPOT"}
+	for _, b := range stats.Buckets {
+		header = append(header, b.Label)
+	}
+	fmt.Fprintln(w, strings.Join(append(header, "TOTAL"), "\t"))
+	for _, row := range stats.HeatMap {
+		cells := []string{row.Path}
+		for _, n := range row.Buckets {
+			if n == 0 {
+				cells = append(cells, "·")
+				continue
+			}
+			shade := heatShades[(n*len(heatShades)-1)/peak]
+			cells = append(cells, fmt.Sprintf("%s %d", shade, n))
+		}
+		fmt.Fprintln(w, strings.Join(append(cells, fmt.Sprint(row.Total)), "\t"))
+	}
+	w.Flush()
+}
+
+// formatDays renders a duration in days, e.g. "2.5 days"
+func formatDays(d time.Duration) string {
+	return fmt.Sprintf("%.1f days", d.Hours()/24)
+}
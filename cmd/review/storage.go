@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneKeepDays int
+	pruneDryRun   bool
+)
+
+func newStorageCmd() *cobra.Command {
+	storageCmd := &cobra.Command{
+		Use:   "storage",
+		Short: "Maintain the report storage",
+		Long: `Reports, their data and saved model responses are stored in the backend set by reports.backend:
+
+  files     content-addressed in reports.output_dir (default): objects/ holds each artifact once, named by its SHA-256, and runs/<date>.json lists the artifacts of each run
+  sqlite    a SQLite database, reports.dsn or cra.db in reports.output_dir
+  bbolt     a bbolt file, reports.dsn or cra.bolt in reports.output_dir
+  postgres  a PostgreSQL database at the reports.dsn URL, shared by a team
+
+Every backend records the SHA-256 of each artifact, which verify checks.`,
+	}
+
+	storageCmd.AddCommand(&cobra.Command{
+		Use:   "migrate",
+		Short: "Move older reports into the configured backend",
+		Long: `With the files backend, moves <date>.md, <date>.json and responses/<date>.json files from older versions into the store. They are read either way; migrating lets verify and prune cover them.
+
+With a database backend, copies every report in reports.output_dir into the database. The files are left in place.`,
+		Args: cobra.NoArgs,
+		RunE: runStorageMigrate,
+	})
+
+	storageCmd.AddCommand(&cobra.Command{
+		Use:   "verify",
+		Short: "Check every stored artifact is present and unchanged",
+		Args:  cobra.NoArgs,
+		RunE:  runStorageVerify,
+	})
+
+	pruneCmd := &cobra.Command{
+		Use:     "prune",
+		Short:   "Remove old runs and the artifacts no remaining run uses",
+		Example: "  review storage prune --keep-days 90 --dry-run",
+		Args:    cobra.NoArgs,
+		RunE:    runStoragePrune,
+	}
+	pruneCmd.Flags().IntVar(&pruneKeepDays, "keep-days", 0, "Remove runs older than this many days (0 keeps every run and only drops unused artifacts)")
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Show what would be removed without removing it")
+	storageCmd.AddCommand(pruneCmd)
+
+	return storageCmd
+}
+
+func loadStore(cmd *cobra.Command) (report.Backend, error) {
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return nil, err
+	}
+	return report.NewFormatter(cfg.Reports).Store(), nil
+}
+
+func runStorageMigrate(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	store, err := loadStore(cmd)
+	if err != nil {
+		return err
+	}
+	migrator, ok := store.(report.Migrator)
+	if !ok {
+		return fmt.Errorf("this storage backend has nothing to migrate")
+	}
+	moved, err := migrator.Migrate()
+	if err != nil {
+		return err
+	}
+	if moved == 0 {
+		fmt.Println("Nothing to migrate.")
+		return nil
+	}
+	fmt.Printf("Moved %d artifacts into the store\n", moved)
+	return nil
+}
+
+func runStorageVerify(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	store, err := loadStore(cmd)
+	if err != nil {
+		return err
+	}
+	problems, orphans, err := store.Verify()
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		fmt.Printf("%s %s: %s\n", p.Date, p.Kind, p.Detail)
+	}
+	if orphans > 0 {
+		fmt.Printf("%d artifacts are no longer used by any run; \"review storage prune\" removes them\n", orphans)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d stored artifacts are missing or corrupt", len(problems))
+	}
+	fmt.Println("All stored artifacts are intact.")
+	return nil
+}
+
+func runStoragePrune(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	if pruneKeepDays < 0 {
+		return errs.Config(fmt.Errorf("invalid --keep-days %d", pruneKeepDays), "pass a number of days, or 0 to keep every run")
+	}
+
+	store, err := loadStore(cmd)
+	if err != nil {
+		return err
+	}
+	before := ""
+	if pruneKeepDays > 0 {
+		before = time.Now().AddDate(0, 0, -pruneKeepDays).Format(report.DateLayout)
+	}
+	result, err := store.Prune(before, pruneDryRun)
+	if err != nil {
+		return err
+	}
+
+	verb := "Removed"
+	if pruneDryRun {
+		verb = "Would remove"
+	}
+	fmt.Printf("%s %d runs and %d artifacts (%s)\n", verb, len(result.Runs), result.Objects, formatBytes(result.Bytes))
+	return nil
+}
+
+// formatBytes renders a size for humans, e.g. 1.5 MB
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/juparave/codereviewer/internal/suppress"
+	"github.com/spf13/cobra"
+)
+
+var (
+	suppressReason string
+	suppressRemove bool
+	suppressList   bool
+)
+
+func newSuppressCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "suppress <finding-id>...",
+		Short: "Stop reporting an accepted finding",
+		Long: `Records findings in the suppressions file (review.suppressions_file, by default suppressions.yaml next to the config file) so they are left out of future reports.
+
+A finding's ID is a hash of its repository, files and title; it is shown in reports and by "review findings". Use --list to see suppressed findings and --remove to report them again.`,
+		Example: `  review suppress 3f2a9c1b7d4e --reason "accepted risk, see ADR-12"
+  review suppress --remove 3f2a9c1b7d4e`,
+		RunE: runSuppress,
+	}
+
+	cmd.Flags().StringVar(&suppressReason, "reason", "", "Why the finding is accepted")
+	cmd.Flags().BoolVar(&suppressRemove, "remove", false, "Lift the suppression of the given IDs")
+	cmd.Flags().BoolVar(&suppressList, "list", false, "List suppressed findings")
+
+	return cmd
+}
+
+func runSuppress(cmd *cobra.Command, args []string) error {
+	if !suppressList && len(args) == 0 {
+		return fmt.Errorf("requires at least 1 finding ID")
+	}
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if cfg.Review.SuppressionsFile == "" {
+		return fmt.Errorf("review.suppressions_file is not set")
+	}
+
+	list, err := suppress.Load(report.OpenState(cfg.Reports), cfg.Review.SuppressionsFile)
+	if err != nil {
+		return err
+	}
+
+	if suppressList {
+		if len(list.Entries) == 0 {
+			fmt.Println("No suppressed findings.")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tADDED\tREPOSITORY\tTITLE\tREASON")
+		for _, e := range list.Entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.ID, e.Added.Format(report.DateLayout), e.Repo, e.Title, e.Reason)
+		}
+		return w.Flush()
+	}
+
+	if suppressRemove {
+		for _, id := range args {
+			if !list.Remove(id) {
+				return fmt.Errorf("%s is not suppressed", id)
+			}
+			fmt.Printf("Unsuppressed %s\n", id)
+		}
+		return list.Save()
+	}
+
+	records, err := report.NewFormatter(cfg.Reports).Findings(report.Query{})
+	if err != nil {
+		return err
+	}
+	for _, id := range args {
+		rec, ok := lookupFinding(records, id)
+		if !ok {
+			return fmt.Errorf("no stored finding with ID %s, see \"review findings\"", id)
+		}
+		if list.Add(rec.Finding, suppressReason) {
+			fmt.Printf("Suppressed %s: %s (%s)\n", id, rec.Title, rec.RepoName)
+		} else {
+			fmt.Printf("%s is already suppressed\n", id)
+		}
+	}
+	return list.Save()
+}
+
+// lookupFinding returns the most recent record with the given ID
+func lookupFinding(records []report.Record, id string) (report.Record, bool) {
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].ID == id {
+			return records[i], true
+		}
+	}
+	return report.Record{}, false
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/spf13/cobra"
+)
+
+var (
+	userLead  bool
+	userRepos []string
+)
+
+func newUserCmd() *cobra.Command {
+	userCmd := &cobra.Command{
+		Use:   "user",
+		Short: "Manage the accounts of the team hub",
+		Long: `With server.auth on, review serve is a team hub: every request needs an API token, and engineers see only the repositories matching their patterns while leads see everything and can trigger reviews.
+
+Accounts are kept by the postgres or sqlite reports backend, so every server sharing the database shares them. Only a hash of each token is stored: a token is shown once, when created or rotated.`,
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "Create an account and print its API token",
+		Example: `  review user add alice --repos 'api-*' --repos web
+  review user add bob --lead`,
+		Args: cobra.ExactArgs(1),
+		RunE: runUserAdd,
+	}
+	addCmd.Flags().BoolVar(&userLead, "lead", false, "Let the user see every repository and trigger reviews")
+	addCmd.Flags().StringSliceVar(&userRepos, "repos", nil, "Patterns of the repositories an engineer sees: globs, /regexps/, or !patterns to exclude")
+	userCmd.AddCommand(addCmd)
+
+	userCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the accounts",
+		Args:  cobra.NoArgs,
+		RunE:  runUserList,
+	})
+
+	userCmd.AddCommand(&cobra.Command{
+		Use:   "remove <name>",
+		Short: "Delete an account and revoke its token",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runUserRemove,
+	})
+
+	userCmd.AddCommand(&cobra.Command{
+		Use:   "token <name>",
+		Short: "Replace the API token of an account and print the new one",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runUserToken,
+	})
+
+	return userCmd
+}
+
+func loadUsers(cmd *cobra.Command) (report.Users, error) {
+	cmd.SilenceUsage = true
+
+	cfg, err := loadConfig(cmd.Context())
+	if err != nil {
+		return nil, err
+	}
+	users, err := report.OpenUsers(cfg.Reports)
+	if err != nil {
+		return nil, errs.Config(err, "set reports.backend to postgres (or sqlite for a single machine) and reports.dsn")
+	}
+	return users, nil
+}
+
+func runUserAdd(cmd *cobra.Command, args []string) error {
+	users, err := loadUsers(cmd)
+	if err != nil {
+		return err
+	}
+
+	u := report.User{Name: args[0], Role: report.RoleEngineer, Repos: userRepos}
+	if userLead {
+		u.Role = report.RoleLead
+	}
+	token, err := users.AddUser(u)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Added %s (%s). API token, shown only once:\n\n  %s\n", u.Name, u.Role, token)
+	return nil
+}
+
+func runUserList(cmd *cobra.Command, args []string) error {
+	users, err := loadUsers(cmd)
+	if err != nil {
+		return err
+	}
+
+	list, err := users.Users()
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		fmt.Println("No users. Add one with `review user add`.")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tROLE\tREPOSITORIES\tCREATED")
+	for _, u := range list {
+		repos := strings.Join(u.Repos, ", ")
+		if u.Lead() {
+			repos = "all"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", u.Name, u.Role, repos, u.Created.Format(report.DateLayout))
+	}
+	return w.Flush()
+}
+
+func runUserRemove(cmd *cobra.Command, args []string) error {
+	users, err := loadUsers(cmd)
+	if err != nil {
+		return err
+	}
+	if err := users.RemoveUser(args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Removed %s\n", args[0])
+	return nil
+}
+
+func runUserToken(cmd *cobra.Command, args []string) error {
+	users, err := loadUsers(cmd)
+	if err != nil {
+		return err
+	}
+	token, err := users.RotateToken(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("New API token for %s, shown only once; the old one no longer works:\n\n  %s\n", args[0], token)
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/juparave/codereviewer/internal/buildinfo"
+	"github.com/spf13/cobra"
+)
+
+var versionJSON bool
+
+func newVersionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the version, commit and build date",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := buildinfo.Get()
+			if versionJSON {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(info)
+			}
+			fmt.Printf("review %s %s\n", info, info.GoVersion)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&versionJSON, "json", false, "Print the build metadata as JSON")
+
+	return cmd
+}
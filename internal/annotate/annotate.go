@@ -0,0 +1,97 @@
+// Package annotate stores notes the user leaves for the next review run,
+// such as "migrating auth service today, expect churn".
+package annotate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/state"
+	"gopkg.in/yaml.v3"
+)
+
+// Note is a user note for a review run
+type Note struct {
+	Text  string    `yaml:"text"`
+	Added time.Time `yaml:"added"`
+	// Used is the date (YYYY-MM-DD) of the report the note went into;
+	// empty while the note waits for the next run
+	Used string `yaml:"used,omitempty"`
+}
+
+// List is the set of notes stored in a YAML file
+type List struct {
+	store state.Store
+	doc   state.Doc
+	Notes []Note `yaml:"notes"`
+}
+
+// Load reads the notes file at path. A missing file is an empty list.
+func Load(st state.Store, path string) (*List, error) {
+	list := &List{store: st, doc: state.Doc{Key: "notes", Path: path, Perm: 0644}}
+
+	data, err := st.Read(list.doc)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return list, nil
+		}
+		return nil, fmt.Errorf("reading notes: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return list, nil
+}
+
+// Save writes the list back to its file
+func (l *List) Save() error {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	if err := l.store.Write(l.doc, data); err != nil {
+		return fmt.Errorf("writing notes: %w", err)
+	}
+	return nil
+}
+
+// Add queues a note for the next run
+func (l *List) Add(text string) {
+	l.Notes = append(l.Notes, Note{Text: text, Added: time.Now()})
+}
+
+// Pending returns the texts of the notes not used by a run yet
+func (l *List) Pending() []string {
+	var texts []string
+	for _, n := range l.Notes {
+		if n.Used == "" {
+			texts = append(texts, n.Text)
+		}
+	}
+	return texts
+}
+
+// MarkUsed records that the pending notes went into the report for date
+func (l *List) MarkUsed(date time.Time) {
+	for i := range l.Notes {
+		if l.Notes[i].Used == "" {
+			l.Notes[i].Used = date.Format("2006-01-02")
+		}
+	}
+}
+
+// ClearPending drops the notes not used by a run yet, returning how many
+func (l *List) ClearPending() int {
+	kept := l.Notes[:0]
+	for _, n := range l.Notes {
+		if n.Used != "" {
+			kept = append(kept, n)
+		}
+	}
+	dropped := len(l.Notes) - len(kept)
+	l.Notes = kept
+	return dropped
+}
@@ -0,0 +1,181 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/notify"
+	"github.com/juparave/codereviewer/internal/report"
+)
+
+// alertWeek is the window compared by a growth rule
+const alertWeek = 7 * 24 * time.Hour
+
+// checkAlerts evaluates the alerts rules against rpt and sends each one
+// that fires through its channels. A rule fires at most once a day, so a
+// spike doesn't alert again with every --watch batch.
+func (r *Runner) checkAlerts(ctx context.Context, rpt *domain.Report) error {
+	if len(r.config.Alerts) == 0 {
+		return nil
+	}
+	if r.holding() {
+		r.logger.Debug("paused, not checking alerts")
+		return nil
+	}
+
+	var history []*domain.Report
+	for i, rule := range r.config.Alerts {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("alerts[%d]", i)
+		}
+		day := rpt.Date.Format(report.DateLayout)
+		if r.alerted[name] == day {
+			continue
+		}
+
+		severity := domain.SeverityHigh
+		if rule.Severity != "" {
+			var ok bool
+			if severity, ok = domain.ParseSeverity(rule.Severity); !ok {
+				return errs.Config(fmt.Errorf("alerts %s: invalid severity %q", name, rule.Severity), "set severity to High, Medium or Low")
+			}
+		}
+		if rule.Growth > 0 && history == nil {
+			var err error
+			if history, err = r.report.History(); err != nil {
+				return fmt.Errorf("reading history for alerts: %w", err)
+			}
+		}
+
+		message := alertMessage(rule, severity, rpt, history)
+		if message == "" {
+			continue
+		}
+		alert := &domain.Alert{Rule: name, Message: message, Severity: severity, Findings: findingsOf(rpt, severity), Date: rpt.Date}
+		r.logger.Warn("alert fired", "rule", name, "message", message)
+		if err := r.sendAlert(ctx, rule, alert); err != nil {
+			return err
+		}
+		if r.alerted == nil {
+			r.alerted = make(map[string]string)
+		}
+		r.alerted[name] = day
+	}
+	return nil
+}
+
+// alertMessage describes why rule fires for rpt, empty when it doesn't
+func alertMessage(rule config.AlertRule, severity domain.Severity, rpt *domain.Report, history []*domain.Report) string {
+	count := len(findingsOf(rpt, severity))
+	if rule.Above > 0 && count > rule.Above {
+		return fmt.Sprintf("%d %s findings in one run, over the limit of %d", count, severity, rule.Above)
+	}
+	if rule.Growth > 0 {
+		current, previous := weeklyCounts(rpt, history, severity)
+		if previous > 0 && float64(current) >= rule.Growth*float64(previous) {
+			return fmt.Sprintf("%d %s findings in the last 7 days, %.1f× the %d of the week before", current, severity,
+				float64(current)/float64(previous), previous)
+		}
+	}
+	return ""
+}
+
+// weeklyCounts counts the findings of severity in the 7 days up to rpt,
+// rpt included, and in the 7 days before. Stored reports of rpt's day
+// are left out, as rpt replaces or adds to them.
+func weeklyCounts(rpt *domain.Report, history []*domain.Report, severity domain.Severity) (current, previous int) {
+	current = len(findingsOf(rpt, severity))
+	day := rpt.Date.Format(report.DateLayout)
+	for _, h := range history {
+		if h.Date.Format(report.DateLayout) == day {
+			continue
+		}
+		switch age := rpt.Date.Sub(h.Date); {
+		case age < 0:
+		case age < alertWeek:
+			current += len(findingsOf(h, severity))
+		case age < 2*alertWeek:
+			previous += len(findingsOf(h, severity))
+		}
+	}
+	return current, previous
+}
+
+func findingsOf(rpt *domain.Report, severity domain.Severity) []domain.Finding {
+	var found []domain.Finding
+	for _, f := range rpt.Findings {
+		if f.Severity == severity {
+			found = append(found, f)
+		}
+	}
+	return found
+}
+
+// sendAlert delivers alert through each of rule's channels
+func (r *Runner) sendAlert(ctx context.Context, rule config.AlertRule, alert *domain.Alert) error {
+	for _, channel := range rule.ResolveChannels() {
+		switch channel {
+		case "email":
+			if !r.config.Email.Enabled {
+				r.logger.Warn("alert not emailed, email is disabled", "rule", alert.Rule)
+				continue
+			}
+			if err := r.ensureNotify(); err != nil {
+				return err
+			}
+			if err := r.notify.SendAlert(ctx, alert, rule.To); err != nil {
+				return errs.Delivery(fmt.Errorf("emailing alert %s: %w", alert.Rule, err))
+			}
+		case "desktop":
+			if err := notify.Desktop(ctx, "🚨 Code review alert: "+alert.Rule, alert.Message); err != nil {
+				r.logger.Warn("alert not shown on the desktop", "rule", alert.Rule, "err", err)
+			}
+		default:
+			return errs.Config(fmt.Errorf("alerts %s: unknown channel %q", alert.Rule, channel), "run `review config validate`")
+		}
+	}
+	return nil
+}
+
+// usesDesktop reports whether runs show desktop notifications, for reports
+// or alerts
+func (r *Runner) usesDesktop() bool {
+	if r.config.Notify.Desktop {
+		return true
+	}
+	for _, rule := range r.config.Alerts {
+		if slices.Contains(rule.ResolveChannels(), "desktop") {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyDesktop shows the severity summary of rpt on the desktop when
+// notify.desktop is on. Notifications are best effort: a failure is
+// logged, not returned.
+func (r *Runner) notifyDesktop(ctx context.Context, rpt *domain.Report) {
+	if !r.config.Notify.Desktop || r.holding() {
+		return
+	}
+	title := "✅ Code review: no issues found"
+	if rpt.HasFindings() {
+		title = fmt.Sprintf("%s Code review: %d %s, %d %s, %d %s", r.report.Emoji(rpt.HighestSeverity()),
+			rpt.HighCount(), r.report.Label(domain.SeverityHigh),
+			rpt.MediumCount(), r.report.Label(domain.SeverityMedium),
+			rpt.LowCount(), r.report.Label(domain.SeverityLow))
+	}
+	body := fmt.Sprintf("%d commits in %d repositories", rpt.CommitCount, len(rpt.Repositories))
+	if len(rpt.Failures) > 0 {
+		body += fmt.Sprintf("; %d parts could not be reviewed", len(rpt.Failures))
+	}
+	if err := notify.Desktop(ctx, title, body); err != nil {
+		r.logger.Warn("report not shown on the desktop", "err", err)
+	}
+}
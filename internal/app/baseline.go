@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// Baseline reviews the current state of every repository under root_path
+// and returns the findings, for recording as the baseline. With
+// commitsOnly, only the commits in the review window are reviewed instead.
+// Suppressed findings and those in review.baseline_file are filtered out
+// as in any run.
+func (r *Runner) Baseline(ctx context.Context, commitsOnly bool) ([]domain.Finding, error) {
+	repos, err := r.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []domain.Diff
+	if commitsOnly {
+		commits, _, err := r.findCommits(ctx, repos)
+		if err != nil {
+			return nil, err
+		}
+		diffs = r.extractDiffs(ctx, commits)
+	} else {
+		r.logger.Debug("extracting current trees", "repos", len(repos))
+		for _, repo := range repos {
+			if op := r.git.OperationInProgress(ctx, repo.Path); op != "" {
+				r.logger.Warn("operation in progress, recording HEAD as is", "repo", repo.Name, "operation", op)
+			}
+			tree, err := r.diff.ExtractTree(ctx, repo, "HEAD")
+			if err != nil {
+				r.logger.Warn("reading repository failed", "repo", repo.Name, "err", err)
+				continue
+			}
+			diffs = append(diffs, tree...)
+		}
+		diffs = r.filterPaths(diffs)
+		r.logger.Debug("extracted files", "files", len(diffs))
+	}
+
+	if len(diffs) == 0 {
+		return nil, fmt.Errorf("no reviewable files found under %s", r.config.RootPath)
+	}
+
+	result, err := r.reviewDiffs(ctx, diffs)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Failures) > 0 {
+		return nil, fmt.Errorf("%d of the review chunks failed, the baseline would be incomplete: %s",
+			len(result.Failures), result.Failures[0].Error)
+	}
+	return result.Findings, nil
+}
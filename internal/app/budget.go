@@ -0,0 +1,38 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/review"
+)
+
+// budgetDiffs applies review.limits.token_budget: when reviewing diffs
+// would take more input tokens than that, the extractor keeps the
+// highest-priority files that fit. It returns the kept diffs and, when
+// some were left out, a note for the report.
+func (r *Runner) budgetDiffs(diffs []domain.Diff) ([]domain.Diff, string, error) {
+	budget := r.config.Review.Limits.TokenBudget
+	if budget <= 0 || r.config.Review.LLMDisabled() || len(diffs) == 0 {
+		return diffs, "", nil
+	}
+
+	sensitive, err := r.sensitivePaths()
+	if err != nil {
+		return nil, "", err
+	}
+	estimate := func(diffs []domain.Diff) int {
+		return review.EstimateUsage(diffs).InputTokens
+	}
+	total := estimate(diffs)
+	kept := r.diff.FitBudget(diffs, budget, sensitive, estimate)
+	if len(kept) == len(diffs) {
+		return diffs, "", nil
+	}
+
+	r.logger.Warn("changes over review.limits.token_budget, reviewing the highest-priority files",
+		"files", len(kept), "of", len(diffs), "tokens", total, "budget", budget)
+	note := fmt.Sprintf("Token budget: %d of %d files (about %d of %d input tokens) fit review.limits.token_budget; security-sensitive files and source went first, the rest are listed as left out",
+		len(kept), len(diffs), estimate(kept), total)
+	return kept, note, nil
+}
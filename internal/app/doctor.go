@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/git"
+	"github.com/juparave/codereviewer/internal/notify"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/juparave/codereviewer/internal/review"
+)
+
+// Doctor checks the environment a review depends on (git, LLM provider,
+// SMTP delivery, desktop notifications, reports directory) and returns one result per check
+func (r *Runner) Doctor(ctx context.Context) []config.Check {
+	var checks []config.Check
+
+	checks = append(checks, r.checkGit(ctx))
+	checks = append(checks, r.checkProvider(ctx))
+	checks = append(checks, r.checkSMTP())
+	if r.usesDesktop() {
+		checks = append(checks, checkDesktop())
+	}
+	checks = append(checks, r.checkReportsDir())
+
+	return checks
+}
+
+func (r *Runner) checkGit(ctx context.Context) config.Check {
+	version, err := r.git.Version(ctx)
+	if err != nil {
+		return config.Check{Field: "git", Status: config.CheckFail, Message: err.Error(), Fix: "install git and make sure it is on PATH"}
+	}
+	if !git.VersionAtLeast(version, git.MinVersion) {
+		return config.Check{
+			Field:   "git",
+			Status:  config.CheckFail,
+			Message: fmt.Sprintf("version %s is older than %s", version, git.MinVersion),
+			Fix:     "upgrade git to " + git.MinVersion + " or newer",
+		}
+	}
+	return config.Check{Field: "git", Status: config.CheckPass, Message: "version " + version}
+}
+
+func (r *Runner) checkProvider(ctx context.Context) config.Check {
+	field := "llm (" + r.config.Review.Provider + ")"
+	if r.config.Review.LLMDisabled() {
+		return config.Check{Field: "llm", Status: config.CheckWarn, Message: "LLM disabled, heuristics only, skipped"}
+	}
+	if r.config.Review.NeedsAPIKey() && r.config.Review.ResolveAPIKey() == "" {
+		return config.Check{
+			Field:   field,
+			Status:  config.CheckFail,
+			Message: "no API key configured",
+			Fix:     "set review.api_key or export one of the provider's API key variables",
+		}
+	}
+
+	reviewer, err := review.NewReviewer(r.config.Review, r.logger)
+	if err == nil {
+		err = reviewer.Ping(ctx)
+	}
+	if err != nil {
+		return config.Check{Field: field, Status: config.CheckFail, Message: err.Error(), Fix: "check the API key, model name and base_url"}
+	}
+	return config.Check{Field: field, Status: config.CheckPass, Message: "test generation succeeded with " + r.config.Review.Model}
+}
+
+func (r *Runner) checkSMTP() config.Check {
+	if !r.config.Email.Enabled {
+		return config.Check{Field: "smtp", Status: config.CheckWarn, Message: "email disabled, skipped"}
+	}
+
+	notifier, err := notify.NewService(r.config.Email, r.report, r.logger)
+	if err == nil {
+		err = notifier.CheckAuth()
+	}
+	if err != nil {
+		return config.Check{Field: "smtp", Status: config.CheckFail, Message: err.Error(), Fix: "check smtp_host, smtp_port and credentials"}
+	}
+	return config.Check{Field: "smtp", Status: config.CheckPass, Message: fmt.Sprintf("connected and authenticated to %s", r.config.Email.SMTPHost)}
+}
+
+// checkDesktop looks for the program showing desktop notifications
+func checkDesktop() config.Check {
+	command := notify.DesktopCommand()
+	if _, err := exec.LookPath(command); err != nil {
+		return config.Check{Field: "desktop", Status: config.CheckFail, Message: command + " not found on PATH", Fix: "install " + command + " (libnotify on Linux), or turn notify.desktop off"}
+	}
+	return config.Check{Field: "desktop", Status: config.CheckPass, Message: "notifications shown with " + command}
+}
+
+func (r *Runner) checkReportsDir() config.Check {
+	backend := r.config.Reports.Backend
+	if backend == "" {
+		backend = report.BackendSQLite
+	}
+	if backend != report.BackendFiles {
+		if err := r.report.CheckWritable(); err != nil {
+			return config.Check{Field: "reports", Status: config.CheckFail, Message: err.Error(), Fix: "check reports.backend and reports.dsn"}
+		}
+		return config.Check{Field: "reports", Status: config.CheckPass, Message: backend + " storage is reachable"}
+	}
+	if err := r.report.CheckWritable(); err != nil {
+		return config.Check{Field: "reports", Status: config.CheckFail, Message: err.Error(), Fix: "fix permissions or change reports.output_dir"}
+	}
+	return config.Check{Field: "reports", Status: config.CheckPass, Message: r.config.Reports.OutputDir + " is writable"}
+}
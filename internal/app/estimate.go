@@ -0,0 +1,74 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/review"
+	"github.com/juparave/codereviewer/internal/util"
+)
+
+// EstimateResult summarizes the work and expected LLM usage of a run
+type EstimateResult struct {
+	Repositories int
+	Commits      int
+	Files        int
+	review.Estimate
+}
+
+// Estimate runs scanning, commit discovery and diff extraction, then
+// estimates chunks and tokens without calling the LLM
+func (r *Runner) Estimate(ctx context.Context) (*EstimateResult, error) {
+	repos, commits, diffs, err := r.collectDiffs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EstimateResult{
+		Repositories: len(repos),
+		Commits:      len(commits),
+		Files:        len(diffs),
+		Estimate:     review.EstimateUsage(diffs),
+	}, nil
+}
+
+// Prompts runs scanning, commit discovery and diff extraction, then builds
+// the prompts a review would send without calling the LLM
+func (r *Runner) Prompts(ctx context.Context) ([]review.Prompt, error) {
+	if err := r.loadUserNotes(); err != nil {
+		return nil, err
+	}
+	_, _, diffs, err := r.collectDiffs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return review.Prompts(r.config.Review, diffs)
+}
+
+// collectDiffs runs the pipeline up to diff extraction
+func (r *Runner) collectDiffs(ctx context.Context) ([]domain.Repository, []domain.Commit, []domain.Diff, error) {
+	// Delivery and provider settings are irrelevant here, only the root matters
+	if !util.DirExists(r.config.RootPath) {
+		return nil, nil, nil, errs.Config(fmt.Errorf("root_path does not exist: %s", r.config.RootPath), "set root_path or pass --root")
+	}
+
+	repos, err := r.scan()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	commits, _, err := r.findCommits(ctx, repos)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	diffs, _, err := r.sampleDiffs(r.extractDiffs(ctx, commits))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	diffs, _, err = r.budgetDiffs(diffs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return repos, commits, diffs, nil
+}
@@ -0,0 +1,28 @@
+package app
+
+import "github.com/juparave/codereviewer/internal/domain"
+
+// exclude records a repository (empty path) or file left out of the run
+func (r *Runner) exclude(repo, path, reason, detail string) {
+	r.exclusions = append(r.exclusions, domain.Exclusion{Repo: repo, Path: path, Reason: reason, Detail: detail})
+}
+
+// runExclusions returns what the run left out, listing a file once per
+// reason even when several commits touched it
+func (r *Runner) runExclusions() []domain.Exclusion {
+	r.exclusions = append(r.exclusions, r.diff.TakeExclusions()...)
+
+	seen := make(map[domain.Exclusion]bool)
+	var unique []domain.Exclusion
+	for _, e := range r.exclusions {
+		key := domain.Exclusion{Repo: e.Repo, Path: e.Path, Reason: e.Reason}
+		if e.Path == "" {
+			key.Detail = e.Detail
+		}
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, e)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/forge"
+	"github.com/juparave/codereviewer/internal/scanner"
+)
+
+// linkPullRequests annotates findings whose files were changed by a commit
+// belonging to an open pull request, and optionally comments on that PR.
+// Forge errors are logged and never fail the run.
+func (r *Runner) linkPullRequests(ctx context.Context, findings []domain.Finding, diffs []domain.Diff) {
+	client, err := forge.NewClient(r.config.Forge, r.logger)
+	if err != nil {
+		r.logger.Warn("PR linking disabled", "err", err)
+		return
+	}
+
+	// Map repository+file to the commits that touched it
+	type fileKey struct{ repo, file string }
+	commitsByFile := make(map[fileKey][]string)
+	repoPaths := make(map[string]string)
+	for _, d := range diffs {
+		key := fileKey{d.RepoName, d.FilePath}
+		commitsByFile[key] = append(commitsByFile[key], d.CommitHash)
+		repoPaths[d.RepoName] = d.RepoPath
+	}
+
+	// Cache lookups: many findings share commits
+	remotes := make(map[string]string)
+	prsByCommit := make(map[string]*forge.PullRequest)
+
+	for i := range findings {
+		f := &findings[i]
+
+		repoPath, ok := repoPaths[f.RepoName]
+		if !ok {
+			continue
+		}
+		remote, ok := remotes[repoPath]
+		if !ok {
+			if url := r.git.RemoteURL(ctx, repoPath); url != "" {
+				remote = scanner.NameFromRemoteURL(url)
+			}
+			remotes[repoPath] = remote
+		}
+		if remote == "" {
+			continue
+		}
+
+		for _, file := range f.Files {
+			for _, sha := range commitsByFile[fileKey{f.RepoName, file}] {
+				pr, seen := prsByCommit[sha]
+				if !seen {
+					prs, err := client.OpenPullRequests(ctx, remote, sha)
+					if err != nil {
+						r.logger.Warn("looking up pull requests failed", "remote", remote, "commit", sha, "err", err)
+					} else if len(prs) > 0 {
+						pr = &prs[0]
+					}
+					prsByCommit[sha] = pr
+				}
+				if pr != nil && f.PRNumber == 0 {
+					f.PRNumber = pr.Number
+					f.PRURL = pr.URL
+				}
+			}
+		}
+
+		if f.PRNumber == 0 || !r.config.Forge.PostComments {
+			continue
+		}
+		body := "**Code Review Agent** found an issue in this pull request:\n\n" + r.report.FormatFinding(*f)
+		if err := client.Comment(ctx, remote, f.PRNumber, body); err != nil {
+			r.logger.Warn("commenting on pull request failed", "remote", remote, "pr", f.PRNumber, "err", err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/patch"
+	"github.com/juparave/codereviewer/internal/review"
+	"github.com/juparave/codereviewer/internal/scanner"
+	"github.com/juparave/codereviewer/internal/util"
+)
+
+// ReviewRange reviews the commits selected by revs (e.g. "origin/main..HEAD")
+// in the repository containing dir, without writing a report or sending email
+func (r *Runner) ReviewRange(ctx context.Context, dir string, revs ...string) (*review.Result, error) {
+	repo, err := r.localRepository(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := r.git.GetCommitsInRange(ctx, repo, revs...)
+	if err != nil {
+		return nil, errs.Git(err, "check the revisions exist, e.g. fetch before reviewing origin/main..HEAD")
+	}
+	r.logger.Debug("found commits", "commits", len(commits), "revisions", strings.Join(revs, " "))
+
+	diffs := r.extractDiffs(ctx, commits)
+	if len(diffs) == 0 {
+		return &review.Result{Summary: "No reviewable changes in range."}, nil
+	}
+	return r.reviewDiffs(ctx, diffs)
+}
+
+// ReviewStaged reviews the changes staged in the repository containing dir
+func (r *Runner) ReviewStaged(ctx context.Context, dir string) (*review.Result, error) {
+	repo, err := r.localRepository(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs, err := r.diff.ExtractStaged(ctx, repo)
+	if err != nil {
+		return nil, errs.Git(err, "check that git works in this repository with `git diff --cached`")
+	}
+	diffs = r.filterPaths(diffs)
+	if len(diffs) == 0 {
+		return &review.Result{Summary: "No reviewable staged changes."}, nil
+	}
+	return r.reviewDiffs(ctx, diffs)
+}
+
+// localRepository resolves the repository containing dir
+func (r *Runner) localRepository(ctx context.Context, dir string) (domain.Repository, error) {
+	top, err := r.git.TopLevel(ctx, dir)
+	if err != nil {
+		return domain.Repository{}, errs.Git(err, "run the command inside a repository or pass --repo")
+	}
+	repo := domain.Repository{Path: top, Name: scanner.GetRepoName(top)}
+
+	// Strictness and paths overrides and .cra.yaml apply; skip doesn't,
+	// the repository was asked for explicitly
+	if err := r.loadOverrides(); err != nil {
+		return domain.Repository{}, err
+	}
+	r.applyRepoSettings(repo)
+	return repo, nil
+}
+
+// ReviewURL downloads the patch or gist at rawURL and reviews its changes,
+// without writing a report or sending email
+func (r *Runner) ReviewURL(ctx context.Context, rawURL string) (*review.Result, error) {
+	src, err := patch.NewFetcher(r.config.Forge.ResolveToken()).Fetch(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// The exclude patterns apply; the patch belongs to no repository here
+	if err := r.loadOverrides(); err != nil {
+		return nil, err
+	}
+	diffs := r.diff.ExtractPatch(src.Patch, src.Name)
+	r.logger.Debug("parsed patch", "source", src.Name, "bytes", len(src.Patch), "files", len(diffs))
+	if len(diffs) == 0 {
+		return &review.Result{Summary: "No reviewable changes in the patch."}, nil
+	}
+	return r.reviewDiffs(ctx, diffs)
+}
+
+// ReviewPatches reviews the patches of src, such as a mailbox of git
+// format-patch mails from a mailing list, returning their commits with
+// the author, date and message of each mail. Commits by authors the
+// authors setting leaves out are skipped.
+func (r *Runner) ReviewPatches(ctx context.Context, src *patch.Source) (*review.Result, []domain.Commit, error) {
+	// The exclude patterns apply; the patches belong to no repository here
+	if err := r.loadOverrides(); err != nil {
+		return nil, nil, err
+	}
+	authors, err := util.CompilePatterns(r.config.Authors, true)
+	if err != nil {
+		return nil, nil, errs.Config(fmt.Errorf("invalid authors filter: %w", err), "fix the glob, or the regular expression between slashes, in authors or --authors")
+	}
+
+	all, diffs := r.diff.ExtractPatches(src.Patch, src.Name)
+	var commits []domain.Commit
+	skipped := make(map[string]bool)
+	for _, c := range all {
+		// Plain diffs have no author to match
+		if (c.Author == "" && c.Email == "") || authors.Match(c.Author, c.Email) {
+			commits = append(commits, c)
+		} else {
+			skipped[c.Hash] = true
+		}
+	}
+	diffs = slices.DeleteFunc(diffs, func(d domain.Diff) bool { return skipped[d.CommitHash] })
+	r.logger.Debug("parsed patches", "source", src.Name, "bytes", len(src.Patch), "patches", len(all), "kept", len(commits), "files", len(diffs))
+
+	if len(diffs) == 0 {
+		return &review.Result{Summary: "No reviewable changes in the patches."}, commits, nil
+	}
+	result, err := r.reviewDiffs(ctx, diffs)
+	return result, commits, err
+}
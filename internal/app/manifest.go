@@ -0,0 +1,117 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/report"
+)
+
+// elapsedMS is the milliseconds since start, for manifest timings
+func elapsedMS(start time.Time) int64 {
+	return time.Since(start).Milliseconds()
+}
+
+// recordRepos adds the scanned repositories to the run manifest
+func (r *Runner) recordRepos(repos []domain.Repository) {
+	for _, repo := range repos {
+		r.manifest.Repos = append(r.manifest.Repos, domain.ManifestRepo{Name: repo.Name, Path: repo.Path})
+	}
+}
+
+// recordCommits adds the commits in the review window to the run manifest
+func (r *Runner) recordCommits(commits []domain.Commit) {
+	perRepo := make(map[string]int)
+	for _, c := range commits {
+		perRepo[c.RepoPath]++
+		r.manifest.Commits = append(r.manifest.Commits, domain.ManifestCommit{
+			Repo:    c.RepoName,
+			Hash:    c.Hash,
+			Author:  c.Author,
+			Email:   c.Email,
+			Time:    c.Timestamp,
+			Subject: c.Message,
+		})
+	}
+	for i := range r.manifest.Repos {
+		r.manifest.Repos[i].Commits = perRepo[r.manifest.Repos[i].Path]
+	}
+}
+
+// recordDiffs adds the file changes sent for review to the run manifest
+func (r *Runner) recordDiffs(diffs []domain.Diff) {
+	for _, d := range diffs {
+		r.manifest.Diffs = append(r.manifest.Diffs, domain.ManifestDiff{
+			Repo:     d.RepoName,
+			Commit:   d.CommitHash,
+			Path:     d.FilePath,
+			OldPath:  d.OldPath,
+			Language: d.Language,
+			Lines:    d.LineCount,
+		})
+	}
+}
+
+// recordReport adds the outcome of the run in rpt to the run manifest
+func (r *Runner) recordReport(rpt *domain.Report, path string) {
+	m := r.manifest
+	m.ReportDate = rpt.Date.Format(report.DateLayout)
+	m.ReportPath = path
+	m.Model = rpt.Model
+	m.Findings = rpt.Findings
+	m.Failures = rpt.Failures
+	m.Exclusions = rpt.Exclusions
+	m.Sampling = rpt.Sampling
+	m.Usage = rpt.Usage
+	m.Provenance = rpt.Provenance
+	if rpt.NothingToNote {
+		m.Status = domain.RunNoChanges
+	} else {
+		m.Status = domain.RunReviewed
+	}
+}
+
+// writeManifest finishes the run manifest with the run's error, if any,
+// and writes it as JSON to path
+func (r *Runner) writeManifest(path string, runErr error) error {
+	m := r.manifest
+	m.Finished = time.Now()
+	m.Timings.TotalMS = m.Finished.Sub(m.Started).Milliseconds()
+	if runErr != nil {
+		m.Status = domain.RunFailed
+		m.Error = runErr.Error()
+	}
+	if m.Provenance.Version == "" {
+		m.Provenance = r.provenance()
+	}
+	// Empty lists rather than null keep consumers simple
+	if m.Repos == nil {
+		m.Repos = []domain.ManifestRepo{}
+	}
+	if m.Commits == nil {
+		m.Commits = []domain.ManifestCommit{}
+	}
+	if m.Diffs == nil {
+		m.Diffs = []domain.ManifestDiff{}
+	}
+	if m.Findings == nil {
+		m.Findings = []domain.Finding{}
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding run manifest: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating run manifest directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("writing run manifest: %w", err)
+	}
+	r.logger.Debug("run manifest saved", "path", path)
+	return nil
+}
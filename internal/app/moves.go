@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/report"
+)
+
+// linkMoves keeps the fingerprints of findings on files moved in the review
+// window stable: a finding that matches one of an earlier report on the
+// files' old paths takes over its OriginFiles, so suppressions, aging and
+// resolution tracking keep following it. Every earlier report is searched,
+// as the code around a finding may go untouched for weeks before the move,
+// and so are the suppressions and baseline, whose findings no report
+// lists. Errors are logged and leave the findings as they are. It reports
+// whether any finding was linked.
+func (r *Runner) linkMoves(ctx context.Context, findings []domain.Finding, commits []domain.Commit) bool {
+	if len(findings) == 0 {
+		return false
+	}
+	reports, err := r.report.History()
+	if err != nil {
+		r.logger.Warn("reading report history failed", "err", err)
+	}
+	lists, err := r.suppressionLists()
+	if err != nil {
+		r.logger.Warn("reading suppressions failed", "err", err)
+	}
+
+	// Earlier findings by the fingerprint of the paths they last named
+	day := r.reportDate().Format(report.DateLayout)
+	earlier := make(map[string]domain.Finding)
+	for _, rpt := range reports {
+		if rpt.Date.Format(report.DateLayout) >= day {
+			break
+		}
+		for _, f := range rpt.Findings {
+			earlier[domain.FingerprintOf(f.RepoName, f.Files, f.Title)] = f
+		}
+	}
+	if len(earlier) == 0 && len(lists) == 0 {
+		return false
+	}
+	suppressed := func(id string) bool {
+		for _, l := range lists {
+			if l.list.Contains(id) {
+				return true
+			}
+		}
+		return false
+	}
+
+	linked := false
+	history := commitsByRepo(commits)
+	renames := make(map[string]map[string]string)
+	for i := range findings {
+		f := &findings[i]
+		if len(f.Files) == 0 || len(f.OriginFiles) > 0 {
+			continue
+		}
+
+		moved, ok := renames[f.RepoName]
+		if !ok {
+			if repoCommits := history[f.RepoName]; len(repoCommits) > 0 {
+				var err error
+				moved, err = r.git.Renames(ctx, repoCommits[0].RepoPath, commitHashes(repoCommits))
+				if err != nil {
+					r.logger.Warn("looking up moved files failed", "repo", f.RepoName, "err", err)
+				}
+			}
+			renames[f.RepoName] = moved
+		}
+
+		oldPaths := make([]string, len(f.Files))
+		anyMoved := false
+		for j, file := range f.Files {
+			oldPaths[j] = file
+			if from, ok := moved[file]; ok {
+				oldPaths[j] = from
+				anyMoved = true
+			}
+		}
+		if !anyMoved {
+			continue
+		}
+
+		id := domain.FingerprintOf(f.RepoName, oldPaths, f.Title)
+		if match, ok := earlier[id]; ok {
+			f.OriginFiles = match.OriginFiles
+			if len(f.OriginFiles) == 0 {
+				f.OriginFiles = match.Files
+			}
+		} else if suppressed(id) {
+			f.OriginFiles = oldPaths
+		} else {
+			continue
+		}
+		linked = true
+		r.logger.Debug("finding's files were moved", "finding", f.Fingerprint(), "files", f.Files)
+	}
+	return linked
+}
@@ -0,0 +1,34 @@
+package app
+
+import (
+	"github.com/juparave/codereviewer/internal/annotate"
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// loadUserNotes passes the notes left with `review note` since the last
+// run to the reviewer
+func (r *Runner) loadUserNotes() error {
+	if r.config.Review.NotesFile == "" {
+		return nil
+	}
+	list, err := annotate.Load(r.report.State(), r.config.Review.NotesFile)
+	if err != nil {
+		return err
+	}
+	r.userNotes = list
+	r.config.Review.RunNotes = list.Pending()
+	if n := len(r.config.Review.RunNotes); n > 0 {
+		r.logger.Debug("using author notes", "notes", n)
+	}
+	return nil
+}
+
+// markNotesUsed records that the pending notes went into rpt, so the next
+// run doesn't repeat them
+func (r *Runner) markNotesUsed(rpt *domain.Report) error {
+	if r.userNotes == nil || len(rpt.UserNotes) == 0 {
+		return nil
+	}
+	r.userNotes.MarkUsed(rpt.Date)
+	return r.userNotes.Save()
+}
@@ -0,0 +1,231 @@
+package app
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/scanner"
+	"github.com/juparave/codereviewer/internal/util"
+)
+
+// repoOverride is a compiled overrides entry
+type repoOverride struct {
+	config.RepoOverride
+	repo  *scanner.Filter
+	paths *util.Patterns
+}
+
+// repoFile holds the compiled settings files of a repository: its
+// .cra.yaml and .cra/config.yaml, over those of the directories above it
+type repoFile struct {
+	config.RepoSettings
+	paths *util.Patterns
+}
+
+// loadOverrides compiles the overrides section, and passes exclude to the
+// extractor, on first use
+func (r *Runner) loadOverrides() error {
+	if r.overrides != nil {
+		return nil
+	}
+	if err := r.diff.SetExclude(r.config.Exclude); err != nil {
+		return errs.Config(fmt.Errorf("exclude: %w", err), "fix the gitignore-style pattern")
+	}
+
+	overrides := make([]repoOverride, 0, len(r.config.Overrides))
+	for i, o := range r.config.Overrides {
+		if o.Repo == "" {
+			continue
+		}
+		repo, err := scanner.NewFilter([]string{o.Repo})
+		if err != nil {
+			return errs.Config(fmt.Errorf("overrides[%d]: %w", i, err), "fix the repo pattern in overrides")
+		}
+		paths, err := util.CompilePatterns(o.Paths, false)
+		if err != nil {
+			return errs.Config(fmt.Errorf("overrides[%d].paths: %w", i, err), "fix the path pattern in overrides")
+		}
+		if _, err := util.CompileIgnore(o.Exclude); err != nil {
+			return errs.Config(fmt.Errorf("overrides[%d].exclude: %w", i, err), "fix the gitignore-style pattern in overrides")
+		}
+		overrides = append(overrides, repoOverride{RepoOverride: o, repo: repo, paths: paths})
+	}
+	r.overrides = overrides
+	return nil
+}
+
+// overrideFor returns the first override matching repo, or nil
+func (r *Runner) overrideFor(repo domain.Repository) *repoOverride {
+	for i := range r.overrides {
+		if r.overrides[i].repo.Match(repo) {
+			return &r.overrides[i]
+		}
+	}
+	return nil
+}
+
+// repoFileFor returns the compiled settings files of repo, nil when it has
+// none. Files that can't be read or parsed are ignored with a warning, so
+// one repository can't break the run.
+func (r *Runner) repoFileFor(repo domain.Repository) *repoFile {
+	if rf, ok := r.repoFiles[repo.Path]; ok {
+		return rf
+	}
+	if r.repoFiles == nil {
+		r.repoFiles = make(map[string]*repoFile)
+	}
+
+	var compiled *repoFile
+	settings, err := config.LoadRepoSettings(r.config.RootPath, repo.Path)
+	if err == nil && settings != nil {
+		var paths *util.Patterns
+		if paths, err = util.CompilePatterns(settings.Paths, false); err == nil {
+			if _, err = util.CompileIgnore(settings.Exclude); err == nil {
+				compiled = &repoFile{RepoSettings: *settings, paths: paths}
+				r.logger.Debug("loaded repository settings", "repo", repo.Name, "files", settings.Files)
+			}
+		}
+	}
+	if err != nil {
+		r.logger.Warn("ignoring repository settings", "repo", repo.Name, "err", err)
+	}
+	r.repoFiles[repo.Path] = compiled
+	return compiled
+}
+
+// skipReason returns where skip is set for repo, empty when it's reviewed
+func (r *Runner) skipReason(repo domain.Repository) string {
+	if o := r.overrideFor(repo); o != nil && o.Skip {
+		return "overrides: " + o.Repo
+	}
+	if rf := r.repoFileFor(repo); rf != nil && rf.Skip {
+		return rf.SkipFile
+	}
+	return ""
+}
+
+// applyOverrides drops repositories marked skip and passes the settings
+// of the others to the extractor and reviewer
+func (r *Runner) applyOverrides(repos []domain.Repository) ([]domain.Repository, error) {
+	if err := r.loadOverrides(); err != nil {
+		return nil, err
+	}
+
+	kept := repos[:0:0]
+	for _, repo := range repos {
+		if reason := r.skipReason(repo); reason != "" {
+			r.logger.Debug("skipping repository", "repo", repo.Name, "reason", "skip is set in "+reason)
+			r.exclude(repo.Name, "", domain.ExcludedRepoSkip, reason)
+			continue
+		}
+		r.applyRepoSettings(repo)
+		kept = append(kept, repo)
+	}
+	return kept, nil
+}
+
+// applyRepoSettings passes repo's strictness and risk, from its override
+// or else its settings files, to the reviewer, along with the guidance of
+// its settings files, and its excludes and languages to the extractor. Risk
+// critical also loads full files as context; experimental lowers the
+// strictness to low unless one is set. Only overrides choose the LLM
+// backend: a repository can't send its own code elsewhere.
+func (r *Runner) applyRepoSettings(repo domain.Repository) {
+	o, rf := r.overrideFor(repo), r.repoFileFor(repo)
+
+	var exclude []string
+	if o != nil {
+		exclude = append(exclude, o.Exclude...)
+	}
+	if rf != nil {
+		exclude = append(exclude, rf.Exclude...)
+	}
+	if len(exclude) > 0 {
+		// Both lists were compiled when loaded
+		r.diff.SetRepoExclude(repo.Path, exclude)
+	}
+
+	strictness := ""
+	if rf != nil {
+		strictness = rf.Strictness
+	}
+	if o != nil && o.Strictness != "" {
+		strictness = o.Strictness
+	}
+
+	risk := ""
+	if rf != nil {
+		risk = rf.Risk
+	}
+	if o != nil && o.Risk != "" {
+		risk = o.Risk
+	}
+	if risk != "" && risk != config.RiskNormal {
+		if r.config.Review.RepoRisk == nil {
+			r.config.Review.RepoRisk = make(map[string]string)
+		}
+		r.config.Review.RepoRisk[repo.Name] = risk
+	}
+	if o != nil && o.Backend != "" {
+		if r.config.Review.RepoBackend == nil {
+			r.config.Review.RepoBackend = make(map[string]string)
+		}
+		r.config.Review.RepoBackend[repo.Name] = o.Backend
+	}
+
+	switch risk {
+	case config.RiskCritical:
+		r.diff.SetRepoFullContext(repo.Path)
+	case config.RiskExperimental:
+		if strictness == "" {
+			strictness = "low"
+		}
+	}
+
+	if strictness != "" {
+		if r.config.Review.RepoStrictness == nil {
+			r.config.Review.RepoStrictness = make(map[string]string)
+		}
+		r.config.Review.RepoStrictness[repo.Name] = strictness
+	}
+
+	if rf == nil {
+		return
+	}
+	if len(rf.Languages) > 0 {
+		r.diff.SetRepoLanguages(repo.Path, rf.Languages)
+	}
+	if rf.PromptAddendum != "" {
+		if r.config.Review.RepoGuidance == nil {
+			r.config.Review.RepoGuidance = make(map[string]string)
+		}
+		r.config.Review.RepoGuidance[repo.Name] = rf.PromptAddendum
+	}
+}
+
+// filterPaths drops diffs outside the paths of their repository's
+// override, or of its settings files when the override sets none
+func (r *Runner) filterPaths(diffs []domain.Diff) []domain.Diff {
+	var kept []domain.Diff
+	for _, d := range diffs {
+		repo := domain.Repository{Path: d.RepoPath, Name: d.RepoName}
+		names := []string{d.FilePath, path.Base(d.FilePath)}
+		if o := r.overrideFor(repo); o != nil && !o.paths.Empty() {
+			if !o.paths.Match(names...) {
+				r.exclude(d.RepoName, d.FilePath, domain.ExcludedOverridePaths, "overrides: "+o.Repo)
+				continue
+			}
+		} else if rf := r.repoFileFor(repo); rf != nil && !rf.paths.Match(names...) {
+			r.exclude(d.RepoName, d.FilePath, domain.ExcludedOverridePaths, rf.PathsFile)
+			continue
+		}
+		kept = append(kept, d)
+	}
+	if dropped := len(diffs) - len(kept); dropped > 0 {
+		r.logger.Debug("dropped files outside override paths", "files", dropped)
+	}
+	return kept
+}
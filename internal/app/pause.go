@@ -0,0 +1,151 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/pause"
+	"github.com/juparave/codereviewer/internal/report"
+)
+
+// checkPause applies the pause window at the start of a run, reporting
+// whether to skip the run. Once a pause has ended, the reports held during
+// it are emailed as a catch-up digest and the pause is cleared.
+func (r *Runner) checkPause(ctx context.Context) (bool, error) {
+	if r.config.PauseFile == "" {
+		return false, nil
+	}
+	state, err := pause.Load(r.report.State(), r.config.PauseFile)
+	if err != nil {
+		return false, err
+	}
+	r.pause = state
+
+	now := time.Now()
+	switch {
+	case state.Active(now) && state.Mode != pause.ModeHold:
+		return true, nil
+	case state.Active(now):
+		r.logger.Debug("paused, holding email for a catch-up digest", "until", state.Until.Format(report.DateLayout))
+	case state.Ended(now) || len(state.Held) > 0:
+		if _, err := r.Resume(ctx); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// holding reports whether an active pause holds email
+func (r *Runner) holding() bool {
+	return r.pause != nil && r.pause.Active(time.Now()) && r.pause.Mode == pause.ModeHold
+}
+
+// holdReport records that rpt's email is held until the pause ends
+func (r *Runner) holdReport(rpt *domain.Report) error {
+	r.pause.Hold(rpt.Date.Format(report.DateLayout))
+	r.logger.Debug("email held", "until", r.pause.Until.Format(report.DateLayout))
+	return r.pause.Save()
+}
+
+// holdStored applies an active pause to emailing a stored report outside
+// a run, with send or replay --send: a pause that holds email holds it for
+// the catch-up digest, one that skips reviews refuses. It reports whether
+// the email was held.
+func (r *Runner) holdStored(ctx context.Context, rpt *domain.Report) (bool, error) {
+	skip, err := r.checkPause(ctx)
+	if err != nil {
+		return false, err
+	}
+	if skip {
+		return false, errs.Config(fmt.Errorf("paused until %s", r.pause.Until.Format(report.DateLayout)),
+			"run `review resume` to end the pause first")
+	}
+	if !r.holding() {
+		return false, nil
+	}
+	r.logger.Info("paused, email held for the catch-up digest", "until", r.pause.Until.Format(report.DateLayout))
+	return true, r.holdReport(rpt)
+}
+
+// Resume ends the pause and emails a catch-up digest of the reports held
+// during it, returning the digest (nil when nothing was held)
+func (r *Runner) Resume(ctx context.Context) (*domain.Report, error) {
+	state := r.pause
+	if state == nil {
+		var err error
+		if state, err = pause.Load(r.report.State(), r.config.PauseFile); err != nil {
+			return nil, err
+		}
+	}
+
+	digest, err := r.catchUpDigest(state.Held)
+	if err != nil {
+		return nil, err
+	}
+	if digest != nil {
+		r.logger.Info("pause over, sending catch-up digest", "held", len(state.Held))
+		if err := r.sendReport(ctx, digest, nil); err != nil {
+			return digest, err
+		}
+	}
+
+	state.Clear()
+	r.pause = state
+	return digest, state.Save()
+}
+
+// catchUpDigest combines the held reports into one, keeping each finding
+// once, from the latest report it appears in
+func (r *Runner) catchUpDigest(dates []string) (*domain.Report, error) {
+	var reports []*domain.Report
+	for _, date := range dates {
+		rpt, err := r.report.Load(date)
+		if err != nil {
+			r.logger.Warn("loading held report failed", "date", date, "err", err)
+			continue
+		}
+		reports = append(reports, rpt)
+	}
+	if len(reports) == 0 {
+		return nil, nil
+	}
+
+	first := reports[0].Date.Format(report.DateLayout)
+	last := reports[len(reports)-1].Date.Format(report.DateLayout)
+	digest := &domain.Report{
+		Date:       r.now(),
+		Summary:    fmt.Sprintf("Catch-up digest of %d reviews held while paused, from %s to %s.", len(reports), first, last),
+		Model:      reports[len(reports)-1].Model,
+		Provenance: r.provenance(),
+	}
+
+	index := make(map[string]int)
+	repos := make(map[string]bool)
+	for _, rpt := range reports {
+		digest.CommitCount += rpt.CommitCount
+		digest.FileCount += rpt.FileCount
+		digest.Failures = append(digest.Failures, rpt.Failures...)
+		for _, name := range rpt.Repositories {
+			if !repos[name] {
+				repos[name] = true
+				digest.Repositories = append(digest.Repositories, name)
+			}
+		}
+		for _, f := range rpt.Findings {
+			id := f.Fingerprint()
+			if i, ok := index[id]; ok {
+				digest.Findings[i] = f
+				continue
+			}
+			index[id] = len(digest.Findings)
+			digest.Findings = append(digest.Findings, f)
+		}
+		if rpt.Summary != "" && !rpt.NothingToNote {
+			digest.Notes = append(digest.Notes, fmt.Sprintf("%s: %s", rpt.Date.Format(report.DateLayout), rpt.Summary))
+		}
+	}
+	return digest, nil
+}
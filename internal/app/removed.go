@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"sort"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// markRemoved downgrades to Low the findings whose files were all deleted
+// by a later commit in the review window, noting the commit in RemovedIn:
+// the code they point at is already gone, so they shouldn't raise alarms.
+// Git errors are logged and leave the findings as they are.
+func (r *Runner) markRemoved(ctx context.Context, findings []domain.Finding, commits []domain.Commit) {
+	if len(findings) == 0 {
+		return
+	}
+
+	history := commitsByRepo(commits)
+	deleted := make(map[string]map[string]string)
+	for i := range findings {
+		f := &findings[i]
+		if len(f.Files) == 0 {
+			continue
+		}
+
+		files, ok := deleted[f.RepoName]
+		if !ok {
+			if repoCommits := history[f.RepoName]; len(repoCommits) > 0 {
+				var err error
+				files, err = r.git.DeletedFiles(ctx, repoCommits[0].RepoPath, commitHashes(repoCommits))
+				if err != nil {
+					r.logger.Warn("looking up deleted files failed", "repo", f.RepoName, "err", err)
+				}
+			}
+			deleted[f.RepoName] = files
+		}
+
+		removedIn := ""
+		for _, file := range f.Files {
+			hash, ok := files[file]
+			if !ok {
+				removedIn = ""
+				break
+			}
+			removedIn = hash
+		}
+		if removedIn == "" {
+			continue
+		}
+		r.logger.Debug("finding's code was removed later", "finding", f.Fingerprint(), "commit", removedIn)
+		f.Severity = domain.SeverityLow
+		f.RemovedIn = removedIn
+	}
+}
+
+// commitsByRepo groups commits by repository name, oldest first so the
+// last change to a file decides
+func commitsByRepo(commits []domain.Commit) map[string][]domain.Commit {
+	byRepo := make(map[string][]domain.Commit)
+	for _, c := range commits {
+		byRepo[c.RepoName] = append(byRepo[c.RepoName], c)
+	}
+	for _, repoCommits := range byRepo {
+		sort.SliceStable(repoCommits, func(a, b int) bool {
+			return repoCommits[a].Timestamp.Before(repoCommits[b].Timestamp)
+		})
+	}
+	return byRepo
+}
+
+// commitHashes returns the hashes of commits, in order
+func commitHashes(commits []domain.Commit) []string {
+	hashes := make([]string, len(commits))
+	for i, c := range commits {
+		hashes[i] = c.Hash
+	}
+	return hashes
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/review"
+)
+
+// Replay rebuilds the report for date (YYYY-MM-DD) from the model answers
+// saved with review.save_responses, without calling the LLM. Answers are
+// parsed again and the current severity and suppression filters apply.
+// The report is rewritten, and emailed when send is true.
+func (r *Runner) Replay(ctx context.Context, date string, send bool) (*domain.Report, error) {
+	stored, err := r.report.Load(date)
+	if err != nil {
+		return nil, fmt.Errorf("no report data for %s: %w", date, err)
+	}
+	data, err := r.report.LoadResponses(date)
+	if err != nil {
+		return nil, errs.Config(err, "set review.save_responses: true and run a review to save model responses")
+	}
+	transcript, err := review.DecodeTranscript(data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := review.Replay(transcript.Responses)
+	r.logger.Debug("replayed model responses", "responses", len(transcript.Responses), "findings", len(result.Findings), "failed_chunks", len(result.Failures))
+
+	findings := append(result.Findings, transcript.Local...)
+	findings = r.filterFocus(r.filterSeverity(findings))
+	if findings, err = r.filterSuppressed(findings); err != nil {
+		return nil, err
+	}
+
+	rpt := *stored
+	rpt.Summary = result.Summary
+	rpt.Findings = findings
+	rpt.Failures = result.Failures
+	rpt.NothingToNote = false
+	rpt.Provenance = r.provenance()
+	rpt.Provenance.Provider = stored.Provenance.Provider
+	// Keep the run's time so the report stays the latest run of its day
+	rpt.Provenance.GeneratedAt = stored.Provenance.GeneratedAt
+	if rpt.Provenance.GeneratedAt.IsZero() {
+		rpt.Provenance.GeneratedAt = time.Now()
+	}
+
+	reportPath, err := r.report.Write(&rpt)
+	if err != nil {
+		return nil, fmt.Errorf("writing report: %w", err)
+	}
+	r.logger.Debug("report saved", "path", reportPath)
+
+	if send {
+		if err := r.config.Validate(); err != nil {
+			return &rpt, errs.Config(fmt.Errorf("invalid configuration: %w", err), "run `review config validate` to see every problem and its fix")
+		}
+		if held, err := r.holdStored(ctx, &rpt); err != nil || held {
+			return &rpt, err
+		}
+		if err := r.sendReport(ctx, &rpt, r.report.Previous(rpt.Date)); err != nil {
+			return &rpt, err
+		}
+	}
+	return &rpt, nil
+}
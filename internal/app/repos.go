@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/git"
+	"github.com/juparave/codereviewer/internal/scanner"
+	"github.com/juparave/codereviewer/internal/util"
+)
+
+// RepoStatus describes a discovered repository's activity in the review window
+type RepoStatus struct {
+	domain.Repository
+	LastCommit time.Time // Zero when the repository has no commits
+	Commits    int       // Commits in the review window
+	Files      int       // Reviewable file diffs in those commits
+	Included   bool      // Whether a review would cover this repository
+	Reason     string    // Why the repository is excluded
+	Err        error     // Set when the repository's history couldn't be read
+}
+
+// ListRepositories scans for repositories and reports, for each one, its
+// recent activity and whether it would be included in a review
+func (r *Runner) ListRepositories(ctx context.Context) ([]RepoStatus, error) {
+	if !util.DirExists(r.config.RootPath) {
+		return nil, errs.Config(fmt.Errorf("root_path does not exist: %s", r.config.RootPath), "set root_path or pass --root")
+	}
+
+	repos, err := r.scanAll()
+	if err != nil {
+		return nil, err
+	}
+	list, err := scanner.NewListFilter(r.config.Repos.Include, r.config.Repos.Exclude)
+	if err != nil {
+		return nil, errs.Config(err, "fix the glob, or the regular expression between slashes")
+	}
+	filter, err := scanner.NewFilter(r.config.Scanner.Repos)
+	if err != nil {
+		return nil, errs.Config(err, "fix the pattern in scanner.repos or --repos")
+	}
+	if err := r.loadOverrides(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]RepoStatus, 0, len(repos))
+	for _, repo := range repos {
+		if reason := list.Reason(repo); reason != "" {
+			statuses = append(statuses, RepoStatus{Repository: repo, Reason: "filtered by " + reason})
+			continue
+		}
+		if !filter.Match(repo) {
+			statuses = append(statuses, RepoStatus{Repository: repo, Reason: "filtered by scanner.repos"})
+			continue
+		}
+		if reason := r.skipReason(repo); reason != "" {
+			statuses = append(statuses, RepoStatus{Repository: repo, Reason: "skipped by " + reason})
+			continue
+		}
+		r.applyRepoSettings(repo)
+		opts, err := r.logOptions(repo)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, r.repoStatus(ctx, repo, opts))
+	}
+	return statuses, nil
+}
+
+func (r *Runner) repoStatus(ctx context.Context, repo domain.Repository, opts git.LogOptions) RepoStatus {
+	status := RepoStatus{Repository: repo}
+
+	last, err := r.git.LastCommitTime(ctx, repo)
+	if err != nil {
+		status.Err = err
+		status.Reason = "git error"
+		return status
+	}
+	status.LastCommit = last
+
+	commits, err := r.git.GetCommits(ctx, repo, opts)
+	if err != nil {
+		status.Err = err
+		status.Reason = "git error"
+		return status
+	}
+	status.Commits = len(commits)
+	if status.Commits == 0 {
+		status.Reason = "no commits in window"
+		return status
+	}
+
+	status.Files = len(r.extractDiffs(ctx, commits))
+	if status.Files == 0 {
+		status.Reason = "no reviewable files"
+		return status
+	}
+
+	status.Included = true
+	return status
+}
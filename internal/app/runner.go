@@ -2,186 +2,816 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/juparave/codereviewer/internal/annotate"
+	"github.com/juparave/codereviewer/internal/buildinfo"
+	"github.com/juparave/codereviewer/internal/cache"
 	"github.com/juparave/codereviewer/internal/config"
 	"github.com/juparave/codereviewer/internal/diff"
 	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/dupes"
+	"github.com/juparave/codereviewer/internal/errs"
 	"github.com/juparave/codereviewer/internal/git"
+	"github.com/juparave/codereviewer/internal/heuristics"
 	"github.com/juparave/codereviewer/internal/notify"
+	"github.com/juparave/codereviewer/internal/pause"
+	"github.com/juparave/codereviewer/internal/progress"
 	"github.com/juparave/codereviewer/internal/report"
 	"github.com/juparave/codereviewer/internal/review"
 	"github.com/juparave/codereviewer/internal/scanner"
+	"github.com/juparave/codereviewer/internal/state"
+	"github.com/juparave/codereviewer/internal/suppress"
+	"github.com/juparave/codereviewer/internal/util"
 )
 
 // Runner orchestrates the full code review flow
 type Runner struct {
 	config  *config.Config
-	logger  *log.Logger
+	logger  *slog.Logger
 	scanner *scanner.Scanner
 	git     *git.Client
 	diff    *diff.Extractor
 	review  *review.Reviewer
 	report  *report.Formatter
 	notify  *notify.Service
+	cache   *cache.Store // Shared with the other CRA processes; nil when it can't be located
+
+	progress  *progress.Reporter // Events of the run, nil when nobody listens; see SetProgress
+	userNotes *annotate.List     // Loaded by Run
+	pause     *pause.State       // Loaded by Run
+	manifest  *domain.Manifest   // Record of the current Run
+
+	overrides  []repoOverride       // Compiled config.Overrides, see loadOverrides
+	repoFiles  map[string]*repoFile // Compiled settings files by repository path, see repoFileFor
+	exclusions []domain.Exclusion   // What the run left out, see runExclusions
+	alerted    map[string]string    // Day each alerts rule last fired, see checkAlerts
+
+	reviewWindow *reviewWindow  // Resolved since and overlap, see window
+	unreported   *domain.Usage  // LLM calls of the run no report holds yet, see recordFailedRun
+	location     *time.Location // Configured timezone, see now
+	patchIDs     []string       // Of the commits found, with overlap set
+
+	reloadMu sync.Mutex
+	reloaded *Runner // Set by Reload, taken over by Watch before its next poll
 }
 
 // NewRunner creates a new Runner instance
 func NewRunner(cfg *config.Config) *Runner {
-	logger := log.New(os.Stdout, "[CRA] ", log.LstdFlags)
+	// The process-wide logger, set up from the log settings; it writes to
+	// stderr or the log file so stdout carries only requested output
+	logger := slog.Default()
+
+	extractor := diff.NewExtractor(cfg.Languages, logger)
+	extractor.SetLimits(cfg.Review.Limits.MaxDiffLines, cfg.Review.Limits.MaxFileBytes)
+
+	store, err := cache.Open(cfg.Cache, logger)
+	if err != nil {
+		logger.Debug("not caching", "err", err)
+	}
+	repoScanner := scanner.New(cfg.Scanner, logger)
+	repoScanner.SetCache(store)
 
 	return &Runner{
-		config:  cfg,
-		logger:  logger,
-		scanner: scanner.New(logger),
-		git:     git.NewClient(logger),
-		diff:    diff.NewExtractor(logger),
-		report:  report.NewFormatter(cfg.Reports.OutputDir),
+		config:   cfg,
+		logger:   logger,
+		scanner:  repoScanner,
+		git:      git.NewClient(logger),
+		diff:     extractor,
+		report:   report.NewFormatter(cfg.Reports),
+		cache:    store,
+		location: cfg.Location(),
 		// review and notify initialized in Run() after validation
 	}
 }
 
-// Run executes the full review pipeline
-func (r *Runner) Run(ctx context.Context) error {
-	startTime := time.Now()
+// SetProgress emits the progress of runs to p
+func (r *Runner) SetProgress(p *progress.Reporter) {
+	r.progress = p
+}
+
+// Run executes the full review pipeline and returns the report it wrote,
+// nil when there were no repositories to review. With
+// reports.manifest_path set, it also writes a run manifest there, failed
+// runs included.
+func (r *Runner) Run(ctx context.Context) (*domain.Report, error) {
+	r.manifest = &domain.Manifest{Version: domain.ManifestVersion, Started: time.Now()}
+	r.reviewWindow, r.patchIDs, r.unreported = nil, nil, nil
+	rpt, err := r.run(ctx)
+	switch {
+	case err != nil:
+		r.progress.Stage(progress.StageFailed, err.Error())
+		r.recordFailedRun(err)
+	case rpt == nil:
+		r.progress.Stage(progress.StageDone, "nothing to review")
+	default:
+		r.progress.Stage(progress.StageDone, fmt.Sprintf("%d findings", len(rpt.Findings)))
+	}
+	if path := r.config.Reports.ManifestPath; path != "" {
+		if writeErr := r.writeManifest(path, err); writeErr != nil && err == nil {
+			err = writeErr
+		}
+	}
+	return rpt, err
+}
+
+func (r *Runner) run(ctx context.Context) (*domain.Report, error) {
+	startTime := r.manifest.Started
 
 	// Validate configuration
 	if err := r.config.Validate(); err != nil {
-		return fmt.Errorf("invalid configuration: %w", err)
+		return nil, errs.Config(fmt.Errorf("invalid configuration: %w", err), "run `review config validate` to see every problem and its fix")
 	}
 
-	r.log("Starting code review for %s", r.config.RootPath)
-	r.log("Using LLM Provider: %s | Model: %s", r.config.Review.Provider, r.config.Review.Model)
+	if skip, err := r.checkPause(ctx); err != nil || skip {
+		if skip {
+			r.logger.Info("paused, skipping the review", "until", r.pause.Until.Format(report.DateLayout))
+			r.manifest.Status = domain.RunPaused
+		}
+		return nil, err
+	}
+	if err := r.loadUserNotes(); err != nil {
+		return nil, err
+	}
+
+	r.logger.Debug("starting review", "root", r.config.RootPath)
+	if r.config.Review.LLMDisabled() {
+		r.logger.Debug("LLM disabled, reviewing with built-in heuristics")
+	} else {
+		r.logger.Debug("using LLM", "provider", r.config.Review.Provider, "model", r.config.Review.Model)
+	}
 
 	// Step 1: Scan for repositories
-	r.log("Scanning for Git repositories...")
-	repos, err := r.scanner.FindRepositories(r.config.RootPath)
+	r.progress.Stage(progress.StageScan, "scanning "+r.config.RootPath)
+	stage := time.Now()
+	repos, err := r.scan()
 	if err != nil {
-		return fmt.Errorf("scanning repositories: %w", err)
+		return nil, err
 	}
-	r.log("Found %d repositories", len(repos))
+	r.manifest.Timings.ScanMS = elapsedMS(stage)
+	r.recordRepos(repos)
 
 	if len(repos) == 0 {
-		r.log("No repositories found, nothing to review")
-		return nil
+		r.logger.Debug("no repositories found, nothing to review")
+		r.manifest.Status = domain.RunNoRepositories
+		return nil, nil
 	}
 
 	// Step 2: Find commits
-	if r.config.Since != "" {
-		r.log("Finding commits since %s...", r.config.Since)
-	} else {
-		r.log("Finding today's commits...")
-	}
-
-	var allCommits []domain.Commit
-	for _, repoPath := range repos {
-		commits, err := r.git.GetCommits(ctx, repoPath, r.config.Since)
-		if err != nil {
-			r.log("Warning: failed to get commits from %s: %v", repoPath, err)
-			continue
-		}
-		allCommits = append(allCommits, commits...)
+	stage = time.Now()
+	allCommits, notes, err := r.findCommits(ctx, repos)
+	if err != nil {
+		return nil, err
 	}
-	r.log("Found %d commits from today", len(allCommits))
+	r.manifest.Timings.CommitsMS = elapsedMS(stage)
+	r.recordCommits(allCommits)
 
 	if len(allCommits) == 0 {
-		r.log("No commits today, nothing to review")
-		return r.handleNoFindings(ctx)
+		r.logger.Debug("no commits in the review window, nothing to review")
+		return r.handleNoFindings(ctx, notes)
 	}
 
 	// Step 3: Extract diffs
-	r.log("Extracting diffs...")
-	var allDiffs []domain.Diff
-	for _, commit := range allCommits {
-		diffs, err := r.diff.Extract(ctx, commit)
-		if err != nil {
-			r.log("Warning: failed to extract diff for %s: %v", commit.Hash[:8], err)
-			continue
-		}
-		allDiffs = append(allDiffs, diffs...)
-	}
-	r.log("Extracted %d file diffs", len(allDiffs))
+	r.progress.Stage(progress.StageDiffs, fmt.Sprintf("%d commits", len(allCommits)))
+	stage = time.Now()
+	allDiffs := r.extractDiffs(ctx, allCommits)
+	r.manifest.Timings.DiffsMS = elapsedMS(stage)
+	r.recordDiffs(allDiffs)
 
 	if len(allDiffs) == 0 {
-		r.log("No relevant diffs found, nothing to review")
-		return r.handleNoFindings(ctx)
+		r.logger.Debug("no reviewable diffs, nothing to review")
+		return r.handleNoFindings(ctx, notes)
 	}
 
-	// Step 4: Initialize reviewer and perform review
-	r.log("Initializing LLM reviewer...")
-	reviewer, err := review.NewReviewer(r.config.Review, r.logger)
+	// Step 4: Initialize reviewer and review the diffs, or a sample of
+	// them on a day over review.sampling.max_lines
+	stage = time.Now()
+	reviewed, sampling, err := r.sampleDiffs(allDiffs)
+	if err != nil {
+		return nil, err
+	}
+	if sampling != nil {
+		notes = append(notes, samplingNote(sampling))
+	}
+	reviewed, note, err := r.budgetDiffs(reviewed)
+	if err != nil {
+		return nil, err
+	}
+	if note != "" {
+		notes = append(notes, note)
+	}
+	result, err := r.reviewDiffs(ctx, reviewed)
 	if err != nil {
-		return fmt.Errorf("initializing reviewer: %w", err)
+		return nil, err
 	}
-	r.review = reviewer
 
-	r.log("Reviewing code changes...")
-	findings, summary, err := r.review.Review(ctx, allDiffs)
+	duplicates, err := r.findDuplicates(allDiffs)
 	if err != nil {
-		return fmt.Errorf("reviewing code: %w", err)
+		return nil, err
+	}
+	result.Findings = append(result.Findings, duplicates...)
+	if r.linkMoves(ctx, result.Findings, allCommits) {
+		// Suppressions and the baseline name moved findings by origin
+		result.Findings, err = r.filterSuppressed(result.Findings)
+		if err != nil {
+			return nil, err
+		}
+	}
+	r.markRemoved(ctx, result.Findings, allCommits)
+	// Downgraded findings may now fall below review.min_severity
+	result.Findings = r.filterSeverity(result.Findings)
+
+	if r.config.Forge.Provider != "" && len(result.Findings) > 0 {
+		r.logger.Debug("linking findings to open pull requests")
+		r.linkPullRequests(ctx, result.Findings, allDiffs)
 	}
-	r.log("Found %d issues", len(findings))
+	r.manifest.Timings.ReviewMS = elapsedMS(stage)
 
 	// Step 5: Generate report
-	r.log("Generating report...")
+	r.logger.Debug("generating report")
+	r.progress.Stage(progress.StageReport, fmt.Sprintf("%d findings", len(result.Findings)))
+	stage = time.Now()
 	rpt := &domain.Report{
-		Date:         time.Now(),
-		Summary:      summary,
-		Findings:     findings,
-		Repositories: repos,
+		Date:         r.reportDate(),
+		Summary:      result.Summary,
+		Findings:     result.Findings,
+		Repositories: repoNames(repos),
 		CommitCount:  len(allCommits),
-		FileCount:    len(allDiffs),
-		Model:        r.config.Review.Model,
+		FileCount:    len(reviewed),
+		Model:        r.model(),
+		Notes:        notes,
+		UserNotes:    r.config.Review.RunNotes,
+		Failures:     result.Failures,
+		Exclusions:   r.runExclusions(),
+		Provenance:   r.provenance(),
 	}
+	if result.Usage.Requests() > 0 || result.Usage.Reused > 0 {
+		rpt.Usage = &result.Usage
+	}
+	rpt.Sampling = sampling
+	rpt.Diffs = r.reportDiffs(reviewed)
+	r.splitDays(rpt, allCommits, allDiffs)
 
 	reportPath, err := r.report.Write(rpt)
 	if err != nil {
-		return fmt.Errorf("writing report: %w", err)
+		return nil, fmt.Errorf("writing report: %w", err)
+	}
+	r.unreported = nil
+	r.logger.Debug("report saved", "path", reportPath)
+	r.recordReport(rpt, reportPath)
+	if err := r.recordWindow(rpt.Date); err != nil {
+		return rpt, err
+	}
+	if err := r.markNotesUsed(rpt); err != nil {
+		return rpt, err
 	}
-	r.log("Report saved to %s", reportPath)
 
-	// Step 6: Send email notification
-	if r.config.Email.Enabled && rpt.HasFindings() {
-		r.log("Sending email notification...")
-		notifier, err := notify.NewService(r.config.Email, r.logger)
+	if r.config.Review.SaveResponses && len(result.Responses) > 0 {
+		data, err := review.EncodeTranscript(&review.Transcript{Responses: result.Responses, Local: duplicates})
 		if err != nil {
-			return fmt.Errorf("initializing email service: %w", err)
+			return rpt, err
 		}
-		r.notify = notifier
+		path, err := r.report.SaveResponses(rpt.Date.Format(report.DateLayout), data)
+		if err != nil {
+			return rpt, err
+		}
+		r.logger.Debug("model responses saved", "path", path)
 
-		if err := r.notify.SendReport(ctx, rpt); err != nil {
-			return fmt.Errorf("sending email: %w", err)
+		data, err = review.EncodeRequests(result.Requests)
+		if err != nil {
+			return rpt, err
+		}
+		if _, err := r.report.SavePrompts(rpt.Date.Format(report.DateLayout), data); err != nil {
+			return rpt, err
 		}
-		r.log("Email sent successfully")
 	}
+	r.manifest.Timings.ReportMS = elapsedMS(stage)
+
+	// Step 6: Send email notification, unless a pause holds it
+	r.progress.Stage(progress.StageDeliver, "")
+	stage = time.Now()
+	if r.holding() {
+		if err := r.holdReport(rpt); err != nil {
+			return rpt, err
+		}
+	} else if err := r.sendReport(ctx, rpt, r.report.Previous(rpt.Date)); err != nil {
+		return rpt, err
+	}
+	r.notifyDesktop(ctx, rpt)
+	if err := r.checkAlerts(ctx, rpt); err != nil {
+		return rpt, err
+	}
+	r.manifest.Timings.EmailMS = elapsedMS(stage)
 
 	elapsed := time.Since(startTime)
-	r.log("Review complete in %s", elapsed.Round(time.Millisecond))
+	r.logger.Debug("review complete", "duration", elapsed.Round(time.Millisecond), "findings", len(rpt.Findings))
+
+	return rpt, nil
+}
+
+// sendReport emails rpt when email is enabled and it has findings or
+// failures. previous, which may be nil, is used for the manager view trend.
+func (r *Runner) sendReport(ctx context.Context, rpt, previous *domain.Report) error {
+	if !r.config.Email.Enabled || (!rpt.HasFindings() && len(rpt.Failures) == 0) {
+		return nil
+	}
+	return r.deliverEmail(ctx, rpt, previous)
+}
+
+// deliverEmail emails the report
+func (r *Runner) deliverEmail(ctx context.Context, rpt, previous *domain.Report) error {
+	r.logger.Debug("sending email")
+	if err := r.ensureNotify(); err != nil {
+		return err
+	}
+
+	if err := r.notify.SendReport(ctx, rpt, previous); err != nil {
+		return errs.Delivery(fmt.Errorf("sending email: %w", err))
+	}
+	r.logger.Debug("email sent", "findings", len(rpt.Findings))
+	return nil
+}
 
+// ensureNotify sets up the email service on first use
+func (r *Runner) ensureNotify() error {
+	if r.notify != nil {
+		return nil
+	}
+	notifier, err := notify.NewService(r.config.Email, r.report, r.logger)
+	if err != nil {
+		return errs.Config(fmt.Errorf("initializing email service: %w", err), "check the email settings with `review config validate`")
+	}
+	r.notify = notifier
 	return nil
 }
 
-func (r *Runner) handleNoFindings(ctx context.Context) error {
+// reviewDiffs sends diffs to the LLM reviewer and applies severity filtering
+func (r *Runner) reviewDiffs(ctx context.Context, diffs []domain.Diff) (*review.Result, error) {
+	if r.config.Review.LLMDisabled() {
+		return r.reviewHeuristics(diffs)
+	}
+
+	if r.review == nil {
+		r.logger.Debug("initializing LLM reviewer")
+		reviewer, err := review.NewReviewer(r.config.Review, r.logger)
+		var cfgErr *errs.ConfigError
+		if errors.As(err, &cfgErr) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, errs.Provider(fmt.Errorf("initializing reviewer: %w", err))
+		}
+		r.review = reviewer
+	}
+	r.review.SetProgress(func(done, total int) {
+		r.progress.Step(progress.StageReview, "", done, total, fmt.Sprintf("%d of %d requests", done, total))
+	})
+	if r.config.Cache.Responses {
+		r.review.SetCache(r.cache)
+	}
+
+	r.logger.Debug("reviewing changes", "files", len(diffs))
+	result, err := r.review.Review(ctx, diffs)
+	if result != nil {
+		r.unreported = &result.Usage
+	}
+	if err != nil {
+		return nil, errs.Provider(fmt.Errorf("reviewing code: %w", err))
+	}
+	result.Findings = r.filterFocus(r.filterSeverity(result.Findings))
+	result.Findings, err = r.filterSuppressed(result.Findings)
+	if err != nil {
+		return nil, err
+	}
+	r.logger.Debug("review finished", "findings", len(result.Findings))
+	if u := result.Usage; u.Calls > 0 {
+		r.logger.Debug("LLM usage", "calls", u.Calls, "input_tokens", u.InputTokens, "cached_tokens", u.CachedTokens,
+			"cache_hit_rate", u.CacheHitRate(), "repeated_tokens", u.RepeatedTokens, "output_tokens", u.OutputTokens, "reused", u.Reused)
+	}
+	if len(result.Failures) > 0 {
+		r.logger.Warn("review chunks failed, see the report", "failed_chunks", len(result.Failures))
+	}
+
+	return result, nil
+}
+
+// reviewHeuristics reviews diffs with the built-in heuristics instead of
+// the LLM
+func (r *Runner) reviewHeuristics(diffs []domain.Diff) (*review.Result, error) {
+	r.logger.Debug("checking changes with heuristics", "files", len(diffs))
+	r.progress.Stage(progress.StageReview, "checking with heuristics")
+	findings := heuristics.Review(diffs)
+	summary := heuristics.Summary(diffs, findings)
+
+	findings = r.filterFocus(r.filterSeverity(findings))
+	findings, err := r.filterSuppressed(findings)
+	if err != nil {
+		return nil, err
+	}
+	r.logger.Debug("review finished", "findings", len(findings))
+	return &review.Result{Findings: findings, Summary: summary}, nil
+}
+
+// model names what reviewed the changes, for the report
+func (r *Runner) model() string {
+	if r.config.Review.LLMDisabled() {
+		return heuristics.Model
+	}
+	return r.config.Review.Model
+}
+
+// findDuplicates reports near-identical changes made in several
+// repositories, filtered like LLM findings
+func (r *Runner) findDuplicates(diffs []domain.Diff) ([]domain.Finding, error) {
+	if r.config.Review.DuplicateMinLines <= 0 {
+		return nil, nil
+	}
+
+	findings := dupes.Find(diffs, r.config.Review.DuplicateMinLines)
+	if len(findings) == 0 {
+		return nil, nil
+	}
+	r.logger.Debug("found changes duplicated across repositories", "findings", len(findings))
+	findings = r.filterFocus(r.filterSeverity(findings))
+	return r.filterSuppressed(findings)
+}
+
+// scan finds the repositories under the configured root path that pass
+// repos.include and repos.exclude and the scanner.repos filter, and
+// aren't skipped by an override
+func (r *Runner) scan() ([]domain.Repository, error) {
+	list, err := scanner.NewListFilter(r.config.Repos.Include, r.config.Repos.Exclude)
+	if err != nil {
+		return nil, errs.Config(err, "fix the glob, or the regular expression between slashes")
+	}
+	filter, err := scanner.NewFilter(r.config.Scanner.Repos)
+	if err != nil {
+		return nil, errs.Config(err, "fix the pattern in scanner.repos or --repos")
+	}
+
+	// Only the repositories the filters keep count toward max_repos
+	r.scanner.SetCounted(func(repo domain.Repository) bool {
+		return list.Reason(repo) == "" && (filter.Empty() || filter.Match(repo))
+	}, fmt.Sprintf("%q %q %q", r.config.Repos.Include, r.config.Repos.Exclude, r.config.Scanner.Repos))
+	repos, err := r.scanAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var listed []domain.Repository
+	for _, repo := range repos {
+		if reason := list.Reason(repo); reason != "" {
+			r.exclude(repo.Name, "", domain.ExcludedRepoList, reason)
+			continue
+		}
+		listed = append(listed, repo)
+	}
+	repos = listed
+
+	if !filter.Empty() {
+		patterns := strings.Join(r.config.Scanner.Repos, ",")
+		var kept []domain.Repository
+		for _, repo := range repos {
+			if filter.Match(repo) {
+				kept = append(kept, repo)
+			} else {
+				r.exclude(repo.Name, "", domain.ExcludedRepoFilter, "scanner.repos: "+patterns)
+			}
+		}
+		repos = kept
+		r.logger.Debug("filtered repositories", "repos", len(repos), "patterns", patterns)
+	}
+	return r.applyOverrides(repos)
+}
+
+// Discover finds every repository under the configured root path,
+// ignoring scanner.repos and overrides
+func (r *Runner) Discover() ([]domain.Repository, error) {
+	r.scanner.SetCounted(nil, "")
+	return r.scanAll()
+}
+
+// scanAll finds every repository under the configured root path
+func (r *Runner) scanAll() ([]domain.Repository, error) {
+	r.logger.Debug("scanning for repositories", "root", r.config.RootPath)
+	repos, err := r.scanner.FindRepositories(r.config.RootPath)
+	if err != nil {
+		return nil, errs.Config(fmt.Errorf("scanning repositories: %w", err), "check that root_path exists and is readable")
+	}
+	r.logger.Debug("found repositories", "repos", len(repos))
+	return repos, nil
+}
+
+// findCommits lists the commits in the review window across repos,
+// skipping repositories whose history can't be read. It returns notes for
+// the report about repositories skipped or reviewed partially because a
+// rebase, merge or similar operation is unfinished.
+func (r *Runner) findCommits(ctx context.Context, repos []domain.Repository) ([]domain.Commit, []string, error) {
+	w, err := r.window()
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case r.config.Until != "":
+		r.logger.Debug("finding commits", "since", git.ParseSince(w.since, r.now()), "until", r.config.Until)
+	case w.since != "":
+		r.logger.Debug("finding commits", "since", w.since)
+	default:
+		r.logger.Debug("finding commits", "since", "today")
+	}
+
+	var allCommits []domain.Commit
+	var notes []string
+	for i, repo := range repos {
+		r.progress.Step(progress.StageCommits, repo.Name, i, len(repos), "reading commits")
+		if op := r.git.OperationInProgress(ctx, repo.Path); op != "" {
+			if r.config.Scanner.InProgress == "skip" {
+				r.logger.Debug("skipping repository", "repo", repo.Name, "reason", op+" in progress")
+				notes = append(notes, fmt.Sprintf("%s was skipped: a %s is in progress.", repo.Name, op))
+				r.exclude(repo.Name, "", domain.ExcludedInProgress, op+" in progress")
+				continue
+			}
+			r.logger.Debug("operation in progress, reviewing only commits on branches", "repo", repo.Name, "operation", op)
+			notes = append(notes, fmt.Sprintf("%s has a %s in progress; only commits already on a branch were reviewed.", repo.Name, op))
+		}
+
+		opts, err := r.logOptions(repo)
+		if err != nil {
+			return nil, nil, err
+		}
+		commits, err := r.git.GetCommits(ctx, repo, opts)
+		if err != nil {
+			r.logger.Warn("reading commits failed", "repo", repo.Name, "err", err)
+			r.exclude(repo.Name, "", domain.ExcludedGitError, err.Error())
+			continue
+		}
+		commits, patchIDs := r.dropReviewed(ctx, repo, commits)
+		r.patchIDs = append(r.patchIDs, patchIDs...)
+		allCommits = append(allCommits, commits...)
+	}
+	r.logger.Debug("found commits", "commits", len(allCommits))
+	return allCommits, notes, nil
+}
+
+// logOptions builds the commit selection for repo from the configured
+// window, author filter and branch selection
+func (r *Runner) logOptions(repo domain.Repository) (git.LogOptions, error) {
+	authors, err := util.CompilePatterns(r.config.Authors, true)
+	if err != nil {
+		return git.LogOptions{}, errs.Config(fmt.Errorf("invalid authors filter: %w", err), "fix the glob, or the regular expression between slashes, in authors or --authors")
+	}
+	selection, err := r.branchSelection(repo)
+	if err != nil {
+		return git.LogOptions{}, err
+	}
+	branches, err := util.CompilePatterns(selection.Include, false)
+	if err != nil {
+		return git.LogOptions{}, errs.Config(fmt.Errorf("invalid branches filter: %w", err), "fix the glob, or the regular expression between slashes, in branches or --branch")
+	}
+	w, err := r.window()
+	if err != nil {
+		return git.LogOptions{}, err
+	}
+	return git.LogOptions{
+		Since:         w.since,
+		Until:         r.config.Until,
+		Authors:       authors,
+		Branches:      branches,
+		DefaultBranch: selection.DefaultBranch,
+		Merges:        r.config.MergeResolutions,
+		Location:      r.location,
+	}, nil
+}
+
+// branchSelection returns the branches.repos entry matching repo, or the
+// top-level branch selection
+func (r *Runner) branchSelection(repo domain.Repository) (config.BranchSelection, error) {
+	for _, override := range r.config.Branches.Repos {
+		filter, err := scanner.NewFilter([]string{override.Repo})
+		if err != nil {
+			return config.BranchSelection{}, errs.Config(fmt.Errorf("branches.repos: %w", err), "fix the repo pattern in branches.repos")
+		}
+		if filter.Match(repo) {
+			return override.BranchSelection, nil
+		}
+	}
+	return r.config.Branches.BranchSelection, nil
+}
+
+// extractDiffs collects the reviewable file diffs of the given commits
+func (r *Runner) extractDiffs(ctx context.Context, commits []domain.Commit) []domain.Diff {
+	r.logger.Debug("extracting diffs", "commits", len(commits))
+	var allDiffs []domain.Diff
+	for i, commit := range commits {
+		r.progress.Step(progress.StageDiffs, commit.RepoName, i, len(commits), "extracting "+domain.ShortHash(commit.Hash))
+		diffs, err := r.diff.Extract(ctx, commit)
+		if err != nil {
+			r.logger.Warn("extracting diff failed", "repo", commit.RepoName, "commit", commit.Hash, "err", err)
+			continue
+		}
+		allDiffs = append(allDiffs, diffs...)
+	}
+	allDiffs = r.filterPaths(allDiffs)
+	r.logger.Debug("extracted diffs", "files", len(allDiffs))
+	return allDiffs
+}
+
+func (r *Runner) handleNoFindings(ctx context.Context, notes []string) (*domain.Report, error) {
 	rpt := &domain.Report{
-		Date:          time.Now(),
+		Date:          r.reportDate(),
 		Summary:       "No code changes to review today.",
 		NothingToNote: true,
+		Notes:         notes,
+		UserNotes:     r.config.Review.RunNotes,
+		Exclusions:    r.runExclusions(),
+		Provenance:    r.provenance(),
 	}
 
 	reportPath, err := r.report.Write(rpt)
 	if err != nil {
-		return fmt.Errorf("writing report: %w", err)
+		return nil, fmt.Errorf("writing report: %w", err)
+	}
+	r.unreported = nil
+	r.logger.Debug("report saved", "path", reportPath)
+	r.recordReport(rpt, reportPath)
+	if err := r.recordWindow(rpt.Date); err != nil {
+		return rpt, err
+	}
+	if err := r.markNotesUsed(rpt); err != nil {
+		return rpt, err
 	}
-	r.log("Report saved to %s", reportPath)
+	r.notifyDesktop(ctx, rpt)
 
-	return nil
+	return rpt, nil
+}
+
+// provenance describes this build and run for the report
+func (r *Runner) provenance() domain.Provenance {
+	info := buildinfo.Get()
+	return domain.Provenance{
+		Version:     info.Version,
+		Commit:      info.Commit,
+		BuildDate:   info.Date,
+		Provider:    r.config.Review.Provider,
+		GeneratedAt: time.Now(),
+	}
+}
+
+// recordFailedRun stores the LLM calls of a run that failed before its
+// report was written, so provider health counts them. Storage errors are
+// only logged: the run's own error is the one to report.
+func (r *Runner) recordFailedRun(runErr error) {
+	if r.unreported == nil || r.unreported.Requests() == 0 {
+		return
+	}
+	r.manifest.Usage = r.unreported
+	run := domain.FailedRun{Time: time.Now(), Error: runErr.Error(), Usage: *r.unreported}
+	if err := r.report.SaveFailedRun(r.reportDate().Format(report.DateLayout), run); err != nil {
+		r.logger.Warn("recording the failed run for provider health failed", "err", err)
+	}
+	r.unreported = nil
+}
+
+// reportDate is the date a report is filed under: the last day of the
+// window when --until names a day, so reruns of past days don't overwrite
+// today's report
+func (r *Runner) reportDate() time.Time {
+	now := r.now()
+	if day, ok := git.UntilDate(r.config.Until, now); ok {
+		return day
+	}
+	return now
+}
+
+// now returns the current time in the configured timezone, which decides
+// where days start
+func (r *Runner) now() time.Time {
+	if r.location == nil {
+		return time.Now()
+	}
+	return time.Now().In(r.location)
+}
+
+// filterFocus drops findings outside review.focus, uncategorized ones
+// included as nothing places them in focus
+func (r *Runner) filterFocus(findings []domain.Finding) []domain.Finding {
+	if len(r.config.Review.Focus) == 0 {
+		return findings
+	}
+	focus := make(map[domain.Category]bool)
+	for _, area := range r.config.Review.Focus {
+		if c, ok := domain.ParseCategory(area); ok {
+			focus[c] = true
+		}
+	}
+
+	var kept []domain.Finding
+	for _, f := range findings {
+		if focus[f.Category] {
+			kept = append(kept, f)
+		}
+	}
+	if dropped := len(findings) - len(kept); dropped > 0 {
+		r.logger.Debug("dropped findings outside the focus", "findings", dropped)
+	}
+	return kept
+}
+
+// filterSeverity drops findings below review.min_severity
+func (r *Runner) filterSeverity(findings []domain.Finding) []domain.Finding {
+	min, ok := domain.ParseSeverity(r.config.Review.MinSeverity)
+	if !ok {
+		return findings
+	}
+
+	var kept []domain.Finding
+	for _, f := range findings {
+		if f.Severity.Rank() >= min.Rank() {
+			kept = append(kept, f)
+		}
+	}
+	if dropped := len(findings) - len(kept); dropped > 0 {
+		r.logger.Debug("dropped findings below minimum severity", "findings", dropped, "min_severity", min)
+	}
+	return kept
+}
+
+// suppressionList is a loaded suppressions file or baseline
+type suppressionList struct {
+	kind string
+	list *suppress.List
+}
+
+// suppressionLists loads the suppressions file and the baseline, those set
+func (r *Runner) suppressionLists() ([]suppressionList, error) {
+	// The baseline is committed with the repository, so it stays a file
+	files := []struct {
+		kind, path string
+		store      state.Store
+	}{
+		{"suppressed", r.config.Review.SuppressionsFile, r.report.State()},
+		{"baseline", r.config.Review.BaselineFile, state.Files},
+	}
+	var lists []suppressionList
+	for _, file := range files {
+		if file.path == "" {
+			continue
+		}
+		list, err := suppress.Load(file.store, file.path)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, suppressionList{file.kind, list})
+	}
+	return lists, nil
+}
+
+// filterSuppressed drops findings recorded in the suppressions file or
+// the baseline
+func (r *Runner) filterSuppressed(findings []domain.Finding) ([]domain.Finding, error) {
+	lists, err := r.suppressionLists()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range lists {
+		var dropped int
+		findings, dropped = l.list.Filter(findings)
+		if dropped > 0 {
+			r.logger.Debug("dropped findings", "findings", dropped, "list", l.kind)
+		}
+	}
+	return findings, nil
+}
+
+// reportDiffs returns the diffs as reviewed for the report's appendix,
+// nil when reports.appendix is off
+func (r *Runner) reportDiffs(diffs []domain.Diff) []domain.ReportDiff {
+	if !r.config.Reports.Appendix.Enabled() {
+		return nil
+	}
+	out := make([]domain.ReportDiff, len(diffs))
+	for i, d := range diffs {
+		out[i] = domain.ReportDiff{
+			Repo:    d.RepoName,
+			Commit:  d.CommitHash,
+			Path:    d.FilePath,
+			OldPath: d.OldPath,
+			Content: d.Content,
+		}
+	}
+	return out
 }
 
-func (r *Runner) log(format string, args ...interface{}) {
-	if r.config.Verbose {
-		r.logger.Printf(format, args...)
+// repoNames returns the display names of the given repositories
+func repoNames(repos []domain.Repository) []string {
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		names[i] = repo.Name
 	}
+	return names
 }
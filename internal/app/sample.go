@@ -0,0 +1,103 @@
+package app
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"path"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/juparave/codereviewer/internal/util"
+)
+
+// sampleDiffs applies review.sampling: when diffs exceed max_lines, it
+// keeps every security-sensitive file and a random sample of the others
+// that fits the rest of the budget. The sample is seeded with the report
+// date, so a rerun of the same day reviews the same files. It returns the
+// kept diffs and, when some were left out, what was sampled.
+func (r *Runner) sampleDiffs(diffs []domain.Diff) ([]domain.Diff, *domain.Sampling, error) {
+	limit := r.config.Review.Sampling.MaxLines
+	if limit <= 0 || r.config.Review.LLMDisabled() {
+		return diffs, nil, nil
+	}
+
+	total := 0
+	for _, d := range diffs {
+		total += r.reviewedLines(d)
+	}
+	if total <= limit {
+		return diffs, nil, nil
+	}
+
+	sensitive, err := r.sensitivePaths()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sampling := &domain.Sampling{TotalFiles: len(diffs), TotalLines: total}
+	keep := make([]bool, len(diffs))
+	var rest []int
+	budget := limit
+	for i, d := range diffs {
+		if sensitive.Match(d.FilePath, path.Base(d.FilePath)) {
+			keep[i] = true
+			sampling.SensitiveFiles++
+			budget -= r.reviewedLines(d)
+		} else {
+			rest = append(rest, i)
+		}
+	}
+
+	seed := fnv.New64a()
+	seed.Write([]byte(r.reportDate().Format(report.DateLayout)))
+	rng := rand.New(rand.NewPCG(seed.Sum64(), 0))
+	rng.Shuffle(len(rest), func(i, j int) { rest[i], rest[j] = rest[j], rest[i] })
+	for _, i := range rest {
+		if lines := r.reviewedLines(diffs[i]); lines <= budget {
+			keep[i] = true
+			budget -= lines
+		}
+	}
+
+	var kept []domain.Diff
+	for i, d := range diffs {
+		if !keep[i] {
+			r.exclude(d.RepoName, d.FilePath, domain.ExcludedSampled, fmt.Sprintf("review.sampling.max_lines: %d", limit))
+			continue
+		}
+		kept = append(kept, d)
+		sampling.ReviewedFiles++
+		sampling.ReviewedLines += r.reviewedLines(d)
+	}
+	r.logger.Info("sampled changes over review.sampling.max_lines", "files", sampling.ReviewedFiles, "of", sampling.TotalFiles,
+		"lines", sampling.ReviewedLines, "total_lines", sampling.TotalLines, "sensitive", sampling.SensitiveFiles)
+	return kept, sampling, nil
+}
+
+// sensitivePaths compiles review.sampling.sensitive, defaulting to
+// config.DefaultSensitivePaths
+func (r *Runner) sensitivePaths() (*util.Patterns, error) {
+	patterns := r.config.Review.Sampling.Sensitive
+	if len(patterns) == 0 {
+		patterns = config.DefaultSensitivePaths
+	}
+	sensitive, err := util.CompilePatterns(patterns, true)
+	if err != nil {
+		return nil, errs.Config(fmt.Errorf("review.sampling.sensitive: %w", err), "fix the glob, or the regular expression between slashes")
+	}
+	return sensitive, nil
+}
+
+// reviewedLines is the number of lines of d sent for review
+func (r *Runner) reviewedLines(d domain.Diff) int {
+	return min(d.LineCount, r.diff.MaxLines())
+}
+
+// samplingNote describes the sample for the report
+func samplingNote(s *domain.Sampling) string {
+	return fmt.Sprintf("Sampled review: %.0f%% of the changed lines (%d of %d files) were reviewed, including all %d security-sensitive files; see review.sampling",
+		s.Rate()*100, s.ReviewedFiles, s.TotalFiles, s.SensitiveFiles)
+}
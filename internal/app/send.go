@@ -0,0 +1,37 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+)
+
+// Channels lists the delivery channels a stored report can be sent through
+var Channels = config.Channels
+
+// Send delivers a stored report through channel again, whether or not it
+// has findings, so delivery can be retried or tested apart from a review.
+// The report is compared with the run before it as in the original email.
+// It reports whether the report was sent, false when a pause holds it.
+func (r *Runner) Send(ctx context.Context, rpt *domain.Report, channel string) (bool, error) {
+	if !slices.Contains(Channels, channel) {
+		return false, errs.Config(fmt.Errorf("unknown channel %q", channel), "use one of: "+strings.Join(Channels, ", "))
+	}
+	if !r.config.Email.Enabled {
+		return false, errs.Config(fmt.Errorf("email is disabled"), "set email.enabled: true and the SMTP settings")
+	}
+	if err := r.config.Validate(); err != nil {
+		return false, errs.Config(fmt.Errorf("invalid configuration: %w", err), "run `review config validate` to see every problem and its fix")
+	}
+	if held, err := r.holdStored(ctx, rpt); err != nil || held {
+		return false, err
+	}
+
+	r.logger.Info("sending stored report", "date", rpt.Date.Format("2006-01-02"), "channel", channel)
+	return true, r.deliverEmail(ctx, rpt, r.report.Previous(rpt.Date))
+}
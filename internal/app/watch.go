@@ -0,0 +1,226 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/report"
+	"github.com/juparave/codereviewer/internal/review"
+)
+
+// Watch polls the repositories every interval and reviews the commits that
+// landed since the previous poll, calling onBatch with each batch's report
+// and emailing it when email is enabled. Commits present when watching
+// starts, or when a repository first appears, aren't reviewed. Batch
+// reports aren't written to the reports directory so they don't replace
+// the daily report. A pause set with `review pause` skips polls or, in
+// hold mode, keeps batches from being emailed. A configuration passed to
+// Reload is picked up before the next poll. Watch returns when ctx is
+// cancelled.
+func (r *Runner) Watch(ctx context.Context, interval time.Duration, onBatch func(*domain.Report)) error {
+	if err := r.config.Validate(); err != nil {
+		return errs.Config(fmt.Errorf("invalid configuration: %w", err), "run `review config validate` to see every problem and its fix")
+	}
+
+	// Tips of the watched refs per repository path, as of the last poll
+	seen := make(map[string][]string)
+	r.logger.Info("watching for new commits", "root", r.config.RootPath, "interval", interval)
+	for {
+		r.applyReload()
+		if err := r.poll(ctx, seen, onBatch); err != nil {
+			var cfgErr *errs.ConfigError
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.As(err, &cfgErr) {
+				return err
+			}
+			r.logger.Warn("poll failed", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Reload has Watch switch to cfg before its next poll, keeping the commits
+// it has seen and the alerts it has sent. It validates cfg and sets up its
+// LLM reviewer and email service, and fails without touching the running
+// configuration when any of them fails.
+func (r *Runner) Reload(cfg *config.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return errs.Config(fmt.Errorf("invalid configuration: %w", err), "run `review config validate` to see every problem and its fix")
+	}
+	next := NewRunner(cfg)
+	next.progress = r.progress
+	if !cfg.Review.LLMDisabled() {
+		reviewer, err := review.NewReviewer(cfg.Review, next.logger)
+		if err != nil {
+			return fmt.Errorf("initializing reviewer: %w", err)
+		}
+		next.review = reviewer
+	}
+	if cfg.Email.Enabled {
+		if err := next.ensureNotify(); err != nil {
+			return err
+		}
+	}
+
+	r.reloadMu.Lock()
+	defer r.reloadMu.Unlock()
+	r.reloaded = next
+	return nil
+}
+
+// applyReload switches to the configuration passed to Reload, if any
+func (r *Runner) applyReload() {
+	r.reloadMu.Lock()
+	next := r.reloaded
+	r.reloaded = nil
+	r.reloadMu.Unlock()
+	if next == nil {
+		return
+	}
+
+	r.config, r.scanner, r.diff, r.report, r.location = next.config, next.scanner, next.diff, next.report, next.location
+	r.review, r.notify = next.review, next.notify
+	r.overrides, r.repoFiles, r.exclusions = nil, nil, nil
+	r.logger.Info("watching with the reloaded configuration", "root", r.config.RootPath)
+}
+
+// poll reviews the commits that appeared since the previous poll
+func (r *Runner) poll(ctx context.Context, seen map[string][]string, onBatch func(*domain.Report)) error {
+	if skip, err := r.checkPause(ctx); err != nil || skip {
+		if skip {
+			r.logger.Debug("paused, skipping the poll", "until", r.pause.Until.Format(report.DateLayout))
+		}
+		return err
+	}
+
+	r.exclusions = nil
+	repos, err := r.scan()
+	if err != nil {
+		return err
+	}
+
+	var commits []domain.Commit
+	var changed []domain.Repository
+	// Tips of the changed repositories, seen once their batch is reviewed
+	// so a failed review is retried on the next poll
+	reviewed := make(map[string][]string)
+	for _, repo := range repos {
+		// Half-finished commits of a rebase or similar are picked up once
+		// it completes
+		if r.git.OperationInProgress(ctx, repo.Path) != "" {
+			continue
+		}
+
+		opts, err := r.logOptions(repo)
+		if err != nil {
+			return err
+		}
+		tips, err := r.git.Tips(ctx, repo.Path, opts)
+		if err != nil {
+			r.logger.Warn("reading refs failed", "repo", repo.Name, "err", err)
+			continue
+		}
+
+		previous, known := seen[repo.Path]
+		if !known || len(tips) == 0 || slices.Equal(previous, tips) {
+			seen[repo.Path] = tips
+			continue
+		}
+
+		found, err := r.git.NewCommits(ctx, repo, tips, previous, opts.Authors)
+		if err != nil {
+			// e.g. a force push made a previous tip unreachable
+			r.logger.Warn("listing new commits failed", "repo", repo.Name, "err", err)
+			seen[repo.Path] = tips
+			continue
+		}
+		if len(found) == 0 {
+			seen[repo.Path] = tips
+			continue
+		}
+		r.logger.Info("new commits", "repo", repo.Name, "commits", len(found))
+		commits = append(commits, found...)
+		changed = append(changed, repo)
+		reviewed[repo.Path] = tips
+	}
+	if len(commits) == 0 {
+		return nil
+	}
+
+	rpt, err := r.reviewBatch(ctx, changed, commits)
+	if err != nil {
+		return err
+	}
+	for path, tips := range reviewed {
+		seen[path] = tips
+	}
+	if rpt == nil {
+		return nil
+	}
+	onBatch(rpt)
+	if r.holding() {
+		// Batch reports aren't stored, so they can't join the digest
+		r.logger.Debug("paused, not emailing the batch")
+		return nil
+	}
+	if err := r.sendReport(ctx, rpt, nil); err != nil {
+		return err
+	}
+	r.notifyDesktop(ctx, rpt)
+	return r.checkAlerts(ctx, rpt)
+}
+
+// reviewBatch reviews one batch of new commits, returning nil when they
+// have no reviewable changes
+func (r *Runner) reviewBatch(ctx context.Context, repos []domain.Repository, commits []domain.Commit) (*domain.Report, error) {
+	diffs := r.extractDiffs(ctx, commits)
+	if len(diffs) == 0 {
+		r.logger.Debug("no reviewable diffs in the new commits")
+		return nil, nil
+	}
+
+	result, err := r.reviewDiffs(ctx, diffs)
+	if err != nil {
+		return nil, err
+	}
+	duplicates, err := r.findDuplicates(diffs)
+	if err != nil {
+		return nil, err
+	}
+	result.Findings = append(result.Findings, duplicates...)
+
+	if r.config.Forge.Provider != "" && len(result.Findings) > 0 {
+		r.linkPullRequests(ctx, result.Findings, diffs)
+	}
+
+	rpt := &domain.Report{
+		Date:         r.now(),
+		Summary:      result.Summary,
+		Findings:     result.Findings,
+		Repositories: repoNames(repos),
+		CommitCount:  len(commits),
+		FileCount:    len(diffs),
+		Model:        r.model(),
+		Failures:     result.Failures,
+		Exclusions:   r.runExclusions(),
+		Provenance:   r.provenance(),
+		Diffs:        r.reportDiffs(diffs),
+	}
+	if result.Usage.Requests() > 0 || result.Usage.Reused > 0 {
+		rpt.Usage = &result.Usage
+	}
+	return rpt, nil
+}
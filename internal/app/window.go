@@ -0,0 +1,230 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/juparave/codereviewer/internal/git"
+	"github.com/juparave/codereviewer/internal/report"
+)
+
+// SinceLastRun is the since value continuing from the end of the previous
+// run's window
+const SinceLastRun = "last_run"
+
+// windowState is what a run records of its window in the reports store,
+// for since: last_run and the overlap of the next run
+type windowState struct {
+	End      time.Time `json:"end"`                 // When the run read the commits
+	PatchIDs []string  `json:"patch_ids,omitempty"` // Of the commits reviewed, with overlap set
+}
+
+// reviewWindow is the resolved start of the current run's window
+type reviewWindow struct {
+	since    string        // Window start passed to git
+	end      time.Time     // When the window was resolved
+	overlap  time.Duration // How far since was moved back
+	previous *windowState  // The previous run's, nil when unknown
+	backfill bool          // Whether the window reaches back over missed days
+}
+
+// window resolves since: last_run and overlap once per run
+func (r *Runner) window() (*reviewWindow, error) {
+	if r.reviewWindow != nil {
+		return r.reviewWindow, nil
+	}
+
+	w := &reviewWindow{since: r.config.Since, end: r.now()}
+	if r.config.Overlap != "" {
+		overlap, err := time.ParseDuration(r.config.Overlap)
+		if err != nil || overlap < 0 {
+			return nil, errs.Config(fmt.Errorf("invalid overlap %q", r.config.Overlap), "use a duration such as 30m or 1h")
+		}
+		w.overlap = overlap
+	}
+	backfill := r.config.Backfill > 0 && r.config.Until == ""
+	if w.since == SinceLastRun || w.overlap > 0 || backfill {
+		previous, err := r.previousWindow()
+		if err != nil {
+			return nil, err
+		}
+		w.previous = previous
+	}
+
+	if w.since == SinceLastRun {
+		w.since = ""
+		if w.previous != nil {
+			w.since = git.FormatSince(w.previous.End.In(w.end.Location()))
+		} else {
+			r.logger.Debug("no previous run, reviewing today", "since", SinceLastRun)
+		}
+	}
+	if backfill {
+		r.backfill(w)
+	}
+	if w.overlap > 0 {
+		if start, ok := git.SinceTime(w.since, w.end); ok {
+			w.since = git.FormatSince(start.Add(-w.overlap))
+		} else {
+			r.logger.Warn("overlap ignored, the window start isn't a time", "since", w.since)
+		}
+	}
+
+	r.reviewWindow = w
+	return w, nil
+}
+
+// backfill moves the start of w back to where the previous run ended when
+// a whole day passed without a run and w starts later, up to
+// config.Backfill days
+func (r *Runner) backfill(w *reviewWindow) {
+	today, _ := git.SinceTime("today", w.end)
+	if w.previous == nil || !w.previous.End.Before(today.AddDate(0, 0, -1)) {
+		return
+	}
+	since, ok := git.SinceTime(w.since, w.end)
+	if !ok {
+		r.logger.Debug("not backfilling, the window start isn't a time", "since", w.since)
+		return
+	}
+	start := w.previous.End.In(w.end.Location())
+	if limit := today.AddDate(0, 0, -r.config.Backfill); start.Before(limit) {
+		start = limit
+	}
+	if !start.Before(since) {
+		return
+	}
+	w.since = git.FormatSince(start)
+	w.backfill = true
+	r.logger.Info("backfilling the days since the previous run", "since", start.Format(time.DateTime), "previous_run", w.previous.End.Format(time.DateTime))
+}
+
+// splitDays files each finding under the day of the latest commit to its
+// files and counts the commits of each day, for a report that backfilled
+// missed days; findings matching no commit stay on the report's date
+func (r *Runner) splitDays(rpt *domain.Report, commits []domain.Commit, diffs []domain.Diff) {
+	if r.reviewWindow == nil || !r.reviewWindow.backfill {
+		return
+	}
+
+	commitDays := make(map[string]string, len(commits))
+	counts := make(map[string]int)
+	for _, c := range commits {
+		day := c.Timestamp.In(r.location).Format(report.DateLayout)
+		commitDays[c.Hash] = day
+		counts[day]++
+	}
+	fileDays := make(map[string]string)
+	for _, d := range diffs {
+		key := d.RepoName + "\x00" + d.FilePath
+		if day := commitDays[d.CommitHash]; day > fileDays[key] {
+			fileDays[key] = day
+		}
+	}
+
+	for i := range rpt.Findings {
+		f := &rpt.Findings[i]
+		for _, file := range f.Files {
+			if day := fileDays[f.RepoName+"\x00"+file]; day > f.Day {
+				f.Day = day
+			}
+		}
+		if f.Day == "" {
+			f.Day = rpt.Date.Format(report.DateLayout)
+		}
+		if _, ok := counts[f.Day]; !ok {
+			counts[f.Day] = 0
+		}
+	}
+
+	for _, day := range slices.Sorted(maps.Keys(counts)) {
+		rpt.Days = append(rpt.Days, domain.Day{Date: day, Commits: counts[day]})
+	}
+	rpt.Notes = append(rpt.Notes, fmt.Sprintf("No run since %s: this report also covers the days missed, split by day.",
+		r.reviewWindow.previous.End.In(r.location).Format(report.DateLayout)))
+}
+
+// previousWindow reads the window the previous run recorded, nil when
+// there is none
+func (r *Runner) previousWindow() (*windowState, error) {
+	data, err := r.report.LatestWindow()
+	if err != nil {
+		return nil, fmt.Errorf("reading the previous review window: %w", err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+	var state windowState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing the previous review window: %w", err)
+	}
+	return &state, nil
+}
+
+// dropReviewed leaves out the commits of repo the previous run already
+// reviewed, by patch ID, when overlap is set. It returns the kept commits
+// and the patch IDs of every commit, for recordWindow.
+func (r *Runner) dropReviewed(ctx context.Context, repo domain.Repository, commits []domain.Commit) ([]domain.Commit, []string) {
+	w := r.reviewWindow
+	if w == nil || w.overlap == 0 || len(commits) == 0 {
+		return commits, nil
+	}
+
+	hashes := make([]string, len(commits))
+	for i, c := range commits {
+		hashes[i] = c.Hash
+	}
+	ids, err := r.git.PatchIDs(ctx, repo.Path, hashes)
+	if err != nil {
+		// Reviewing twice beats missing a commit
+		r.logger.Warn("reading patch IDs failed, commits in the overlap may be reviewed again", "repo", repo.Name, "err", err)
+		return commits, nil
+	}
+
+	reviewed := make(map[string]bool)
+	if w.previous != nil {
+		for _, id := range w.previous.PatchIDs {
+			reviewed[id] = true
+		}
+	}
+	var kept []domain.Commit
+	var patchIDs []string
+	for _, c := range commits {
+		id := ids[c.Hash]
+		if id != "" {
+			patchIDs = append(patchIDs, id)
+		}
+		if id != "" && reviewed[id] {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	if skipped := len(commits) - len(kept); skipped > 0 {
+		r.logger.Debug("skipping commits the previous run reviewed", "repo", repo.Name, "commits", skipped)
+	}
+	return kept, patchIDs
+}
+
+// recordWindow stores the end of the run's window and the patch IDs it
+// reviewed for the next run. Reruns of past days (until set) don't move
+// the window.
+func (r *Runner) recordWindow(date time.Time) error {
+	w := r.reviewWindow
+	if w == nil || r.config.Until != "" {
+		return nil
+	}
+	data, err := json.Marshal(windowState{End: w.end, PatchIDs: r.patchIDs})
+	if err != nil {
+		return err
+	}
+	if err := r.report.SaveWindow(date.Format(report.DateLayout), data); err != nil {
+		return fmt.Errorf("saving the review window: %w", err)
+	}
+	return nil
+}
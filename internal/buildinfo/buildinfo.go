@@ -0,0 +1,80 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time with
+//
+//	go build -ldflags "-X github.com/juparave/codereviewer/internal/buildinfo.Version=1.2.0 \
+//	  -X github.com/juparave/codereviewer/internal/buildinfo.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/juparave/codereviewer/internal/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Commit and Date fall back to the VCS stamp Go embeds when building from a
+// git checkout.
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes the running binary
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // Built from a dirty working tree
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build metadata of the running binary
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Version == "dev" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version // go install module@version
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = s.Value
+				if len(info.Commit) > 12 {
+					info.Commit = info.Commit[:12]
+				}
+			}
+		case "vcs.time":
+			if info.Date == "" {
+				info.Date = s.Value
+			}
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
+
+// String renders the info on one line, e.g. "1.2.0 (abc123, 2025-01-10)"
+func (i Info) String() string {
+	s := i.Version
+	switch {
+	case i.Commit != "" && i.Date != "":
+		s += " (" + i.Commit + ", " + i.Date + ")"
+	case i.Commit != "":
+		s += " (" + i.Commit + ")"
+	}
+	if i.Modified {
+		s += " modified"
+	}
+	return s
+}
@@ -0,0 +1,372 @@
+// Package cache keeps what CRA can work out again, such as the
+// repositories found under a root path and the model's answers to
+// prompts, in one directory shared by every CRA process on the machine:
+// scheduled runs, `review serve` and `review watch` may use it at once.
+//
+// Each kind of data has its own namespace directory. Entries are replaced
+// atomically through a temporary file, so a reader never sees half of one,
+// and a lock file keeps eviction from removing entries while other
+// processes read or write them. Once the namespaces grow past
+// cache.max_mb, the least recently used entries are removed.
+package cache
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/filelock"
+	"github.com/juparave/codereviewer/internal/logging"
+)
+
+// Namespaces of the cache
+const (
+	Scan      = "scan"      // Repositories found under each root path, see scanner.cache
+	Responses = "responses" // Model answers by prompt, see cache.responses
+	// Policy holds the last policy fetched from policy.url, which is never
+	// evicted: it stands in when the URL can't be reached
+	Policy = "policy"
+)
+
+// Evictable lists the namespaces whose entries are removed to keep the
+// cache under its size limit
+var Evictable = []string{Scan, Responses}
+
+// lockName is the lock file in the cache directory
+const lockName = ".lock"
+
+// tempPrefix starts the names of entries being written
+const tempPrefix = ".tmp-"
+
+// staleTemp is how old a temporary file is when the process writing it
+// died, and eviction removes it
+const staleTemp = time.Hour
+
+// Store is the cache directory
+type Store struct {
+	dir     string
+	maxSize int64
+	logger  *slog.Logger
+
+	mu   sync.Mutex
+	size int64 // Of the evictable namespaces as last measured, -1 before
+}
+
+var (
+	openMu sync.Mutex
+	opened = make(map[string]*Store)
+)
+
+// Open returns the cache configured in cfg. Stores are shared within the
+// process by directory, so its components account for the size together.
+func Open(cfg config.CacheConfig, logger *slog.Logger) (*Store, error) {
+	dir, err := cfg.Directory()
+	if err != nil {
+		return nil, err
+	}
+	openMu.Lock()
+	defer openMu.Unlock()
+	if s, ok := opened[dir]; ok {
+		s.mu.Lock()
+		s.maxSize = cfg.MaxBytes()
+		s.mu.Unlock()
+		return s, nil
+	}
+	s := &Store{dir: dir, maxSize: cfg.MaxBytes(), logger: logging.OrDefault(logger), size: -1}
+	opened[dir] = s
+	return s, nil
+}
+
+// Dir returns the cache directory
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Key returns a file name for an entry identified by parts, such as a
+// model and a prompt
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// path returns the file of entry name in namespace
+func (s *Store) path(namespace, name string) string {
+	return filepath.Join(s.dir, namespace, name)
+}
+
+// Get returns the entry name in namespace, marking entries of evictable
+// namespaces used. A missing entry wraps fs.ErrNotExist.
+func (s *Store) Get(namespace, name string) ([]byte, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	path := s.path(namespace, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(Evictable, namespace) {
+		// The modification time orders entries for eviction; atime isn't
+		// kept on every filesystem
+		now := time.Now()
+		os.Chtimes(path, now, now)
+	}
+	return data, nil
+}
+
+// Modified returns when the entry name in namespace was last written, or
+// read when the namespace is evictable
+func (s *Store) Modified(namespace, name string) (time.Time, error) {
+	info, err := os.Stat(s.path(namespace, name))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// Put writes data as the entry name in namespace, replacing it
+// atomically, then evicts entries if the cache grew past its limit
+func (s *Store) Put(namespace, name string, data []byte) error {
+	written, err := s.write(namespace, name, data)
+	if err != nil {
+		return fmt.Errorf("writing %s cache: %w", namespace, err)
+	}
+	if !slices.Contains(Evictable, namespace) {
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.size >= 0 {
+		s.size += written
+	}
+	over := s.size < 0 || s.size > s.maxSize
+	s.mu.Unlock()
+	if over {
+		if _, err := s.Evict(false); err != nil {
+			s.logger.Debug("evicting cache entries failed", "dir", s.dir, "err", err)
+		}
+	}
+	return nil
+}
+
+// write stores an entry under a shared lock, returning how much it grew
+// the cache
+func (s *Store) write(namespace, name string, data []byte) (int64, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	path := s.path(namespace, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, err
+	}
+	var previous int64
+	if info, err := os.Stat(path); err == nil {
+		previous = info.Size()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), tempPrefix+"*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	return int64(len(data)) - previous, nil
+}
+
+// entry is a file of an evictable namespace
+type entry struct {
+	path     string
+	size     int64
+	modified time.Time
+}
+
+// Eviction is what an eviction removed
+type Eviction struct {
+	Entries int
+	Bytes   int64
+	Left    int64 // Size of the evictable namespaces after it
+}
+
+// Evict removes the least recently used entries of the evictable
+// namespaces until they take at most nine tenths of the limit, leaving
+// room to grow before the next eviction. With all, it removes every one.
+// It holds the lock exclusively, waiting for other processes' reads and
+// writes to finish.
+func (s *Store) Evict(all bool) (Eviction, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return Eviction{}, err
+	}
+	defer unlock()
+
+	maxSize := s.MaxSize()
+	var entries []entry
+	var total int64
+	var ev Eviction
+	for _, namespace := range Evictable {
+		err := filepath.WalkDir(filepath.Join(s.dir, namespace), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			if strings.HasPrefix(d.Name(), tempPrefix) {
+				// Left by a process that died while writing
+				if time.Since(info.ModTime()) > staleTemp && os.Remove(path) == nil {
+					ev.Entries++
+					ev.Bytes += info.Size()
+				}
+				return nil
+			}
+			entries = append(entries, entry{path: path, size: info.Size(), modified: info.ModTime()})
+			total += info.Size()
+			return nil
+		})
+		if err != nil {
+			return ev, err
+		}
+	}
+
+	target := maxSize * 9 / 10
+	if all {
+		target = 0
+	}
+	if total > maxSize || all {
+		slices.SortFunc(entries, func(a, b entry) int {
+			return cmp.Or(a.modified.Compare(b.modified), strings.Compare(a.path, b.path))
+		})
+		for _, e := range entries {
+			if total <= target {
+				break
+			}
+			if err := os.Remove(e.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return ev, err
+			}
+			total -= e.size
+			ev.Entries++
+			ev.Bytes += e.size
+		}
+		if ev.Entries > 0 {
+			s.logger.Debug("evicted cache entries", "dir", s.dir, "entries", ev.Entries, "bytes", ev.Bytes, "left", total)
+		}
+	}
+	ev.Left = total
+
+	s.mu.Lock()
+	s.size = total
+	s.mu.Unlock()
+	return ev, nil
+}
+
+// Clear removes every entry of namespace, returning how many it removed
+func (s *Store) Clear(namespace string) (int, error) {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	removed := 0
+	err = filepath.WalkDir(filepath.Join(s.dir, namespace), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		removed++
+		return nil
+	})
+
+	s.mu.Lock()
+	s.size = -1 // Measured again by the next eviction
+	s.mu.Unlock()
+	return removed, err
+}
+
+// Usage is the size of a namespace
+type Usage struct {
+	Namespace string
+	Entries   int
+	Bytes     int64
+}
+
+// Usage measures each namespace
+func (s *Store) Usage() ([]Usage, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	var usage []Usage
+	for _, namespace := range append(slices.Clone(Evictable), Policy) {
+		u := Usage{Namespace: namespace}
+		err := filepath.WalkDir(filepath.Join(s.dir, namespace), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || strings.HasPrefix(d.Name(), tempPrefix) {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				u.Entries++
+				u.Bytes += info.Size()
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
+
+// MaxSize returns the size the evictable namespaces are kept under
+func (s *Store) MaxSize() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maxSize
+}
+
+// lock takes the cache's lock file, shared or exclusive, returning the
+// function releasing it
+func (s *Store) lock(exclusive bool) (func(), error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+	unlock, err := filelock.Lock(filepath.Join(s.dir, lockName), exclusive)
+	if err != nil {
+		return nil, fmt.Errorf("locking cache: %w", err)
+	}
+	return unlock, nil
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/juparave/codereviewer/internal/config"
+)
+
+func TestConcurrentWriters(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(config.CacheConfig{Dir: dir}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Writers replace the same entries while readers read them: every
+	// read sees one writer's whole value, never a mix or a partial file
+	const writers, rounds = 8, 50
+	value := func(writer int) []byte {
+		return bytes.Repeat([]byte{byte('a' + writer)}, 64<<10)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*rounds*2)
+	for w := range writers {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range rounds {
+				if err := store.Put(Responses, fmt.Sprintf("shared-%d.txt", i%4), value(w)); err != nil {
+					errs <- err
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := range rounds {
+				data, err := store.Get(Responses, fmt.Sprintf("shared-%d.txt", i%4))
+				if err != nil {
+					continue // Not written yet
+				}
+				if len(data) != 64<<10 || bytes.Count(data, data[:1]) != len(data) {
+					errs <- fmt.Errorf("read a torn entry of %d bytes", len(data))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Join(dir, Responses))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), tempPrefix) {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+	if len(entries) != 4 {
+		t.Errorf("got %d entries, want 4", len(entries))
+	}
+}
+
+func TestEvictUnderLimit(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(config.CacheConfig{Dir: dir, MaxMB: 1}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 20 {
+		if err := store.Put(Responses, fmt.Sprintf("%d.txt", i), make([]byte, 100<<10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	usage, err := store.Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var total int64
+	for _, u := range usage {
+		total += u.Bytes
+	}
+	if total > store.MaxSize() {
+		t.Errorf("cache holds %d bytes, over its %d limit", total, store.MaxSize())
+	}
+	if _, err := store.Get(Responses, "19.txt"); err != nil {
+		t.Errorf("the latest entry was evicted: %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+// Package ci detects the CI system a review runs in and reports findings
+// in the forms it displays: GitHub Actions annotations and job summary,
+// and GitLab code quality reports.
+package ci
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// CI systems Detect recognizes
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+// Env is the CI job a review runs in
+type Env struct {
+	Provider string // GitHub or GitLab
+	Dir      string // Checkout of the repository
+	// Base is the commit the change is compared against, empty when it's
+	// unknown (e.g. the first push of a branch); only Head is reviewed then
+	Base string
+	Head string
+	// PullRequest is the pull or merge request number, 0 outside one
+	PullRequest int
+	// SummaryPath is the job summary file (GitHub only)
+	SummaryPath string
+}
+
+// Revisions returns the git log revision arguments selecting the change
+func (e *Env) Revisions() []string {
+	if e.Base == "" {
+		return []string{"-n1", e.Head}
+	}
+	return []string{e.Base + ".." + e.Head}
+}
+
+// Detect reads the CI environment through getenv (os.Getenv outside
+// tests), returning nil when not running in a supported CI system
+func Detect(getenv func(string) string) (*Env, error) {
+	switch {
+	case getenv("GITHUB_ACTIONS") == "true":
+		return detectGitHub(getenv)
+	case getenv("GITLAB_CI") == "true":
+		return detectGitLab(getenv), nil
+	}
+	return nil, nil
+}
+
+// githubEvent is the part of the workflow event payload naming the change
+type githubEvent struct {
+	PullRequest *struct {
+		Number int `json:"number"`
+		Base   struct {
+			SHA string `json:"sha"`
+		} `json:"base"`
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	} `json:"pull_request"`
+	// Push events
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+func detectGitHub(getenv func(string) string) (*Env, error) {
+	env := &Env{
+		Provider:    GitHub,
+		Dir:         getenv("GITHUB_WORKSPACE"),
+		Head:        getenv("GITHUB_SHA"),
+		SummaryPath: getenv("GITHUB_STEP_SUMMARY"),
+	}
+
+	path := getenv("GITHUB_EVENT_PATH")
+	if path == "" {
+		return env, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading GitHub event: %w", err)
+	}
+	var event githubEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, fmt.Errorf("parsing GitHub event %s: %w", path, err)
+	}
+
+	switch {
+	case event.PullRequest != nil:
+		// GITHUB_SHA is the merge commit of a pull_request event; review the
+		// branch itself
+		env.PullRequest = event.PullRequest.Number
+		env.Base = event.PullRequest.Base.SHA
+		env.Head = event.PullRequest.Head.SHA
+	case event.After != "":
+		env.Base = nullSHA(event.Before)
+		env.Head = event.After
+	}
+	return env, nil
+}
+
+func detectGitLab(getenv func(string) string) *Env {
+	env := &Env{
+		Provider: GitLab,
+		Dir:      getenv("CI_PROJECT_DIR"),
+		Head:     getenv("CI_COMMIT_SHA"),
+	}
+	if base := getenv("CI_MERGE_REQUEST_DIFF_BASE_SHA"); base != "" {
+		env.Base = base
+		fmt.Sscan(getenv("CI_MERGE_REQUEST_IID"), &env.PullRequest)
+	} else {
+		env.Base = nullSHA(getenv("CI_COMMIT_BEFORE_SHA"))
+	}
+	return env
+}
+
+// nullSHA returns sha, or empty for the all-zero SHA CI systems report
+// when a branch is created
+func nullSHA(sha string) string {
+	if strings.Trim(sha, "0") == "" {
+		return ""
+	}
+	return sha
+}
+
+// WriteAnnotations prints a GitHub Actions workflow command per finding so
+// it shows on the first of its files in the pull request
+func WriteAnnotations(w io.Writer, findings []domain.Finding) {
+	for _, f := range findings {
+		level := "notice"
+		switch f.Severity {
+		case domain.SeverityHigh:
+			level = "error"
+		case domain.SeverityMedium:
+			level = "warning"
+		}
+		props := "title=" + escapeProperty(f.Title)
+		if len(f.Files) > 0 {
+			props = "file=" + escapeProperty(f.Files[0]) + "," + props
+		}
+		fmt.Fprintf(w, "::%s %s::%s\n", level, props, escapeData(f.Explanation+"\n\nFix: "+f.Action))
+	}
+}
+
+// escapeData escapes a workflow command message
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property value
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// AppendSummary appends markdown to the GitHub job summary at path
+func AppendSummary(path, markdown string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("opening job summary: %w", err)
+	}
+	if _, err := file.WriteString(markdown); err != nil {
+		file.Close()
+		return fmt.Errorf("writing job summary: %w", err)
+	}
+	return file.Close()
+}
+
+// codeQualityIssue is one entry of a GitLab code quality report
+type codeQualityIssue struct {
+	Description string `json:"description"`
+	CheckName   string `json:"check_name"`
+	Fingerprint string `json:"fingerprint"`
+	Severity    string `json:"severity"`
+	Location    struct {
+		Path  string `json:"path"`
+		Lines struct {
+			Begin int `json:"begin"`
+		} `json:"lines"`
+	} `json:"location"`
+}
+
+// WriteCodeQuality writes findings as a GitLab code quality report, shown
+// in the merge request widget when the job publishes it as
+// artifacts:reports:codequality
+func WriteCodeQuality(path string, findings []domain.Finding) error {
+	issues := make([]codeQualityIssue, 0, len(findings))
+	for _, f := range findings {
+		issue := codeQualityIssue{
+			Description: f.Title + ": " + f.Explanation,
+			CheckName:   "cra",
+			Fingerprint: f.Fingerprint(),
+			Severity:    codeQualitySeverity(f.Severity),
+		}
+		if len(f.Files) > 0 {
+			issue.Location.Path = f.Files[0]
+		}
+		// Findings aren't tied to lines
+		issue.Location.Lines.Begin = 1
+		issues = append(issues, issue)
+	}
+
+	data, err := json.MarshalIndent(issues, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding code quality report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("writing code quality report: %w", err)
+	}
+	return nil
+}
+
+func codeQualitySeverity(s domain.Severity) string {
+	switch s {
+	case domain.SeverityHigh:
+		return "critical"
+	case domain.SeverityMedium:
+		return "major"
+	}
+	return "minor"
+}
@@ -1,11 +1,19 @@
 package config
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/juparave/codereviewer/internal/cron"
 	"gopkg.in/yaml.v3"
 )
 
@@ -13,12 +21,83 @@ import (
 type Config struct {
 	RootPath string        `yaml:"root_path"`
 	Email    EmailConfig   `yaml:"email"`
+	Notify   NotifyConfig  `yaml:"notify"`
 	Review   ReviewConfig  `yaml:"review"`
 	Reports  ReportsConfig `yaml:"reports"`
-	Verbose  bool          `yaml:"-"`     // Set via CLI only
-	Since    string        `yaml:"since"` // Can be set via config or CLI
+	Scanner  ScannerConfig `yaml:"scanner"`
+	Server   ServerConfig  `yaml:"server"`
+	Forge    ForgeConfig   `yaml:"forge"`
+	Policy   PolicyConfig  `yaml:"policy"`
+	Proxy    ProxyConfig   `yaml:"proxy"`
+	Log      LogConfig     `yaml:"log"`
+	Cache    CacheConfig   `yaml:"cache"`
+	Since    string        `yaml:"since"` // Can be set via config or CLI; last_run continues from the previous run
+	Until    string        `yaml:"until"` // End of the review window, empty for now
+	// Overlap starts the window this long earlier (e.g. 1h), so commits
+	// landing around the cutoff aren't missed when clocks disagree; those
+	// the previous run reviewed are recognized by patch ID and skipped
+	Overlap string `yaml:"overlap"`
+	// Backfill, when a whole day passed without a run (the machine was
+	// asleep or off) and the window starts after the previous run ended,
+	// starts it where the previous run ended, up to this many days back,
+	// and splits the report by day; 7 by default, 0 disables
+	Backfill int `yaml:"backfill"`
+	// Schedule lists when `review serve` runs reviews by itself, as cron
+	// expressions in Timezone; a single expression may be given as a
+	// string
+	Schedule Schedules `yaml:"schedule"`
+	// MergeResolutions also reviews merge commits, limited to the hunks
+	// where the merge result differs from every parent: the conflicts
+	// resolved by hand
+	MergeResolutions bool `yaml:"merge_resolutions"`
+	// Timezone is the IANA zone (e.g. "America/Mexico_City") whose
+	// midnight starts "today" and whose dates name reports; empty uses the
+	// machine's local time
+	Timezone string `yaml:"timezone"`
+	// Authors limits reviews to commits whose author name or email matches
+	// these globs or /regexps/; a leading "!" excludes (e.g. "!dependabot*")
+	Authors []string `yaml:"authors"`
+	// Repos permanently includes or excludes repositories, e.g. forks and
+	// mirrors, whatever scanner.repos and --repos select
+	Repos RepoListConfig `yaml:"repos"`
+	// Branches selects the branches commits are read from; by default
+	// every ref is read
+	Branches BranchConfig `yaml:"branches"`
+	// Overrides adjusts settings for repositories matching each entry's
+	// Repo; the first matching entry applies. Edited by `review config repos`.
+	Overrides []RepoOverride `yaml:"overrides"`
+
+	// Exclude lists gitignore-style patterns of paths never reviewed, on
+	// top of the built-in vendor/, node_modules/, generated code, mocks and
+	// testdata/; "!" re-includes (e.g. "!testdata/")
+	Exclude []string `yaml:"exclude"`
+
+	// Alerts send an extra, distinct notification when findings spike,
+	// checked after every run and --watch batch
+	Alerts []AlertRule `yaml:"alerts"`
+
+	// Languages maps extra file extensions to the language label used in
+	// prompts, on top of the built-in set (e.g. ".py": python). A built-in
+	// extension can be relabeled, or left unreviewed with an empty label.
+	Languages map[string]string `yaml:"languages"`
+
+	// PauseFile holds the pause window set with `review pause`
+	PauseFile string `yaml:"pause_file"`
+
+	// Include lists YAML files merged in before this one, relative to its
+	// directory; the including file's own values win
+	Include []string `yaml:"include" env:"-"`
+	// Profiles are named sets of settings applied over the rest of the
+	// config, e.g. work and personal, selected with Profile
+	Profiles map[string]yaml.Node `yaml:"profiles"`
+	// Profile selects the profile applied by default; $CRA_PROFILE and
+	// --profile override it
+	Profile string `yaml:"profile"`
 }
 
+// ProfileEnv selects the profile, overriding profile in the file
+const ProfileEnv = EnvPrefix + "PROFILE"
+
 // EmailConfig holds email delivery settings
 type EmailConfig struct {
 	Enabled      bool   `yaml:"enabled"`
@@ -30,20 +109,564 @@ type EmailConfig struct {
 	FromAddress  string `yaml:"from_address"`
 	FromName     string `yaml:"from_name"`
 	ToAddress    string `yaml:"to_address"`
+	// Routes sends report views to their own recipients. When empty, the
+	// engineer view goes to ToAddress.
+	Routes []EmailRoute `yaml:"routes"`
+	// Senders vary the From header by the report's highest severity, keyed
+	// by high, medium, low or clear (no findings), so mail rules can sort
+	// by urgency
+	Senders map[string]Sender `yaml:"senders"`
+	// ManageURL is the address recipients reach `review serve` at. When
+	// set, each recipient gets their own email with a link to a page
+	// where they mute repositories, raise the minimum severity or
+	// unsubscribe.
+	ManageURL string `yaml:"manage_url"`
+	// PreferencesFile holds the preferences set through those links
+	PreferencesFile string `yaml:"preferences_file"`
+}
+
+// NotifyConfig holds the notification channels other than email
+type NotifyConfig struct {
+	// Desktop shows a native notification with the severity summary when
+	// a run or --watch batch completes, for the agent running on a
+	// workstation; alerts can use the desktop channel too
+	Desktop bool `yaml:"desktop"`
+}
+
+// Sender overrides the From header of a report's email. Empty fields keep
+// from_name and from_address; the SMTP envelope always uses from_address.
+type Sender struct {
+	Name    string `yaml:"name"`
+	Address string `yaml:"address"`
+	Tag     string `yaml:"tag"` // Prefixed to the name, e.g. "🔴"
+}
+
+// SenderLevels are the keys of EmailConfig.Senders
+var SenderLevels = []string{"high", "medium", "low", "clear"}
+
+// From returns the From name and address for a report whose highest
+// severity is level (one of SenderLevels)
+func (e EmailConfig) From(level string) (name, address string) {
+	name, address = e.FromName, e.FromAddress
+	for key, sender := range e.Senders {
+		if !strings.EqualFold(key, level) {
+			continue
+		}
+		if sender.Name != "" {
+			name = sender.Name
+		}
+		if sender.Address != "" {
+			address = sender.Address
+		}
+		if sender.Tag != "" {
+			name = strings.TrimSpace(sender.Tag + " " + name)
+		}
+	}
+	return name, address
+}
+
+// checkManageURL checks manage_url is an http(s) address, when set
+func (e EmailConfig) checkManageURL() error {
+	if e.ManageURL == "" {
+		return nil
+	}
+	u, err := url.Parse(e.ManageURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid email.manage_url %q: expected the http:// or https:// address of review serve", e.ManageURL)
+	}
+	if e.PreferencesFile == "" {
+		return fmt.Errorf("email.manage_url needs email.preferences_file")
+	}
+	return nil
+}
+
+// EmailRoute delivers one report view to a list of recipients
+type EmailRoute struct {
+	View string   `yaml:"view"` // engineer or manager
+	To   []string `yaml:"to"`
+}
+
+// ResolveRoutes returns the configured routes, defaulting to the engineer
+// view sent to to_address
+func (e EmailConfig) ResolveRoutes() []EmailRoute {
+	if len(e.Routes) > 0 {
+		return e.Routes
+	}
+	return []EmailRoute{{View: "engineer", To: []string{e.ToAddress}}}
 }
 
 // ReviewConfig holds LLM review settings
 type ReviewConfig struct {
 	Strictness string `yaml:"strictness"` // low, medium, high
-	Provider   string `yaml:"provider"`   // openai, googleai, vertexai, ollama
+	Provider   string `yaml:"provider"`   // googleai, openai or ollama; none disables the LLM
 	Model      string `yaml:"model"`
 	APIKey     string `yaml:"api_key"`
 	BaseURL    string `yaml:"base_url"` // Custom API endpoint (for Zhipu AI, etc.)
+	// MinSeverity drops findings below this level (High, Medium, Low)
+	MinSeverity string `yaml:"min_severity"`
+	// Focus limits reviews to these areas (see FocusAreas): the prompt
+	// asks only about them and findings outside are dropped; empty reviews
+	// everything
+	Focus []string `yaml:"focus"`
+	// PromptAddendum is extra guidance appended to the system prompt
+	PromptAddendum string `yaml:"prompt_addendum"`
+	// SystemPromptFile and OutputInstructionsFile replace the built-in
+	// review instructions and JSON output format with Go text/templates
+	// (see review.PromptData); the built-in text is {{.Default}}
+	SystemPromptFile       string `yaml:"system_prompt_file"`
+	OutputInstructionsFile string `yaml:"output_instructions_file"`
+	// LanguagePrompts maps language labels (see Diff.Language) to guidance
+	// added when a review includes files in that language. It replaces the
+	// built-in guidance for that language; an empty text removes it.
+	LanguagePrompts map[string]string `yaml:"language_prompts"`
+	// LanguagePromptsDir holds <language>.md files read like
+	// LanguagePrompts, whose entries win
+	LanguagePromptsDir string `yaml:"language_prompts_dir"`
+	// SuppressionsFile lists accepted findings left out of reports,
+	// maintained with `review suppress`
+	SuppressionsFile string `yaml:"suppressions_file"`
+	// BaselineFile lists pre-existing findings recorded by `review
+	// baseline`; only findings not in it are reported
+	BaselineFile string `yaml:"baseline_file"`
+	// NotesFile holds the notes left with `review note` for the next run
+	NotesFile string `yaml:"notes_file"`
+	// DuplicateMinLines is the number of added lines from which the same
+	// change landing in several repositories is reported; 0 (default)
+	// disables
+	DuplicateMinLines int `yaml:"duplicate_min_lines"`
+	// SaveResponses keeps the raw model answers of each run, and the
+	// prompts they answer, in the reports store for `review replay`
+	SaveResponses bool `yaml:"save_responses"`
+	// NoLLM replaces the LLM review with built-in heuristics (credentials,
+	// nil dereferences, error wrapping, TODOs); no API key is needed.
+	// Setting provider to none does the same.
+	NoLLM bool `yaml:"no_llm"`
+	// Sampling caps the diff volume sent to the LLM on busy days
+	Sampling SamplingConfig `yaml:"sampling"`
+	// Limits caps the size of each file's diff and of the whole run
+	Limits LimitsConfig `yaml:"limits"`
+	// FindingFields are extra fields the model fills for every finding,
+	// kept in the finding's extensions and shown in reports
+	FindingFields []FindingField `yaml:"finding_fields"`
+
+	// RepoStrictness maps repository names to the strictness from their
+	// override, set by the runner
+	RepoStrictness map[string]string `yaml:"-"`
+	// StrongModel reviews and verifies repositories with risk critical,
+	// and TriageModel reviews those with risk experimental; both default
+	// to Model and use the same provider
+	StrongModel string `yaml:"strong_model"`
+	TriageModel string `yaml:"triage_model"`
+	// Backends are other LLM backends, by name, that overrides assign to
+	// repositories, e.g. a local Ollama model for proprietary code
+	Backends map[string]Backend `yaml:"backends"`
+
+	// RepoRisk maps repository names to the risk profile from their
+	// override or .cra.yaml, set by the runner; unset is normal
+	RepoRisk map[string]string `yaml:"-"`
+	// RepoBackend maps repository names to the backend from their
+	// override, set by the runner; unset uses the provider above
+	RepoBackend map[string]string `yaml:"-"`
+	// RepoGuidance maps repository names to the prompt_addendum of their
+	// .cra.yaml, set by the runner
+	RepoGuidance map[string]string `yaml:"-"`
+	// RunNotes are the user's notes for this run, set by the runner
+	RunNotes []string `yaml:"-"`
+}
+
+// Backend is an LLM provider and model in review.backends
+type Backend struct {
+	Provider string `yaml:"provider"` // googleai, openai or ollama
+	Model    string `yaml:"model"`
+	APIKey   string `yaml:"api_key"`  // Default: the provider's environment variables
+	BaseURL  string `yaml:"base_url"` // Custom API endpoint
+}
+
+// SamplingConfig caps the volume of a review. When the day's diffs exceed
+// MaxLines, every file matching Sensitive is reviewed and a random sample
+// of the others fills the rest of the budget; the report states the rate.
+type SamplingConfig struct {
+	MaxLines int `yaml:"max_lines"` // Diff lines reviewed per run; 0 reviews everything
+	// Sensitive lists path globs or /regexps/, matched against the path and
+	// the file name, that are always reviewed; DefaultSensitivePaths when
+	// empty
+	Sensitive []string `yaml:"sensitive"`
+}
+
+// LimitsConfig bounds what a run sends to the LLM. Files over a per-file
+// limit are truncated, with a summary of the lines cut; once TokenBudget is spent, the lowest-priority
+// files are left out and listed in the report.
+type LimitsConfig struct {
+	MaxDiffLines int `yaml:"max_diff_lines"` // Lines of a file's diff; 300 when 0
+	MaxFileBytes int `yaml:"max_file_bytes"` // Bytes of a file's diff; 0 for no cap
+	// TokenBudget caps the estimated input tokens of a run, instructions
+	// included; 0 for no cap
+	TokenBudget int `yaml:"token_budget"`
+}
+
+// FindingField is an extra field of every finding, e.g. estimated_effort
+type FindingField struct {
+	Name        string `yaml:"name"`        // JSON key, e.g. owner_team_guess
+	Description string `yaml:"description"` // What the model should put there
+}
+
+// findingFieldName is the form of FindingField.Name: a lowercase JSON key
+var findingFieldName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// validateFindingFields checks the names of review.finding_fields
+func validateFindingFields(fields []FindingField) error {
+	seen := make(map[string]bool)
+	for _, f := range fields {
+		if !findingFieldName.MatchString(f.Name) {
+			return fmt.Errorf("review.finding_fields: invalid name %q, use lowercase letters, digits and underscores", f.Name)
+		}
+		if seen[f.Name] {
+			return fmt.Errorf("review.finding_fields: %q is declared twice", f.Name)
+		}
+		seen[f.Name] = true
+	}
+	return nil
+}
+
+// DefaultSensitivePaths are the paths always reviewed when sampling
+var DefaultSensitivePaths = []string{
+	"/(?i)(auth|security|crypto|secret|password|credential|token|session|permission|acl|payment|billing)/",
+	"/(?i)(^|/)migrations?/",
+	"*.sql",
 }
 
 // ReportsConfig holds report storage settings
 type ReportsConfig struct {
 	OutputDir string `yaml:"output_dir"`
+	// Backend stores the report history and, for the databases, the run
+	// state (pause window, notes, suppressions, preferences): sqlite
+	// (default, cra.db in OutputDir), bbolt, postgres or files
+	// (content-addressed in OutputDir, state in its own files)
+	Backend string `yaml:"backend"`
+	// DSN locates the database: a file for sqlite and bbolt (default
+	// cra.db or cra.bolt in OutputDir), a connection URL for postgres
+	DSN string `yaml:"dsn"`
+	// ManifestPath, when set, is where each run writes a JSON manifest of
+	// what it scanned, reviewed and found (or --output)
+	ManifestPath string `yaml:"manifest_path"`
+	// Severity overrides the label, emoji and color of each severity,
+	// keyed by high, medium or low
+	Severity map[string]SeverityStyle `yaml:"severity"`
+	// Appendix adds the reviewed diffs to HTML reports, each finding
+	// linking to the diffs of its files
+	Appendix AppendixConfig `yaml:"appendix"`
+}
+
+// AppendixConfig selects the HTML reports that end with the reviewed diffs
+type AppendixConfig struct {
+	// Email adds the appendix to emailed reports; off by default, as
+	// diffs make emails large
+	Email bool `yaml:"email"`
+	// HTML stores the reviewed diffs with each report and adds them to
+	// its pages in the dashboard and from generate-report
+	HTML bool `yaml:"html"`
+}
+
+// Enabled reports whether any report gets the appendix
+func (a AppendixConfig) Enabled() bool {
+	return a.Email || a.HTML
+}
+
+// SeverityStyle customizes how a severity is shown in reports and emails.
+// Empty fields keep the default.
+type SeverityStyle struct {
+	Label string `yaml:"label"` // e.g. "Critical"
+	Emoji string `yaml:"emoji"` // e.g. "🚨"
+	Color string `yaml:"color"` // CSS color, e.g. "#b91c1c"
+}
+
+// ScannerConfig holds repository discovery settings
+type ScannerConfig struct {
+	// IncludeNested also reports repositories nested inside another
+	// repository's working tree (skipping paths that repo ignores)
+	IncludeNested bool `yaml:"include_nested"`
+	// RepoNames selects how repositories are labelled in reports:
+	// relative (path relative to root_path), base (directory name only)
+	// or remote (owner/name from the origin URL)
+	RepoNames string `yaml:"repo_names"`
+	// InProgress decides what happens to a repository in the middle of a
+	// rebase, merge, cherry-pick, revert or bisect: completed (review only
+	// commits already on a branch) or skip (leave it out of the run)
+	InProgress string `yaml:"in_progress"`
+	// Repos restricts runs to repositories matching these globs, or
+	// regular expressions between slashes; a leading "!" excludes
+	Repos []string `yaml:"repos"`
+	// MaxDepth limits how many directory levels below root_path
+	// repositories are looked for; 0 has no limit
+	MaxDepth int `yaml:"max_depth"`
+	// MaxRepos stops scanning once this many repositories that repos and
+	// scanner.repos keep are found; 0 has no limit
+	MaxRepos int `yaml:"max_repos"`
+	// FollowSymlinks also walks symlinked directories, skipping those that
+	// lead back into a tree already walked
+	FollowSymlinks bool `yaml:"follow_symlinks"`
+	// Workers is how many top-level directories of root_path are walked at
+	// once; 0 for 8, 1 to walk the tree in one go
+	Workers int `yaml:"workers"`
+	// Cache keeps what each scan found in the cache (see CacheConfig), so the
+	// next one only walks the subtrees where directories changed
+	Cache bool `yaml:"cache"`
+	// Rescan walks the whole tree, refreshing the cache; set by --rescan
+	Rescan bool `yaml:"-"`
+}
+
+// AlertRule fires when a run's findings of one severity cross a
+// threshold. Set Above, Growth or both; either firing fires the rule,
+// at most once a day.
+type AlertRule struct {
+	Name     string `yaml:"name"`
+	Severity string `yaml:"severity"` // High (default), Medium or Low
+	// Above fires when one run has more than this many findings
+	Above int `yaml:"above"`
+	// Growth fires when the last 7 days have at least this many times the
+	// findings of the 7 days before, e.g. 2 for doubled
+	Growth float64 `yaml:"growth"`
+	// Channels the alert is sent through; default email
+	Channels []string `yaml:"channels"`
+	// To overrides the email recipients, which default to every recipient
+	// of the report
+	To []string `yaml:"to"`
+}
+
+// ResolveChannels returns the rule's channels, defaulting to email
+func (a AlertRule) ResolveChannels() []string {
+	if len(a.Channels) > 0 {
+		return a.Channels
+	}
+	return []string{"email"}
+}
+
+// RepoListConfig is the permanent allowlist and denylist of repositories.
+// Entries are globs or /regexps/ matched against the display name and the
+// directory name.
+type RepoListConfig struct {
+	Include []string `yaml:"include"` // When set, only matching repositories are reviewed
+	Exclude []string `yaml:"exclude"` // Matching repositories are never reviewed
+}
+
+// BranchConfig selects the branches commits are read from
+type BranchConfig struct {
+	BranchSelection `yaml:",inline"`
+	// Repos overrides the selection for repositories matching Repo; the
+	// first matching entry wins
+	Repos []RepoBranches `yaml:"repos"`
+}
+
+// BranchSelection lists the branches to read. When neither field is set
+// every ref is read, including stale and work-in-progress branches.
+type BranchSelection struct {
+	// Include lists branch globs or /regexps/ ("main", "release/*");
+	// remote branches match with or without their remote prefix and a
+	// leading "!" excludes
+	Include []string `yaml:"include"`
+	// DefaultBranch adds the default branch (origin/HEAD, else main or
+	// master), both local and remote
+	DefaultBranch bool `yaml:"default_branch"`
+}
+
+// IsZero reports whether the selection reads every ref
+func (b BranchSelection) IsZero() bool {
+	return len(b.Include) == 0 && !b.DefaultBranch
+}
+
+// RepoBranches is the branch selection for repositories matching Repo
+type RepoBranches struct {
+	Repo            string `yaml:"repo"` // Repository glob or /regexp/, as in scanner.repos
+	BranchSelection `yaml:",inline"`
+}
+
+// RepoOverride holds per-repository settings
+type RepoOverride struct {
+	Repo       string `yaml:"repo"`                 // Repository glob or /regexp/, as in scanner.repos
+	Skip       bool   `yaml:"skip,omitempty"`       // Leave the repository out of reviews
+	Strictness string `yaml:"strictness,omitempty"` // Replaces review.strictness
+	Risk       string `yaml:"risk,omitempty"`       // critical, normal or experimental; see RiskProfiles
+	// Backend names the review.backends entry reviewing these
+	// repositories instead of review.provider
+	Backend string `yaml:"backend,omitempty"`
+	// Paths limits the reviewed files to paths matching these globs or
+	// /regexps/, tested against the path and the file name; a leading "!"
+	// excludes (e.g. "!*_gen.go")
+	Paths []string `yaml:"paths,omitempty"`
+	// Exclude adds gitignore-style patterns of paths left out, as in
+	// exclude, for these repositories only
+	Exclude []string `yaml:"exclude,omitempty"`
+}
+
+// IsZero reports whether the override changes nothing
+func (o RepoOverride) IsZero() bool {
+	return !o.Skip && o.Strictness == "" && o.Risk == "" && o.Backend == "" && len(o.Paths) == 0 && len(o.Exclude) == 0
+}
+
+// LogConfig selects how much is logged, and where. The --log-level,
+// --log-format, --log-file and --verbose flags override it.
+type LogConfig struct {
+	Level  string `yaml:"level"`  // debug, info (default), warn or error
+	Format string `yaml:"format"` // text (default) or json
+	File   string `yaml:"file"`   // Appended to instead of stderr
+}
+
+// CacheConfig holds the cache shared by every CRA process on the machine:
+// runs, `review serve` and `review watch` alike
+type CacheConfig struct {
+	// Dir holds the cache; default cra in the user cache directory, e.g.
+	// $XDG_CACHE_HOME/cra or ~/.cache/cra
+	Dir string `yaml:"dir"`
+	// MaxMB is the size the cache is kept under by removing the least
+	// recently used entries; 512 when 0
+	MaxMB int `yaml:"max_mb"`
+	// Responses reuses the model's answer to a prompt sent before, as when
+	// the same changes are reviewed again
+	Responses bool `yaml:"responses"`
+}
+
+// DefaultCacheMB is the cache size limit when cache.max_mb is 0
+const DefaultCacheMB = 512
+
+// Directory returns where the cache is kept
+func (c CacheConfig) Directory() (string, error) {
+	if c.Dir != "" {
+		return c.Dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locating cache directory: %w", err)
+	}
+	return filepath.Join(dir, "cra"), nil
+}
+
+// MaxBytes returns the size the cache is kept under
+func (c CacheConfig) MaxBytes() int64 {
+	return int64(cmp.Or(c.MaxMB, DefaultCacheMB)) << 20
+}
+
+// ServerConfig holds settings for `review serve`
+type ServerConfig struct {
+	Addr string `yaml:"addr"` // Listen address, e.g. 127.0.0.1:8080
+	// Auth requires an API token on every request and shows each user
+	// only their repositories. Accounts are kept by the postgres or
+	// sqlite reports backend; add them with `review user add`.
+	Auth bool `yaml:"auth"`
+}
+
+// ForgeConfig holds settings for linking findings to open pull requests
+type ForgeConfig struct {
+	Provider     string `yaml:"provider"`      // github; empty disables PR linking
+	APIURL       string `yaml:"api_url"`       // Defaults to https://api.github.com
+	Token        string `yaml:"token"`         // Or set GITHUB_TOKEN
+	PostComments bool   `yaml:"post_comments"` // Also comment findings on the PR
+}
+
+// PolicyConfig points at a centrally managed review policy fragment
+type PolicyConfig struct {
+	URL string `yaml:"url"` // HTTPS URL of the policy YAML
+	// PublicKey is the base64 ed25519 key the policy must be signed with,
+	// its signature published at URL + ".sig"; required
+	PublicKey string `yaml:"public_key"`
+	CacheTTL  string `yaml:"cache_ttl"` // How long a fetched policy is reused, e.g. "24h"
+}
+
+// ProxyConfig routes outgoing traffic through an HTTP or SOCKS5 proxy.
+// Empty fields fall back to $HTTPS_PROXY, $HTTP_PROXY and $NO_PROXY.
+type ProxyConfig struct {
+	// URL is http://, https:// or socks5:// with the proxy's host and
+	// port, and user:password@ when it needs credentials
+	URL string `yaml:"url"`
+	// NoProxy lists the hosts, domains (.corp.example) and CIDRs reached
+	// directly, comma-separated
+	NoProxy string `yaml:"no_proxy"`
+	// SMTP also tunnels the SMTP connection through the proxy, with
+	// CONNECT for HTTP proxies; by default only HTTP traffic uses it
+	SMTP bool `yaml:"smtp"`
+}
+
+// ProxySchemes are the accepted schemes of proxy.url
+var ProxySchemes = []string{"http", "https", "socks5", "socks5h"}
+
+// ParseURL parses URL, nil when it is empty
+func (p ProxyConfig) ParseURL() (*url.URL, error) {
+	if p.URL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy.url: %w", err)
+	}
+	if !slices.Contains(ProxySchemes, u.Scheme) || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy.url %q: expected scheme://host:port with scheme one of %s", u.Redacted(), strings.Join(ProxySchemes, ", "))
+	}
+	return u, nil
+}
+
+// Schedule is a cron schedule of reviews run by `review serve`
+type Schedule struct {
+	// Cron is a five-field cron expression, e.g. "0 7 * * 1-5" for 7:00
+	// on weekdays
+	Cron string `yaml:"cron"`
+	// Name tells schedules apart in logs; the expression by default
+	Name string `yaml:"name"`
+	// Since replaces since for the reviews of this schedule, e.g.
+	// last_run for an incremental review between full ones
+	Since string `yaml:"since"`
+}
+
+// Label names the schedule in logs
+func (s Schedule) Label() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Cron
+}
+
+// UnmarshalYAML accepts a bare cron expression as a schedule
+func (s *Schedule) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&s.Cron)
+	}
+	type plain Schedule
+	return node.Decode((*plain)(s))
+}
+
+// Schedules are the schedules of reviews run by `review serve`
+type Schedules []Schedule
+
+// UnmarshalYAML accepts a single schedule in place of a list
+func (s *Schedules) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.SequenceNode {
+		var one Schedule
+		if err := node.Decode(&one); err != nil {
+			return err
+		}
+		*s = Schedules{one}
+		return nil
+	}
+	var list []Schedule
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// Parse parses the cron expressions of the schedules, in order
+func (s Schedules) Parse() ([]*cron.Expression, error) {
+	exprs := make([]*cron.Expression, 0, len(s))
+	for i, schedule := range s {
+		expr, err := cron.Parse(schedule.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("schedule[%d]: %w", i, err)
+		}
+		if expr.Next(time.Now()).IsZero() {
+			return nil, fmt.Errorf("schedule[%d]: cron expression %q never matches", i, schedule.Cron)
+		}
+		exprs = append(exprs, expr)
+	}
+	return exprs, nil
 }
 
 // DefaultConfig returns a configuration with sensible defaults
@@ -51,6 +674,7 @@ func DefaultConfig() *Config {
 	homeDir, _ := os.UserHomeDir()
 	return &Config{
 		RootPath: filepath.Join(homeDir, "projects"),
+		Backfill: 7,
 		Email: EmailConfig{
 			Enabled:  true,
 			SendAt:   "08:00",
@@ -64,46 +688,185 @@ func DefaultConfig() *Config {
 		},
 		Reports: ReportsConfig{
 			OutputDir: "reports",
+			Backend:   "sqlite",
+			Appendix:  AppendixConfig{HTML: true},
+		},
+		Cache: CacheConfig{
+			Responses: true,
+		},
+		Scanner: ScannerConfig{
+			RepoNames:  "relative",
+			InProgress: "completed",
+			Cache:      true,
+		},
+		Server: ServerConfig{
+			Addr: "127.0.0.1:8080",
 		},
 	}
 }
 
-// Load reads configuration from file and merges with defaults
+// Load reads configuration from file and merges with defaults, then
+// applies the CRA_* environment overrides (see EnvVars)
 func Load(path string) (*Config, error) {
 	cfg := DefaultConfig()
 
-	// Determine config file path
-	if path == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return cfg, nil // Use defaults if can't find home
+	// Determine config file path; without one the defaults are used
+	path = ResolvePath(path)
+	if path != "" {
+		cfg.Review.SuppressionsFile = filepath.Join(filepath.Dir(path), "suppressions.yaml")
+		cfg.Review.BaselineFile = filepath.Join(filepath.Dir(path), "baseline.yaml")
+		cfg.Review.NotesFile = filepath.Join(filepath.Dir(path), "notes.yaml")
+		cfg.PauseFile = filepath.Join(filepath.Dir(path), "pause.yaml")
+		cfg.Email.PreferencesFile = filepath.Join(filepath.Dir(path), "preferences.yaml")
+		if err := readFile(cfg, path); err != nil {
+			return nil, err
 		}
-		path = filepath.Join(homeDir, ".config", "cra", "config.yaml")
 	}
 
-	// Expand ~ in path
-	path = expandPath(path)
-
-	// Read config file if it exists
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return cfg, nil // Use defaults if file doesn't exist
-		}
-		return nil, fmt.Errorf("reading config: %w", err)
+	// CRA_* variables override the file
+	if err := applyEnv(cfg); err != nil {
+		return nil, fmt.Errorf("environment override: %w", err)
 	}
 
-	if err := yaml.Unmarshal(data, cfg); err != nil {
-		return nil, fmt.Errorf("parsing config: %w", err)
+	// keyring:// values, from either, name secrets in the OS keyring
+	if err := resolveKeyring(cfg); err != nil {
+		return nil, err
 	}
 
 	// Expand paths
 	cfg.RootPath = expandPath(cfg.RootPath)
 	cfg.Reports.OutputDir = expandPath(cfg.Reports.OutputDir)
+	cfg.Reports.ManifestPath = expandPath(cfg.Reports.ManifestPath)
+	cfg.Review.SuppressionsFile = expandPath(cfg.Review.SuppressionsFile)
+	cfg.Review.BaselineFile = expandPath(cfg.Review.BaselineFile)
+	cfg.Review.NotesFile = expandPath(cfg.Review.NotesFile)
+	cfg.Review.SystemPromptFile = expandPath(cfg.Review.SystemPromptFile)
+	cfg.Review.OutputInstructionsFile = expandPath(cfg.Review.OutputInstructionsFile)
+	cfg.Review.LanguagePromptsDir = expandPath(cfg.Review.LanguagePromptsDir)
+	cfg.PauseFile = expandPath(cfg.PauseFile)
+	cfg.Email.PreferencesFile = expandPath(cfg.Email.PreferencesFile)
+	cfg.Log.File = expandPath(cfg.Log.File)
+	cfg.Cache.Dir = expandPath(cfg.Cache.Dir)
 
 	return cfg, nil
 }
 
+// readFile decodes the config file at path, and the files it includes,
+// over cfg and applies the selected profile. cfg is kept as is when the
+// file doesn't exist.
+func readFile(cfg *Config, path string) error {
+	if err := decodeFile(cfg, path, nil); err != nil {
+		if os.IsNotExist(err) {
+			return applyProfile(cfg)
+		}
+		return err
+	}
+	return applyProfile(cfg)
+}
+
+// decodeFile decodes the config file at path over cfg, after the files it
+// includes. including lists the files on the way to path, to catch cycles.
+func decodeFile(cfg *Config, path string, including []string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && len(including) == 0 {
+			return err
+		}
+		return fmt.Errorf("reading config: %w", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	if root.Kind == 0 {
+		return nil
+	}
+	if err := decryptVault(&root); err != nil {
+		return err
+	}
+	if problems := unknownKeys(&root, reflect.TypeOf(Config{}), ""); len(problems) > 0 {
+		return &UnknownKeyError{Path: path, Problems: problems}
+	}
+
+	var head struct {
+		Include []string `yaml:"include"`
+	}
+	if err := root.Decode(&head); err != nil {
+		return fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	including = append(including, path)
+	for _, include := range head.Include {
+		include = expandPath(include)
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		if slices.Contains(including, include) {
+			return fmt.Errorf("%s includes itself through %s", include, strings.Join(including, " -> "))
+		}
+		if err := decodeFile(cfg, include, including); err != nil {
+			return fmt.Errorf("include %s: %w", include, err)
+		}
+	}
+
+	if err := root.Decode(cfg); err != nil {
+		return fmt.Errorf("parsing config %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyProfile decodes the selected profile over cfg
+func applyProfile(cfg *Config) error {
+	name := cfg.Profile
+	if env := os.Getenv(ProfileEnv); env != "" {
+		name = env
+	}
+	if name == "" {
+		return nil
+	}
+
+	profile, ok := cfg.Profiles[name]
+	if !ok {
+		names := slices.Sorted(maps.Keys(cfg.Profiles))
+		if len(names) == 0 {
+			return fmt.Errorf("no profile %q: the config has no profiles", name)
+		}
+		return fmt.Errorf("no profile %q, expected one of: %s", name, strings.Join(names, ", "))
+	}
+	for i := 0; i+1 < len(profile.Content); i += 2 {
+		switch key := profile.Content[i].Value; key {
+		case "include", "profiles", "profile":
+			return fmt.Errorf("profile %s: %s can't be set in a profile", name, key)
+		}
+	}
+	if err := profile.Decode(cfg); err != nil {
+		return fmt.Errorf("profile %s: %w", name, err)
+	}
+	cfg.Profile = name
+	return nil
+}
+
+// ResolvePath returns the config file path to use, falling back to
+// ~/.config/cra/config.yaml when path is empty
+func ResolvePath(path string) string {
+	if path == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		return filepath.Join(homeDir, ".config", "cra", "config.yaml")
+	}
+	return expandPath(path)
+}
+
+// ResolveToken returns the configured forge token, falling back to $GITHUB_TOKEN
+func (f ForgeConfig) ResolveToken() string {
+	if f.Token != "" {
+		return f.Token
+	}
+	return os.Getenv("GITHUB_TOKEN")
+}
+
 // expandPath expands ~ to home directory
 func expandPath(path string) string {
 	if strings.HasPrefix(path, "~/") {
@@ -129,19 +892,134 @@ func (c *Config) Validate() error {
 		if c.Email.SMTPHost == "" {
 			return fmt.Errorf("smtp_host is required when email is enabled")
 		}
-		if c.Email.ToAddress == "" {
-			return fmt.Errorf("to_address is required when email is enabled")
+		if c.Email.ToAddress == "" && len(c.Email.Routes) == 0 {
+			return fmt.Errorf("to_address or routes is required when email is enabled")
+		}
+	}
+	if err := c.Email.checkManageURL(); err != nil {
+		return err
+	}
+
+	if err := validateFindingFields(c.Review.FindingFields); err != nil {
+		return err
+	}
+
+	for _, area := range c.Review.Focus {
+		if !contains(FocusAreas, strings.ToLower(strings.TrimSpace(area))) {
+			return fmt.Errorf("unknown review.focus area %q, use any of: %s", area, strings.Join(FocusAreas, ", "))
+		}
+	}
+
+	for name, b := range c.Review.Backends {
+		if !contains(SupportedProviders, b.Provider) {
+			return fmt.Errorf("review.backends.%s: unsupported provider %q, use one of: %s", name, b.Provider, strings.Join(SupportedProviders, ", "))
+		}
+	}
+	for i, o := range c.Overrides {
+		if _, ok := c.Review.Backends[o.Backend]; o.Backend != "" && !ok {
+			return fmt.Errorf("overrides[%d]: unknown backend %q, add it to review.backends", i, o.Backend)
+		}
+		if o.Risk != "" && !contains(RiskProfiles, o.Risk) {
+			return fmt.Errorf("overrides[%d]: invalid risk %q, use critical, normal or experimental", i, o.Risk)
+		}
+	}
+
+	if _, err := c.Proxy.ParseURL(); err != nil {
+		return err
+	}
+	if _, err := c.Schedule.Parse(); err != nil {
+		return err
+	}
+	if c.Backfill < 0 {
+		return fmt.Errorf("backfill must not be negative, got %d", c.Backfill)
+	}
+	if c.Scanner.MaxDepth < 0 || c.Scanner.MaxRepos < 0 || c.Scanner.Workers < 0 {
+		return fmt.Errorf("scanner.max_depth, scanner.max_repos and scanner.workers must not be negative")
+	}
+	if c.Cache.MaxMB < 0 {
+		return fmt.Errorf("cache.max_mb must not be negative, got %d", c.Cache.MaxMB)
+	}
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", c.Timezone, err)
 		}
 	}
 
 	if c.Review.APIKey == "" {
 		// Check environment variable
-		if key := os.Getenv("GOOGLE_API_KEY"); key != "" {
-			c.Review.APIKey = key
-		} else if key := os.Getenv("OPENAI_API_KEY"); key != "" {
-			c.Review.APIKey = key
-		}
+		c.Review.APIKey = c.Review.ResolveAPIKey()
 	}
 
 	return nil
 }
+
+// Location returns the configured timezone, the machine's local time when
+// it is unset or invalid
+func (c *Config) Location() *time.Location {
+	if c.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// ProviderNone is the review.provider that disables the LLM
+const ProviderNone = "none"
+
+// ProviderOllama is a local Ollama server, reached through its
+// OpenAI-compatible API; it needs no API key
+const ProviderOllama = "ollama"
+
+// NeedsAPIKey reports whether the provider authenticates with an API key
+func (r ReviewConfig) NeedsAPIKey() bool {
+	return !r.LLMDisabled() && r.Provider != ProviderOllama
+}
+
+// Backend returns the review settings with the provider, model, API key
+// and base URL of the named review.backends entry, the settings themselves
+// when there is no such backend
+func (r ReviewConfig) Backend(name string) ReviewConfig {
+	b, ok := r.Backends[name]
+	if !ok {
+		return r
+	}
+	r.Provider, r.Model, r.APIKey, r.BaseURL = b.Provider, b.Model, b.APIKey, b.BaseURL
+	r.StrongModel, r.TriageModel = "", ""
+	return r
+}
+
+// LLMDisabled reports whether reviews run only the built-in heuristics and
+// deterministic checks, without any LLM provider
+func (r ReviewConfig) LLMDisabled() bool {
+	return r.NoLLM || r.Provider == ProviderNone
+}
+
+// APIKeyEnvVars returns the environment variables consulted for the
+// provider's API key, in order of precedence
+func (r ReviewConfig) APIKeyEnvVars() []string {
+	switch r.Provider {
+	case "openai":
+		return []string{"ZHIPU_API_KEY", "OPENAI_API_KEY"}
+	case ProviderOllama:
+		return nil
+	default:
+		return []string{"GEMINI_API_KEY", "GOOGLE_API_KEY"}
+	}
+}
+
+// ResolveAPIKey returns the configured API key, falling back to the
+// provider's environment variables
+func (r ReviewConfig) ResolveAPIKey() string {
+	if r.APIKey != "" {
+		return r.APIKey
+	}
+	for _, name := range r.APIKeyEnvVars() {
+		if key := os.Getenv(name); key != "" {
+			return key
+		}
+	}
+	return ""
+}
@@ -0,0 +1,782 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CheckStatus is the outcome of a single configuration check
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "PASS"
+	CheckWarn CheckStatus = "WARN"
+	CheckFail CheckStatus = "FAIL"
+)
+
+// Check is the result of validating one configuration field
+type Check struct {
+	Field   string
+	Status  CheckStatus
+	Message string
+	Fix     string // Suggested remediation, empty when passing
+}
+
+// SupportedProviders lists the LLM providers the reviewer can initialize
+var SupportedProviders = []string{"googleai", "openai", ProviderOllama}
+
+// RepoNameStyles lists the accepted scanner.repo_names values
+var RepoNameStyles = []string{"relative", "base", "remote"}
+
+// InProgressModes lists the accepted scanner.in_progress values
+var InProgressModes = []string{"completed", "skip"}
+
+// StrictnessLevels lists the accepted review.strictness values
+var StrictnessLevels = []string{"low", "medium", "high"}
+
+// RiskProfiles lists the accepted risk values of overrides and .cra.yaml
+var RiskProfiles = []string{RiskCritical, RiskNormal, RiskExperimental}
+
+// Repository risk profiles, which set how deeply a repository is reviewed
+const (
+	// RiskCritical reviews with the full files as context, the strong
+	// model and a verifier pass
+	RiskCritical = "critical"
+	RiskNormal   = "normal"
+	// RiskExperimental gets a triage pass only: the triage model looking
+	// for High severity issues
+	RiskExperimental = "experimental"
+)
+
+// FocusAreas lists the accepted review.focus values, the categories
+// findings are filed under
+var FocusAreas = []string{"security", "bugs", "data", "design", "performance", "maintainability"}
+
+// Channels lists the channels stored reports can be sent through
+var Channels = []string{"email"}
+
+// AlertChannels lists the channels alerts can be sent through
+var AlertChannels = []string{"email", "desktop"}
+
+// ReportBackends lists the accepted reports.backend values
+var ReportBackends = []string{"files", "sqlite", "bbolt", "postgres"}
+
+// ReportViews lists the accepted email.routes view values
+var ReportViews = []string{"engineer", "manager"}
+
+// Diagnose loads the config file at path and runs every validation check,
+// returning one result per field instead of stopping at the first error
+func Diagnose(path string) (*Config, []Check) {
+	var checks []Check
+	path = ResolvePath(path)
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		checks = append(checks, pass("config_file", path))
+		checks = append(checks, checkUnknownKeys(path, data))
+	case os.IsNotExist(err):
+		checks = append(checks, Check{
+			Field:   "config_file",
+			Status:  CheckWarn,
+			Message: fmt.Sprintf("%s not found, using defaults", path),
+			Fix:     "copy config.example.yaml to ~/.config/cra/config.yaml",
+		})
+	default:
+		checks = append(checks, fail("config_file", err.Error(), "check the file permissions"))
+	}
+
+	if check, ok := checkEnv(); ok {
+		checks = append(checks, check)
+	}
+
+	cfg, err := Load(path)
+	var unknown *UnknownKeyError
+	if errors.As(err, &unknown) {
+		// Already reported by the keys check
+		return nil, checks
+	}
+	if err != nil {
+		checks = append(checks, fail("config_file", err.Error(), "fix the YAML syntax error, vault key, keyring:// secret or CRA_* variable"))
+		return nil, checks
+	}
+
+	if cfg.Profile != "" {
+		checks = append(checks, pass("profile", cfg.Profile))
+	}
+	checks = append(checks, cfg.checkRootPath())
+	checks = append(checks, cfg.checkProvider())
+	checks = append(checks, checkAPIKey("review.api_key", cfg.Review))
+	checks = append(checks, cfg.checkBackends()...)
+	checks = append(checks, cfg.checkStrictness())
+	checks = append(checks, cfg.checkRepoNames())
+	checks = append(checks, cfg.checkInProgress())
+	if cfg.Scanner.MaxDepth != 0 || cfg.Scanner.MaxRepos != 0 || cfg.Scanner.Workers != 0 {
+		checks = append(checks, cfg.checkScannerLimits())
+	}
+	if cfg.Cache.Dir != "" || cfg.Cache.MaxMB != 0 {
+		checks = append(checks, cfg.checkCache())
+	}
+	if cfg.Log.Level != "" || cfg.Log.Format != "" {
+		checks = append(checks, cfg.checkLog())
+	}
+	if cfg.Review.MinSeverity != "" {
+		checks = append(checks, cfg.checkMinSeverity())
+	}
+	if len(cfg.Review.Focus) > 0 {
+		checks = append(checks, cfg.checkFocus())
+	}
+	if len(cfg.Review.FindingFields) > 0 {
+		checks = append(checks, cfg.checkFindingFields())
+	}
+	for _, t := range []struct{ field, path string }{
+		{"review.system_prompt_file", cfg.Review.SystemPromptFile},
+		{"review.output_instructions_file", cfg.Review.OutputInstructionsFile},
+	} {
+		if t.path != "" {
+			checks = append(checks, checkPromptTemplate(t.field, t.path))
+		}
+	}
+	if cfg.Review.LanguagePromptsDir != "" {
+		checks = append(checks, cfg.checkLanguagePromptsDir())
+	}
+	if cfg.Policy.URL != "" {
+		checks = append(checks, cfg.checkPolicy())
+	}
+	if cfg.Proxy != (ProxyConfig{}) {
+		checks = append(checks, cfg.checkProxy())
+	}
+	if len(cfg.Reports.Severity) > 0 {
+		checks = append(checks, cfg.checkSeverityStyles())
+	}
+	if cfg.Reports.Backend != "" {
+		checks = append(checks, cfg.checkReportBackend())
+	}
+	for i, rule := range cfg.Alerts {
+		checks = append(checks, cfg.checkAlert(i, rule))
+	}
+	if len(cfg.Languages) > 0 {
+		checks = append(checks, cfg.checkLanguages())
+	}
+	if cfg.Overlap != "" {
+		checks = append(checks, cfg.checkOverlap())
+	}
+	if cfg.Backfill != 0 {
+		checks = append(checks, cfg.checkBackfill())
+	}
+	if cfg.Timezone != "" {
+		checks = append(checks, cfg.checkTimezone())
+	}
+	if len(cfg.Schedule) > 0 {
+		checks = append(checks, cfg.checkSchedule())
+	}
+	if cfg.Review.Limits != (LimitsConfig{}) {
+		checks = append(checks, cfg.checkLimits())
+	}
+	if cfg.Server.Auth {
+		checks = append(checks, cfg.checkServerAuth())
+	}
+	checks = append(checks, cfg.checkEmail()...)
+	if cfg.Forge.Provider != "" {
+		checks = append(checks, cfg.checkForge())
+	}
+
+	return cfg, checks
+}
+
+// checkUnknownKeys checks the keys of the config file at path, its
+// profiles and the files it includes to catch misspelled keys
+func checkUnknownKeys(path string, data []byte) Check {
+	problems, err := fileUnknownKeys(path, data, nil)
+	if err != nil {
+		return fail("keys", err.Error(), "fix the YAML syntax error")
+	}
+	if len(problems) > 0 {
+		return fail("keys", strings.Join(problems, "; "), "remove or rename the unknown keys")
+	}
+	return pass("keys", "no unknown keys")
+}
+
+// fileUnknownKeys returns the unknown keys of the config file at path,
+// holding data, and of the files it includes, prefixed with their path.
+// including lists the files on the way to path, to stop at cycles.
+func fileUnknownKeys(path string, data []byte, including []string) ([]string, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if root.Kind == 0 {
+		return nil, nil
+	}
+	// Encrypted values are strings as far as key checking goes
+	stripVault(&root)
+
+	problems := unknownKeys(&root, reflect.TypeOf(Config{}), "")
+	if len(including) > 0 {
+		for i, problem := range problems {
+			problems[i] = path + ": " + problem
+		}
+	}
+
+	var head struct {
+		Include []string `yaml:"include"`
+	}
+	_ = root.Decode(&head)
+	including = append(including, path)
+	for _, include := range head.Include {
+		include = expandPath(include)
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		if slices.Contains(including, include) {
+			continue
+		}
+		data, err := os.ReadFile(include)
+		if err != nil {
+			continue
+		}
+		more, err := fileUnknownKeys(include, data, including)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, more...)
+	}
+	return problems, nil
+}
+
+func (c *Config) checkRootPath() Check {
+	if c.RootPath == "" {
+		return fail("root_path", "not set", "set root_path to the directory containing your repositories")
+	}
+	info, err := os.Stat(c.RootPath)
+	if err != nil {
+		return fail("root_path", fmt.Sprintf("%s does not exist", c.RootPath), "create the directory or point root_path elsewhere")
+	}
+	if !info.IsDir() {
+		return fail("root_path", fmt.Sprintf("%s is not a directory", c.RootPath), "point root_path at a directory")
+	}
+	return pass("root_path", c.RootPath)
+}
+
+func (c *Config) checkProvider() Check {
+	if contains(SupportedProviders, c.Review.Provider) {
+		return pass("review.provider", c.Review.Provider)
+	}
+	if c.Review.Provider == ProviderNone {
+		return pass("review.provider", "none, heuristics only")
+	}
+	return fail("review.provider", fmt.Sprintf("unsupported provider %q", c.Review.Provider),
+		"set review.provider to one of: "+strings.Join(SupportedProviders, ", ")+", or none for heuristics only")
+}
+
+// checkAPIKey checks the API key of the provider of r, reported as field
+func checkAPIKey(field string, r ReviewConfig) Check {
+	if r.LLMDisabled() {
+		return pass(field, "not needed, the LLM is disabled")
+	}
+	if !r.NeedsAPIKey() {
+		return pass(field, "not needed for "+r.Provider)
+	}
+	if r.APIKey != "" {
+		return pass(field, "set in config")
+	}
+	envVars := r.APIKeyEnvVars()
+	for _, name := range envVars {
+		if os.Getenv(name) != "" {
+			return pass(field, "found in $"+name)
+		}
+	}
+	return fail(field, fmt.Sprintf("no API key for provider %q", r.Provider),
+		fmt.Sprintf("set %s or export %s", field, strings.Join(envVars, " / ")))
+}
+
+// checkBackends checks the provider and API key of each review.backends
+// entry, and that overrides only name existing ones
+func (c *Config) checkBackends() []Check {
+	var checks []Check
+	names := make([]string, 0, len(c.Review.Backends))
+	for name := range c.Review.Backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field := "review.backends." + name
+		backend := c.Review.Backend(name)
+		if !contains(SupportedProviders, backend.Provider) {
+			checks = append(checks, fail(field, fmt.Sprintf("unsupported provider %q", backend.Provider),
+				"set provider to one of: "+strings.Join(SupportedProviders, ", ")))
+			continue
+		}
+		checks = append(checks, checkAPIKey(field+".api_key", backend))
+	}
+	for i, o := range c.Overrides {
+		if _, ok := c.Review.Backends[o.Backend]; o.Backend != "" && !ok {
+			checks = append(checks, fail(fmt.Sprintf("overrides[%d].backend", i), fmt.Sprintf("unknown backend %q", o.Backend),
+				"add it to review.backends, or remove backend to use review.provider"))
+		}
+	}
+	return checks
+}
+
+func (c *Config) checkStrictness() Check {
+	if contains(StrictnessLevels, c.Review.Strictness) {
+		return pass("review.strictness", c.Review.Strictness)
+	}
+	return fail("review.strictness", fmt.Sprintf("invalid value %q", c.Review.Strictness),
+		"set review.strictness to one of: "+strings.Join(StrictnessLevels, ", "))
+}
+
+func (c *Config) checkReportBackend() Check {
+	if !contains(ReportBackends, c.Reports.Backend) {
+		return fail("reports.backend", fmt.Sprintf("invalid value %q", c.Reports.Backend),
+			"set reports.backend to one of: "+strings.Join(ReportBackends, ", "))
+	}
+	if c.Reports.Backend == "postgres" && c.Reports.DSN == "" {
+		return fail("reports.backend", "postgres needs reports.dsn", "set reports.dsn to a postgres:// URL")
+	}
+	return pass("reports.backend", c.Reports.Backend)
+}
+
+// checkAlert requires a threshold, a known severity and known channels
+func (c *Config) checkAlert(i int, rule AlertRule) Check {
+	field := fmt.Sprintf("alerts[%d]", i)
+	if rule.Name != "" {
+		field = "alerts." + rule.Name
+	}
+	if rule.Above <= 0 && rule.Growth <= 0 {
+		return fail(field, "no threshold", "set above (findings in one run), growth (week-over-week multiple) or both")
+	}
+	if rule.Growth > 0 && rule.Growth <= 1 {
+		return fail(field, fmt.Sprintf("growth %g never means an increase", rule.Growth), "set growth above 1, e.g. 2 for doubled")
+	}
+	if rule.Severity != "" && !contains([]string{"high", "medium", "low"}, strings.ToLower(rule.Severity)) {
+		return fail(field, fmt.Sprintf("invalid severity %q", rule.Severity), "set severity to High, Medium or Low")
+	}
+	for _, channel := range rule.ResolveChannels() {
+		if !contains(AlertChannels, channel) {
+			return fail(field, fmt.Sprintf("unknown channel %q", channel), "use one of: "+strings.Join(AlertChannels, ", "))
+		}
+		if channel == "email" && !c.Email.Enabled {
+			return fail(field, "the email channel needs email.enabled", "enable email or choose another channel")
+		}
+	}
+	return pass(field, strings.Join(rule.ResolveChannels(), ", "))
+}
+
+// checkLanguages rejects keys that aren't file extensions, such as paths
+// or globs
+func (c *Config) checkLanguages() Check {
+	var bad []string
+	for ext := range c.Languages {
+		name := strings.TrimPrefix(strings.TrimSpace(ext), ".")
+		if name == "" || strings.ContainsAny(name, "./\\*?[ ") {
+			bad = append(bad, strconv.Quote(ext))
+		}
+	}
+	if len(bad) > 0 {
+		sort.Strings(bad)
+		return fail("languages", "not file extensions: "+strings.Join(bad, ", "), `use extensions such as ".py" as keys`)
+	}
+	return pass("languages", fmt.Sprintf("%d extensions configured", len(c.Languages)))
+}
+
+func (c *Config) checkOverlap() Check {
+	overlap, err := time.ParseDuration(c.Overlap)
+	if err != nil || overlap < 0 {
+		return fail("overlap", fmt.Sprintf("invalid duration %q", c.Overlap), "use a duration such as 30m or 1h")
+	}
+	return pass("overlap", fmt.Sprintf("windows start %s early; commits the previous run reviewed are skipped", overlap))
+}
+
+func (c *Config) checkBackfill() Check {
+	if c.Backfill < 0 {
+		return fail("backfill", fmt.Sprintf("negative day count %d", c.Backfill), "set backfill to the most days a run catches up on, e.g. 7, or 0 to turn it off")
+	}
+	return pass("backfill", fmt.Sprintf("runs after a gap review the missed days, up to %d back", c.Backfill))
+}
+
+func (c *Config) checkSchedule() Check {
+	exprs, err := c.Schedule.Parse()
+	if err != nil {
+		return fail("schedule", err.Error(), `use minute hour day-of-month month weekday, e.g. "0 7 * * 1-5" for 7:00 on weekdays`)
+	}
+	now := time.Now().In(c.Location())
+	var next time.Time
+	var label string
+	for i, expr := range exprs {
+		if t := expr.Next(now); next.IsZero() || t.Before(next) {
+			next, label = t, c.Schedule[i].Label()
+		}
+	}
+	return pass("schedule", fmt.Sprintf("%d schedule(s) for review serve, next %s at %s", len(exprs), label, next.Format("Mon 2006-01-02 15:04 MST")))
+}
+
+func (c *Config) checkTimezone() Check {
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return fail("timezone", fmt.Sprintf("unknown zone %q", c.Timezone), `use an IANA zone name such as "Europe/Madrid" or "UTC"`)
+	}
+	return pass("timezone", "today starts at midnight "+loc.String()+", now "+time.Now().In(loc).Format("15:04 MST"))
+}
+
+func (c *Config) checkLimits() Check {
+	l := c.Review.Limits
+	if l.MaxDiffLines < 0 || l.MaxFileBytes < 0 || l.TokenBudget < 0 {
+		return fail("review.limits", "limits can't be negative", "set max_diff_lines, max_file_bytes and token_budget to 0 or more; 0 keeps the default")
+	}
+	var set []string
+	if l.MaxDiffLines > 0 {
+		set = append(set, fmt.Sprintf("%d lines per file", l.MaxDiffLines))
+	}
+	if l.MaxFileBytes > 0 {
+		set = append(set, fmt.Sprintf("%d bytes per file", l.MaxFileBytes))
+	}
+	if l.TokenBudget > 0 {
+		set = append(set, fmt.Sprintf("%d input tokens per run", l.TokenBudget))
+	}
+	return pass("review.limits", strings.Join(set, ", "))
+}
+
+// checkServerAuth requires a backend that keeps user accounts
+func (c *Config) checkServerAuth() Check {
+	if c.Reports.Backend != "postgres" && c.Reports.Backend != "sqlite" && c.Reports.Backend != "" {
+		return fail("server.auth", "user accounts need the postgres or sqlite reports backend",
+			"set reports.backend to postgres, or turn server.auth off")
+	}
+	backend := c.Reports.Backend
+	if backend == "" {
+		backend = "sqlite"
+	}
+	return pass("server.auth", "accounts kept by the "+backend+" backend")
+}
+
+func (c *Config) checkRepoNames() Check {
+	if contains(RepoNameStyles, c.Scanner.RepoNames) {
+		return pass("scanner.repo_names", c.Scanner.RepoNames)
+	}
+	return fail("scanner.repo_names", fmt.Sprintf("invalid value %q", c.Scanner.RepoNames),
+		"set scanner.repo_names to one of: "+strings.Join(RepoNameStyles, ", "))
+}
+
+func (c *Config) checkInProgress() Check {
+	if contains(InProgressModes, c.Scanner.InProgress) {
+		return pass("scanner.in_progress", c.Scanner.InProgress)
+	}
+	return fail("scanner.in_progress", fmt.Sprintf("invalid value %q", c.Scanner.InProgress),
+		"set scanner.in_progress to one of: "+strings.Join(InProgressModes, ", "))
+}
+
+func (c *Config) checkScannerLimits() Check {
+	l := c.Scanner
+	if l.MaxDepth < 0 || l.MaxRepos < 0 || l.Workers < 0 {
+		return fail("scanner", "limits can't be negative", "set scanner.max_depth and scanner.max_repos to 0 or more, 0 having no limit, and scanner.workers to 0 or more")
+	}
+	var set []string
+	if l.MaxDepth > 0 {
+		set = append(set, fmt.Sprintf("repositories up to %d levels below root_path", l.MaxDepth))
+	}
+	if l.MaxRepos > 0 {
+		set = append(set, fmt.Sprintf("at most %d repositories", l.MaxRepos))
+	}
+	if l.Workers > 0 {
+		set = append(set, fmt.Sprintf("%d directories walked at once", l.Workers))
+	}
+	return pass("scanner", strings.Join(set, ", "))
+}
+
+// checkCache verifies the cache directory can be created and its size
+// limit is valid
+func (c *Config) checkCache() Check {
+	if c.Cache.MaxMB < 0 {
+		return fail("cache", "max_mb can't be negative", "set cache.max_mb to 0 or more, 0 for the default of 512")
+	}
+	dir, err := c.Cache.Directory()
+	if err == nil {
+		err = os.MkdirAll(dir, 0755)
+	}
+	if err != nil {
+		return fail("cache", err.Error(), "set cache.dir to a writable directory")
+	}
+	return pass("cache", fmt.Sprintf("%s, up to %d MB", dir, c.Cache.MaxBytes()>>20))
+}
+
+// checkLog rejects unknown log levels and formats
+func (c *Config) checkLog() Check {
+	if c.Log.Level != "" && !contains([]string{"debug", "info", "warn", "error"}, strings.ToLower(c.Log.Level)) {
+		return fail("log.level", fmt.Sprintf("invalid value %q", c.Log.Level), "set log.level to debug, info, warn or error")
+	}
+	if c.Log.Format != "" && !contains([]string{"text", "json"}, strings.ToLower(c.Log.Format)) {
+		return fail("log.format", fmt.Sprintf("invalid value %q", c.Log.Format), "set log.format to text or json")
+	}
+	return pass("log", strings.TrimSpace(c.Log.Level+" "+c.Log.Format))
+}
+
+func (c *Config) checkMinSeverity() Check {
+	for _, level := range []string{"High", "Medium", "Low"} {
+		if strings.EqualFold(c.Review.MinSeverity, level) {
+			return pass("review.min_severity", level)
+		}
+	}
+	return fail("review.min_severity", fmt.Sprintf("invalid value %q", c.Review.MinSeverity),
+		"set review.min_severity to High, Medium or Low")
+}
+
+func (c *Config) checkFocus() Check {
+	var bad []string
+	for _, area := range c.Review.Focus {
+		if !contains(FocusAreas, strings.ToLower(strings.TrimSpace(area))) {
+			bad = append(bad, area)
+		}
+	}
+	if len(bad) > 0 {
+		return fail("review.focus", "unknown areas: "+strings.Join(bad, ", "), "use any of: "+strings.Join(FocusAreas, ", "))
+	}
+	return pass("review.focus", "only "+strings.Join(c.Review.Focus, ", ")+" reviewed")
+}
+
+func (c *Config) checkFindingFields() Check {
+	if err := validateFindingFields(c.Review.FindingFields); err != nil {
+		return fail("review.finding_fields", err.Error(), "give every field a unique name such as estimated_effort")
+	}
+	names := make([]string, len(c.Review.FindingFields))
+	for i, f := range c.Review.FindingFields {
+		names[i] = f.Name
+	}
+	return pass("review.finding_fields", strings.Join(names, ", "))
+}
+
+// PromptFuncs are the functions prompt templates may call besides the
+// text/template built-ins
+var PromptFuncs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+}
+
+// checkPromptTemplate parses a prompt template; the fields it uses are
+// checked when a review loads it
+func checkPromptTemplate(field, path string) Check {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fail(field, err.Error(), "point "+field+" at a readable file, or remove it to use the built-in prompt")
+	}
+	if _, err := template.New(path).Funcs(PromptFuncs).Parse(string(data)); err != nil {
+		return fail(field, err.Error(), "fix the Go text/template syntax; {{.Default}} is the built-in text")
+	}
+	return pass(field, path)
+}
+
+// checkLanguagePromptsDir lists the languages review.language_prompts_dir
+// has guidance for
+func (c *Config) checkLanguagePromptsDir() Check {
+	const field = "review.language_prompts_dir"
+	entries, err := os.ReadDir(c.Review.LanguagePromptsDir)
+	if err != nil {
+		return fail(field, err.Error(), "point "+field+" at a directory of <language>.md files, or remove it")
+	}
+	var languages []string
+	for _, e := range entries {
+		if !e.IsDir() && filepath.Ext(e.Name()) == ".md" {
+			languages = append(languages, strings.TrimSuffix(e.Name(), ".md"))
+		}
+	}
+	if len(languages) == 0 {
+		return fail(field, "no .md files in "+c.Review.LanguagePromptsDir, "name each file after a language label, e.g. go.md or sql.md")
+	}
+	return pass(field, strings.Join(languages, ", "))
+}
+
+// checkSeverityStyles rejects reports.severity keys that aren't a severity
+func (c *Config) checkSeverityStyles() Check {
+	var unknown []string
+	for name := range c.Reports.Severity {
+		valid := false
+		for _, level := range []string{"high", "medium", "low"} {
+			valid = valid || strings.EqualFold(name, level)
+		}
+		if !valid {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fail("reports.severity", "unknown severities: "+strings.Join(unknown, ", "), "key styles by high, medium or low")
+	}
+	return pass("reports.severity", fmt.Sprintf("%d overrides", len(c.Reports.Severity)))
+}
+
+// checkSenders rejects email.senders keys that aren't a severity or clear
+func (c *Config) checkSenders() Check {
+	var unknown []string
+	for name := range c.Email.Senders {
+		valid := false
+		for _, level := range SenderLevels {
+			valid = valid || strings.EqualFold(name, level)
+		}
+		if !valid {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fail("email.senders", "unknown levels: "+strings.Join(unknown, ", "), "key senders by high, medium, low or clear")
+	}
+	return pass("email.senders", fmt.Sprintf("%d overrides", len(c.Email.Senders)))
+}
+
+func (c *Config) checkPolicy() Check {
+	if !strings.HasPrefix(c.Policy.URL, "https://") {
+		return fail("policy.url", "must use https", "serve the policy over HTTPS")
+	}
+	if c.Policy.PublicKey == "" {
+		return fail("policy.public_key", "not set, the policy can't be verified and won't be applied",
+			"set policy.public_key to the platform team's ed25519 key")
+	}
+	return pass("policy.url", c.Policy.URL)
+}
+
+func (c *Config) checkProxy() Check {
+	u, err := c.Proxy.ParseURL()
+	if err != nil {
+		return fail("proxy.url", err.Error(), "use a URL such as http://proxy.corp.example:3128 or socks5://proxy.corp.example:1080")
+	}
+	via := "$HTTPS_PROXY / $HTTP_PROXY"
+	if u != nil {
+		via = u.Redacted()
+	}
+	if c.Proxy.SMTP {
+		return pass("proxy", "HTTP and SMTP traffic through "+via)
+	}
+	return pass("proxy", "HTTP traffic through "+via)
+}
+
+func (c *Config) checkEmail() []Check {
+	if !c.Email.Enabled {
+		return []Check{pass("email.enabled", "false, delivery checks skipped")}
+	}
+
+	var checks []Check
+	required := []struct {
+		field string
+		value string
+	}{
+		{"email.smtp_host", c.Email.SMTPHost},
+		{"email.from_address", c.Email.FromAddress},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			checks = append(checks, fail(r.field, "required when email is enabled", "set "+r.field+" or disable email"))
+		} else {
+			checks = append(checks, pass(r.field, r.value))
+		}
+	}
+
+	checks = append(checks, c.checkRoutes()...)
+	if len(c.Email.Senders) > 0 {
+		checks = append(checks, c.checkSenders())
+	}
+	if c.Email.ManageURL != "" {
+		if err := c.Email.checkManageURL(); err != nil {
+			checks = append(checks, fail("email.manage_url", err.Error(), "set email.manage_url to the address recipients reach review serve at, e.g. https://cra.example.com"))
+		} else {
+			checks = append(checks, pass("email.manage_url", "one email per recipient, with a manage link to "+c.Email.ManageURL))
+		}
+	}
+
+	if c.Email.SMTPPort <= 0 || c.Email.SMTPPort > 65535 {
+		checks = append(checks, fail("email.smtp_port", fmt.Sprintf("invalid port %d", c.Email.SMTPPort), "use 587 (STARTTLS) or 25"))
+	} else {
+		checks = append(checks, pass("email.smtp_port", fmt.Sprintf("%d", c.Email.SMTPPort)))
+	}
+
+	if c.Email.SMTPUser != "" && c.Email.SMTPPassword == "" {
+		checks = append(checks, Check{
+			Field:   "email.smtp_password",
+			Status:  CheckWarn,
+			Message: "smtp_user is set but smtp_password is empty, authentication will be skipped",
+			Fix:     "set email.smtp_password",
+		})
+	}
+
+	return checks
+}
+
+// checkRoutes validates email.routes, or email.to_address when no routes are set
+func (c *Config) checkRoutes() []Check {
+	if len(c.Email.Routes) == 0 {
+		if c.Email.ToAddress == "" {
+			return []Check{fail("email.to_address", "required when email is enabled", "set email.to_address or email.routes")}
+		}
+		return []Check{pass("email.to_address", c.Email.ToAddress)}
+	}
+
+	var checks []Check
+	for i, route := range c.Email.Routes {
+		field := fmt.Sprintf("email.routes[%d]", i)
+		switch {
+		case !contains(ReportViews, route.View):
+			checks = append(checks, fail(field, fmt.Sprintf("unknown view %q", route.View), "use one of: "+strings.Join(ReportViews, ", ")))
+		case len(route.To) == 0:
+			checks = append(checks, fail(field, "no recipients", "list addresses under to"))
+		default:
+			checks = append(checks, pass(field, route.View+" -> "+strings.Join(route.To, ", ")))
+		}
+	}
+	return checks
+}
+
+func (c *Config) checkForge() Check {
+	if c.Forge.Provider != "github" {
+		return fail("forge.provider", fmt.Sprintf("unsupported forge %q", c.Forge.Provider), "set forge.provider to github or remove it")
+	}
+	if c.Forge.ResolveToken() == "" {
+		return Check{
+			Field:   "forge.token",
+			Status:  CheckWarn,
+			Message: "no token, only public repositories can be looked up and comments can't be posted",
+			Fix:     "set forge.token or export GITHUB_TOKEN",
+		}
+	}
+	return pass("forge.provider", c.Forge.Provider)
+}
+
+// checkEnv reports the CRA_* variables overriding the file, if any
+func checkEnv() (Check, bool) {
+	var set []string
+	for _, v := range EnvVars() {
+		if _, ok := os.LookupEnv(v.Name); ok {
+			set = append(set, v.Name)
+		}
+	}
+	if len(set) == 0 {
+		return Check{}, false
+	}
+	return pass("environment", "overridden by "+strings.Join(set, ", ")), true
+}
+
+func pass(field, message string) Check {
+	return Check{Field: field, Status: CheckPass, Message: message}
+}
+
+func fail(field, message, fix string) Check {
+	return Check{Field: field, Status: CheckFail, Message: message, Fix: fix}
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix starts the name of every environment variable that overrides a
+// config field
+const EnvPrefix = "CRA_"
+
+// EnvVar is an environment variable that overrides a config field
+type EnvVar struct {
+	Name  string // e.g. CRA_EMAIL_SMTP_PASSWORD
+	Field string // YAML path of the field, e.g. email.smtp_password
+	Type  string // string, bool, int or list (comma-separated)
+}
+
+// EnvVars lists the environment variables read by Load, one per scalar
+// or list-of-strings field, named after the field's YAML path: root_path
+// is CRA_ROOT_PATH and review.provider is CRA_REVIEW_PROVIDER. Maps and
+// lists of entries such as overrides can only be set in the file.
+func EnvVars() []EnvVar {
+	var vars []EnvVar
+	walkEnv(reflect.ValueOf(&Config{}).Elem(), nil, func(path []string, _ reflect.Value, kind string) error {
+		vars = append(vars, EnvVar{Name: envName(path), Field: strings.Join(path, "."), Type: kind})
+		return nil
+	})
+	return vars
+}
+
+// applyEnv overrides cfg with the CRA_* variables that are set. Flags are
+// applied by the CLI afterwards, so the precedence is flags, environment,
+// config file, defaults.
+func applyEnv(cfg *Config) error {
+	return walkEnv(reflect.ValueOf(cfg).Elem(), nil, func(path []string, field reflect.Value, kind string) error {
+		name := envName(path)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return nil
+		}
+		switch kind {
+		case "bool":
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a boolean", name, value)
+			}
+			field.SetBool(b)
+		case "int":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not an integer", name, value)
+			}
+			field.SetInt(int64(n))
+		case "list":
+			var items []string
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			field.Set(reflect.ValueOf(items))
+		default:
+			field.SetString(value)
+		}
+		return nil
+	})
+}
+
+// walkEnv calls fn for every field of v that can be set from the
+// environment, with its YAML path and value kind
+func walkEnv(v reflect.Value, path []string, fn func(path []string, field reflect.Value, kind string) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, opts, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		if name == "-" || !sf.IsExported() || sf.Tag.Get("env") == "-" {
+			continue
+		}
+		field := v.Field(i)
+		fieldPath := path
+		if opts != "inline" {
+			fieldPath = append(append([]string(nil), path...), name)
+		}
+
+		var kind string
+		switch sf.Type.Kind() {
+		case reflect.Struct:
+			if err := walkEnv(field, fieldPath, fn); err != nil {
+				return err
+			}
+			continue
+		case reflect.String:
+			kind = "string"
+		case reflect.Bool:
+			kind = "bool"
+		case reflect.Int:
+			kind = "int"
+		case reflect.Slice:
+			if sf.Type.Elem().Kind() != reflect.String {
+				continue
+			}
+			kind = "list"
+		default:
+			continue
+		}
+		if err := fn(fieldPath, field, kind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envName returns the variable name for a YAML path
+func envName(path []string) string {
+	return EnvPrefix + strings.ToUpper(strings.Join(path, "_"))
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+// KeyringScheme prefixes a config value read from the OS keyring (macOS
+// Keychain, Secret Service on Linux, Windows Credential Manager), e.g.
+//
+//	api_key: keyring://cra/openai
+//
+// reads the secret of account openai in service cra.
+const KeyringScheme = "keyring://"
+
+// ParseKeyringRef splits a keyring:// value into its service and account
+func ParseKeyringRef(value string) (service, account string, ok bool) {
+	rest, found := strings.CutPrefix(strings.TrimSpace(value), KeyringScheme)
+	if !found {
+		return "", "", false
+	}
+	service, account, _ = strings.Cut(rest, "/")
+	return service, account, service != "" && account != ""
+}
+
+// KeyringRef returns the keyring:// value of a secret
+func KeyringRef(service, account string) string {
+	return KeyringScheme + service + "/" + account
+}
+
+// SetKeyring stores secret in the OS keyring under service and account
+func SetKeyring(service, account, secret string) error {
+	if err := keyring.Set(service, account, secret); err != nil {
+		return fmt.Errorf("writing %s: %w", KeyringRef(service, account), err)
+	}
+	return nil
+}
+
+// resolveKeyring replaces every keyring:// string field of cfg, from the
+// file or the environment, with the secret it names. The keyring is only
+// opened when such a value is present. The API keys of review.backends,
+// in a map the walk doesn't enter, are resolved too.
+func resolveKeyring(cfg *Config) error {
+	err := walkEnv(reflect.ValueOf(cfg).Elem(), nil, func(path []string, field reflect.Value, kind string) error {
+		if kind != "string" {
+			return nil
+		}
+		secret, err := readKeyring(strings.Join(path, "."), field.String())
+		if err == nil {
+			field.SetString(secret)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for name, b := range cfg.Review.Backends {
+		if b.APIKey, err = readKeyring("review.backends."+name+".api_key", b.APIKey); err != nil {
+			return err
+		}
+		cfg.Review.Backends[name] = b
+	}
+	return nil
+}
+
+// readKeyring returns the secret a keyring:// value names, other values as
+// they are; name is the field, for errors
+func readKeyring(name, value string) (string, error) {
+	if !strings.HasPrefix(strings.TrimSpace(value), KeyringScheme) {
+		return value, nil
+	}
+	service, account, ok := ParseKeyringRef(value)
+	if !ok {
+		return "", fmt.Errorf("%s: %q should be keyring://<service>/<account>", name, value)
+	}
+	secret, err := keyring.Get(service, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", fmt.Errorf("%s: no secret %s in the OS keyring (store it with `review config keyring`)", name, KeyringRef(service, account))
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: reading %s from the OS keyring: %w", name, KeyringRef(service, account), err)
+	}
+	return secret, nil
+}
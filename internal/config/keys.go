@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnknownKeyError lists the keys of a config file no setting matches,
+// usually typos that would otherwise be ignored silently
+type UnknownKeyError struct {
+	Path     string
+	Problems []string // "line 8: unknown key email.smpt_host, did you mean smtp_host?"
+}
+
+func (e *UnknownKeyError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, strings.Join(e.Problems, "; "))
+}
+
+// nodeType is the type of yaml.Node fields, which hold settings decoded
+// later, e.g. profiles
+var nodeType = reflect.TypeOf(yaml.Node{})
+
+// schedulesType is the type of the schedule setting, a list or one mapping
+var schedulesType = reflect.TypeOf(Schedules{})
+
+// unknownKeys checks the keys of node against the yaml fields of t,
+// returning one problem per unknown key with its line and the closest
+// known key. path is the dotted path of node, empty at the root.
+func unknownKeys(node *yaml.Node, t reflect.Type, path string) []string {
+	if node == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if node.Kind == yaml.DocumentNode || node.Kind == yaml.AliasNode {
+		var problems []string
+		for _, child := range node.Content {
+			problems = append(problems, unknownKeys(child, t, path)...)
+		}
+		if node.Kind == yaml.AliasNode {
+			problems = append(problems, unknownKeys(node.Alias, t, path)...)
+		}
+		return problems
+	}
+
+	var problems []string
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == nodeType || node.Kind != yaml.MappingNode {
+			return nil
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				problems = append(problems, unknownKeys(value, t, path)...)
+				continue
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				problems = append(problems, unknownKeyProblem(key, path, fields))
+				continue
+			}
+			problems = append(problems, unknownKeys(value, field, joinKey(path, key.Value))...)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			problems = append(problems, unknownKeys(node.Content[i+1], t.Elem(), joinKey(path, node.Content[i].Value))...)
+		}
+	case reflect.Slice, reflect.Array:
+		// Schedules also accepts a single schedule
+		if node.Kind == yaml.MappingNode && t == schedulesType {
+			return unknownKeys(node, t.Elem(), path)
+		}
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i, item := range node.Content {
+			problems = append(problems, unknownKeys(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return problems
+}
+
+// yamlFields maps the yaml keys of struct t, inline structs included, to
+// their types. Profiles, a map of yaml.Node, is checked as a map of
+// Config.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			for key, typ := range yamlFields(f.Type) {
+				fields[key] = typ
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		typ := f.Type
+		if typ == reflect.TypeOf(map[string]yaml.Node{}) {
+			typ = reflect.TypeOf(map[string]Config{})
+		}
+		fields[name] = typ
+	}
+	return fields
+}
+
+// unknownKeyProblem describes an unknown key, suggesting the closest
+// known one when it looks like a typo
+func unknownKeyProblem(key *yaml.Node, path string, fields map[string]reflect.Type) string {
+	problem := fmt.Sprintf("line %d: unknown key %s", key.Line, joinKey(path, key.Value))
+	best, bestDistance := "", 0
+	for name := range fields {
+		d := editDistance(strings.ToLower(key.Value), name)
+		if best == "" || d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	// Close enough to be a typo: a few edits, fewer than half the letters
+	if best != "" && bestDistance <= 3 && bestDistance*2 < len(best) {
+		problem += ", did you mean " + best + "?"
+	}
+	return problem
+}
+
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// editDistance is the Damerau-Levenshtein distance of a and b, counting a
+// swap of adjacent letters (smpt, smtp) as one edit
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
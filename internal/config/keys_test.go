@@ -0,0 +1,56 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want []string
+	}{
+		{"known keys", "email:\n  smtp_host: mail\nreview:\n  strictness: low\n", nil},
+		{"typo", "email:\n  smpt_host: mail\n", []string{"line 2: unknown key email.smpt_host, did you mean smtp_host?"}},
+		{"no close key", "frobnicate: true\n", []string{"line 1: unknown key frobnicate"}},
+		{"list items", "overrides:\n  - repo: api\n    strictnes: low\n", []string{"line 3: unknown key overrides[0].strictnes, did you mean strictness?"}},
+		{"profiles", "profiles:\n  ci:\n    email:\n      smtp_hots: mail\n", []string{"line 4: unknown key profiles.ci.email.smtp_hots, did you mean smtp_host?"}},
+		{"schedule list", "schedule:\n  - cron: '0 7 * * *'\n    sine: 24h\n", []string{"line 3: unknown key schedule[0].sine, did you mean since?"}},
+		{"single schedule", "schedule:\n  cron: '0 7 * * *'\n  sine: 24h\n", []string{"line 3: unknown key schedule.sine, did you mean since?"}},
+		{"merge keys", "base: &base\n  smpt_host: mail\nemail:\n  <<: *base\n", []string{"line 1: unknown key base", "line 2: unknown key email.smpt_host, did you mean smtp_host?"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var root yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.yaml), &root); err != nil {
+				t.Fatal(err)
+			}
+			got := unknownKeys(&root, reflect.TypeOf(Config{}), "")
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"smtp", "smtp", 0},
+		{"smpt", "smtp", 1},
+		{"smtp_hots", "smtp_host", 1},
+		{"since", "sine", 1},
+		{"", "abc", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
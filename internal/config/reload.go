@@ -0,0 +1,81 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReloadInterval is how often WatchFile checks the config file for changes
+const ReloadInterval = 5 * time.Second
+
+// Sources returns the config file at path and the files it includes,
+// skipping those that can't be read
+func Sources(path string) []string {
+	var sources []string
+	var walk func(path string)
+	walk = func(path string) {
+		if slices.Contains(sources, path) {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		sources = append(sources, path)
+
+		var head struct {
+			Include []string `yaml:"include"`
+		}
+		if yaml.Unmarshal(data, &head) != nil {
+			return
+		}
+		for _, include := range head.Include {
+			include = expandPath(include)
+			if !filepath.IsAbs(include) {
+				include = filepath.Join(filepath.Dir(path), include)
+			}
+			walk(include)
+		}
+	}
+	walk(path)
+	return sources
+}
+
+// stamp fingerprints the size and modification time of the config file at
+// path and its includes
+func stamp(path string) string {
+	var sb strings.Builder
+	sb.WriteString(path)
+	for _, source := range Sources(path) {
+		if info, err := os.Stat(source); err == nil {
+			fmt.Fprintf(&sb, "\x00%s %d %d", source, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return sb.String()
+}
+
+// WatchFile calls onChange after the config file at path, or one of the
+// files it includes, changes, checking every interval until ctx is
+// cancelled. Loading and validating the new configuration is up to
+// onChange.
+func WatchFile(ctx context.Context, path string, interval time.Duration, onChange func()) {
+	last := stamp(path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		if current := stamp(path); current != last {
+			last = current
+			onChange()
+		}
+	}
+}
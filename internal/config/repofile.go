@@ -0,0 +1,150 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoFileName is the settings file a repository can keep at its root. A
+// directory above repositories can keep one too, for all of them.
+const RepoFileName = ".cra.yaml"
+
+// RepoFile holds the review settings a repository sets for itself in
+// .cra.yaml. An overrides entry in the config file matching the repository
+// takes precedence for strictness, risk and paths; skip in either skips it.
+type RepoFile struct {
+	Skip       bool   `yaml:"skip"`       // Leave the repository out of reviews
+	Strictness string `yaml:"strictness"` // Replaces review.strictness
+	Risk       string `yaml:"risk"`       // critical, normal or experimental; see RiskProfiles
+	// Paths limits the reviewed files, as in overrides
+	Paths []string `yaml:"paths"`
+	// Exclude leaves out paths matching these gitignore-style patterns,
+	// after the exclude of the config file and any overrides entry (e.g.
+	// "generated/", "*.snap")
+	Exclude []string `yaml:"exclude"`
+	// Languages adds file extensions to review, as in languages
+	Languages map[string]string `yaml:"languages"`
+	// PromptAddendum is guidance for this repository's changes only, such
+	// as house rules ("every handler must check the tenant ID")
+	PromptAddendum string `yaml:"prompt_addendum"`
+}
+
+// RepoDirFile is where a repository can keep its settings instead, inside
+// a .cra directory, relative to its root; it takes precedence over
+// RepoFileName
+var RepoDirFile = filepath.Join(".cra", "config.yaml")
+
+// RepoSettings are the settings files applying to a repository, merged
+type RepoSettings struct {
+	RepoFile
+	// Files lists the files merged, outermost first: absolute for the
+	// directories above the repository, relative to it for its own
+	Files []string
+	// SkipFile and PathsFile name the files that set skip and the paths in
+	// effect, empty when none does
+	SkipFile, PathsFile string
+}
+
+// LoadRepoSettings merges the settings files of the repository at
+// repoPath, returning nil when there are none. Closer files take
+// precedence: the .cra.yaml of root_path, then of each directory between it
+// and the repository that isn't a repository itself, then the repository's
+// .cra.yaml and its .cra/config.yaml. Strictness, risk and paths of a
+// closer file replace those before it, excludes and prompt addenda add up,
+// languages are updated per extension, and skip in any file skips.
+func LoadRepoSettings(rootPath, repoPath string) (*RepoSettings, error) {
+	var paths, names []string
+	if rel, err := filepath.Rel(rootPath, repoPath); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+		dir := filepath.Dir(repoPath)
+		var dirs []string
+		for {
+			dirs = append(dirs, dir)
+			if dir == filepath.Clean(rootPath) || dir == filepath.Dir(dir) {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+		for _, dir := range slices.Backward(dirs) {
+			if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+				continue // A repository's settings are its own
+			}
+			path := filepath.Join(dir, RepoFileName)
+			paths, names = append(paths, path), append(names, path)
+		}
+	}
+	for _, name := range []string{RepoFileName, RepoDirFile} {
+		paths, names = append(paths, filepath.Join(repoPath, name)), append(names, name)
+	}
+
+	var settings *RepoSettings
+	for i, path := range paths {
+		rf, err := readRepoFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if rf == nil {
+			continue
+		}
+		if settings == nil {
+			settings = &RepoSettings{}
+		}
+		settings.merge(*rf, names[i])
+	}
+	return settings, nil
+}
+
+// merge applies rf, read from the file name, over s
+func (s *RepoSettings) merge(rf RepoFile, name string) {
+	s.Files = append(s.Files, name)
+	if rf.Skip && !s.Skip {
+		s.Skip, s.SkipFile = true, name
+	}
+	s.Strictness = cmp.Or(rf.Strictness, s.Strictness)
+	s.Risk = cmp.Or(rf.Risk, s.Risk)
+	if len(rf.Paths) > 0 {
+		s.Paths, s.PathsFile = rf.Paths, name
+	}
+	s.Exclude = append(s.Exclude, rf.Exclude...)
+	if len(rf.Languages) > 0 {
+		languages := maps.Clone(s.Languages)
+		if languages == nil {
+			languages = make(map[string]string, len(rf.Languages))
+		}
+		maps.Copy(languages, rf.Languages)
+		s.Languages = languages
+	}
+	if rf.PromptAddendum != "" {
+		s.PromptAddendum = strings.TrimSpace(s.PromptAddendum + "\n\n" + rf.PromptAddendum)
+	}
+}
+
+// readRepoFile reads the settings file at path, returning nil when there
+// is none
+func readRepoFile(path string) (*RepoFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var rf RepoFile
+	if err := yaml.Unmarshal(data, &rf); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if rf.Strictness != "" && !contains(StrictnessLevels, rf.Strictness) {
+		return nil, fmt.Errorf("%s: invalid strictness %q, use low, medium or high", path, rf.Strictness)
+	}
+	if rf.Risk != "" && !contains(RiskProfiles, rf.Risk) {
+		return nil, fmt.Errorf("%s: invalid risk %q, use critical, normal or experimental", path, rf.Risk)
+	}
+	return &rf, nil
+}
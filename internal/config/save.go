@@ -0,0 +1,205 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SaveOverrides writes overrides to the overrides section of the config
+// file at path, creating the file if needed. The rest of the file,
+// comments and !vault values included, is kept as is, and so are the
+// comments of the entries and settings that remain.
+func SaveOverrides(path string, overrides []RepoOverride) error {
+	path = ResolvePath(path)
+	if path == "" {
+		return fmt.Errorf("no config file path: the home directory is unknown")
+	}
+
+	var root yaml.Node
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return fmt.Errorf("parsing config: %w", err)
+		}
+	case os.IsNotExist(err):
+	default:
+		return fmt.Errorf("reading config: %w", err)
+	}
+	if root.Kind == 0 {
+		root = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return fmt.Errorf("parsing config: top level is not a mapping")
+	}
+
+	value, err := overridesNode(mappingValue(doc, "overrides"), overrides)
+	if err != nil {
+		return fmt.Errorf("encoding overrides: %w", err)
+	}
+	setKey(doc, "overrides", value)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&root); err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	mode := os.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return os.WriteFile(path, buf.Bytes(), mode)
+}
+
+// overridesNode returns the overrides sequence holding overrides. The
+// entries of old, the sequence in the file, are updated in place, matched
+// by repo, so comments on the entries and their unchanged settings stay.
+func overridesNode(old *yaml.Node, overrides []RepoOverride) (*yaml.Node, error) {
+	seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	existing := make(map[string]*yaml.Node)
+	if old != nil && old.Kind == yaml.SequenceNode {
+		seq = old
+		for _, item := range old.Content {
+			if repo := mappingValue(item, "repo"); repo != nil && existing[repo.Value] == nil {
+				existing[repo.Value] = item
+			}
+		}
+	}
+
+	content := make([]*yaml.Node, 0, len(overrides))
+	for _, o := range overrides {
+		var fresh yaml.Node
+		if err := fresh.Encode(o); err != nil {
+			return nil, err
+		}
+		item, ok := existing[o.Repo]
+		if !ok {
+			content = append(content, &fresh)
+			continue
+		}
+		delete(existing, o.Repo)
+		mergeMapping(item, &fresh)
+		content = append(content, item)
+	}
+	seq.Content = content
+	return seq, nil
+}
+
+// mergeMapping gives dst the keys and values of src, keeping the order
+// and comments of the keys dst has and the nodes of unchanged values.
+// Keys src lacks are removed; new ones are appended.
+func mergeMapping(dst, src *yaml.Node) {
+	values := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		values[src.Content[i].Value] = src.Content[i+1]
+	}
+
+	var content []*yaml.Node
+	for i := 0; i+1 < len(dst.Content); i += 2 {
+		key, old := dst.Content[i], dst.Content[i+1]
+		value, ok := values[key.Value]
+		if !ok {
+			continue
+		}
+		delete(values, key.Value)
+		if !sameNode(old, value) {
+			value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+			old = value
+		}
+		content = append(content, key, old)
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		if _, ok := values[src.Content[i].Value]; ok {
+			content = append(content, src.Content[i], src.Content[i+1])
+		}
+	}
+	dst.Content = content
+}
+
+// sameNode reports whether two nodes hold the same data, whatever their
+// style and comments
+func sameNode(a, b *yaml.Node) bool {
+	if a.Kind != b.Kind || a.Value != b.Value || len(a.Content) != len(b.Content) {
+		return false
+	}
+	for i := range a.Content {
+		if !sameNode(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setKey replaces the value of key in a mapping node, appending the key
+// when it's missing. An empty sequence removes the key.
+func setKey(mapping *yaml.Node, key string, value *yaml.Node) {
+	remove := value.Kind == yaml.SequenceNode && len(value.Content) == 0
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			continue
+		}
+		if remove {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+		// Keep comments attached to the old value
+		value.HeadComment = mapping.Content[i+1].HeadComment
+		value.LineComment = mapping.Content[i+1].LineComment
+		mapping.Content[i+1] = value
+		return
+	}
+	if !remove {
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	}
+}
+
+// schemaModeline tells yaml-language-server which schema a file follows
+const schemaModeline = "# yaml-language-server: $schema="
+
+// AddSchemaModeline puts a yaml-language-server modeline pointing at
+// schema at the top of the YAML file at path, unless it has one. It
+// reports whether the file changed; a missing file is left alone.
+func AddSchemaModeline(path, schema string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if bytes.Contains(data, []byte(schemaModeline)) {
+		return false, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	line := schemaModeline + schema + "\n"
+	return true, os.WriteFile(path, append([]byte(line), data...), info.Mode().Perm())
+}
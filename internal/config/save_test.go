@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveOverridesKeepsComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	original := `# Team settings
+root_path: ~/src # where the code lives
+
+# Per-repository tuning
+overrides:
+  # The billing service is critical
+  - repo: billing
+    strictness: high # agreed with the team
+    paths: ["src/**"]
+  # Generated code only
+  - repo: protos
+    skip: true
+`
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	overrides := []RepoOverride{
+		{Repo: "api", Strictness: "low"},
+		{Repo: "billing", Strictness: "high", Paths: []string{"src/**", "!*_gen.go"}},
+	}
+	if err := SaveOverrides(path, overrides); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := string(data)
+
+	for _, want := range []string{
+		"# Team settings",
+		"# where the code lives",
+		"# Per-repository tuning",
+		"# The billing service is critical",
+		"strictness: high # agreed with the team",
+		"repo: api",
+		"!*_gen.go",
+	} {
+		if !strings.Contains(saved, want) {
+			t.Errorf("saved config lacks %q:\n%s", want, saved)
+		}
+	}
+	for _, gone := range []string{"protos", "# Generated code only"} {
+		if strings.Contains(saved, gone) {
+			t.Errorf("saved config still has %q:\n%s", gone, saved)
+		}
+	}
+}
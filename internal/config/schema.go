@@ -0,0 +1,135 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaFileName is where `review config schema` writes the schema of the
+// config file, next to it
+const SchemaFileName = "cra.schema.json"
+
+// RepoSchemaFileName is the schema of .cra.yaml
+const RepoSchemaFileName = "cra-repo.schema.json"
+
+// schemaEnums lists the accepted values of fields by YAML path; [] stands
+// for the items of a list and * for the values of a map
+var schemaEnums = map[string][]string{
+	"review.strictness":      StrictnessLevels,
+	"review.focus[]":         FocusAreas,
+	"review.provider":        append(append([]string(nil), SupportedProviders...), ProviderNone),
+	"scanner.repo_names":     RepoNameStyles,
+	"scanner.in_progress":    InProgressModes,
+	"reports.backend":        ReportBackends,
+	"email.routes[].view":    ReportViews,
+	"overrides[].strictness": StrictnessLevels,
+	"overrides[].risk":       RiskProfiles,
+	"alerts[].channels[]":    AlertChannels,
+	"strictness":             StrictnessLevels, // .cra.yaml
+	"risk":                   RiskProfiles,     // .cra.yaml
+}
+
+// schemaExamples suggests values of fields that are matched ignoring
+// case, which an enum would reject
+var schemaExamples = map[string][]string{
+	"review.min_severity": {"High", "Medium", "Low"},
+	"alerts[].severity":   {"High", "Medium", "Low"},
+	"log.level":           {"debug", "info", "warn", "error"},
+	"log.format":          {"text", "json"},
+}
+
+// schemaShorthands lists the fields that also accept a string in place
+// of their usual value, e.g. a cron expression for a schedule
+var schemaShorthands = map[string]bool{
+	"schedule":   true,
+	"schedule[]": true,
+}
+
+// Schema returns a JSON Schema (draft-07) of the config file, for editors
+// such as yaml-language-server to complete and validate it
+func Schema() ([]byte, error) {
+	return encodeSchema(reflect.TypeOf(Config{}), "Code Review Agent configuration")
+}
+
+// RepoFileSchema returns a JSON Schema of .cra.yaml
+func RepoFileSchema() ([]byte, error) {
+	return encodeSchema(reflect.TypeOf(RepoFile{}), "Code Review Agent repository settings (.cra.yaml)")
+}
+
+func encodeSchema(t reflect.Type, title string) ([]byte, error) {
+	schema := typeSchema(t, "")
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = title
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// typeSchema describes values of t found at the YAML path
+func typeSchema(t reflect.Type, path string) map[string]any {
+	s := valueSchema(t, path)
+	if schemaShorthands[path] {
+		return map[string]any{"anyOf": []any{map[string]any{"type": "string"}, s}}
+	}
+	return s
+}
+
+// valueSchema describes values of t, shorthands aside
+func valueSchema(t reflect.Type, path string) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(yaml.Node{}) {
+		// A profile: any part of the config
+		return map[string]any{"$ref": "#"}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]any)
+		addProperties(properties, t, path)
+		return map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), path+"[]")}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), join(path, "*"))}
+	case reflect.String:
+		s := map[string]any{"type": "string"}
+		if values := schemaEnums[path]; len(values) > 0 {
+			s["enum"] = values
+		} else if values := schemaExamples[path]; len(values) > 0 {
+			s["examples"] = values
+		}
+		return s
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float64:
+		return map[string]any{"type": "number"}
+	}
+	return map[string]any{}
+}
+
+// addProperties adds the fields of struct t, inlined ones included
+func addProperties(properties map[string]any, t reflect.Type, path string) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, opts, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		if name == "-" || !sf.IsExported() {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			addProperties(properties, sf.Type, path)
+			continue
+		}
+		properties[name] = typeSchema(sf.Type, join(path, name))
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VaultTag marks an encrypted config value, e.g.
+//
+//	smtp_password: !vault 3q2+7w...
+const VaultTag = "!vault"
+
+// VaultKeyEnv holds a base64 vault key, overriding the key file
+const VaultKeyEnv = "CRA_VAULT_KEY"
+
+// VaultPassphraseEnv holds the passphrase of values encrypted with
+// EncryptPassphrase, for machines where a key file is one more thing to
+// copy around
+const VaultPassphraseEnv = "CRA_VAULT_PASSPHRASE"
+
+// passphrasePrefix marks a value encrypted with a passphrase, followed by
+// the base64 salt and sealed value separated by a colon; plain vault
+// values are base64, which has no colons
+const passphrasePrefix = "pbkdf2:"
+
+// passphraseIterations is the PBKDF2-SHA256 work factor deriving a key
+// from a passphrase, as OWASP recommends
+const passphraseIterations = 600_000
+
+// VaultKeyPath returns the default key file, ~/.config/cra/vault.key
+func VaultKeyPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".config", "cra", "vault.key")
+}
+
+// LoadVaultKey returns the AES-256 key from $CRA_VAULT_KEY or the key file
+func LoadVaultKey() ([]byte, error) {
+	encoded := os.Getenv(VaultKeyEnv)
+	source := "$" + VaultKeyEnv
+	if encoded == "" {
+		data, err := os.ReadFile(VaultKeyPath())
+		if err != nil {
+			return nil, fmt.Errorf("reading vault key (set %s or run `review config encrypt` to create %s): %w",
+				VaultKeyEnv, VaultKeyPath(), err)
+		}
+		encoded = string(data)
+		source = VaultKeyPath()
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("vault key in %s is not a base64 32-byte key", source)
+	}
+	return key, nil
+}
+
+// GenerateVaultKey creates a random key file at path, readable only by the
+// owner. It fails if the file already exists.
+func GenerateVaultKey(path string) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("creating key directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("creating vault key: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(base64.StdEncoding.EncodeToString(key) + "\n"); err != nil {
+		return nil, fmt.Errorf("writing vault key: %w", err)
+	}
+	return key, nil
+}
+
+// Encrypt seals plaintext with AES-GCM and returns it base64 encoded
+func Encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt
+func Decrypt(key []byte, value string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return "", fmt.Errorf("not valid base64: %w", err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("decryption failed, wrong key?")
+	}
+	return string(plaintext), nil
+}
+
+// EncryptPassphrase seals plaintext with a key derived from passphrase and
+// a random salt, returning a value decrypted with $CRA_VAULT_PASSPHRASE
+func EncryptPassphrase(passphrase, plaintext string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := passphraseKey(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := Encrypt(key, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return passphrasePrefix + base64.StdEncoding.EncodeToString(salt) + ":" + sealed, nil
+}
+
+// decryptPassphrase opens a value produced by EncryptPassphrase
+func decryptPassphrase(passphrase, value string) (string, error) {
+	encodedSalt, sealed, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(value), passphrasePrefix), ":")
+	salt, err := base64.StdEncoding.DecodeString(encodedSalt)
+	if !ok || err != nil {
+		return "", fmt.Errorf("malformed passphrase-encrypted value")
+	}
+	key, err := passphraseKey(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := Decrypt(key, sealed)
+	if err != nil {
+		return "", fmt.Errorf("decryption failed, wrong passphrase?")
+	}
+	return plaintext, nil
+}
+
+func passphraseKey(passphrase string, salt []byte) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("empty vault passphrase")
+	}
+	return pbkdf2.Key(sha256.New, passphrase, salt, passphraseIterations, 32)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptVault replaces every !vault scalar under node with its plaintext.
+// The key is only loaded when a value encrypted with it is present, and
+// the passphrase when one encrypted with a passphrase is.
+func decryptVault(node *yaml.Node) error {
+	var key []byte
+	open := func(value string) (string, error) {
+		if strings.HasPrefix(strings.TrimSpace(value), passphrasePrefix) {
+			passphrase := os.Getenv(VaultPassphraseEnv)
+			if passphrase == "" {
+				return "", fmt.Errorf("encrypted with a passphrase: set %s", VaultPassphraseEnv)
+			}
+			return decryptPassphrase(passphrase, value)
+		}
+		if key == nil {
+			var err error
+			if key, err = LoadVaultKey(); err != nil {
+				return "", err
+			}
+		}
+		return Decrypt(key, value)
+	}
+
+	var walk func(n *yaml.Node, path string) error
+	walk = func(n *yaml.Node, path string) error {
+		if n.Kind == yaml.ScalarNode && n.Tag == VaultTag {
+			plaintext, err := open(n.Value)
+			if err != nil {
+				return fmt.Errorf("decrypting %s (line %d): %w", path, n.Line, err)
+			}
+			n.Tag = "!!str"
+			n.Value = plaintext
+			return nil
+		}
+
+		for i, child := range n.Content {
+			childPath := path
+			if n.Kind == yaml.MappingNode && i%2 == 1 {
+				childPath = strings.TrimPrefix(path+"."+n.Content[i-1].Value, ".")
+			}
+			if err := walk(child, childPath); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(node, "")
+}
+
+// stripVault retags !vault scalars as plain strings without decrypting them
+func stripVault(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == VaultTag {
+		node.Tag = "!!str"
+	}
+	for _, child := range node.Content {
+		stripVault(child)
+	}
+}
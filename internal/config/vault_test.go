@@ -0,0 +1,91 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	value, err := Encrypt(key, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(value, "s3cret") {
+		t.Fatal("ciphertext holds the plaintext")
+	}
+	got, err := Decrypt(key, value)
+	if err != nil || got != "s3cret" {
+		t.Fatalf("Decrypt = %q, %v; want s3cret", got, err)
+	}
+
+	if _, err := Decrypt(bytes.Repeat([]byte{8}, 32), value); err == nil {
+		t.Error("decrypting with another key succeeded")
+	}
+	if _, err := Decrypt(key, "not base64!"); err == nil {
+		t.Error("decrypting garbage succeeded")
+	}
+	if again, _ := Encrypt(key, "s3cret"); again == value {
+		t.Error("encrypting twice gave the same value, want a random nonce")
+	}
+}
+
+func TestEncryptPassphrase(t *testing.T) {
+	value, err := EncryptPassphrase("correct horse", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(value, passphrasePrefix) {
+		t.Errorf("value %q lacks the %s prefix", value, passphrasePrefix)
+	}
+	got, err := decryptPassphrase("correct horse", value)
+	if err != nil || got != "s3cret" {
+		t.Fatalf("decryptPassphrase = %q, %v; want s3cret", got, err)
+	}
+	if _, err := decryptPassphrase("wrong", value); err == nil {
+		t.Error("decrypting with the wrong passphrase succeeded")
+	}
+}
+
+func TestDecryptVault(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	t.Setenv(VaultKeyEnv, base64.StdEncoding.EncodeToString(key))
+	t.Setenv(VaultPassphraseEnv, "correct horse")
+
+	withKey, err := Encrypt(key, "key secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	withPassphrase, err := EncryptPassphrase("correct horse", "passphrase secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := "email:\n  smtp_password: !vault " + withKey + "\n  smtp_user: !vault " + withPassphrase + "\n  smtp_host: mail\n"
+
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+		t.Fatal(err)
+	}
+	if err := decryptVault(&root); err != nil {
+		t.Fatal(err)
+	}
+	var cfg Config
+	if err := root.Decode(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Email.SMTPPassword != "key secret" || cfg.Email.SMTPUser != "passphrase secret" || cfg.Email.SMTPHost != "mail" {
+		t.Errorf("decrypted email = %+v", cfg.Email)
+	}
+
+	t.Setenv(VaultPassphraseEnv, "")
+	if err := yaml.Unmarshal([]byte(doc), &root); err != nil {
+		t.Fatal(err)
+	}
+	if err := decryptVault(&root); err == nil || !strings.Contains(err.Error(), "email.smtp_user") {
+		t.Errorf("without the passphrase: got %v, want an error naming email.smtp_user", err)
+	}
+}
@@ -0,0 +1,203 @@
+// Package cron parses standard five-field cron expressions, such as
+// "0 7 * * 1-5", and finds the times they match.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expression is a parsed cron expression: the minutes, hours, days of the
+// month, months and weekdays it matches, one bit per value
+type Expression struct {
+	source string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	anyDom bool // Day of month is *, so only the weekday restricts days
+	anyDow bool // Weekday is *, so only the day of month restricts days
+}
+
+// field describes the accepted values of one field of an expression
+type field struct {
+	name     string
+	min, max int
+	names    []string // Names of the values from min, e.g. jan for 1
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "weekday", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// macros are the shorthands accepted in place of the five fields
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression: minute, hour, day of month, month and
+// weekday, each *, a value, a range (1-5), a list (1,3,5) or any of these
+// with a step (*/15, 8-18/2). Months and weekdays also take their first
+// three letters, and 7 is Sunday like 0. @daily, @weekly and the other
+// usual macros stand for their expressions.
+func Parse(expr string) (*Expression, error) {
+	source := strings.TrimSpace(expr)
+	if macro, ok := macros[strings.ToLower(source)]; ok {
+		expr = macro
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron expression %q has %d fields, want 5: minute hour day-of-month month weekday", source, len(parts))
+	}
+
+	e := &Expression{source: source}
+	bits := []*uint64{&e.minute, &e.hour, &e.dom, &e.month, &e.dow}
+	for i, part := range parts {
+		b, err := fields[i].parse(strings.ToLower(part))
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", source, err)
+		}
+		*bits[i] = b
+	}
+	// Sunday is both 0 and 7
+	if e.dow&(1<<7) != 0 {
+		e.dow |= 1
+	}
+	e.anyDom = strings.HasPrefix(parts[2], "*")
+	e.anyDow = strings.HasPrefix(parts[4], "*")
+	return e, nil
+}
+
+// String returns the expression as written
+func (e *Expression) String() string {
+	return e.source
+}
+
+// parse returns the bits of the values a field's comma-separated list
+// matches
+func (f field) parse(list string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(list, ",") {
+		spec, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepText, f.name)
+			}
+			step = n
+		}
+
+		var lo, hi int
+		switch lowText, highText, isRange := strings.Cut(spec, "-"); {
+		case spec == "*":
+			lo, hi = f.min, f.max
+		case isRange:
+			var err error
+			if lo, err = f.value(lowText); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(highText); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q in %s", spec, f.name)
+			}
+		default:
+			var err error
+			if lo, err = f.value(spec); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if hasStep {
+				// 5/15 means from 5 to the end, every 15
+				hi = f.max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value parses one value of a field, a number or a name
+func (f field) value(text string) (int, error) {
+	for i, name := range f.names {
+		if text == name {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", f.name, text)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("%s %d out of range %d-%d", f.name, n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// Match reports whether the expression matches the minute of t, in t's
+// location
+func (e *Expression) Match(t time.Time) bool {
+	return e.minute&(1<<t.Minute()) != 0 &&
+		e.hour&(1<<t.Hour()) != 0 &&
+		e.month&(1<<int(t.Month())) != 0 &&
+		e.matchDay(t)
+}
+
+// matchDay reports whether the day of t matches. As in cron, when both the
+// day of month and the weekday are restricted, either one matching is
+// enough.
+func (e *Expression) matchDay(t time.Time) bool {
+	dom := e.dom&(1<<t.Day()) != 0
+	dow := e.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case e.anyDom && e.anyDow:
+		return true
+	case e.anyDom:
+		return dow
+	case e.anyDow:
+		return dom
+	}
+	return dom || dow
+}
+
+// Next returns the first time after t the expression matches, in t's
+// location, or the zero time when it matches nothing within five years
+// (such as "0 0 30 2 *")
+func (e *Expression) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(5, 0, 0)
+	for t.Before(end) {
+		if e.month&(1<<int(t.Month())) == 0 || !e.matchDay(t) {
+			// Next day at midnight
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if e.hour&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if e.minute&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
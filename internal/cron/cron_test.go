@@ -0,0 +1,66 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"0 7 * *", "has 4 fields, want 5"},
+		{"60 * * * *", "minute"},
+		{"0 24 * * *", "hour"},
+		{"0 0 0 * *", "day of month"},
+		{"0 0 * 13 *", "month"},
+		{"0 0 * * 8", "weekday"},
+		{"0 0 * * funday", "weekday"},
+		{"@sometimes", ""},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		if err == nil {
+			t.Errorf("Parse(%q): want an error", tt.expr)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) = %v, want it to mention %q", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// A Friday
+	from := time.Date(2024, 3, 15, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 7 * * 1-5", time.Date(2024, 3, 18, 7, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 3, 15, 14, 45, 0, 0, time.UTC)},
+		{"30 14 * * *", time.Date(2024, 3, 16, 14, 30, 0, 0, time.UTC)},
+		{"0 9 1 * *", time.Date(2024, 4, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * sun", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Day of month and weekday both restricted: either matches
+		{"0 0 20 * fri", time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 3, 15, 15, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		e, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+		if got := e.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q.Next(%v) = %v, want %v", tt.expr, from, got, tt.want)
+		}
+	}
+}
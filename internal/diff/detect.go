@@ -0,0 +1,129 @@
+package diff
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// fileNames maps files named by convention rather than extension, in
+// lowercase, to the extension of their language. A suffix after a dot
+// doesn't change the type: Dockerfile.prod is a Dockerfile.
+var fileNames = map[string]string{
+	"dockerfile":     ".dockerfile",
+	"containerfile":  ".dockerfile",
+	"makefile":       ".mk",
+	"gnumakefile":    ".mk",
+	"cmakelists.txt": ".cmake",
+	"jenkinsfile":    ".groovy",
+	"rakefile":       ".rb",
+	"gemfile":        ".rb",
+	"vagrantfile":    ".rb",
+	"podfile":        ".rb",
+	"brewfile":       ".rb",
+	"tiltfile":       ".star",
+}
+
+// interpreters maps the interpreter of a shebang, without its version, to
+// the extension of its language
+var interpreters = map[string]string{
+	"sh":      ".sh",
+	"bash":    ".sh",
+	"dash":    ".sh",
+	"ksh":     ".sh",
+	"zsh":     ".sh",
+	"python":  ".py",
+	"pypy":    ".py",
+	"node":    ".js",
+	"nodejs":  ".js",
+	"deno":    ".ts",
+	"bun":     ".ts",
+	"ts-node": ".ts",
+	"tsx":     ".ts",
+	"ruby":    ".rb",
+	"perl":    ".pl",
+	"php":     ".php",
+	"lua":     ".lua",
+	"rscript": ".r",
+	"pwsh":    ".ps1",
+	"fish":    ".fish",
+}
+
+// interpreterVersion is the version suffix of an interpreter name, as in
+// python3.12 or ruby2.7
+var interpreterVersion = regexp.MustCompile(`[0-9.]+$`)
+
+// detectByName returns the extension of the language of a file named by
+// convention, e.g. Dockerfile.prod or Makefile, with a description of the
+// match
+func detectByName(file string) (ext, how string) {
+	name := strings.ToLower(path.Base(file))
+	if ext, ok := fileNames[name]; ok {
+		return ext, "file name " + path.Base(file)
+	}
+	if prefix, _, ok := strings.Cut(name, "."); ok {
+		if ext, ok := fileNames[prefix]; ok {
+			return ext, "file name " + path.Base(file)
+		}
+	}
+	return "", ""
+}
+
+// detectByContent returns the extension of the language of a script from
+// its first line, a shebang or a <?php tag, with a description of the
+// match
+func detectByContent(firstLine string) (ext, how string) {
+	firstLine = strings.TrimSpace(firstLine)
+	if strings.HasPrefix(firstLine, "<?php") {
+		return ".php", "<?php tag"
+	}
+	command, ok := strings.CutPrefix(firstLine, "#!")
+	if !ok {
+		return "", ""
+	}
+
+	// #!/usr/bin/env -S VAR=1 python3 -u names the interpreter after env's
+	// options and variables
+	fields := strings.Fields(command)
+	for len(fields) > 0 && path.Base(fields[0]) == "env" {
+		fields = fields[1:]
+		for len(fields) > 0 && (strings.HasPrefix(fields[0], "-") || strings.Contains(fields[0], "=")) {
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return "", ""
+	}
+	interpreter := strings.ToLower(path.Base(fields[0]))
+	if ext, ok := interpreters[interpreterVersion.ReplaceAllString(interpreter, "")]; ok {
+		return ext, "shebang " + interpreter
+	}
+	return "", ""
+}
+
+// diffFirstLine returns the first line of the file after the change when
+// a hunk of diff starts there, as for new files, and false otherwise
+func diffFirstLine(diff string) (string, bool) {
+	inHunk := false
+	for line := range strings.SplitSeq(diff, "\n") {
+		if m := newHunkStart.FindStringSubmatch(line); m != nil {
+			inHunk = m[1] == "1"
+			continue
+		}
+		if !inHunk {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+"), strings.HasPrefix(line, " "):
+			return line[1:], true
+		case strings.HasPrefix(line, "-"), strings.HasPrefix(line, "\\"):
+			continue
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// newHunkStart matches a hunk header, capturing where its lines start in
+// the file after the change
+var newHunkStart = regexp.MustCompile(`^@@@? (?:-[0-9,]+ )+\+([0-9]+)`)
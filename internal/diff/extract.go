@@ -4,60 +4,336 @@ import (
 	"bufio"
 	"bytes"
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/juparave/codereviewer/internal/domain"
-	"github.com/juparave/codereviewer/internal/scanner"
+	"github.com/juparave/codereviewer/internal/logging"
+	"github.com/juparave/codereviewer/internal/util"
 )
 
+// DefaultExclude lists the gitignore-style patterns of paths never
+// reviewed unless re-included with "!": dependencies, generated code,
+// mocks and test fixtures
+var DefaultExclude = []string{
+	"vendor/",
+	"node_modules/",
+	"*.gen.*",
+	"*.generated.*",
+	"*_generated*",
+	"*.pb.go",
+	"*.mock.go",
+	"*_mock.go",
+	"mocks/",
+	"testdata/",
+}
+
 // Extractor extracts and filters diffs from commits
 type Extractor struct {
-	logger *log.Logger
+	languages map[string]string
+	// repoLanguages adds extensions for single repositories, keyed by path
+	repoLanguages map[string]map[string]string
+	// excludes holds DefaultExclude and the configured patterns; ignore
+	// compiles them, repoIgnore adds each repository's own
+	excludes   []string
+	ignore     *util.Ignore
+	repoIgnore map[string]*util.Ignore
+	// fullContext holds the paths of repositories whose diffs carry the
+	// whole file; see SetRepoFullContext
+	fullContext map[string]bool
+	// maxLines and maxBytes truncate each file's diff; see SetLimits
+	maxLines int
+	maxBytes int
+	logger   *slog.Logger
+	excluded []domain.Exclusion // Files left out since the last TakeExclusions
+}
+
+// NewExtractor creates a new Extractor. languages adds file extensions to
+// the built-in domain.SupportedExtensions, see Languages.
+func NewExtractor(languages map[string]string, logger *slog.Logger) *Extractor {
+	ignore, _ := util.CompileIgnore(DefaultExclude)
+	return &Extractor{
+		languages: Languages(languages),
+		excludes:  DefaultExclude,
+		ignore:    ignore,
+		maxLines:  domain.MaxDiffLines,
+		logger:    logging.OrDefault(logger),
+	}
+}
+
+// SetLimits truncates each file's diff to maxLines lines, domain.MaxDiffLines
+// when 0, and to maxBytes bytes unless 0
+func (e *Extractor) SetLimits(maxLines, maxBytes int) {
+	if maxLines <= 0 {
+		maxLines = domain.MaxDiffLines
+	}
+	e.maxLines, e.maxBytes = maxLines, max(maxBytes, 0)
+}
+
+// MaxLines returns the number of lines of a file's diff sent for review
+func (e *Extractor) MaxLines() int {
+	return e.maxLines
 }
 
-// NewExtractor creates a new Extractor
-func NewExtractor(logger *log.Logger) *Extractor {
-	return &Extractor{logger: logger}
+// SetExclude adds gitignore-style patterns of paths left out of every
+// repository to DefaultExclude; "!" re-includes a default
+func (e *Extractor) SetExclude(patterns []string) error {
+	exclude := append(slices.Clip(DefaultExclude), patterns...)
+	ignore, err := util.CompileIgnore(exclude)
+	if err != nil {
+		return err
+	}
+	e.excludes, e.ignore = exclude, ignore
+	return nil
 }
 
-// Extract extracts diffs from a commit, filtering to supported file types
+// SetRepoExclude adds patterns of paths left out of the repository at
+// repoPath only, after the defaults and SetExclude's
+func (e *Extractor) SetRepoExclude(repoPath string, patterns []string) error {
+	ignore, err := util.CompileIgnore(append(slices.Clip(e.excludes), patterns...))
+	if err != nil {
+		return err
+	}
+	if e.repoIgnore == nil {
+		e.repoIgnore = make(map[string]*util.Ignore)
+	}
+	e.repoIgnore[repoPath] = ignore
+	return nil
+}
+
+// Languages returns the reviewed file extensions and their language
+// labels: the built-in domain.SupportedExtensions updated with languages.
+// An empty label stops an extension from being reviewed.
+func Languages(languages map[string]string) map[string]string {
+	merged := make(map[string]string, len(domain.SupportedExtensions)+len(languages))
+	for ext, lang := range domain.SupportedExtensions {
+		merged[ext] = lang
+	}
+	for ext, lang := range normalizeLanguages(languages) {
+		if lang == "" {
+			delete(merged, ext)
+		} else {
+			merged[ext] = lang
+		}
+	}
+	return merged
+}
+
+// NormalizeExtension lowercases ext and adds the leading dot if missing,
+// so "PY", "py" and ".py" name the same file type
+func NormalizeExtension(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+func normalizeLanguages(languages map[string]string) map[string]string {
+	normalized := make(map[string]string, len(languages))
+	for ext, lang := range languages {
+		normalized[NormalizeExtension(ext)] = strings.TrimSpace(lang)
+	}
+	return normalized
+}
+
+// SetRepoLanguages adds file extensions reviewed in the repository at
+// repoPath only, e.g. from its .cra.yaml. An empty label stops an
+// extension from being reviewed there.
+func (e *Extractor) SetRepoLanguages(repoPath string, languages map[string]string) {
+	if e.repoLanguages == nil {
+		e.repoLanguages = make(map[string]map[string]string)
+	}
+	e.repoLanguages[repoPath] = normalizeLanguages(languages)
+}
+
+// SetRepoFullContext makes the diffs of the repository at repoPath carry
+// the whole file after the change in Diff.Context, up to
+// domain.MaxContextLines lines and the byte limit
+func (e *Extractor) SetRepoFullContext(repoPath string) {
+	if e.fullContext == nil {
+		e.fullContext = make(map[string]bool)
+	}
+	e.fullContext[repoPath] = true
+}
+
+// addContext loads the full-file context of diffs in repositories set with
+// SetRepoFullContext, reading files at rev, the index when empty
+func (e *Extractor) addContext(ctx context.Context, diffs []domain.Diff, rev string) {
+	for i := range diffs {
+		d := &diffs[i]
+		if !e.fullContext[d.RepoPath] {
+			continue
+		}
+		out, err := runGit(ctx, d.RepoPath, "show", rev+":"+d.FilePath)
+		if err != nil {
+			e.logger.Warn("reading file for context failed", "repo", d.RepoName, "file", d.FilePath, "err", err)
+			continue
+		}
+		content := string(out)
+		lines := strings.SplitAfter(content, "\n")
+		if len(lines) > domain.MaxContextLines {
+			content = strings.Join(lines[:domain.MaxContextLines], "") + "... [truncated]"
+		}
+		if e.maxBytes > 0 && len(content) > e.maxBytes {
+			content = content[:e.maxBytes] + "\n... [truncated]"
+		}
+		d.Context = content
+	}
+}
+
+// language returns the language label of ext in the repository at
+// repoPath, false when the file type isn't reviewed
+func (e *Extractor) language(repoPath, ext string) (string, bool) {
+	ext = strings.ToLower(ext)
+	if lang, ok := e.repoLanguages[repoPath][ext]; ok {
+		return lang, lang != ""
+	}
+	lang, ok := e.languages[ext]
+	return lang, ok
+}
+
+// fileHead returns a reader of the first line of files at rev in the
+// repository at repoPath, the index when rev is empty
+func (e *Extractor) fileHead(ctx context.Context, repoPath, rev string) func(file string) string {
+	return func(file string) string {
+		out, err := runGit(ctx, repoPath, "show", rev+":"+file)
+		if err != nil {
+			return ""
+		}
+		first, _, _ := bytes.Cut(out, []byte("\n"))
+		return string(first)
+	}
+}
+
+// Extract extracts diffs from a commit, filtering to supported file types.
+// A merge commit yields combined diffs of only the files whose merge result
+// differs from every parent, holding only those hunks.
 func (e *Extractor) Extract(ctx context.Context, commit domain.Commit) ([]domain.Diff, error) {
 	// Get changed files
-	files, err := e.getChangedFiles(ctx, commit.RepoPath, commit.Hash)
+	files, err := e.getChangedFiles(ctx, commit.RepoPath, commit.Hash, commit.Merge)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := e.buildDiffs(files, commit, func(file string) (string, error) {
+		return e.getFileDiff(ctx, commit.RepoPath, commit.Hash, file, commit.Merge)
+	}, e.fileHead(ctx, commit.RepoPath, commit.Hash))
+	e.addContext(ctx, diffs, commit.Hash)
+	return diffs, nil
+}
+
+// ExtractStaged extracts diffs of the changes staged in the index of repo,
+// filtering to supported file types. The diffs carry no commit hash.
+func (e *Extractor) ExtractStaged(ctx context.Context, repo domain.Repository) ([]domain.Diff, error) {
+	output, err := runGit(ctx, repo.Path, "diff", "--cached", "--name-status")
+	if err != nil {
+		return nil, err
+	}
+	files, err := parseNameStatus(output)
+	if err != nil {
+		return nil, err
+	}
+
+	commit := domain.Commit{RepoPath: repo.Path, RepoName: repo.Name}
+	diffs := e.buildDiffs(files, commit, func(file string) (string, error) {
+		out, err := runGit(ctx, repo.Path, "diff", "--cached", "--no-color", "--", file)
+		return string(out), err
+	}, e.fileHead(ctx, repo.Path, ""))
+	e.addContext(ctx, diffs, "")
+	return diffs, nil
+}
+
+// ExtractTree returns the whole content of the supported files at rev as
+// diffs against the empty tree, for reviewing a codebase as it stands
+func (e *Extractor) ExtractTree(ctx context.Context, repo domain.Repository, rev string) ([]domain.Diff, error) {
+	// The empty tree's hash depends on the repository's object format
+	hashCmd := exec.CommandContext(ctx, "git", "hash-object", "-t", "tree", "--stdin")
+	hashCmd.Dir = repo.Path
+	out, err := hashCmd.Output()
 	if err != nil {
 		return nil, err
 	}
+	empty := strings.TrimSpace(string(out))
 
+	output, err := runGit(ctx, repo.Path, "diff", "--name-status", empty, rev)
+	if err != nil {
+		return nil, err
+	}
+	files, err := parseNameStatus(output)
+	if err != nil {
+		return nil, err
+	}
+
+	commit := domain.Commit{RepoPath: repo.Path, RepoName: repo.Name}
+	return e.buildDiffs(files, commit, func(file string) (string, error) {
+		out, err := runGit(ctx, repo.Path, "diff", "--no-color", empty, rev, "--", file)
+		return string(out), err
+	}, nil), nil
+}
+
+// buildDiffs filters files to supported, non-excluded paths and loads their
+// diffs with getDiff, truncating long ones. Files without a reviewed
+// extension are typed by their name or, lacking an extension, their first
+// line, read with head when their diff doesn't show it; head may be nil.
+func (e *Extractor) buildDiffs(files []string, commit domain.Commit, getDiff func(file string) (string, error), head func(file string) string) []domain.Diff {
 	var diffs []domain.Diff
 	for _, file := range files {
 		// Check if file extension is supported
 		ext := filepath.Ext(file)
-		lang, ok := domain.SupportedExtensions[ext]
+		lang, ok := e.language(commit.RepoPath, ext)
+		var content string
+		var loaded bool
 		if !ok {
-			continue
+			detected, how := detectByName(file)
+			if detected == "" && ext == "" && e.excludePattern(commit.RepoPath, file) == "" {
+				// A script such as deploy or bin/setup
+				var err error
+				if content, err = getDiff(file); err == nil {
+					loaded = true
+				}
+				first, found := diffFirstLine(content)
+				if !found && head != nil {
+					first = head(file)
+				}
+				detected, how = detectByContent(first)
+			}
+			if detected == "" {
+				e.exclude(commit, file, domain.ExcludedExtension, extensionDetail(ext))
+				continue
+			}
+			if lang, ok = e.language(commit.RepoPath, detected); !ok {
+				e.exclude(commit, file, domain.ExcludedExtension, how+": "+extensionDetail(detected))
+				continue
+			}
+			e.logger.Debug("detected file language", "repo", commit.RepoName, "file", file, "language", lang, "by", how)
 		}
 
 		// Skip excluded paths
-		if e.shouldExclude(file) {
+		if pattern := e.excludePattern(commit.RepoPath, file); pattern != "" {
+			e.exclude(commit, file, domain.ExcludedPattern, pattern)
 			continue
 		}
 
 		// Get diff for this file
-		content, err := e.getFileDiff(ctx, commit.RepoPath, commit.Hash, file)
-		if err != nil {
-			e.logger.Printf("Warning: failed to get diff for %s: %v", file, err)
-			continue
+		if !loaded {
+			var err error
+			if content, err = getDiff(file); err != nil {
+				e.logger.Warn("reading file diff failed", "repo", commit.RepoName, "commit", commit.Hash, "file", file, "err", err)
+				continue
+			}
 		}
 
 		// Count lines and truncate if needed
-		lines := strings.Split(content, "\n")
-		lineCount := len(lines)
-		if lineCount > domain.MaxDiffLines {
-			content = strings.Join(lines[:domain.MaxDiffLines], "\n")
-			content += "\n... [truncated]"
+		lineCount := strings.Count(content, "\n") + 1
+		if truncated, detail := e.truncate(content, lineCount); detail != "" {
+			content = truncated
+			e.exclude(commit, file, domain.ExcludedSize, detail)
 		}
 
 		diffs = append(diffs, domain.Diff{
@@ -66,51 +342,166 @@ func (e *Extractor) Extract(ctx context.Context, commit domain.Commit) ([]domain
 			LineCount:  lineCount,
 			CommitHash: commit.Hash,
 			RepoPath:   commit.RepoPath,
-			RepoName:   scanner.GetRepoName(commit.RepoPath),
+			RepoName:   commit.RepoName,
 			Language:   lang,
+			Merge:      commit.Merge,
 		})
 	}
 
-	return diffs, nil
+	return diffs
 }
 
-// shouldExclude checks if a file path should be excluded
-func (e *Extractor) shouldExclude(path string) bool {
-	excludePaths := []string{
-		"vendor/",
-		"node_modules/",
-		".gen.",
-		".generated.",
-		"_generated",
-		".pb.go",
-		".mock.go",
-		"_mock.go",
-		"mocks/",
-		"testdata/",
+// truncate cuts content to the line and byte limits, on a line boundary,
+// and appends a summary of the lines cut (see summarize), taking up to a
+// quarter of the same limits. detail describes the cut, empty when content
+// fits.
+func (e *Extractor) truncate(content string, lineCount int) (truncated, detail string) {
+	if lineCount <= e.maxLines && (e.maxBytes <= 0 || len(content) <= e.maxBytes) {
+		return content, ""
 	}
 
-	for _, exclude := range excludePaths {
-		if strings.Contains(path, exclude) {
-			return true
+	summaryLines := max(e.maxLines/4, 8)
+	kept := content
+	if keepLines := max(e.maxLines-summaryLines-1, 1); lineCount > keepLines {
+		lines := strings.SplitN(content, "\n", keepLines+1)
+		kept = strings.Join(lines[:keepLines], "\n")
+	}
+	if keepBytes := e.maxBytes - e.maxBytes/4; e.maxBytes > 0 && len(kept) > keepBytes {
+		kept = kept[:keepBytes]
+		if i := strings.LastIndexByte(kept, '\n'); i > 0 {
+			kept = kept[:i]
 		}
 	}
+	keptLines := strings.Count(kept, "\n") + 1
+
+	summary := summarize(content, keptLines, summaryLines)
+	if e.maxBytes > 0 && len(summary) > e.maxBytes/4 {
+		summary = summary[:e.maxBytes/4]
+		if i := strings.LastIndexByte(summary, '\n'); i > 0 {
+			summary = summary[:i]
+		}
+	}
+	return kept + "\n... [truncated]\n" + summary, fmt.Sprintf("truncated to %d of %d lines, the rest summarized", keptLines, lineCount)
+}
 
+// FitBudget returns the diffs that fit a budget of tokens, as estimated by
+// cost for a set of diffs, in their original order. When they don't all
+// fit, files matching sensitive go first, then source before tests, then
+// the smallest diffs, so that as many files as possible are reviewed. The
+// files left out are recorded as exclusions.
+func (e *Extractor) FitBudget(diffs []domain.Diff, tokens int, sensitive *util.Patterns, cost func([]domain.Diff) int) []domain.Diff {
+	if tokens <= 0 || cost(diffs) <= tokens {
+		return diffs
+	}
+
+	order := make([]int, len(diffs))
+	for i := range order {
+		order[i] = i
+	}
+	rank := func(d domain.Diff) int {
+		switch {
+		case sensitive.Match(d.FilePath, path.Base(d.FilePath)):
+			return 0
+		case !IsTestFile(d.FilePath):
+			return 1
+		}
+		return 2
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		if ra, rb := rank(diffs[a]), rank(diffs[b]); ra != rb {
+			return ra - rb
+		}
+		return len(diffs[a].Content) - len(diffs[b].Content)
+	})
+
+	keep := make([]bool, len(diffs))
+	var kept []domain.Diff
+	for _, i := range order {
+		if cost(append(kept, diffs[i])) <= tokens {
+			keep[i] = true
+			kept = append(kept, diffs[i])
+		}
+	}
+
+	kept = kept[:0:0]
+	for i, d := range diffs {
+		if keep[i] {
+			kept = append(kept, d)
+			continue
+		}
+		commit := domain.Commit{RepoName: d.RepoName, Hash: d.CommitHash}
+		e.exclude(commit, d.FilePath, domain.ExcludedBudget, fmt.Sprintf("review.limits.token_budget: %d", tokens))
+	}
+	return kept
+}
+
+// IsTestFile reports whether path looks like a test by its name or
+// directory
+func IsTestFile(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	for _, marker := range []string{"_test.", ".test.", "_spec.", ".spec."} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		switch strings.ToLower(dir) {
+		case "test", "tests", "__tests__", "spec":
+			return true
+		}
+	}
 	return false
 }
 
-func (e *Extractor) getChangedFiles(ctx context.Context, repoPath, commitHash string) ([]string, error) {
-	cmd := exec.CommandContext(ctx, "git", "show",
-		"--format=",
-		"--name-status",
-		commitHash,
-	)
-	cmd.Dir = repoPath
+// TakeExclusions returns the files left out, in whole or in part, since
+// the last call
+func (e *Extractor) TakeExclusions() []domain.Exclusion {
+	excluded := e.excluded
+	e.excluded = nil
+	return excluded
+}
 
-	output, err := cmd.Output()
+func (e *Extractor) exclude(commit domain.Commit, file, reason, detail string) {
+	e.excluded = append(e.excluded, domain.Exclusion{Repo: commit.RepoName, Path: file, Reason: reason, Detail: detail})
+}
+
+func extensionDetail(ext string) string {
+	if ext == "" {
+		return "no extension"
+	}
+	return ext + " is not in languages"
+}
+
+// excludePattern returns the exclude pattern leaving out path in the
+// repository at repoPath, or "" when the path should be reviewed
+func (e *Extractor) excludePattern(repoPath, path string) string {
+	ignore := e.ignore
+	if repoIgnore, ok := e.repoIgnore[repoPath]; ok {
+		ignore = repoIgnore
+	}
+	if pattern, excluded := ignore.Match(path); excluded {
+		return pattern
+	}
+	return ""
+}
+
+// getChangedFiles lists the files commitHash changes; for a merge, the
+// files --cc keeps, where the result matches none of the parents
+func (e *Extractor) getChangedFiles(ctx context.Context, repoPath, commitHash string, merge bool) ([]string, error) {
+	args := []string{"show", "--format=", "--name-status"}
+	if merge {
+		args = append(args, "--cc")
+	}
+	output, err := runGit(ctx, repoPath, append(args, commitHash)...)
 	if err != nil {
 		return nil, err
 	}
 
+	return parseNameStatus(output)
+}
+
+// parseNameStatus returns the non-deleted paths from --name-status output
+func parseNameStatus(output []byte) ([]string, error) {
 	var files []string
 	s := bufio.NewScanner(bytes.NewReader(output))
 	for s.Scan() {
@@ -119,11 +510,12 @@ func (e *Extractor) getChangedFiles(ctx context.Context, repoPath, commitHash st
 			continue
 		}
 
-		// Format: "M\tfilename" or "A\tfilename" etc.
+		// Format: "M\tfilename" or "A\tfilename" etc.; merges carry one
+		// status letter per parent, e.g. "MM\tfilename"
 		parts := strings.Fields(line)
 		if len(parts) >= 2 {
 			// Skip deleted files
-			if parts[0] != "D" {
+			if strings.Trim(parts[0], "D") != "" {
 				files = append(files, parts[len(parts)-1])
 			}
 		}
@@ -132,10 +524,16 @@ func (e *Extractor) getChangedFiles(ctx context.Context, repoPath, commitHash st
 	return files, s.Err()
 }
 
-func (e *Extractor) getFileDiff(ctx context.Context, repoPath, commitHash, filePath string) (string, error) {
+func runGit(ctx context.Context, repoPath string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+	return cmd.Output()
+}
+
+func (e *Extractor) getFileDiff(ctx context.Context, repoPath, commitHash, filePath string, merge bool) (string, error) {
 	cmd := exec.CommandContext(ctx, "git", "show",
 		"--format=",
-		"--patch",
+		diffMode(merge),
 		"--no-color",
 		commitHash,
 		"--",
@@ -150,3 +548,13 @@ func (e *Extractor) getFileDiff(ctx context.Context, repoPath, commitHash, fileP
 
 	return string(output), nil
 }
+
+// diffMode returns the git show option producing a commit's patch: the
+// combined --cc diff against all parents for merges, which drops the hunks
+// taken unchanged from one side and keeps the hand-resolved ones
+func diffMode(merge bool) string {
+	if merge {
+		return "--cc"
+	}
+	return "--patch"
+}
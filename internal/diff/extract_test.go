@@ -0,0 +1,63 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/util"
+)
+
+func TestFitBudget(t *testing.T) {
+	diff := func(path string, size int) domain.Diff {
+		return domain.Diff{RepoName: "api", FilePath: path, Content: strings.Repeat("x", size)}
+	}
+	cost := func(diffs []domain.Diff) int {
+		total := 0
+		for _, d := range diffs {
+			total += len(d.Content)
+		}
+		return total
+	}
+	sensitive, err := util.CompilePatterns([]string{"auth*"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diffs := []domain.Diff{
+		diff("big.go", 60),
+		diff("small_test.go", 10),
+		diff("auth.go", 50),
+		diff("small.go", 20),
+		diff("medium.go", 30),
+	}
+
+	tests := []struct {
+		name   string
+		tokens int
+		want   string
+	}{
+		{"no budget", 0, "big.go,small_test.go,auth.go,small.go,medium.go"},
+		{"everything fits", 1000, "big.go,small_test.go,auth.go,small.go,medium.go"},
+		// auth.go first, then source by size, then tests
+		{"sensitive first", 50, "auth.go"},
+		{"then smallest source", 100, "auth.go,small.go,medium.go"},
+		{"tests last", 110, "small_test.go,auth.go,small.go,medium.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewExtractor(nil, nil)
+			kept := e.FitBudget(diffs, tt.tokens, sensitive, cost)
+			var names []string
+			for _, d := range kept {
+				names = append(names, d.FilePath)
+			}
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Errorf("kept %s, want %s", got, tt.want)
+			}
+			if excluded := e.TakeExclusions(); len(excluded) != len(diffs)-len(kept) {
+				t.Errorf("recorded %d exclusions, want %d", len(excluded), len(diffs)-len(kept))
+			}
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package diff
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"mime"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// mboxFrom is the separator line starting each mail of a mailbox: a
+// commit hash in `git format-patch` output, an address or placeholder
+// such as git@z (b4) or mboxrd@z (lore) elsewhere
+var mboxFrom = regexp.MustCompile(`^From (\S+) `)
+
+// commitHash matches the commit a format-patch separator names
+var commitHash = regexp.MustCompile(`^[0-9a-f]{7,64}$`)
+
+// mailHeader matches a mail header line
+var mailHeader = regexp.MustCompile(`^[A-Za-z0-9-]+:`)
+
+// mailStart reports whether lines[i] separates two mails of a mailbox:
+// a From line at the start or after a blank line, followed by a header
+func mailStart(lines []string, i int) bool {
+	return mboxFrom.MatchString(lines[i]) && (i == 0 || lines[i-1] == "") &&
+		i+1 < len(lines) && mailHeader.MatchString(lines[i+1])
+}
+
+// patchCommit is one commit of a patch series, or the whole patch when it
+// isn't mailbox-formatted
+type patchCommit struct {
+	commit   domain.Commit     // Hash, and author, date and subject from the mail headers
+	headers  []string          // Mail header lines, while they are read
+	id       string            // Message-Id header, naming mails without a commit hash
+	message  []string          // Commit message lines after the subject
+	files    []string          // Changed paths, in patch order
+	contents map[string]string // Diff of each path
+}
+
+// ExtractPatch extracts diffs from a unified diff, as written by git diff,
+// git format-patch (one or more commits) or diff -u, filtering to
+// supported file types. name labels the changes as their repository.
+// Deleted files and changes without hunks, such as pure renames or binary
+// files, are left out.
+func (e *Extractor) ExtractPatch(patch []byte, name string) []domain.Diff {
+	_, diffs := e.ExtractPatches(patch, name)
+	return diffs
+}
+
+// ExtractPatches is ExtractPatch returning the commits of the patch too,
+// with their author, date and message when it is a mailbox of git
+// format-patch mails. Commits whose files are all left out, e.g. by
+// extension, are included.
+func (e *Extractor) ExtractPatches(patch []byte, name string) ([]domain.Commit, []domain.Diff) {
+	var commits []domain.Commit
+	var diffs []domain.Diff
+	for _, pc := range parsePatch(string(patch)) {
+		commit := pc.commit
+		commit.RepoName = name
+		commits = append(commits, commit)
+		diffs = append(diffs, e.buildDiffs(pc.files, commit, func(file string) (string, error) {
+			return pc.contents[file], nil
+		}, nil)...)
+	}
+	return commits, diffs
+}
+
+// patchPrefix is the [PATCH v2 1/3] tag format-patch puts before a subject
+var patchPrefix = regexp.MustCompile(`^\[[^\]]*PATCH[^\]]*\]\s*`)
+
+// readHeaders sets the author, date and message of commit from the mail
+// headers and message lines read for it
+func (pc *patchCommit) readHeaders() {
+	msg, err := mail.ReadMessage(strings.NewReader(strings.Join(pc.headers, "\n") + "\n\n"))
+	pc.headers = nil
+	if err != nil {
+		return
+	}
+	dec := new(mime.WordDecoder)
+	if from, err := msg.Header.AddressList("From"); err == nil && len(from) > 0 {
+		pc.commit.Author, pc.commit.Email = from[0].Name, from[0].Address
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		pc.commit.Timestamp = date
+	}
+	pc.id = msg.Header.Get("Message-Id")
+	subject, err := dec.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+	pc.commit.Message = patchPrefix.ReplaceAllString(strings.Join(strings.Fields(subject), " "), "")
+}
+
+// finishMessage appends the commit message lines after the subject
+func (pc *patchCommit) finishMessage() {
+	if body := strings.TrimSpace(strings.Join(pc.message, "\n")); body != "" {
+		pc.commit.Message += "\n\n" + body
+	}
+	pc.message = nil
+}
+
+// hunkHeader matches a hunk's line ranges; omitted counts are 1
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+\d+(?:,(\d+))? @@`)
+
+// parsePatch splits a patch into commits and per-file diffs. Hunk lengths
+// are followed so that changed lines looking like headers stay content.
+func parsePatch(patch string) []*patchCommit {
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+
+	current := &patchCommit{contents: make(map[string]string)}
+	commits := []*patchCommit{current}
+	// Parts of a format-patch mail before its diffs
+	const (
+		inDiff = iota
+		inHeaders
+		inMessage
+	)
+	state := inDiff
+
+	var section []string // Lines of the file diff being read, nil outside one
+	flush := func() {
+		if section != nil {
+			addSection(current, section)
+		}
+		section = nil
+	}
+
+	oldLeft, newLeft := 0, 0 // Lines left in the current hunk
+	for i, line := range lines {
+		if oldLeft > 0 || newLeft > 0 {
+			section = append(section, line)
+			switch {
+			case strings.HasPrefix(line, "-"):
+				oldLeft--
+			case strings.HasPrefix(line, "+"):
+				newLeft--
+			case strings.HasPrefix(line, "\\"):
+				// "\ No newline at end of file"
+			default:
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch state {
+		case inHeaders:
+			if line != "" {
+				current.headers = append(current.headers, line)
+				continue
+			}
+			current.readHeaders()
+			state = inMessage
+			continue
+		case inMessage:
+			// The message ends at the diffstat or, without one, the diffs;
+			// a cover letter has neither and ends at the next mail
+			if line == "---" || strings.HasPrefix(line, "diff --git ") || mailStart(lines, i) {
+				current.finishMessage()
+				state = inDiff
+				if line == "---" {
+					continue
+				}
+			} else {
+				current.message = append(current.message, line)
+				continue
+			}
+		}
+
+		switch {
+		case mailStart(lines, i):
+			flush()
+			current = &patchCommit{contents: make(map[string]string)}
+			if from := mboxFrom.FindStringSubmatch(line)[1]; commitHash.MatchString(from) {
+				current.commit.Hash = from
+			}
+			commits = append(commits, current)
+			state = inHeaders
+			continue
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			section = []string{}
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ") && !gitHeader(section):
+			// A plain unified diff with no diff --git header
+			flush()
+			section = []string{}
+		case section != nil && hunkHeader.MatchString(line):
+			m := hunkHeader.FindStringSubmatch(line)
+			oldLeft, newLeft = hunkLength(m[1]), hunkLength(m[2])
+		case section != nil && !strings.HasPrefix(line, "\\") && sectionHasHunks(section):
+			// Trailing text after the last hunk, e.g. a format-patch signature
+			flush()
+		}
+		if section != nil {
+			section = append(section, line)
+		}
+	}
+	flush()
+	if state == inHeaders {
+		current.readHeaders()
+	}
+	if state != inDiff {
+		current.finishMessage()
+	}
+
+	// Leaves out the text before the first mail and cover letters
+	var nonEmpty []*patchCommit
+	for _, c := range commits {
+		if len(c.files) > 0 {
+			c.nameMail()
+			nonEmpty = append(nonEmpty, c)
+		}
+	}
+	return nonEmpty
+}
+
+// nameMail gives a mail without a commit hash in its separator, as b4 and
+// lore write them, a stable one: the SHA-1 of its Message-Id or, without
+// one, of its diffs. Mails are told apart by it, e.g. when the authors
+// setting skips some.
+func (pc *patchCommit) nameMail() {
+	if pc.commit.Hash != "" || pc.commit.Author == "" && pc.commit.Email == "" {
+		return
+	}
+	h := sha1.New()
+	if pc.id != "" {
+		h.Write([]byte(pc.id))
+	} else {
+		for _, file := range pc.files {
+			h.Write([]byte(pc.contents[file]))
+		}
+	}
+	pc.commit.Hash = hex.EncodeToString(h.Sum(nil))
+}
+
+func hunkLength(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
+
+// gitHeader reports whether section is a diff --git header still waiting
+// for its ---/+++ lines
+func gitHeader(section []string) bool {
+	return len(section) > 0 && strings.HasPrefix(section[0], "diff --git ") && !sectionHasHunks(section)
+}
+
+func sectionHasHunks(section []string) bool {
+	for _, line := range section {
+		if strings.HasPrefix(line, "@@") {
+			return true
+		}
+	}
+	return false
+}
+
+// addSection records the diff of one file in commit
+func addSection(commit *patchCommit, section []string) {
+	path, hunks := "", false
+	for _, line := range section {
+		switch {
+		case strings.HasPrefix(line, "+++ ") && path == "":
+			target := strings.TrimSpace(strings.TrimPrefix(line, "+++ "))
+			// diff -u appends a timestamp after a tab
+			target, _, _ = strings.Cut(target, "\t")
+			if target == "/dev/null" {
+				return // Deleted
+			}
+			path = strings.TrimPrefix(target, "b/")
+		case strings.HasPrefix(line, "@@"):
+			hunks = true
+		}
+	}
+	if path == "" || !hunks {
+		return
+	}
+
+	content := strings.Join(section, "\n") + "\n"
+	if _, ok := commit.contents[path]; !ok {
+		commit.files = append(commit.files, path)
+	}
+	commit.contents[path] += content
+}
@@ -0,0 +1,125 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+const seriesPatch = `From 1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b Mon Sep 17 00:00:00 2001
+From: Ana Lopez <ana@example.com>
+Date: Fri, 15 Mar 2024 10:00:00 -0600
+Subject: [PATCH 1/2] Add greeting
+
+Greets the caller by name.
+---
+ hello.go | 2 +-
+ 1 file changed, 1 insertion(+), 1 deletion(-)
+
+diff --git a/hello.go b/hello.go
+index 1111111..2222222 100644
+--- a/hello.go
++++ b/hello.go
+@@ -1,3 +1,3 @@
+ package main
+-func hello() {}
++func hello(name string) {}
+ // end
+-- 
+2.44.0
+
+From 9f8e7d6c5b4a39281706f5e4d3c2b1a09f8e7d6c Mon Sep 17 00:00:00 2001
+From: Ana Lopez <ana@example.com>
+Date: Fri, 15 Mar 2024 11:00:00 -0600
+Subject: [PATCH 2/2] Drop old file
+
+---
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package main
+diff --git a/lines.go b/lines.go
+--- a/lines.go
++++ b/lines.go
+@@ -1,2 +1,2 @@
+--- looks like a header
++++ looks like a header too
+ kept
+`
+
+func TestParsePatchSeries(t *testing.T) {
+	commits := parsePatch(seriesPatch)
+	if len(commits) != 2 {
+		t.Fatalf("got %d commits, want 2", len(commits))
+	}
+
+	first := commits[0]
+	if first.commit.Hash != "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b" {
+		t.Errorf("hash = %q", first.commit.Hash)
+	}
+	if first.commit.Author != "Ana Lopez" || first.commit.Email != "ana@example.com" {
+		t.Errorf("author = %q <%s>", first.commit.Author, first.commit.Email)
+	}
+	if first.commit.Message != "Add greeting\n\nGreets the caller by name." {
+		t.Errorf("message = %q", first.commit.Message)
+	}
+	if len(first.files) != 1 || first.files[0] != "hello.go" {
+		t.Fatalf("files = %v, want [hello.go]", first.files)
+	}
+	if content := first.contents["hello.go"]; strings.Contains(content, "2.44.0") || !strings.Contains(content, "+func hello(name string) {}") {
+		t.Errorf("hello.go diff = %q", content)
+	}
+
+	// The deleted file is left out, and hunk lines looking like file
+	// headers stay content
+	second := commits[1]
+	if len(second.files) != 1 || second.files[0] != "lines.go" {
+		t.Fatalf("files = %v, want [lines.go]", second.files)
+	}
+	if content := second.contents["lines.go"]; !strings.Contains(content, "+++ looks like a header too") {
+		t.Errorf("lines.go diff = %q", content)
+	}
+}
+
+func TestParsePatchPlainDiff(t *testing.T) {
+	patch := "--- a.go\t2024-03-15 10:00:00\n+++ a.go\t2024-03-15 11:00:00\n@@ -1 +1 @@\n-a\n+b\n" +
+		"--- b.go\n+++ b.go\n@@ -1 +1,2 @@\n b\n+c\n"
+	commits := parsePatch(patch)
+	if len(commits) != 1 {
+		t.Fatalf("got %d commits, want 1", len(commits))
+	}
+	if got := strings.Join(commits[0].files, ","); got != "a.go,b.go" {
+		t.Errorf("files = %s, want a.go,b.go", got)
+	}
+	if commits[0].commit.Hash != "" {
+		t.Errorf("plain diff got hash %q", commits[0].commit.Hash)
+	}
+}
+
+func TestParsePatchMailbox(t *testing.T) {
+	mail := func(id string) string {
+		return "From git@z Thu Jan  1 00:00:00 1970\n" +
+			"From: Ben <ben@example.com>\n" +
+			"Subject: [PATCH] Fix\n" +
+			"Message-Id: <" + id + "@example.com>\n\n" +
+			"From here the body starts\n" +
+			"---\n" +
+			"diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n\n"
+	}
+	commits := parsePatch(mail("one") + mail("two"))
+	if len(commits) != 2 {
+		t.Fatalf("got %d commits, want 2", len(commits))
+	}
+	// Mails without a commit hash are named by their Message-Id
+	a, b := commits[0].commit.Hash, commits[1].commit.Hash
+	if a == "" || a == b || !commitHash.MatchString(a) {
+		t.Errorf("hashes = %q, %q; want two distinct SHA-1s", a, b)
+	}
+	if again := parsePatch(mail("one"))[0].commit.Hash; again != a {
+		t.Errorf("hash not stable: %q then %q", a, again)
+	}
+	if !strings.Contains(commits[0].commit.Message, "From here the body starts") {
+		t.Errorf("message = %q, want the body line starting with From", commits[0].commit.Message)
+	}
+}
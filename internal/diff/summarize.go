@@ -0,0 +1,99 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// declaration matches a changed line that declares a function, type or
+// table, after its +/- marker
+var declaration = regexp.MustCompile(`^\s*(?:(?:export|default|pub(?:\([\w:]+\))?|public|private|protected|internal|static|abstract|final|async|override)\s+)*` +
+	`(?:func|def|fn|class|interface|struct|enum|trait|type|function|impl|module)\s+\S` +
+	`|^\s*(?:export\s+)?(?:const|let)\s+\w+\s*=\s*(?:async\s+)?(?:\([^)]*\)|\w+)\s*=>` +
+	`|(?i:^\s*(?:create|alter|drop)\s+(?:or\s+replace\s+)?(?:table|view|index|function|procedure|trigger|type)\b)`)
+
+// hunkRange matches a hunk header, capturing its ranges and the enclosing
+// function git shows after it
+var hunkRange = regexp.MustCompile(`^@@@? ((?:-[0-9,]+ )+\+[0-9,]+) @@@?(.*)$`)
+
+// summarize describes the lines of a file diff from line from on, those
+// cut to fit the limits: their hunks with line ranges, enclosing function
+// and line counts, and the declarations they add or remove. The model gets
+// it after the part of the diff that fits, to keep some oversight of the
+// rest. The summary has at most maxLines lines.
+func summarize(content string, from, maxLines int) string {
+	type hunk struct {
+		header         string
+		added, removed int
+	}
+	var hunks []hunk
+	var decls []string
+	added, removed := 0, 0
+	current := "" // Header of the hunk being read
+	i := 0
+	for line := range strings.SplitSeq(content, "\n") {
+		i++
+		if m := hunkRange.FindStringSubmatch(line); m != nil {
+			current = "@@ " + m[1] + " @@"
+			if fn := strings.TrimSpace(m[2]); fn != "" {
+				current += " " + fn
+			}
+			if i > from {
+				hunks = append(hunks, hunk{header: current})
+			}
+			continue
+		}
+		if i <= from || current == "" {
+			continue
+		}
+		if len(hunks) == 0 {
+			// The cut falls inside a hunk
+			hunks = append(hunks, hunk{header: current + " (continued)"})
+		}
+		h := &hunks[len(hunks)-1]
+		switch {
+		case strings.HasPrefix(line, "+"):
+			h.added++
+			added++
+		case strings.HasPrefix(line, "-"):
+			h.removed++
+			removed++
+		default:
+			continue
+		}
+		if declaration.MatchString(line[1:]) {
+			decls = append(decls, line[:1]+" "+strings.TrimSpace(line[1:]))
+		}
+	}
+
+	// Lines for each part, declarations first since they say the most
+	lines := []string{fmt.Sprintf("[Summary of the %d lines cut: %d hunks, +%d -%d lines]", max(i-from, 0), len(hunks), added, removed)}
+	room := maxLines - 1
+	list := func(title string, items []string, limit int) {
+		if len(items) == 0 || room < 2 {
+			return
+		}
+		lines = append(lines, title)
+		room--
+		shown := min(len(items), limit, room)
+		if shown < len(items) {
+			shown = max(shown-1, 0)
+		}
+		for _, item := range items[:shown] {
+			lines = append(lines, "  "+item)
+		}
+		room -= shown
+		if shown < len(items) {
+			lines = append(lines, fmt.Sprintf("  ... %d more", len(items)-shown))
+			room--
+		}
+	}
+	list("Declarations added (+) or removed (-):", decls, room*2/3)
+	var ranges []string
+	for _, h := range hunks {
+		ranges = append(ranges, fmt.Sprintf("+%d -%d  %s", h.added, h.removed, h.header))
+	}
+	list("Hunks:", ranges, room)
+	return strings.Join(lines, "\n")
+}
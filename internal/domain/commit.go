@@ -11,6 +11,7 @@ type Commit struct {
 	Message   string
 	RepoPath  string
 	RepoName  string
+	Merge     bool // Has more than one parent
 }
 
 // IsToday checks if the commit was made today
@@ -19,3 +20,11 @@ func (c *Commit) IsToday() bool {
 	return c.Timestamp.Year() == now.Year() &&
 		c.Timestamp.YearDay() == now.YearDay()
 }
+
+// ShortHash abbreviates a commit hash for display
+func ShortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
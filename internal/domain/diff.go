@@ -13,12 +13,22 @@ type Diff struct {
 	RepoPath   string
 	RepoName   string
 	Language   string
+	// Merge marks a combined diff of a merge commit against all its
+	// parents, holding only the hunks resolved by hand
+	Merge bool
+	// Context is the whole file after the change, loaded for repositories
+	// reviewed with full-file context; empty otherwise
+	Context string
 }
 
 // MaxDiffLines is the maximum number of lines to include per file
 const MaxDiffLines = 300
 
-// SupportedExtensions lists file extensions we analyze
+// MaxContextLines caps the full-file context of a diff
+const MaxContextLines = 1500
+
+// SupportedExtensions lists the file extensions analyzed by default and
+// their language labels; the languages config adds to or changes them
 var SupportedExtensions = map[string]string{
 	".go":   "go",
 	".ts":   "typescript",
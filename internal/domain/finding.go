@@ -1,5 +1,15 @@
 package domain
 
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
 // Severity represents the importance level of a finding
 type Severity string
 
@@ -9,17 +19,161 @@ const (
 	SeverityLow    Severity = "Low"
 )
 
+// Rank orders severities from Low (1) to High (3); unknown values rank 0
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityHigh:
+		return 3
+	case SeverityMedium:
+		return 2
+	case SeverityLow:
+		return 1
+	}
+	return 0
+}
+
+// ParseSeverity parses a severity name case-insensitively
+func ParseSeverity(name string) (Severity, bool) {
+	for _, s := range []Severity{SeverityHigh, SeverityMedium, SeverityLow} {
+		if strings.EqualFold(name, string(s)) {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// Category is the area of concern a finding belongs to
+type Category string
+
+const (
+	CategorySecurity        Category = "security"
+	CategoryBugs            Category = "bugs"
+	CategoryData            Category = "data"
+	CategoryDesign          Category = "design"
+	CategoryPerformance     Category = "performance"
+	CategoryMaintainability Category = "maintainability"
+)
+
+// Categories lists every category, in the order the prompt names them
+var Categories = []Category{CategorySecurity, CategoryBugs, CategoryData, CategoryDesign, CategoryPerformance, CategoryMaintainability}
+
+// ParseCategory parses a category name case-insensitively
+func ParseCategory(name string) (Category, bool) {
+	for _, c := range Categories {
+		if strings.EqualFold(strings.TrimSpace(name), string(c)) {
+			return c, true
+		}
+	}
+	return "", false
+}
+
 // Finding represents an issue discovered during code review
 type Finding struct {
 	Title       string   `json:"title"`
 	Severity    Severity `json:"severity"`
+	Category    Category `json:"category,omitempty"`
 	RepoName    string   `json:"repo_name"`
 	Files       []string `json:"files"`
 	Explanation string   `json:"explanation"`
 	Action      string   `json:"suggested_action"`
+	PRNumber    int      `json:"pr_number,omitempty"` // Open pull request containing the change
+	PRURL       string   `json:"pr_url,omitempty"`
+	// RemovedIn is the later commit that deleted the finding's files; such
+	// findings are downgraded to Low as "code removed"
+	RemovedIn string `json:"removed_in,omitempty"`
+	// OriginFiles are the paths Files had when the finding was first
+	// reported, set once they were moved; the fingerprint uses them
+	OriginFiles []string `json:"origin_files,omitempty"`
+	// Day (YYYY-MM-DD) is the day of the latest commit to the finding's
+	// files, set in reports that backfilled missed days
+	Day string `json:"day,omitempty"`
+	// Extensions holds the extra fields review.finding_fields asks the
+	// model to fill, by name
+	Extensions Extensions `json:"extensions,omitempty"`
+}
+
+// Extensions maps extra finding field names to their values
+type Extensions map[string]string
+
+// UnmarshalJSON accepts any JSON values, as models don't always answer
+// with strings: numbers and booleans are kept as written, objects and
+// arrays as compact JSON, and nulls are dropped. Extensions that aren't
+// an object are logged and dropped rather than failing the finding and
+// the rest of its chunk.
+func (e *Extensions) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		slog.Default().Warn("ignoring finding extensions that aren't an object", "extensions", string(data))
+		*e = nil
+		return nil
+	}
+	*e = make(Extensions, len(raw))
+	for name, value := range raw {
+		if string(bytes.TrimSpace(value)) == "null" {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(value, &s); err == nil {
+			(*e)[name] = s
+			continue
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, value); err != nil {
+			return err
+		}
+		(*e)[name] = compact.String()
+	}
+	return nil
+}
+
+// Names returns the extension names in order
+func (e Extensions) Names() []string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Fingerprint identifies the same finding across runs by a short hash of
+// its repository, files and title, ignoring case and file order. Moved
+// files count by their original paths.
+func (f *Finding) Fingerprint() string {
+	files := f.Files
+	if len(f.OriginFiles) > 0 {
+		files = f.OriginFiles
+	}
+	return FingerprintOf(f.RepoName, files, f.Title)
+}
+
+// FingerprintOf returns the fingerprint of a finding with the given
+// repository, files and title
+func FingerprintOf(repo string, files []string, title string) string {
+	lower := make([]string, len(files))
+	for i, file := range files {
+		lower[i] = strings.ToLower(file)
+	}
+	sort.Strings(lower)
+
+	key := strings.ToLower(repo) + "\x00" + strings.Join(lower, ",") + "\x00" +
+		strings.ToLower(strings.Join(strings.Fields(title), " "))
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:6])
 }
 
 // IsHighPriority returns true if the finding is high severity
 func (f *Finding) IsHighPriority() bool {
 	return f.Severity == SeverityHigh
 }
+
+// CountAtOrAbove returns how many findings have at least the given severity
+func CountAtOrAbove(findings []Finding, min Severity) int {
+	count := 0
+	for _, f := range findings {
+		if f.Severity.Rank() >= min.Rank() {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExtensionsUnmarshal(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want Extensions
+	}{
+		{"strings", `{"owner":"api team"}`, Extensions{"owner": "api team"}},
+		{"other values", `{"effort":3,"breaking":true,"refs":["a", "b"],"gone":null}`, Extensions{"effort": "3", "breaking": "true", "refs": `["a","b"]`}},
+		{"not an object", `"high"`, nil},
+		{"list", `[1, 2]`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f Finding
+			data := `{"title":"Bug","severity":"High","extensions":` + tt.json + `}`
+			if err := json.Unmarshal([]byte(data), &f); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if f.Title != "Bug" {
+				t.Errorf("title = %q, want the rest of the finding kept", f.Title)
+			}
+			if len(f.Extensions) != len(tt.want) {
+				t.Fatalf("extensions = %v, want %v", f.Extensions, tt.want)
+			}
+			for name, value := range tt.want {
+				if f.Extensions[name] != value {
+					t.Errorf("%s = %q, want %q", name, f.Extensions[name], value)
+				}
+			}
+		})
+	}
+}
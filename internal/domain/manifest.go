@@ -0,0 +1,78 @@
+package domain
+
+import "time"
+
+// ManifestVersion is bumped when a Manifest field changes meaning or is
+// removed; added fields don't bump it
+const ManifestVersion = 1
+
+// Run outcomes recorded in a manifest
+const (
+	RunReviewed       = "reviewed"        // Changes were reviewed and a report written
+	RunNoChanges      = "no_changes"      // No commits or reviewable diffs in the window
+	RunNoRepositories = "no_repositories" // Nothing to scan under root_path
+	RunPaused         = "paused"          // Skipped by `review pause`
+	RunFailed         = "failed"          // See Manifest.Error
+)
+
+// Manifest is the machine-readable record of one run, written with
+// --output for automation around CRA
+type Manifest struct {
+	Version    int              `json:"version"` // ManifestVersion
+	Status     string           `json:"status"`
+	Error      string           `json:"error,omitempty"`
+	Started    time.Time        `json:"started"`
+	Finished   time.Time        `json:"finished"`
+	Timings    Timings          `json:"timings"`
+	ReportDate string           `json:"report_date,omitempty"` // YYYY-MM-DD
+	ReportPath string           `json:"report_path,omitempty"`
+	Model      string           `json:"model,omitempty"`
+	Repos      []ManifestRepo   `json:"repositories"`
+	Commits    []ManifestCommit `json:"commits"`
+	Diffs      []ManifestDiff   `json:"diffs"`
+	Findings   []Finding        `json:"findings"`
+	Failures   []ReviewFailure  `json:"failures,omitempty"`
+	Exclusions []Exclusion      `json:"exclusions,omitempty"`
+	Usage      *Usage           `json:"usage,omitempty"`
+	Sampling   *Sampling        `json:"sampling,omitempty"`
+	Provenance Provenance       `json:"provenance"`
+}
+
+// ManifestRepo is a repository a run scanned
+type ManifestRepo struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Commits int    `json:"commits"` // In the review window
+}
+
+// ManifestCommit is a commit in the review window
+type ManifestCommit struct {
+	Repo    string    `json:"repo"`
+	Hash    string    `json:"hash"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Time    time.Time `json:"time"`
+	Subject string    `json:"subject"`
+}
+
+// ManifestDiff is a file change sent for review
+type ManifestDiff struct {
+	Repo     string `json:"repo"`
+	Commit   string `json:"commit"`
+	Path     string `json:"path"`
+	OldPath  string `json:"old_path,omitempty"` // Renames
+	Language string `json:"language,omitempty"`
+	Lines    int    `json:"lines"`
+}
+
+// Timings are the milliseconds each stage of a run took; stages a run
+// didn't reach are 0
+type Timings struct {
+	ScanMS    int64 `json:"scan_ms"`
+	CommitsMS int64 `json:"commits_ms"`
+	DiffsMS   int64 `json:"diffs_ms"`
+	ReviewMS  int64 `json:"review_ms"`
+	ReportMS  int64 `json:"report_ms"`
+	EmailMS   int64 `json:"email_ms"`
+	TotalMS   int64 `json:"total_ms"`
+}
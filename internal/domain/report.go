@@ -1,17 +1,189 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Report represents the daily code review report
 type Report struct {
-	Date          time.Time
-	Summary       string
-	Findings      []Finding
-	Repositories  []string
-	CommitCount   int
-	FileCount     int
-	NothingToNote bool
-	Model         string // The LLM model used for review
+	Date          time.Time       `json:"date"`
+	Summary       string          `json:"summary"`
+	Findings      []Finding       `json:"findings"`
+	Repositories  []string        `json:"repositories"` // Display names of the scanned repositories
+	CommitCount   int             `json:"commit_count"`
+	FileCount     int             `json:"file_count"`
+	NothingToNote bool            `json:"nothing_to_note"`
+	Model         string          `json:"model"`                // The LLM model used for review
+	Notes         []string        `json:"notes,omitempty"`      // Repositories skipped or partially reviewed, and why
+	UserNotes     []string        `json:"user_notes,omitempty"` // Left with `review note` for this run
+	Failures      []ReviewFailure `json:"failures,omitempty"`   // Parts of the review that failed
+	Exclusions    []Exclusion     `json:"exclusions,omitempty"`
+	Usage         *Usage          `json:"usage,omitempty"`    // LLM calls and prompt cache hits
+	Sampling      *Sampling       `json:"sampling,omitempty"` // Set when only a sample of the changes was reviewed
+	Days          []Day           `json:"days,omitempty"`     // Set when the run backfilled missed days
+	Provenance    Provenance      `json:"provenance"`
+	// Diffs are the file diffs as sent for review, for the appendix of
+	// HTML reports (see reports.appendix); stored apart from the rest and
+	// left out of the report's JSON
+	Diffs []ReportDiff `json:"-"`
+}
+
+// ReportDiff is a file diff as the reviewer got it, truncation included
+type ReportDiff struct {
+	Repo    string `json:"repo"`
+	Commit  string `json:"commit"`
+	Path    string `json:"path"`
+	OldPath string `json:"old_path,omitempty"` // Renames
+	Content string `json:"content"`
+}
+
+// Day is one day of a report that backfilled the days the scheduler
+// missed, e.g. while the machine was asleep or off
+type Day struct {
+	Date    string `json:"date"` // YYYY-MM-DD
+	Commits int    `json:"commits"`
+}
+
+// Exclusion reasons
+const (
+	ExcludedRepoList      = "repo_list"       // Outside repos.include, or in repos.exclude
+	ExcludedRepoFilter    = "repo_filter"     // scanner.repos doesn't match
+	ExcludedRepoSkip      = "repo_skip"       // skip is set in overrides or .cra.yaml
+	ExcludedInProgress    = "in_progress"     // Rebase or similar in progress, scanner.in_progress: skip
+	ExcludedGitError      = "git_error"       // History couldn't be read
+	ExcludedExtension     = "extension"       // Not a reviewed file type
+	ExcludedPattern       = "exclude_pattern" // Matches a built-in or configured exclude pattern
+	ExcludedOverridePaths = "override_paths"  // Outside the paths of the repository's override or .cra.yaml
+	ExcludedSize          = "size"            // Reviewed only in part: truncated to review.limits
+	ExcludedSampled       = "sampled"         // Left out of the sample, see review.sampling
+	ExcludedBudget        = "budget"          // Over review.limits.token_budget
+)
+
+// Exclusion records a repository or file left out of a review, in whole
+// or in part
+type Exclusion struct {
+	Repo   string `json:"repo"`
+	Path   string `json:"path,omitempty"` // Empty when the whole repository was left out
+	Reason string `json:"reason"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Usage records the LLM calls of a review and how many of their input
+// tokens the provider served from its prompt cache
+type Usage struct {
+	Calls        int `json:"calls"`
+	InputTokens  int `json:"input_tokens"`
+	CachedTokens int `json:"cached_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	// RepeatedTokens estimates the instructions sent again with every
+	// call after the first, which a prompt cache can serve
+	RepeatedTokens int `json:"repeated_tokens"`
+	// Errors counts the calls that failed, retries included
+	Errors int `json:"errors,omitempty"`
+	// LatencyMS is the total time spent waiting on calls, failed ones
+	// included
+	LatencyMS int64 `json:"latency_ms,omitempty"`
+	// Reused counts the answers taken from the response cache instead of
+	// calling the model, see cache.responses
+	Reused int `json:"reused,omitempty"`
+	// Models splits the calls by the provider and model that answered
+	// them, as a run may use several: backends, strong and triage models
+	Models []ModelUsage `json:"models,omitempty"`
+}
+
+// ModelUsage is the part of a run's LLM calls one model made
+type ModelUsage struct {
+	Provider     string `json:"provider"`
+	Model        string `json:"model"`
+	Calls        int    `json:"calls"`
+	Errors       int    `json:"errors,omitempty"`
+	LatencyMS    int64  `json:"latency_ms,omitempty"`
+	InputTokens  int    `json:"input_tokens"`
+	OutputTokens int    `json:"output_tokens"`
+}
+
+// Requests counts the calls attempted, failed ones included
+func (m ModelUsage) Requests() int {
+	return m.Calls + m.Errors
+}
+
+// Model returns the usage of provider's model, adding it when it has
+// none yet
+func (u *Usage) Model(provider, model string) *ModelUsage {
+	for i := range u.Models {
+		if m := &u.Models[i]; m.Provider == provider && m.Model == model {
+			return m
+		}
+	}
+	u.Models = append(u.Models, ModelUsage{Provider: provider, Model: model})
+	return &u.Models[len(u.Models)-1]
+}
+
+// FailedRun records a run that failed after calling the LLM, so provider
+// health counts calls that never made it into a report
+type FailedRun struct {
+	Time  time.Time `json:"time"`
+	Error string    `json:"error"`
+	Usage Usage     `json:"usage"`
+}
+
+// CacheHitRate is the share of input tokens served from cache, 0 to 1
+func (u Usage) CacheHitRate() float64 {
+	if u.InputTokens == 0 {
+		return 0
+	}
+	return float64(u.CachedTokens) / float64(u.InputTokens)
+}
+
+// Requests counts the calls attempted, failed ones included
+func (u Usage) Requests() int {
+	return u.Calls + u.Errors
+}
+
+// Sampling records how much of a run's changes review.sampling kept
+type Sampling struct {
+	TotalFiles     int `json:"total_files"`
+	TotalLines     int `json:"total_lines"`
+	ReviewedFiles  int `json:"reviewed_files"`
+	ReviewedLines  int `json:"reviewed_lines"`
+	SensitiveFiles int `json:"sensitive_files"` // Reviewed in full as security-sensitive
+}
+
+// Rate is the share of diff lines reviewed, 0 to 1
+func (s Sampling) Rate() float64 {
+	if s.TotalLines == 0 {
+		return 1
+	}
+	return float64(s.ReviewedLines) / float64(s.TotalLines)
+}
+
+// ReviewFailure records changes that couldn't be reviewed
+type ReviewFailure struct {
+	Repos []string `json:"repos"`
+	Files []string `json:"files"`
+	Error string   `json:"error"`
+}
+
+// Provenance records what produced a report
+type Provenance struct {
+	Version     string    `json:"version"`              // CRA version
+	Commit      string    `json:"commit,omitempty"`     // CRA build commit
+	BuildDate   string    `json:"build_date,omitempty"` // CRA build date
+	Provider    string    `json:"provider,omitempty"`   // LLM provider
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// HighestSeverity returns the severity of the most severe finding, empty
+// when there are none
+func (r *Report) HighestSeverity() Severity {
+	var highest Severity
+	for _, f := range r.Findings {
+		if f.Severity.Rank() > highest.Rank() {
+			highest = f.Severity
+		}
+	}
+	return highest
 }
 
 // HighCount returns the number of high severity findings
@@ -56,3 +228,35 @@ func (r *Report) TotalFindings() int {
 func (r *Report) HasFindings() bool {
 	return len(r.Findings) > 0
 }
+
+// View selects the audience a report is rendered for
+type View string
+
+const (
+	// ViewEngineer is the full report with files and suggested fixes
+	ViewEngineer View = "engineer"
+	// ViewManager is a condensed summary: counts, trend and top risks
+	ViewManager View = "manager"
+)
+
+// Views lists the supported report views
+var Views = []View{ViewEngineer, ViewManager}
+
+// ParseView parses a view name case-insensitively
+func ParseView(name string) (View, bool) {
+	for _, v := range Views {
+		if strings.EqualFold(name, string(v)) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Alert is a fired alerts rule: why it fired and the findings behind it
+type Alert struct {
+	Rule     string    `json:"rule"`
+	Message  string    `json:"message"`
+	Severity Severity  `json:"severity"`
+	Findings []Finding `json:"findings"` // The run's findings of Severity
+	Date     time.Time `json:"date"`
+}
@@ -0,0 +1,7 @@
+package domain
+
+// Repository is a discovered Git repository
+type Repository struct {
+	Path string // Absolute path to the working tree
+	Name string // Display name, unique within a run
+}
@@ -0,0 +1,218 @@
+// Package dupes finds near-identical changes landing in several
+// repositories, such as the same fix pasted into each service, without
+// calling the LLM.
+package dupes
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+const (
+	// shingleSize is the number of consecutive tokens hashed together
+	shingleSize = 4
+	// Threshold is the Jaccard similarity of shingle sets at which two
+	// hunks count as the same change
+	Threshold = 0.7
+)
+
+// hunk is the added code of one diff hunk
+type hunk struct {
+	repo     string
+	file     string
+	lines    int // Significant added lines
+	shingles map[uint64]struct{}
+}
+
+// Find returns a finding for every group of similar hunks added to two or
+// more repositories. Hunks with fewer than minLines significant added
+// lines are ignored.
+func Find(diffs []domain.Diff, minLines int) []domain.Finding {
+	var hunks []hunk
+	for _, d := range diffs {
+		if d.Merge {
+			// Resolved conflicts repeat code already added on a branch
+			continue
+		}
+		hunks = append(hunks, parseHunks(d, minLines)...)
+	}
+
+	// Union similar hunks from different repositories
+	parent := make([]int, len(hunks))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range hunks {
+		for j := i + 1; j < len(hunks); j++ {
+			if hunks[i].repo != hunks[j].repo && jaccard(hunks[i].shingles, hunks[j].shingles) >= Threshold {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	groups := make(map[int][]hunk)
+	var roots []int
+	for i, h := range hunks {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], h)
+	}
+
+	var findings []domain.Finding
+	for _, root := range roots {
+		if f, ok := finding(groups[root]); ok {
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// finding describes a group of similar hunks, when they span repositories
+func finding(group []hunk) (domain.Finding, bool) {
+	repos := make(map[string]bool)
+	locations := make(map[string]bool)
+	lines := 0
+	for _, h := range group {
+		repos[h.repo] = true
+		locations[h.repo+"/"+h.file] = true
+		if h.lines > lines {
+			lines = h.lines
+		}
+	}
+	if len(repos) < 2 {
+		return domain.Finding{}, false
+	}
+
+	names := sortedKeys(repos)
+	files := sortedKeys(locations)
+	return domain.Finding{
+		Title:    fmt.Sprintf("Same change made in %d repositories", len(names)),
+		Severity: domain.SeverityLow,
+		Category: domain.CategoryMaintainability,
+		RepoName: names[0],
+		Files:    files,
+		Explanation: fmt.Sprintf("Near-identical code (about %d lines) was added to %s: %s. "+
+			"Copies of a fix drift apart, and the next fix has to be made everywhere again.",
+			lines, strings.Join(names, ", "), strings.Join(files, ", ")),
+		Action: "Move the shared code into a common library or module that these repositories depend on.",
+	}, true
+}
+
+// parseHunks extracts the added code of each hunk in a diff
+func parseHunks(d domain.Diff, minLines int) []hunk {
+	var hunks []hunk
+	var added []string
+	flush := func() {
+		if len(added) >= minLines {
+			hunks = append(hunks, hunk{repo: d.RepoName, file: d.FilePath, lines: len(added), shingles: shingles(added)})
+		}
+		added = nil
+	}
+
+	for _, line := range strings.Split(d.Content, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			flush()
+		case strings.HasPrefix(line, "+++"):
+		case strings.HasPrefix(line, "+"):
+			if significant(line[1:]) {
+				added = append(added, line[1:])
+			}
+		}
+	}
+	flush()
+	return hunks
+}
+
+// significant reports whether a line holds more than braces, brackets or
+// punctuation
+func significant(line string) bool {
+	return strings.IndexFunc(line, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) >= 0
+}
+
+// shingles hashes every run of shingleSize consecutive tokens. Tokens are
+// identifiers, numbers and single punctuation characters, so whitespace
+// and formatting don't affect similarity.
+func shingles(lines []string) map[uint64]struct{} {
+	var tokens []string
+	for _, line := range lines {
+		tokens = append(tokens, tokenize(line)...)
+	}
+
+	set := make(map[uint64]struct{})
+	for i := 0; i+shingleSize <= len(tokens); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(tokens[i:i+shingleSize], "\x00")))
+		set[h.Sum64()] = struct{}{}
+	}
+	if len(set) == 0 && len(tokens) > 0 {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(tokens, "\x00")))
+		set[h.Sum64()] = struct{}{}
+	}
+	return set
+}
+
+func tokenize(line string) []string {
+	var tokens []string
+	var word strings.Builder
+	for _, r := range line {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			word.WriteRune(r)
+		default:
+			if word.Len() > 0 {
+				tokens = append(tokens, word.String())
+				word.Reset()
+			}
+			if !unicode.IsSpace(r) {
+				tokens = append(tokens, string(r))
+			}
+		}
+	}
+	if word.Len() > 0 {
+		tokens = append(tokens, word.String())
+	}
+	return tokens
+}
+
+func jaccard(a, b map[uint64]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	shared := 0
+	for h := range a {
+		if _, ok := b[h]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
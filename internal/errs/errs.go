@@ -0,0 +1,175 @@
+// Package errs classifies failures so the CLI can print a remediation hint
+// and exit with a code that tells cron wrappers what went wrong.
+package errs
+
+import (
+	"errors"
+	"strings"
+)
+
+// Exit codes returned by the review command
+const (
+	ExitOK       = 0
+	ExitFailure  = 1 // Anything not classified below, or findings over --fail-on
+	ExitConfig   = 2
+	ExitGit      = 3
+	ExitProvider = 4
+	ExitDelivery = 5
+)
+
+// ConfigError is an invalid or unreadable configuration
+type ConfigError struct {
+	Err  error
+	Hint string
+}
+
+func (e *ConfigError) Error() string { return e.Err.Error() }
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// GitError is a failed git command or a path that isn't a repository
+type GitError struct {
+	Err  error
+	Hint string
+}
+
+func (e *GitError) Error() string { return e.Err.Error() }
+func (e *GitError) Unwrap() error { return e.Err }
+
+// ProviderError is a failed call to the LLM provider
+type ProviderError struct {
+	Err  error
+	Hint string
+}
+
+func (e *ProviderError) Error() string { return e.Err.Error() }
+func (e *ProviderError) Unwrap() error { return e.Err }
+
+// DeliveryError is a report that couldn't be emailed
+type DeliveryError struct {
+	Err  error
+	Hint string
+}
+
+func (e *DeliveryError) Error() string { return e.Err.Error() }
+func (e *DeliveryError) Unwrap() error { return e.Err }
+
+// Config wraps err as a ConfigError with the given hint
+func Config(err error, hint string) error {
+	if err == nil {
+		return nil
+	}
+	return &ConfigError{Err: err, Hint: hint}
+}
+
+// Git wraps err as a GitError with the given hint
+func Git(err error, hint string) error {
+	if err == nil {
+		return nil
+	}
+	return &GitError{Err: err, Hint: hint}
+}
+
+// Provider wraps err as a ProviderError, guessing the hint from the
+// provider's message
+func Provider(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ProviderError{Err: err, Hint: providerHint(err.Error())}
+}
+
+// Delivery wraps err as a DeliveryError, guessing the hint from the SMTP
+// server's message
+func Delivery(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &DeliveryError{Err: err, Hint: deliveryHint(err.Error())}
+}
+
+// ExitCode returns the exit code for err
+func ExitCode(err error) int {
+	var (
+		configErr   *ConfigError
+		gitErr      *GitError
+		providerErr *ProviderError
+		deliveryErr *DeliveryError
+	)
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &configErr):
+		return ExitConfig
+	case errors.As(err, &gitErr):
+		return ExitGit
+	case errors.As(err, &providerErr):
+		return ExitProvider
+	case errors.As(err, &deliveryErr):
+		return ExitDelivery
+	default:
+		return ExitFailure
+	}
+}
+
+// Hint returns the remediation hint for err, empty when there is none
+func Hint(err error) string {
+	var (
+		configErr   *ConfigError
+		gitErr      *GitError
+		providerErr *ProviderError
+		deliveryErr *DeliveryError
+	)
+	switch {
+	case errors.As(err, &configErr):
+		return configErr.Hint
+	case errors.As(err, &gitErr):
+		return gitErr.Hint
+	case errors.As(err, &providerErr):
+		return providerErr.Hint
+	case errors.As(err, &deliveryErr):
+		return deliveryErr.Hint
+	default:
+		return ""
+	}
+}
+
+func providerHint(msg string) string {
+	msg = strings.ToLower(msg)
+	switch {
+	case containsAny(msg, "401", "403", "unauthorized", "permission denied", "api key", "api_key"):
+		return "the API key was rejected; it may be expired or revoked. Set review.api_key or export GEMINI_API_KEY / ZHIPU_API_KEY, then run `review doctor`"
+	case containsAny(msg, "429", "quota", "rate limit", "resource exhausted", "resource_exhausted"):
+		return "the provider is rate limiting or the quota is used up; retry later or raise the quota"
+	case containsAny(msg, "404", "not found") && strings.Contains(msg, "model"):
+		return "the model was not found; check review.model"
+	case containsAny(msg, "deadline exceeded", "timeout", "connection refused", "no such host", "dial tcp"):
+		return "the provider could not be reached; check the network and review.base_url"
+	default:
+		return "run `review doctor` to test the provider connection"
+	}
+}
+
+func deliveryHint(msg string) string {
+	msg = strings.ToLower(msg)
+	switch {
+	case containsAny(msg, "535", "authenticat", "username and password"):
+		return "the SMTP server rejected the login; check email.smtp_user and email.smtp_password (Gmail needs an app password)"
+	case containsAny(msg, "connection refused", "no such host", "i/o timeout", "dial tcp", "cannot reach"):
+		return "the SMTP server is unreachable; check email.smtp_host, email.smtp_port and the network"
+	case containsAny(msg, "tls", "certificate", "x509"):
+		return "the TLS handshake failed; check email.smtp_port (587 for STARTTLS) and the server certificate"
+	case containsAny(msg, "recipient", "550", "553"):
+		return "a recipient was rejected; check email.to_address and email.routes"
+	default:
+		return "run `review doctor` to test the SMTP connection"
+	}
+}
+
+func containsAny(s string, subs ...string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+// Package filelock coordinates CRA processes sharing files, such as the
+// cache directory and the reports directory, through advisory lock files
+package filelock
+
+import (
+	"os"
+)
+
+// Lock takes the lock file at path, shared or exclusive, waiting for
+// other holders, and returns the function releasing it. Each call opens
+// the file anew: flock locks belong to an open file, so one shared by
+// goroutines wouldn't keep them apart.
+func Lock(path string, exclusive bool) (func(), error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
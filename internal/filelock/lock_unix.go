@@ -0,0 +1,26 @@
+//go:build !windows
+
+package filelock
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile waits for a shared or exclusive lock on f
+func lockFile(f *os.File, exclusive bool) error {
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,118 @@
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/logging"
+)
+
+// DefaultGitHubAPI is the public GitHub REST API endpoint
+const DefaultGitHubAPI = "https://api.github.com"
+
+// PullRequest is the subset of a forge pull request CRA needs
+type PullRequest struct {
+	Number int    `json:"number"`
+	URL    string `json:"html_url"`
+	State  string `json:"state"`
+	Title  string `json:"title"`
+}
+
+// Client talks to the GitHub REST API
+type Client struct {
+	config config.ForgeConfig
+	logger *slog.Logger
+	http   *http.Client
+	apiURL string
+}
+
+// NewClient creates a new forge Client
+func NewClient(cfg config.ForgeConfig, logger *slog.Logger) (*Client, error) {
+	if cfg.Provider != "github" {
+		return nil, fmt.Errorf("unsupported forge provider %q", cfg.Provider)
+	}
+
+	apiURL := cfg.APIURL
+	if apiURL == "" {
+		apiURL = DefaultGitHubAPI
+	}
+
+	return &Client{
+		config: cfg,
+		logger: logging.OrDefault(logger),
+		http:   &http.Client{Timeout: 30 * time.Second},
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+	}, nil
+}
+
+// OpenPullRequests returns the open pull requests that contain the commit.
+// repo is "owner/name".
+func (c *Client) OpenPullRequests(ctx context.Context, repo, sha string) ([]PullRequest, error) {
+	var prs []PullRequest
+	path := fmt.Sprintf("/repos/%s/commits/%s/pulls", repo, sha)
+	if err := c.do(ctx, http.MethodGet, path, nil, &prs); err != nil {
+		return nil, err
+	}
+
+	var open []PullRequest
+	for _, pr := range prs {
+		if pr.State == "open" {
+			open = append(open, pr)
+		}
+	}
+	return open, nil
+}
+
+// Comment posts a Markdown comment on a pull request. repo is "owner/name".
+func (c *Client) Comment(ctx context.Context, repo string, number int, body string) error {
+	path := fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number)
+	return c.do(ctx, http.MethodPost, path, map[string]string{"body": body}, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token := c.config.ResolveToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
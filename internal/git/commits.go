@@ -5,57 +5,78 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/juparave/codereviewer/internal/domain"
-	"github.com/juparave/codereviewer/internal/scanner"
+	"github.com/juparave/codereviewer/internal/logging"
+	"github.com/juparave/codereviewer/internal/util"
 )
 
 // Client interacts with Git repositories
 type Client struct {
-	logger *log.Logger
+	logger *slog.Logger
 }
 
 // NewClient creates a new Git client
-func NewClient(logger *log.Logger) *Client {
-	return &Client{logger: logger}
-}
-
-// GetCommits returns commits made since the given time in the given repository
-func (c *Client) GetCommits(ctx context.Context, repoPath string, since string) ([]domain.Commit, error) {
-	var sinceParam string
-
-	// Determine time window
-	if since == "" || since == "today" {
-		// Get today's date at midnight in local timezone
-		now := time.Now()
-		midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-		sinceParam = midnight.Format("2006-01-02T15:04:05")
-	} else {
-		// Try parsing as duration (e.g. "24h")
-		if d, err := time.ParseDuration(since); err == nil {
-			sinceParam = time.Now().Add(-d).Format("2006-01-02T15:04:05")
-		} else {
-			// Pass raw string to git (e.g. "yesterday", "2023-01-01")
-			sinceParam = since
-		}
+func NewClient(logger *slog.Logger) *Client {
+	return &Client{logger: logging.OrDefault(logger)}
+}
+
+// LogOptions selects the commits GetCommits returns
+type LogOptions struct {
+	Since string // Window start, see ParseSince
+	Until string // Window end, see ParseUntil; empty for now
+	// Authors keeps only commits whose author name or email matches
+	// (nil keeps all)
+	Authors *util.Patterns
+	// Branches limits the log to local and remote branches matching these
+	// patterns; with DefaultBranch unset, nil reads every ref
+	Branches *util.Patterns
+	// DefaultBranch adds the repository's default branch
+	DefaultBranch bool
+	// Merges includes merge commits, which are skipped by default
+	Merges bool
+	// Location is the timezone of "today" and dates in Since and Until;
+	// nil for local time
+	Location *time.Location
+}
+
+// commitFormat is the git log format parseCommits reads:
+// hash|author|email|timestamp|parents|subject
+const commitFormat = "%H|%an|%ae|%aI|%P|%s"
+
+// GetCommits returns the commits of the given repository selected by opts
+func (c *Client) GetCommits(ctx context.Context, repo domain.Repository, opts LogOptions) ([]domain.Commit, error) {
+	now := time.Now()
+	if opts.Location != nil {
+		now = now.In(opts.Location)
 	}
 
-	// Git log format: hash|author|email|timestamp|subject
-	format := "%H|%an|%ae|%aI|%s"
+	refs, err := c.logRefs(ctx, repo.Path, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		// No branch matches the selection
+		return nil, nil
+	}
 
-	cmd := exec.CommandContext(ctx, "git", "log",
-		"--since="+sinceParam,
-		"--no-merges",
-		"--format="+format,
-		"--all",
-	)
-	cmd.Dir = repoPath
+	args := []string{"log", "--since=" + ParseSince(opts.Since, now), "--format=" + commitFormat}
+	if !opts.Merges {
+		args = append(args, "--no-merges")
+	}
+	if opts.Until != "" {
+		args = append(args, "--until="+ParseUntil(opts.Until, now))
+	}
+	cmd := exec.CommandContext(ctx, "git", append(args, refs...)...)
+	cmd.Dir = repo.Path
 
 	output, err := cmd.Output()
 	if err != nil {
@@ -68,12 +89,233 @@ func (c *Client) GetCommits(ctx context.Context, repoPath string, since string)
 		return nil, fmt.Errorf("git log failed: %w", err)
 	}
 
-	return c.parseCommits(output, repoPath)
+	commits, err := c.parseCommits(output, repo)
+	if err != nil {
+		return nil, err
+	}
+	kept := filterAuthors(commits, opts.Authors)
+	c.logger.Debug("read commits", "repo", repo.Name, "refs", len(refs), "commits", len(commits), "kept", len(kept))
+	return kept, nil
+}
+
+// filterAuthors keeps the commits whose author name or email matches
+// authors; nil keeps all
+func filterAuthors(commits []domain.Commit, authors *util.Patterns) []domain.Commit {
+	if authors.Empty() {
+		return commits
+	}
+
+	var kept []domain.Commit
+	for _, commit := range commits {
+		if authors.Match(commit.Author, commit.Email) {
+			kept = append(kept, commit)
+		}
+	}
+	return kept
+}
+
+// logRefs returns the git log arguments selecting the refs opts asks for
+func (c *Client) logRefs(ctx context.Context, repoPath string, opts LogOptions) ([]string, error) {
+	if opts.Branches.Empty() && !opts.DefaultBranch {
+		// While a rebase or similar is in progress HEAD holds half-finished
+		// commits, so only commits already on a ref are reviewed
+		if c.OperationInProgress(ctx, repoPath) != "" {
+			return []string{"--branches", "--tags", "--remotes"}, nil
+		}
+		return []string{"--all"}, nil
+	}
+
+	branches, err := c.branches(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var defaultName string
+	if opts.DefaultBranch {
+		if defaultName, err = c.DefaultBranch(ctx, repoPath); err != nil {
+			return nil, err
+		}
+	}
+
+	var refs []string
+	for _, b := range branches {
+		if (opts.DefaultBranch && b.name == defaultName) ||
+			(!opts.Branches.Empty() && opts.Branches.Match(b.short, b.name)) {
+			refs = append(refs, b.ref)
+		}
+	}
+	return refs, nil
+}
+
+// Tips returns the commits that the refs selected by opts point at,
+// sorted, so two calls can be compared to detect new commits
+func (c *Client) Tips(ctx context.Context, repoPath string, opts LogOptions) ([]string, error) {
+	refs, err := c.logRefs(ctx, repoPath, opts)
+	if err != nil || len(refs) == 0 {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"rev-parse"}, refs...)...)
+	cmd.Dir = repoPath
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git rev-parse failed: %w", err)
+	}
+
+	tips := strings.Fields(string(output))
+	sort.Strings(tips)
+	return slices.Compact(tips), nil
+}
+
+// NewCommits returns the non-merge commits reachable from tips but not
+// from seen, keeping only those whose author matches authors (nil keeps
+// all)
+func (c *Client) NewCommits(ctx context.Context, repo domain.Repository, tips, seen []string, authors *util.Patterns) ([]domain.Commit, error) {
+	revs := append([]string(nil), tips...)
+	if len(seen) > 0 {
+		revs = append(append(revs, "--not"), seen...)
+	}
+	commits, err := c.GetCommitsInRange(ctx, repo, revs...)
+	if err != nil {
+		return nil, err
+	}
+	return filterAuthors(commits, authors), nil
+}
+
+// branch is a local or remote-tracking branch
+type branch struct {
+	ref   string // Full ref, e.g. refs/remotes/origin/main
+	short string // Ref as git shows it, e.g. origin/main
+	name  string // Branch name without the remote, e.g. main
+}
+
+// branches lists the local and remote-tracking branches of a repository
+func (c *Client) branches(ctx context.Context, repoPath string) ([]branch, error) {
+	cmd := exec.CommandContext(ctx, "git", "for-each-ref", "--format=%(refname)", "refs/heads", "refs/remotes")
+	cmd.Dir = repoPath
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing branches: %w", err)
+	}
+
+	var branches []branch
+	for _, ref := range strings.Fields(string(output)) {
+		if short, ok := strings.CutPrefix(ref, "refs/heads/"); ok {
+			branches = append(branches, branch{ref: ref, short: short, name: short})
+			continue
+		}
+		short := strings.TrimPrefix(ref, "refs/remotes/")
+		_, name, ok := strings.Cut(short, "/")
+		if !ok || name == "HEAD" {
+			continue
+		}
+		branches = append(branches, branch{ref: ref, short: short, name: name})
+	}
+	return branches, nil
+}
+
+// DefaultBranch returns the name of a repository's default branch: the
+// branch origin/HEAD points at, else main or master
+func (c *Client) DefaultBranch(ctx context.Context, repoPath string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD")
+	cmd.Dir = repoPath
+	if output, err := cmd.Output(); err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(string(output)), "origin/"), nil
+	}
+
+	for _, name := range []string{"main", "master"} {
+		cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", "refs/heads/"+name)
+		cmd.Dir = repoPath
+		if cmd.Run() == nil {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no default branch: origin/HEAD is not set (run git remote set-head origin --auto) and there is no main or master branch")
+}
+
+// GetCommitsInRange returns the non-merge commits selected by git log
+// revision arguments such as "origin/main..HEAD" or "abc123 --not --remotes"
+func (c *Client) GetCommitsInRange(ctx context.Context, repo domain.Repository, revs ...string) ([]domain.Commit, error) {
+	args := []string{"log", "--no-merges", "--format=" + commitFormat}
+	args = append(args, revs...)
+	args = append(args, "--")
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repo.Path
+
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git log %s failed: %s", strings.Join(revs, " "), strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git log failed: %w", err)
+	}
+
+	commits, err := c.parseCommits(output, repo)
+	if err == nil {
+		c.logger.Debug("read commits", "repo", repo.Name, "revisions", strings.Join(revs, " "), "commits", len(commits))
+	}
+	return commits, err
+}
+
+// operationMarkers maps the files git keeps in the git directory during an
+// unfinished operation to the operation's name
+var operationMarkers = []struct {
+	path      string
+	operation string
+}{
+	{"rebase-merge", "rebase"},
+	{"rebase-apply", "rebase"},
+	{"MERGE_HEAD", "merge"},
+	{"CHERRY_PICK_HEAD", "cherry-pick"},
+	{"REVERT_HEAD", "revert"},
+	{"BISECT_LOG", "bisect"},
+}
+
+// OperationInProgress returns the name of the rebase, merge, cherry-pick,
+// revert or bisect in progress in the repository, or an empty string
+func (c *Client) OperationInProgress(ctx context.Context, repoPath string) string {
+	args := []string{"rev-parse"}
+	for _, m := range operationMarkers {
+		args = append(args, "--git-path", m.path)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = repoPath
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	paths := strings.Split(strings.TrimSpace(string(output)), "\n")
+	for i, path := range paths {
+		if i >= len(operationMarkers) {
+			break
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(repoPath, path)
+		}
+		if _, err := os.Stat(path); err == nil {
+			return operationMarkers[i].operation
+		}
+	}
+	return ""
+}
+
+// TopLevel returns the root of the working tree containing dir
+func (c *Client) TopLevel(ctx context.Context, dir string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--show-toplevel")
+	cmd.Dir = dir
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not inside a git repository", dir)
+	}
+	return strings.TrimSpace(string(output)), nil
 }
 
-func (c *Client) parseCommits(output []byte, repoPath string) ([]domain.Commit, error) {
+func (c *Client) parseCommits(output []byte, repo domain.Repository) ([]domain.Commit, error) {
 	var commits []domain.Commit
-	repoName := scanner.GetRepoName(repoPath)
 
 	s := bufio.NewScanner(bytes.NewReader(output))
 	for s.Scan() {
@@ -82,14 +324,14 @@ func (c *Client) parseCommits(output []byte, repoPath string) ([]domain.Commit,
 			continue
 		}
 
-		parts := strings.SplitN(line, "|", 5)
-		if len(parts) < 5 {
+		parts := strings.SplitN(line, "|", 6)
+		if len(parts) < 6 {
 			continue
 		}
 
 		timestamp, err := time.Parse(time.RFC3339, parts[3])
 		if err != nil {
-			c.logger.Printf("Warning: failed to parse timestamp %s: %v", parts[3], err)
+			c.logger.Warn("unparsable commit timestamp", "repo", repo.Name, "timestamp", parts[3], "err", err)
 			continue
 		}
 
@@ -98,15 +340,157 @@ func (c *Client) parseCommits(output []byte, repoPath string) ([]domain.Commit,
 			Author:    parts[1],
 			Email:     parts[2],
 			Timestamp: timestamp,
-			Message:   parts[4],
-			RepoPath:  repoPath,
-			RepoName:  repoName,
+			Message:   parts[5],
+			RepoPath:  repo.Path,
+			RepoName:  repo.Name,
+			Merge:     len(strings.Fields(parts[4])) > 1,
 		})
 	}
 
 	return commits, s.Err()
 }
 
+// LastCommitTime returns the author date of the most recent commit on any
+// ref, or the zero time for a repository without commits
+func (c *Client) LastCommitTime(ctx context.Context, repo domain.Repository) (time.Time, error) {
+	cmd := exec.CommandContext(ctx, "git", "log", "-1", "--all", "--format=%aI")
+	cmd.Dir = repo.Path
+
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if strings.Contains(string(exitErr.Stderr), "does not have any commits") {
+				return time.Time{}, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("git log failed: %w", err)
+	}
+
+	stamp := strings.TrimSpace(string(output))
+	if stamp == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, stamp)
+}
+
+// RemoteURL returns the URL of the origin remote, or an empty string when
+// the repository has none
+func (c *Client) RemoteURL(ctx context.Context, repoPath string) string {
+	cmd := exec.CommandContext(ctx, "git", "config", "--get", "remote.origin.url")
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// PatchIDs returns the stable patch ID of each of the commits by hash,
+// which stays the same when a commit is rebased or cherry-picked.
+// Commits without changes have none.
+func (c *Client) PatchIDs(ctx context.Context, repoPath string, hashes []string) (map[string]string, error) {
+	ids := make(map[string]string, len(hashes))
+	if len(hashes) == 0 {
+		return ids, nil
+	}
+
+	show := exec.CommandContext(ctx, "git", append([]string{"show", "--no-color", "--patch", "--format=commit %H"}, hashes...)...)
+	show.Dir = repoPath
+	patches, err := show.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git show failed: %w", err)
+	}
+
+	patchID := exec.CommandContext(ctx, "git", "patch-id", "--stable")
+	patchID.Dir = repoPath
+	patchID.Stdin = bytes.NewReader(patches)
+	output, err := patchID.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git patch-id failed: %w", err)
+	}
+
+	// Format: "<patch id> <commit hash>"
+	s := bufio.NewScanner(bytes.NewReader(output))
+	for s.Scan() {
+		if id, hash, ok := strings.Cut(s.Text(), " "); ok {
+			ids[hash] = id
+		}
+	}
+	return ids, s.Err()
+}
+
+// DeletedFiles returns the files left deleted by the commits by hash,
+// given oldest first, mapped to the hash of the commit deleting them. A
+// file changed again by a later commit is not deleted; renames move code
+// rather than remove it and don't count.
+func (c *Client) DeletedFiles(ctx context.Context, repoPath string, hashes []string) (map[string]string, error) {
+	deleted := make(map[string]string)
+	err := c.nameStatus(ctx, repoPath, hashes, func(hash, status string, paths []string) {
+		path := paths[len(paths)-1]
+		if status == "D" {
+			deleted[path] = hash
+		} else {
+			delete(deleted, path)
+		}
+	})
+	return deleted, err
+}
+
+// Renames returns the files the commits by hash, given oldest first, moved,
+// mapped from their final to their first path. A file moved twice maps to
+// where it started.
+func (c *Client) Renames(ctx context.Context, repoPath string, hashes []string) (map[string]string, error) {
+	renamed := make(map[string]string)
+	err := c.nameStatus(ctx, repoPath, hashes, func(hash, status string, paths []string) {
+		if !strings.HasPrefix(status, "R") || len(paths) < 2 {
+			return
+		}
+		from, to := paths[0], paths[1]
+		if origin, ok := renamed[from]; ok {
+			from = origin
+			delete(renamed, paths[0])
+		}
+		if from != to {
+			renamed[to] = from
+		}
+	})
+	return renamed, err
+}
+
+// nameStatus calls fn with each file change of the commits by hash, in the
+// order given, with rename detection on
+func (c *Client) nameStatus(ctx context.Context, repoPath string, hashes []string, fn func(hash, status string, paths []string)) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"show", "--no-color", "--name-status", "-M", "--format=commit %H"}, hashes...)...)
+	cmd.Dir = repoPath
+	output, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("git show failed: %w", err)
+	}
+
+	// git show lists the commits in the order given
+	var hash string
+	s := bufio.NewScanner(bytes.NewReader(output))
+	for s.Scan() {
+		line := s.Text()
+		if h, ok := strings.CutPrefix(line, "commit "); ok {
+			hash = h
+			continue
+		}
+		// Format: "D\tfilename", "R100\told\tnew" etc.
+		parts := strings.Split(line, "\t")
+		if len(parts) < 2 {
+			continue
+		}
+		fn(hash, parts[0], parts[1:])
+	}
+	return s.Err()
+}
+
 // GetDiff returns the diff for a specific commit
 func (c *Client) GetDiff(ctx context.Context, repoPath, commitHash string) (string, error) {
 	cmd := exec.CommandContext(ctx, "git", "show",
@@ -171,6 +555,62 @@ func (c *Client) GetFileDiff(ctx context.Context, repoPath, commitHash, filePath
 	return string(output), nil
 }
 
+// FileChanges returns the commits touching filePath between since and
+// until, newest first, with their patches, or "" if there are none
+func (c *Client) FileChanges(ctx context.Context, repoPath, filePath string, since, until time.Time) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "log",
+		"--patch",
+		"--no-color",
+		"--since="+since.Format(time.RFC3339),
+		"--until="+until.Format(time.RFC3339),
+		"--",
+		filePath,
+	)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git log for file failed: %w", err)
+	}
+
+	return string(output), nil
+}
+
+// MinVersion is the oldest git release CRA is tested against
+const MinVersion = "2.20"
+
+// Version returns the installed git version (e.g. "2.43.0")
+func (c *Client) Version(ctx context.Context) (string, error) {
+	output, err := exec.CommandContext(ctx, "git", "--version").Output()
+	if err != nil {
+		return "", fmt.Errorf("running git --version: %w", err)
+	}
+
+	// Format: "git version 2.43.0" (optionally followed by a vendor suffix)
+	fields := strings.Fields(string(output))
+	if len(fields) < 3 {
+		return "", fmt.Errorf("unexpected git --version output: %q", output)
+	}
+	return fields[2], nil
+}
+
+// VersionAtLeast reports whether the dotted version v is >= min
+func VersionAtLeast(v, min string) bool {
+	have := strings.Split(v, ".")
+	want := strings.Split(min, ".")
+	for i := range want {
+		var h, w int
+		if i < len(have) {
+			fmt.Sscanf(have[i], "%d", &h)
+		}
+		fmt.Sscanf(want[i], "%d", &w)
+		if h != w {
+			return h > w
+		}
+	}
+	return true
+}
+
 // IsValidRepo checks if a path is a valid Git repository
 func IsValidRepo(path string) bool {
 	gitDir := filepath.Join(path, ".git")
@@ -0,0 +1,89 @@
+package git
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitDateLayout is the timestamp format passed to git log --since/--until;
+// the offset keeps days in the configured timezone whatever TZ git runs in
+const gitDateLayout = "2006-01-02T15:04:05-07:00"
+
+// ParseSince converts a review window start to a git date. Empty and
+// "today" mean midnight today, "yesterday" midnight yesterday, durations
+// ("24h", "3d", "2w") count back from now and YYYY-MM-DD is that day's
+// midnight. Anything else is passed to git as is.
+func ParseSince(since string, now time.Time) string {
+	switch since {
+	case "", "today":
+		return midnight(now).Format(gitDateLayout)
+	case "yesterday":
+		return midnight(now).AddDate(0, 0, -1).Format(gitDateLayout)
+	}
+	if d, ok := parseDuration(since); ok {
+		return now.Add(-d).Format(gitDateLayout)
+	}
+	if day, err := time.ParseInLocation("2006-01-02", since, now.Location()); err == nil {
+		return day.Format(gitDateLayout)
+	}
+	return since
+}
+
+// SinceTime returns the time a window start names, false when it is left
+// to git to interpret
+func SinceTime(since string, now time.Time) (time.Time, bool) {
+	t, err := time.ParseInLocation(gitDateLayout, ParseSince(since, now), now.Location())
+	return t, err == nil
+}
+
+// FormatSince formats t as a window start, which ParseSince passes on
+func FormatSince(t time.Time) string {
+	return t.Format(gitDateLayout)
+}
+
+// ParseUntil converts a review window end to a git date. Empty means no
+// end, YYYY-MM-DD includes that whole day, "yesterday" ends at midnight
+// today and durations count back from now. Anything else is passed to git.
+func ParseUntil(until string, now time.Time) string {
+	if until == "" {
+		return ""
+	}
+	if until == "yesterday" {
+		return midnight(now).Add(-time.Second).Format(gitDateLayout)
+	}
+	if d, ok := parseDuration(until); ok {
+		return now.Add(-d).Format(gitDateLayout)
+	}
+	if day, err := time.ParseInLocation("2006-01-02", until, now.Location()); err == nil {
+		return day.AddDate(0, 0, 1).Add(-time.Second).Format(gitDateLayout)
+	}
+	return until
+}
+
+// UntilDate returns the last day of the window for dated reports when
+// until names a day, so rerunning a past day files the report under it
+func UntilDate(until string, now time.Time) (time.Time, bool) {
+	if until == "yesterday" {
+		return midnight(now).AddDate(0, 0, -1), true
+	}
+	day, err := time.ParseInLocation("2006-01-02", until, now.Location())
+	return day, err == nil
+}
+
+// parseDuration accepts Go durations plus day ("3d") and week ("2w") units
+func parseDuration(s string) (time.Duration, bool) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, true
+	}
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(s, suffix)); err == nil && strings.HasSuffix(s, suffix) && n >= 0 {
+			return time.Duration(n) * unit, true
+		}
+	}
+	return 0, false
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
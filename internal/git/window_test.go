@@ -0,0 +1,53 @@
+package git
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	loc := time.FixedZone("CST", -6*3600)
+	now := time.Date(2024, 3, 15, 14, 30, 0, 0, loc)
+
+	tests := []struct {
+		since string
+		want  string
+	}{
+		{"", "2024-03-15T00:00:00-06:00"},
+		{"today", "2024-03-15T00:00:00-06:00"},
+		{"yesterday", "2024-03-14T00:00:00-06:00"},
+		{"24h", "2024-03-14T14:30:00-06:00"},
+		{"90m", "2024-03-15T13:00:00-06:00"},
+		{"3d", "2024-03-12T14:30:00-06:00"},
+		{"2w", "2024-03-01T14:30:00-06:00"},
+		{"2024-03-01", "2024-03-01T00:00:00-06:00"},
+		{"last monday", "last monday"},
+		{"-3d", "-3d"},
+	}
+	for _, tt := range tests {
+		if got := ParseSince(tt.since, now); got != tt.want {
+			t.Errorf("ParseSince(%q) = %q, want %q", tt.since, got, tt.want)
+		}
+	}
+}
+
+func TestParseUntil(t *testing.T) {
+	loc := time.FixedZone("CST", -6*3600)
+	now := time.Date(2024, 3, 15, 14, 30, 0, 0, loc)
+
+	tests := []struct {
+		until string
+		want  string
+	}{
+		{"", ""},
+		{"yesterday", "2024-03-14T23:59:59-06:00"},
+		{"2h", "2024-03-15T12:30:00-06:00"},
+		{"2024-03-01", "2024-03-01T23:59:59-06:00"},
+		{"last monday", "last monday"},
+	}
+	for _, tt := range tests {
+		if got := ParseUntil(tt.until, now); got != tt.want {
+			t.Errorf("ParseUntil(%q) = %q, want %q", tt.until, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// Package heuristics reviews diffs with built-in static checks instead of
+// an LLM, for air-gapped machines or when the API quota is used up.
+package heuristics
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// Model is recorded as the report's model for heuristic reviews
+const Model = "heuristics"
+
+// line is an added line and its number in the new file
+type line struct {
+	number int
+	text   string
+}
+
+// rule flags added lines of a file
+type rule struct {
+	title    string
+	severity domain.Severity
+	category domain.Category
+	// languages limits the rule to these diff languages; empty means all
+	languages   []string
+	explanation string
+	action      string
+	// match returns the numbers of the offending lines
+	match func(lines []line) []int
+}
+
+var rules = []rule{
+	{
+		title:       "Hardcoded credential",
+		severity:    domain.SeverityHigh,
+		category:    domain.CategorySecurity,
+		explanation: "A secret appears to be committed in the source. Anyone with access to the repository, or its history, can use it.",
+		action:      "Remove the secret, rotate it, and load it from the environment or a secret manager.",
+		match:       matchEach(isCredential),
+	},
+	{
+		title:       "Possible nil dereference",
+		severity:    domain.SeverityMedium,
+		category:    domain.CategoryBugs,
+		languages:   []string{"go"},
+		explanation: "A value is used inside the block that runs when it is nil, which panics at run time.",
+		action:      "Return or assign before using the value in the nil branch, or fix the condition.",
+		match:       matchNilDeref,
+	},
+	{
+		title:       "Error wrapped without %w",
+		severity:    domain.SeverityLow,
+		category:    domain.CategoryMaintainability,
+		languages:   []string{"go"},
+		explanation: "fmt.Errorf formats an error with %v or %s, so callers can't match the cause with errors.Is or errors.As.",
+		action:      "Use %w for the error argument.",
+		match:       matchEach(isUnwrappedError),
+	},
+	{
+		title:       "TODO left in new code",
+		severity:    domain.SeverityLow,
+		category:    domain.CategoryMaintainability,
+		explanation: "New code carries a TODO/FIXME marker, which usually means unfinished work is being shipped.",
+		action:      "Finish the work, or track it in an issue and reference it in the comment.",
+		match:       matchEach(isTodo),
+	},
+}
+
+// Review runs every rule over the added lines of diffs and returns one
+// finding per rule and file
+func Review(diffs []domain.Diff) []domain.Finding {
+	var findings []domain.Finding
+	for _, d := range diffs {
+		lines := addedLines(d.Content)
+		if len(lines) == 0 {
+			continue
+		}
+		for _, r := range rules {
+			if !r.applies(d.Language) {
+				continue
+			}
+			if numbers := r.match(lines); len(numbers) > 0 {
+				findings = append(findings, domain.Finding{
+					Title:       r.title,
+					Severity:    r.severity,
+					Category:    r.category,
+					RepoName:    d.RepoName,
+					Files:       []string{d.FilePath},
+					Explanation: fmt.Sprintf("%s (%s)", r.explanation, lineList(numbers)),
+					Action:      r.action,
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// Summary describes a heuristic review for the report
+func Summary(diffs []domain.Diff, findings []domain.Finding) string {
+	return fmt.Sprintf("Heuristic review without an LLM of %d file changes: %d findings. Only built-in checks ran (credentials, nil dereferences, error wrapping, TODOs); logic and design issues were not reviewed.",
+		len(diffs), len(findings))
+}
+
+func (r rule) applies(language string) bool {
+	if len(r.languages) == 0 {
+		return true
+	}
+	for _, l := range r.languages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// addedLines returns the added lines of a unified or combined diff with
+// their line numbers in the new file
+func addedLines(content string) []line {
+	var lines []line
+	number := 0
+	// Combined diffs of merges mark each line in one column per parent
+	width := 1
+	for _, text := range strings.Split(content, "\n") {
+		switch {
+		case strings.HasPrefix(text, "@@"):
+			number = hunkStart(text)
+			width = len(text) - len(strings.TrimLeft(text, "@")) - 1
+		case strings.HasPrefix(text, "+++"), strings.HasPrefix(text, "---"):
+		case len(text) < width:
+		default:
+			marks := text[:width]
+			switch {
+			case strings.Contains(marks, "-"):
+				// Removed from a parent, not part of the result
+			case strings.Contains(marks, "+"):
+				lines = append(lines, line{number: number, text: text[width:]})
+				number++
+			case strings.TrimLeft(marks, " ") == "":
+				number++
+			}
+		}
+	}
+	return lines
+}
+
+var hunkHeader = regexp.MustCompile(`^@@+ (?:-\d+(?:,\d+)? )+\+(\d+)`)
+
+// hunkStart returns the first new-file line number of a hunk header,
+// plain or combined
+func hunkStart(header string) int {
+	m := hunkHeader.FindStringSubmatch(header)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+// matchEach flags every line for which fn is true
+func matchEach(fn func(string) bool) func([]line) []int {
+	return func(lines []line) []int {
+		var numbers []int
+		for _, l := range lines {
+			if fn(l.text) {
+				numbers = append(numbers, l.number)
+			}
+		}
+		return numbers
+	}
+}
+
+var (
+	todoPattern       = regexp.MustCompile(`(//|#|/\*|--|\*)\s*(TODO|FIXME|XXX|HACK)\b`)
+	credentialPattern = regexp.MustCompile(`(?i)\b\w*(password|passwd|secret|api_?key|access_?key|token)\w*["']?\s*(:=|=|:)\s*["']([^"'\s]{8,})["']`)
+	awsKeyPattern     = regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`)
+	privateKeyPattern = regexp.MustCompile(`-----BEGIN ([A-Z]+ )?PRIVATE KEY-----`)
+	errorfPattern     = regexp.MustCompile(`fmt\.Errorf\("([^"\\]|\\.)*%[vs]([^"\\]|\\.)*",.*\berr\b`)
+	nilCheckPattern   = regexp.MustCompile(`^\s*if\s+([A-Za-z_]\w*(?:\.\w+)*)\s*==\s*nil\s*\{\s*$`)
+)
+
+func isTodo(text string) bool {
+	return todoPattern.MatchString(text)
+}
+
+// placeholders are credential values that are clearly not real secrets
+var placeholders = []string{"example", "changeme", "placeholder", "your", "xxxx", "<", "${", "{{", "dummy", "redacted"}
+
+func isCredential(text string) bool {
+	if awsKeyPattern.MatchString(text) || privateKeyPattern.MatchString(text) {
+		return true
+	}
+	m := credentialPattern.FindStringSubmatch(text)
+	if m == nil {
+		return false
+	}
+	value := strings.ToLower(m[3])
+	for _, p := range placeholders {
+		if strings.Contains(value, p) {
+			return false
+		}
+	}
+	return true
+}
+
+func isUnwrappedError(text string) bool {
+	return errorfPattern.MatchString(text) && !strings.Contains(text, "%w")
+}
+
+// matchNilDeref flags uses of x.field or x.method inside an added
+// "if x == nil {" block before the block returns or assigns x
+func matchNilDeref(lines []line) []int {
+	var numbers []int
+	for i, l := range lines {
+		m := nilCheckPattern.FindStringSubmatch(l.text)
+		if m == nil {
+			continue
+		}
+		name := regexp.QuoteMeta(m[1])
+		use := regexp.MustCompile(`(^|[^\w.])` + name + `\.\w`)
+		assign := regexp.MustCompile(`(^|[^\w.])` + name + `\s*(=|:=)[^=]`)
+
+		prev := l.number
+		for _, body := range lines[i+1:] {
+			// Unchanged lines in between: the rest of the block isn't new
+			if body.number != prev+1 {
+				break
+			}
+			prev = body.number
+
+			if assign.MatchString(body.text) {
+				break
+			}
+			if use.MatchString(body.text) {
+				numbers = append(numbers, body.number)
+				break
+			}
+			text := strings.TrimSpace(body.text)
+			if text == "}" || strings.HasPrefix(text, "} else") || strings.HasPrefix(text, "return") {
+				break
+			}
+		}
+	}
+	return numbers
+}
+
+// lineList renders line numbers, e.g. "lines 3, 10"
+func lineList(numbers []int) string {
+	sort.Ints(numbers)
+	parts := make([]string, len(numbers))
+	for i, n := range numbers {
+		parts[i] = strconv.Itoa(n)
+	}
+	if len(parts) == 1 {
+		return "line " + parts[0]
+	}
+	return "lines " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,155 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// marker identifies hooks written by CRA so they can be updated and removed
+const marker = "# cra-hook: managed by `review install-hook`"
+
+// backupSuffix is appended to a pre-existing hook that CRA chains to
+const backupSuffix = ".pre-cra"
+
+// Supported lists the hook types that can be installed
+var Supported = []string{"pre-push", "pre-commit"}
+
+// Options configures an installed hook
+type Options struct {
+	Hook    string // pre-push or pre-commit
+	FailOn  string // Severity gate passed as --fail-on
+	Command string // Path to the review binary
+}
+
+// Install writes the hook into the repository containing dir. An existing
+// hook not written by CRA is kept as <hook>.pre-cra and run first. It
+// returns the path of the installed hook.
+func Install(ctx context.Context, dir string, opts Options) (string, error) {
+	path, err := hookPath(ctx, dir, opts.Hook)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("creating hooks directory: %w", err)
+	}
+
+	if existing, err := os.ReadFile(path); err == nil && !bytes.Contains(existing, []byte(marker)) {
+		backup := path + backupSuffix
+		if _, err := os.Stat(backup); err == nil {
+			return "", fmt.Errorf("%s exists and %s is already taken; resolve manually", path, filepath.Base(backup))
+		}
+		if err := os.Rename(path, backup); err != nil {
+			return "", fmt.Errorf("preserving existing hook: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(script(opts)), 0755); err != nil {
+		return "", fmt.Errorf("writing hook: %w", err)
+	}
+	return path, nil
+}
+
+// Uninstall removes a CRA hook from the repository containing dir and
+// restores the hook it replaced, if any
+func Uninstall(ctx context.Context, dir, hook string) (string, error) {
+	path, err := hookPath(ctx, dir, hook)
+	if err != nil {
+		return "", err
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("no %s hook installed", hook)
+		}
+		return "", err
+	}
+	if !bytes.Contains(existing, []byte(marker)) {
+		return "", fmt.Errorf("%s was not installed by CRA, leaving it alone", path)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path + backupSuffix); err == nil {
+		if err := os.Rename(path+backupSuffix, path); err != nil {
+			return "", fmt.Errorf("restoring previous hook: %w", err)
+		}
+	}
+	return path, nil
+}
+
+// hookPath resolves the hook file, honouring core.hooksPath
+func hookPath(ctx context.Context, dir, hook string) (string, error) {
+	valid := false
+	for _, h := range Supported {
+		valid = valid || h == hook
+	}
+	if !valid {
+		return "", fmt.Errorf("unsupported hook %q, use one of: %s", hook, strings.Join(Supported, ", "))
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--git-path", "hooks")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s is not inside a git repository", dir)
+	}
+
+	hooksDir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(dir, hooksDir)
+	}
+	return filepath.Join(hooksDir, hook), nil
+}
+
+// script renders the shell hook
+func script(opts Options) string {
+	var sb strings.Builder
+	sb.WriteString("#!/bin/sh\n")
+	sb.WriteString(marker + "\n")
+	sb.WriteString(fmt.Sprintf("REVIEW=%s\n", shellQuote(opts.Command)))
+	sb.WriteString(fmt.Sprintf("FAIL_ON=%s\n", shellQuote(opts.FailOn)))
+	sb.WriteString(fmt.Sprintf("PREVIOUS=\"$(dirname \"$0\")/%s%s\"\n\n", opts.Hook, backupSuffix))
+
+	switch opts.Hook {
+	case "pre-commit":
+		sb.WriteString(`if [ -x "$PREVIOUS" ]; then
+	"$PREVIOUS" "$@" || exit $?
+fi
+
+exec "$REVIEW" staged --fail-on "$FAIL_ON"
+`)
+	case "pre-push":
+		// git passes "<local ref> <local sha> <remote ref> <remote sha>"
+		// lines on stdin; the previous hook needs them too
+		sb.WriteString(`INPUT=$(cat)
+
+if [ -x "$PREVIOUS" ]; then
+	printf '%s\n' "$INPUT" | "$PREVIOUS" "$@" || exit $?
+fi
+
+ZERO=0000000000000000000000000000000000000000
+printf '%s\n' "$INPUT" | while read -r local_ref local_sha remote_ref remote_sha; do
+	[ -z "$local_sha" ] && continue
+	[ "$local_sha" = "$ZERO" ] && continue
+	if [ "$remote_sha" = "$ZERO" ]; then
+		# New branch: review commits not on any remote yet
+		"$REVIEW" range --fail-on "$FAIL_ON" -- "$local_sha" --not --remotes || exit 1
+	else
+		"$REVIEW" range --fail-on "$FAIL_ON" -- "$remote_sha..$local_sha" || exit 1
+	fi
+done
+`)
+	}
+	return sb.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
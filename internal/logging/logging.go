@@ -0,0 +1,78 @@
+// Package logging builds the structured logger shared by the runner, git
+// client, reviewer and notifier.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/config"
+)
+
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Levels lists the accepted log levels, most verbose first
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// ParseLevel parses a level name case-insensitively
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("invalid log level %q, use one of: %s", name, strings.Join(Levels, ", "))
+}
+
+// New builds a logger writing cfg.Format records at cfg.Level or above to
+// cfg.File, appending, or to w when no file is set. The returned closer
+// closes the file, if any.
+func New(cfg config.LogConfig, w io.Writer) (*slog.Logger, io.Closer, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var closer io.Closer = io.NopCloser(nil)
+	if cfg.File != "" {
+		if err := os.MkdirAll(filepath.Dir(cfg.File), 0755); err != nil {
+			return nil, nil, fmt.Errorf("creating log directory: %w", err)
+		}
+		file, err := os.OpenFile(cfg.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("opening log file: %w", err)
+		}
+		w, closer = file, file
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(cfg.Format) {
+	case "", FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), closer, nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), closer, nil
+	}
+	closer.Close()
+	return nil, nil, fmt.Errorf("invalid log format %q, use %s or %s", cfg.Format, FormatText, FormatJSON)
+}
+
+// OrDefault returns logger, or the process-wide default when it is nil
+func OrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
@@ -0,0 +1,181 @@
+// Package netproxy sends outgoing connections through the HTTP or SOCKS5
+// proxy of the proxy config, falling back to $HTTPS_PROXY, $HTTP_PROXY and
+// $NO_PROXY, so corporate networks without direct internet access can
+// reach the LLM providers and the SMTP server.
+package netproxy
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/proxy"
+)
+
+var (
+	mu        sync.RWMutex
+	proxyFunc = httpproxy.FromEnvironment().ProxyFunc()
+	smtp      bool // Whether Dial tunnels through the proxy
+)
+
+// Configure routes the HTTP clients built on http.DefaultTransport, which
+// the LLM providers, policy and forge clients use, through cfg's proxy,
+// and SMTP connections made with Dial when cfg.SMTP is set. It can be
+// called again when the config is reloaded.
+func Configure(cfg config.ProxyConfig) error {
+	u, err := cfg.ParseURL()
+	if err != nil {
+		return err
+	}
+	settings := httpproxy.FromEnvironment()
+	if u != nil {
+		settings.HTTPProxy, settings.HTTPSProxy = u.String(), u.String()
+	}
+	if cfg.NoProxy != "" {
+		settings.NoProxy = cfg.NoProxy
+	}
+
+	mu.Lock()
+	proxyFunc = settings.ProxyFunc()
+	smtp = cfg.SMTP
+	mu.Unlock()
+
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFor(req.URL)
+		}
+	}
+	return nil
+}
+
+// proxyFor returns the proxy for target, nil to connect directly
+func proxyFor(target *url.URL) (*url.URL, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return proxyFunc(target)
+}
+
+// Dial connects to addr (host:port) over TCP, through the proxy when SMTP
+// tunneling is configured and addr isn't in no_proxy
+func Dial(addr string, timeout time.Duration) (net.Conn, error) {
+	mu.RLock()
+	tunnel := smtp
+	mu.RUnlock()
+
+	var p *url.URL
+	if tunnel {
+		var err error
+		// The https proxy applies: the tunnel carries arbitrary TCP
+		if p, err = proxyFor(&url.URL{Scheme: "https", Host: addr}); err != nil {
+			return nil, fmt.Errorf("selecting proxy: %w", err)
+		}
+	}
+	if p == nil {
+		return net.DialTimeout("tcp", addr, timeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	switch p.Scheme {
+	case "socks5", "socks5h":
+		return dialSOCKS5(ctx, p, addr, timeout)
+	default:
+		return dialConnect(ctx, p, addr, timeout)
+	}
+}
+
+// dialSOCKS5 connects to addr through the SOCKS5 proxy p
+func dialSOCKS5(ctx context.Context, p *url.URL, addr string, timeout time.Duration) (net.Conn, error) {
+	var auth *proxy.Auth
+	if p.User != nil {
+		password, _ := p.User.Password()
+		auth = &proxy.Auth{User: p.User.Username(), Password: password}
+	}
+	dialer, err := proxy.SOCKS5("tcp", hostPort(p), auth, &net.Dialer{Timeout: timeout})
+	if err != nil {
+		return nil, fmt.Errorf("SOCKS5 proxy %s: %w", p.Redacted(), err)
+	}
+	var conn net.Conn
+	if cd, ok := dialer.(proxy.ContextDialer); ok {
+		conn, err = cd.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("SOCKS5 proxy %s: %w", p.Redacted(), err)
+	}
+	return conn, nil
+}
+
+// dialConnect opens a tunnel to addr with CONNECT through the HTTP proxy p
+func dialConnect(ctx context.Context, p *url.URL, addr string, timeout time.Duration) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", hostPort(p))
+	if err != nil {
+		return nil, fmt.Errorf("HTTP proxy %s: %w", p.Redacted(), err)
+	}
+	if p.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: p.Hostname()})
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if p.User != nil {
+		password, _ := p.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(p.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("HTTP proxy %s: %w", p.Redacted(), err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("HTTP proxy %s: %w", p.Redacted(), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("HTTP proxy %s refused the tunnel to %s: %s", p.Redacted(), addr, resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	// The server may have spoken already; keep what was buffered
+	return &bufferedConn{Conn: conn, r: br}, nil
+}
+
+// hostPort returns the proxy's address, with the scheme's default port
+// when it names none
+func hostPort(p *url.URL) string {
+	if p.Port() != "" {
+		return p.Host
+	}
+	port := map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}[p.Scheme]
+	return net.JoinHostPort(p.Hostname(), port)
+}
+
+// bufferedConn reads through the reader that parsed the CONNECT response
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
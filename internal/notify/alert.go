@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/mail"
+	"slices"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+var alertTmpl = template.Must(template.New("alert").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; max-width: 720px;">
+<div style="background: #fef2f2; border-left: 4px solid #b91c1c; padding: 12px 16px;">
+<h2 style="margin: 0 0 8px; color: #b91c1c;">Alert: {{.Alert.Rule}}</h2>
+<p style="margin: 0;">{{.Alert.Message}}</p>
+</div>
+{{if .Alert.Findings}}
+<h3>{{.Label}} findings of this run</h3>
+<ul>
+{{range .Alert.Findings}}<li><strong>{{.Title}}</strong> &mdash; {{.RepoName}}{{if .Files}} ({{range $i, $f := .Files}}{{if $i}}, {{end}}{{$f}}{{end}}){{end}}</li>
+{{end}}</ul>
+{{end}}
+<p style="color: #6b7280;">Sent by the alerts rules of the Code Review Agent configuration, separately from the report.</p>
+</body>
+</html>`))
+
+// SendAlert emails a fired alert to to or, when empty, to every recipient
+// of the report. Alerts use their own subject and the From header of a
+// High report, so mail rules can tell them apart.
+func (s *Service) SendAlert(ctx context.Context, alert *domain.Alert, to []string) error {
+	if len(to) == 0 {
+		for _, route := range s.config.ResolveRoutes() {
+			for _, addr := range route.To {
+				if !slices.Contains(to, addr) {
+					to = append(to, addr)
+				}
+			}
+		}
+	}
+
+	label := string(alert.Severity)
+	if s.formatter != nil {
+		label = s.formatter.Label(alert.Severity)
+	}
+	var body bytes.Buffer
+	if err := alertTmpl.Execute(&body, struct {
+		Alert *domain.Alert
+		Label string
+	}{alert, label}); err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("[CRA] 🚨 Alert - %s - %s", alert.Date.Format("Jan 2"), alert.Message)
+	name, address := s.config.From(strings.ToLower(string(domain.SeverityHigh)))
+	from := (&mail.Address{Name: name, Address: address}).String()
+	if err := s.send(ctx, from, to, subject, body.String(), ""); err != nil {
+		return err
+	}
+	s.logger.Debug("alert delivered", "rule", alert.Rule, "to", to)
+	return nil
+}
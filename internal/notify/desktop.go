@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Desktop shows a native desktop notification with title and body:
+// through osascript on macOS, notify-send on Linux and the BSDs, and a
+// PowerShell balloon tip on Windows
+func Desktop(ctx context.Context, title, body string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))
+		cmd = exec.CommandContext(ctx, DesktopCommand(), "-e", script)
+	case "windows":
+		script := `Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(10000, $env:CRA_TITLE, $env:CRA_BODY, 'Info')
+Start-Sleep -Seconds 1
+$n.Dispose()`
+		cmd = exec.CommandContext(ctx, DesktopCommand(), "-NoProfile", "-NonInteractive", "-Command", script)
+		// Passed through the environment to avoid quoting them into the script
+		cmd.Env = append(cmd.Environ(), "CRA_TITLE="+title, "CRA_BODY="+body)
+	default:
+		cmd = exec.CommandContext(ctx, DesktopCommand(), "--app-name=Code Review Agent", title, body)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("desktop notification: %w: %s", err, msg)
+		}
+		return fmt.Errorf("desktop notification: %w", err)
+	}
+	return nil
+}
+
+// DesktopCommand names the program Desktop runs on this system
+func DesktopCommand() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "osascript"
+	case "windows":
+		return "powershell"
+	}
+	return "notify-send"
+}
+
+// appleScriptString quotes s as an AppleScript string literal
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -5,40 +5,145 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
-	"log"
+	"html"
+	"log/slog"
 	"net"
+	"net/mail"
 	"net/smtp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/juparave/codereviewer/internal/config"
 	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/logging"
+	"github.com/juparave/codereviewer/internal/netproxy"
+	"github.com/juparave/codereviewer/internal/prefs"
 	"github.com/juparave/codereviewer/internal/report"
+	"github.com/juparave/codereviewer/internal/state"
 )
 
 // Service handles email notifications
 type Service struct {
 	config    config.EmailConfig
-	logger    *log.Logger
+	logger    *slog.Logger
 	formatter *report.Formatter
 }
 
-// NewService creates a new notification Service
-func NewService(cfg config.EmailConfig, logger *log.Logger) (*Service, error) {
+// NewService creates a new notification Service. formatter renders the
+// email bodies and may be nil when only checking the connection.
+func NewService(cfg config.EmailConfig, formatter *report.Formatter, logger *slog.Logger) (*Service, error) {
 	return &Service{
 		config:    cfg,
-		logger:    logger,
-		formatter: report.NewFormatter(""),
+		logger:    logging.OrDefault(logger),
+		formatter: formatter,
 	}, nil
 }
 
-// SendReport sends the code review report via email
-func (s *Service) SendReport(ctx context.Context, rpt *domain.Report) error {
-	// Build email content
-	htmlBody := s.formatter.ToHTML(rpt)
+// SendReport emails each configured view of the report to its recipients.
+// previous is the prior report used for the manager view's trend, may be nil.
+// With email.manage_url, each recipient gets the report filtered by their
+// preferences, with a link to change them.
+func (s *Service) SendReport(ctx context.Context, rpt, previous *domain.Report) error {
+	var store *prefs.Store
+	if s.config.ManageURL != "" {
+		var err error
+		if store, err = prefs.Load(s.state(), s.config.PreferencesFile); err != nil {
+			return err
+		}
+	}
+
+	// Repositories newly emailed to a recipient, which their manage page
+	// then lists
+	sent := false
+	for _, route := range s.config.ResolveRoutes() {
+		view, ok := domain.ParseView(route.View)
+		if !ok {
+			return fmt.Errorf("unknown report view %q", route.View)
+		}
+		if store == nil {
+			if err := s.sendView(ctx, view, rpt, previous, route.To, "", ""); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, addr := range route.To {
+			p := store.For(addr)
+			if p.Unsubscribed {
+				s.logger.Debug("recipient unsubscribed, not sending", "view", view, "to", addr)
+				continue
+			}
+			token, created, err := store.Token(addr)
+			if err != nil {
+				return err
+			}
+			if created {
+				if err := store.Save(); err != nil {
+					return err
+				}
+			}
+			manage := prefs.Link(s.config.ManageURL, addr, token)
+			if err := s.sendView(ctx, view, prefs.Apply(rpt, p), previous, []string{addr}, manage, p.Describe()); err != nil {
+				return err
+			}
+			if store.RecordSent(addr, rpt.Repositories) {
+				sent = true
+			}
+		}
+	}
+	if sent {
+		return store.Save()
+	}
+	return nil
+}
+
+// sendView emails view of rpt to to, with a footer and List-Unsubscribe
+// header pointing at manage when set. filtered describes the recipient's
+// preferences applied to rpt.
+// state returns where recipient preferences live: with the reports, or
+// in their file when only checking the connection
+func (s *Service) state() state.Store {
+	if s.formatter == nil {
+		return state.Files
+	}
+	return s.formatter.State()
+}
+
+func (s *Service) sendView(ctx context.Context, view domain.View, rpt, previous *domain.Report, to []string, manage, filtered string) error {
 	subject := s.buildSubject(rpt)
+	var htmlBody string
+	switch view {
+	case domain.ViewManager:
+		subject = strings.Replace(subject, "Daily Review", "Daily Summary", 1)
+		htmlBody = s.formatter.ManagerHTML(rpt, previous)
+	default:
+		htmlBody = s.formatter.EmailHTML(rpt)
+	}
+	if manage != "" {
+		htmlBody = withManageLink(htmlBody, manage, filtered)
+	}
 
-	// Send email
-	return s.send(ctx, subject, htmlBody)
+	if err := s.send(ctx, s.from(rpt), to, subject, htmlBody, manage); err != nil {
+		return fmt.Errorf("%s view: %w", view, err)
+	}
+	s.logger.Debug("email delivered", "view", view, "to", to, "subject", subject, "bytes", len(htmlBody))
+	return nil
+}
+
+// withManageLink adds a footer linking to manage to the end of htmlBody,
+// stating the preferences already filtering it
+func withManageLink(htmlBody, manage, filtered string) string {
+	footer := fmt.Sprintf("<p style='color: #6b7280; font-size: 12px;'>Too noisy? <a href=\"%s\">Mute repositories, raise the minimum severity or unsubscribe</a>.</p>\n",
+		html.EscapeString(manage))
+	if filtered != "" {
+		footer = fmt.Sprintf("<p style='color: #6b7280; font-size: 12px;'>Filtered by your preferences: %s. <a href=\"%s\">Change them or unsubscribe</a>.</p>\n",
+			html.EscapeString(filtered), html.EscapeString(manage))
+	}
+	if i := strings.LastIndex(htmlBody, "</body>"); i >= 0 {
+		return htmlBody[:i] + footer + htmlBody[i:]
+	}
+	return htmlBody + footer
 }
 
 func (s *Service) buildSubject(rpt *domain.Report) string {
@@ -52,28 +157,42 @@ func (s *Service) buildSubject(rpt *domain.Report) string {
 	high := rpt.HighCount()
 
 	if high > 0 {
-		return fmt.Sprintf("[CRA] Daily Review - %s - ⚠️ %d findings (%d high)", date, findings, high)
+		return fmt.Sprintf("[CRA] Daily Review - %s - ⚠️ %d findings (%d %s)", date, findings, high,
+			strings.ToLower(s.formatter.Label(domain.SeverityHigh)))
 	}
 
 	return fmt.Sprintf("[CRA] Daily Review - %s - %d findings", date, findings)
 }
 
-func (s *Service) send(ctx context.Context, subject, htmlBody string) error {
-	addr := fmt.Sprintf("%s:%d", s.config.SMTPHost, s.config.SMTPPort)
+// from is the From header for rpt, which depends on its highest severity
+// when email.senders is set
+func (s *Service) from(rpt *domain.Report) string {
+	level := "clear"
+	if highest := rpt.HighestSeverity(); highest != "" {
+		level = strings.ToLower(string(highest))
+	}
+	name, address := s.config.From(level)
+	return (&mail.Address{Name: name, Address: address}).String()
+}
+
+// send emails htmlBody to to, with manage as its List-Unsubscribe link
+// when set
+func (s *Service) send(ctx context.Context, from string, to []string, subject, htmlBody, manage string) error {
+	addr := net.JoinHostPort(s.config.SMTPHost, strconv.Itoa(s.config.SMTPPort))
 
 	// Build message
-	message := s.buildMessage(subject, htmlBody)
+	message := s.buildMessage(from, to, subject, htmlBody, manage)
 
 	// Retry logic
 	var lastErr error
 	for attempt := 1; attempt <= 3; attempt++ {
-		err := s.sendWithTimeout(addr, message, 30*time.Second)
+		err := s.sendWithTimeout(addr, to, message, 30*time.Second)
 		if err == nil {
 			return nil
 		}
 
 		lastErr = err
-		s.logger.Printf("Email attempt %d failed: %v", attempt, err)
+		s.logger.Warn("email attempt failed", "attempt", attempt, "host", s.config.SMTPHost, "err", err)
 
 		if attempt < 3 {
 			time.Sleep(time.Duration(attempt*attempt) * time.Second)
@@ -83,13 +202,17 @@ func (s *Service) send(ctx context.Context, subject, htmlBody string) error {
 	return fmt.Errorf("failed after 3 attempts: %w", lastErr)
 }
 
-func (s *Service) buildMessage(subject, htmlBody string) []byte {
+func (s *Service) buildMessage(from string, to []string, subject, htmlBody, manage string) []byte {
 	var buf bytes.Buffer
 
 	// Headers
-	buf.WriteString(fmt.Sprintf("From: %s <%s>\r\n", s.config.FromName, s.config.FromAddress))
-	buf.WriteString(fmt.Sprintf("To: %s\r\n", s.config.ToAddress))
+	buf.WriteString(fmt.Sprintf("From: %s\r\n", from))
+	buf.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(to, ", ")))
 	buf.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
+	if manage != "" {
+		buf.WriteString(fmt.Sprintf("List-Unsubscribe: <%s>\r\n", manage))
+		buf.WriteString("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	}
 	buf.WriteString("MIME-Version: 1.0\r\n")
 	buf.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
 	buf.WriteString(fmt.Sprintf("Date: %s\r\n", time.Now().Format(time.RFC1123Z)))
@@ -102,26 +225,62 @@ func (s *Service) buildMessage(subject, htmlBody string) []byte {
 	return buf.Bytes()
 }
 
-func (s *Service) sendWithTimeout(addr string, message []byte, timeout time.Duration) error {
-	conn, err := net.DialTimeout("tcp", addr, timeout)
+func (s *Service) sendWithTimeout(addr string, to []string, message []byte, timeout time.Duration) error {
+	conn, client, err := s.dial(addr, timeout)
 	if err != nil {
-		return fmt.Errorf("connecting to SMTP server: %w", err)
+		return err
 	}
 	defer conn.Close()
+	defer client.Quit()
+
+	// Set sender
+	if err = client.Mail(s.config.FromAddress); err != nil {
+		return fmt.Errorf("setting sender: %w", err)
+	}
+
+	// Set recipients
+	for _, rcpt := range to {
+		if err = client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("setting recipient %s: %w", rcpt, err)
+		}
+	}
+
+	// Send message body
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("getting data writer: %w", err)
+	}
+
+	_, err = writer.Write(message)
+	if err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+
+	return writer.Close()
+}
+
+// dial connects to the SMTP server, upgrades to TLS on port 587 and
+// authenticates when credentials are configured
+func (s *Service) dial(addr string, timeout time.Duration) (net.Conn, *smtp.Client, error) {
+	conn, err := netproxy.Dial(addr, timeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connecting to SMTP server: %w", err)
+	}
 
 	conn.SetDeadline(time.Now().Add(timeout))
 
 	client, err := smtp.NewClient(conn, s.config.SMTPHost)
 	if err != nil {
-		return fmt.Errorf("creating SMTP client: %w", err)
+		conn.Close()
+		return nil, nil, fmt.Errorf("creating SMTP client: %w", err)
 	}
-	defer client.Quit()
 
 	// Start TLS if port is 587
 	if s.config.SMTPPort == 587 {
 		tlsConfig := &tls.Config{ServerName: s.config.SMTPHost}
 		if err = client.StartTLS(tlsConfig); err != nil {
-			return fmt.Errorf("starting TLS: %w", err)
+			conn.Close()
+			return nil, nil, fmt.Errorf("starting TLS: %w", err)
 		}
 	}
 
@@ -129,32 +288,24 @@ func (s *Service) sendWithTimeout(addr string, message []byte, timeout time.Dura
 	if s.config.SMTPUser != "" && s.config.SMTPPassword != "" {
 		auth := smtp.PlainAuth("", s.config.SMTPUser, s.config.SMTPPassword, s.config.SMTPHost)
 		if err = client.Auth(auth); err != nil {
-			return fmt.Errorf("authenticating: %w", err)
+			conn.Close()
+			return nil, nil, fmt.Errorf("authenticating: %w", err)
 		}
 	}
 
-	// Set sender
-	if err = client.Mail(s.config.FromAddress); err != nil {
-		return fmt.Errorf("setting sender: %w", err)
-	}
-
-	// Set recipient
-	if err = client.Rcpt(s.config.ToAddress); err != nil {
-		return fmt.Errorf("setting recipient: %w", err)
-	}
-
-	// Send message body
-	writer, err := client.Data()
-	if err != nil {
-		return fmt.Errorf("getting data writer: %w", err)
-	}
+	return conn, client, nil
+}
 
-	_, err = writer.Write(message)
+// CheckAuth connects to the SMTP server and authenticates without sending mail
+func (s *Service) CheckAuth() error {
+	addr := net.JoinHostPort(s.config.SMTPHost, strconv.Itoa(s.config.SMTPPort))
+	conn, client, err := s.dial(addr, 30*time.Second)
 	if err != nil {
-		return fmt.Errorf("writing message: %w", err)
+		return err
 	}
+	defer conn.Close()
 
-	return writer.Close()
+	return client.Quit()
 }
 
 // Validate checks if the email configuration is valid
@@ -162,16 +313,21 @@ func (s *Service) Validate() error {
 	if s.config.SMTPHost == "" {
 		return fmt.Errorf("smtp_host is required")
 	}
-	if s.config.ToAddress == "" {
-		return fmt.Errorf("to_address is required")
+	if s.config.ToAddress == "" && len(s.config.Routes) == 0 {
+		return fmt.Errorf("to_address or routes is required")
 	}
 	if s.config.FromAddress == "" {
 		return fmt.Errorf("from_address is required")
 	}
 
 	// Check if we can reach the SMTP server
-	addr := fmt.Sprintf("%s:%d", s.config.SMTPHost, s.config.SMTPPort)
-	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	return s.CheckConnection()
+}
+
+// CheckConnection verifies the SMTP server accepts TCP connections
+func (s *Service) CheckConnection() error {
+	addr := net.JoinHostPort(s.config.SMTPHost, strconv.Itoa(s.config.SMTPPort))
+	conn, err := netproxy.Dial(addr, 10*time.Second)
 	if err != nil {
 		return fmt.Errorf("cannot reach SMTP server: %w", err)
 	}
@@ -0,0 +1,196 @@
+package patch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxSize is the largest patch Fetch downloads or Read reads
+const MaxSize = 10 << 20
+
+var (
+	// GitHub pages with a .patch rendering: a commit, pull request or compare
+	githubPage = regexp.MustCompile(`^/[^/]+/[^/]+/(commit/[0-9a-fA-F]+|pull/\d+|compare/[^/]+)/?$`)
+	// A gist page, /<user>/<id> or /<id>
+	gistPage = regexp.MustCompile(`^(?:/[^/]+)?/([0-9a-fA-F]+)/?$`)
+)
+
+// Source is a downloaded patch
+type Source struct {
+	Name  string // Label for the reviewed changes, e.g. owner/repo or gist:<id>
+	Patch []byte // Unified diff, possibly several mailbox-formatted commits
+}
+
+// Fetcher downloads patches over HTTP
+type Fetcher struct {
+	http    *http.Client
+	token   string // GitHub token, sent only to GitHub hosts
+	gistAPI string
+}
+
+// NewFetcher returns a Fetcher; token, when set, authenticates GitHub
+// requests for private repositories and higher rate limits
+func NewFetcher(token string) *Fetcher {
+	return &Fetcher{
+		http:    &http.Client{Timeout: 30 * time.Second},
+		token:   token,
+		gistAPI: "https://api.github.com/gists/",
+	}
+}
+
+// Fetch downloads the patch at rawURL. Links to GitHub commits, pull
+// requests and compares are fetched as their .patch, and gists are turned
+// into a patch adding each of their files. Anything else is read as a
+// unified diff.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Source, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q: use an http or https link", rawURL)
+	}
+
+	switch {
+	case u.Host == "gist.github.com" && gistPage.MatchString(u.Path):
+		return f.fetchGist(ctx, gistPage.FindStringSubmatch(u.Path)[1])
+	case u.Host == "github.com" && githubPage.MatchString(u.Path):
+		u.Path = strings.TrimSuffix(u.Path, "/") + ".patch"
+	}
+
+	data, err := f.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	return &Source{Name: sourceName(u), Patch: data}, nil
+}
+
+// Read reads the patch in the file at path, or stdin for "-", such as a
+// mailbox of git format-patch mails saved from a mailing list. name labels
+// the changes; by default the file name without its extension.
+func Read(path, name string) (*Source, error) {
+	in := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading patch: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+	data, err := io.ReadAll(io.LimitReader(in, MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading patch %s: %w", path, err)
+	}
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("reading patch %s: larger than %d MB", path, MaxSize>>20)
+	}
+
+	if name == "" {
+		name = "patch"
+		if path != "-" {
+			name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+	}
+	return &Source{Name: name, Patch: data}, nil
+}
+
+// fetchGist returns the files of a gist as a patch adding each of them
+func (f *Fetcher) fetchGist(ctx context.Context, id string) (*Source, error) {
+	u, err := url.Parse(f.gistAPI + id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := f.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	var gist struct {
+		Files map[string]struct {
+			Content   string `json:"content"`
+			Truncated bool   `json:"truncated"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(data, &gist); err != nil {
+		return nil, fmt.Errorf("parsing gist %s: %w", id, err)
+	}
+
+	names := make([]string, 0, len(gist.Files))
+	for name := range gist.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		file := gist.Files[name]
+		if file.Truncated {
+			return nil, fmt.Errorf("gist %s: %s is too large to review", id, name)
+		}
+		sb.WriteString(Added(name, file.Content))
+	}
+	return &Source{Name: "gist:" + id, Patch: []byte(sb.String())}, nil
+}
+
+// Added returns a git-style diff creating path with content
+func Added(path, content string) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "diff --git a/%s b/%s\nnew file mode 100644\n--- /dev/null\n+++ b/%s\n", path, path, path)
+	fmt.Fprintf(&sb, "@@ -0,0 +1,%d @@\n", len(lines))
+	for _, line := range lines {
+		sb.WriteString("+" + line + "\n")
+	}
+	return sb.String()
+}
+
+func (f *Fetcher) get(ctx context.Context, u *url.URL) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.token != "" && isGitHub(u.Host) {
+		req.Header.Set("Authorization", "Bearer "+f.token)
+	}
+
+	resp, err := f.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", u.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", u.Redacted(), resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s: %w", u.Redacted(), err)
+	}
+	if len(data) > MaxSize {
+		return nil, fmt.Errorf("fetching %s: larger than %d MB", u.Redacted(), MaxSize>>20)
+	}
+	return data, nil
+}
+
+func isGitHub(host string) bool {
+	return host == "github.com" || strings.HasSuffix(host, ".github.com") || host == "patch-diff.githubusercontent.com"
+}
+
+// sourceName labels a patch with the owner/repo of a GitHub link, else the
+// host and path
+func sourceName(u *url.URL) string {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if u.Host == "github.com" && len(parts) >= 2 {
+		return parts[0] + "/" + parts[1]
+	}
+	return u.Host + u.Path
+}
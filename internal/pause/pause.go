@@ -0,0 +1,96 @@
+// Package pause stores a vacation window during which scheduled reviews
+// are skipped, or run with their notifications held for a catch-up digest.
+package pause
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/state"
+	"gopkg.in/yaml.v3"
+)
+
+// Pause modes
+const (
+	ModeSkip = "skip" // Don't run reviews
+	ModeHold = "hold" // Run reviews but hold email until the pause ends
+)
+
+// State is the pause window stored in a YAML file
+type State struct {
+	store state.Store
+	doc   state.Doc
+	// Until is the first day reviews run normally again; zero when not
+	// paused
+	Until time.Time `yaml:"until,omitempty"`
+	Mode  string    `yaml:"mode,omitempty"`
+	// Held lists the dates (YYYY-MM-DD) of reports whose email was held
+	Held []string `yaml:"held,omitempty"`
+}
+
+// Load reads the pause file at path. A missing file is no pause.
+func Load(st state.Store, path string) (*State, error) {
+	s := &State{store: st, doc: state.Doc{Key: "pause", Path: path, Perm: 0644}}
+
+	data, err := st.Read(s.doc)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("reading pause: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Save writes the state back to its file, removing the file when there is
+// no pause and nothing held
+func (s *State) Save() error {
+	if s.Until.IsZero() && len(s.Held) == 0 {
+		if err := s.store.Remove(s.doc); err != nil {
+			return fmt.Errorf("removing pause: %w", err)
+		}
+		return nil
+	}
+
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Write(s.doc, data); err != nil {
+		return fmt.Errorf("writing pause: %w", err)
+	}
+	return nil
+}
+
+// Active reports whether now falls in the pause window
+func (s *State) Active(now time.Time) bool {
+	return !s.Until.IsZero() && now.Before(s.Until)
+}
+
+// Ended reports whether a pause was set and its window is over
+func (s *State) Ended(now time.Time) bool {
+	return !s.Until.IsZero() && !now.Before(s.Until)
+}
+
+// Hold records that the email of the report for date was held
+func (s *State) Hold(date string) {
+	for _, d := range s.Held {
+		if d == date {
+			return
+		}
+	}
+	s.Held = append(s.Held, date)
+}
+
+// Clear ends the pause and forgets the held reports
+func (s *State) Clear() {
+	s.Until = time.Time{}
+	s.Mode = ""
+	s.Held = nil
+}
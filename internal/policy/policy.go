@@ -0,0 +1,202 @@
+package policy
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/cache"
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultCacheTTL is how long a fetched policy is reused when cache_ttl is unset
+const DefaultCacheTTL = 24 * time.Hour
+
+// Policy is a centrally managed fragment of review settings
+type Policy struct {
+	// Languages adds reviewed file extensions (".py": python)
+	Languages map[string]string `yaml:"languages"`
+	// MinSeverity drops findings below this level
+	MinSeverity string `yaml:"min_severity"`
+	// PromptAddendum is house guidance appended to the system prompt
+	PromptAddendum string `yaml:"prompt_addendum"`
+}
+
+// Apply fetches the policy configured in cfg.Policy and merges it into cfg.
+// Policy languages are added to the local ones, the policy's minimum
+// severity wins, and its prompt addendum is appended after the local one.
+func Apply(ctx context.Context, cfg *config.Config, logger *slog.Logger) error {
+	store, err := cache.Open(cfg.Cache, logger)
+	if err != nil {
+		logger.Debug("not caching the policy", "err", err)
+	}
+	p, err := Fetch(ctx, cfg.Policy, store, logger)
+	if err != nil {
+		return err
+	}
+
+	if len(p.Languages) > 0 && cfg.Languages == nil {
+		cfg.Languages = make(map[string]string)
+	}
+	for ext, lang := range p.Languages {
+		cfg.Languages[ext] = lang
+	}
+
+	if p.MinSeverity != "" {
+		cfg.Review.MinSeverity = p.MinSeverity
+	}
+
+	if p.PromptAddendum != "" {
+		if cfg.Review.PromptAddendum != "" {
+			cfg.Review.PromptAddendum += "\n\n"
+		}
+		cfg.Review.PromptAddendum += p.PromptAddendum
+	}
+
+	return nil
+}
+
+// Fetch returns the policy, downloading it when the copy cached in store
+// is older than the cache TTL. If the download fails a stale cached copy
+// is used. store may be nil, not to cache. The policy changes what is
+// reviewed and what the model is told, so it must be signed with
+// cfg.PublicKey.
+func Fetch(ctx context.Context, cfg config.PolicyConfig, store *cache.Store, logger *slog.Logger) (*Policy, error) {
+	if !strings.HasPrefix(cfg.URL, "https://") {
+		return nil, fmt.Errorf("policy url must use https: %s", cfg.URL)
+	}
+	if cfg.PublicKey == "" {
+		return nil, fmt.Errorf("policy.public_key is not set: a fetched policy must be signed")
+	}
+
+	ttl := DefaultCacheTTL
+	if cfg.CacheTTL != "" {
+		d, err := time.ParseDuration(cfg.CacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy cache_ttl: %w", err)
+		}
+		ttl = d
+	}
+
+	name := cache.Key(cfg.URL) + ".yaml"
+	if store != nil {
+		if fetched, err := store.Modified(cache.Policy, name); err == nil && time.Since(fetched) < ttl {
+			if p, err := loadCached(store, name, cfg); err == nil {
+				return p, nil
+			}
+		}
+	}
+
+	body, sig, err := download(ctx, cfg)
+	if err != nil {
+		if p, cacheErr := loadCached(store, name, cfg); cacheErr == nil {
+			logger.Warn("fetching policy failed, using cached copy", "url", cfg.URL, "err", err)
+			return p, nil
+		}
+		return nil, fmt.Errorf("fetching policy: %w", err)
+	}
+
+	p, err := parse(body, sig, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache only verified policies, the signature first so a policy is
+	// never read with an older one
+	if store != nil {
+		store.Put(cache.Policy, name+".sig", sig)
+		store.Put(cache.Policy, name, body)
+	}
+
+	return p, nil
+}
+
+// download fetches the policy and its signature
+func download(ctx context.Context, cfg config.PolicyConfig) (body, sig []byte, err error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	body, err = get(ctx, client, cfg.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err = get(ctx, client, cfg.URL+".sig")
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching signature: %w", err)
+	}
+	return body, sig, nil
+}
+
+func get(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// parse verifies the signature and decodes the policy
+func parse(body, sig []byte, cfg config.PolicyConfig) (*Policy, error) {
+	if err := verify(body, sig, cfg.PublicKey); err != nil {
+		return nil, err
+	}
+
+	var p Policy
+	if err := yaml.Unmarshal(body, &p); err != nil {
+		return nil, fmt.Errorf("parsing policy: %w", err)
+	}
+	if p.MinSeverity != "" {
+		if _, ok := domain.ParseSeverity(p.MinSeverity); !ok {
+			return nil, fmt.Errorf("policy min_severity %q is not High, Medium or Low", p.MinSeverity)
+		}
+	}
+	return &p, nil
+}
+
+// verify checks a base64 ed25519 signature over body
+func verify(body, sig []byte, publicKey string) error {
+	key, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("policy.public_key is not a base64 ed25519 public key")
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("policy signature is not valid base64: %w", err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), body, signature) {
+		return fmt.Errorf("policy signature verification failed")
+	}
+	return nil
+}
+
+// loadCached reads and checks the policy cached in store as name
+func loadCached(store *cache.Store, name string, cfg config.PolicyConfig) (*Policy, error) {
+	if store == nil {
+		return nil, fmt.Errorf("no policy cache")
+	}
+	body, err := store.Get(cache.Policy, name)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := store.Get(cache.Policy, name+".sig")
+	if err != nil {
+		return nil, err
+	}
+	return parse(body, sig, cfg)
+}
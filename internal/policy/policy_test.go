@@ -0,0 +1,44 @@
+package policy
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/juparave/codereviewer/internal/config"
+)
+
+func TestFetchRequiresPublicKey(t *testing.T) {
+	_, err := Fetch(context.Background(), config.PolicyConfig{URL: "https://policy.example.com/cra.yaml"}, nil, slog.Default())
+	if err == nil || !strings.Contains(err.Error(), "public_key") {
+		t.Fatalf("Fetch without a key: %v, want a public_key error", err)
+	}
+}
+
+func TestParseVerifiesSignature(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.PolicyConfig{PublicKey: base64.StdEncoding.EncodeToString(public)}
+	body := []byte("min_severity: Medium\n")
+	sig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(private, body)))
+
+	p, err := parse(body, sig, cfg)
+	if err != nil {
+		t.Fatalf("parse signed policy: %v", err)
+	}
+	if p.MinSeverity != "Medium" {
+		t.Errorf("MinSeverity = %q, want Medium", p.MinSeverity)
+	}
+
+	if _, err := parse([]byte("min_severity: Low\n"), sig, cfg); err == nil {
+		t.Error("parse accepted a policy changed after signing")
+	}
+	if _, err := parse(body, nil, cfg); err == nil {
+		t.Error("parse accepted an unsigned policy")
+	}
+}
@@ -0,0 +1,206 @@
+// Package prefs stores the email preferences recipients set themselves
+// through the manage links of report emails: repositories they muted, the
+// lowest severity they want, or no reports at all.
+package prefs
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/state"
+	"gopkg.in/yaml.v3"
+)
+
+// Recipient holds the preferences of one email address
+type Recipient struct {
+	// MinSeverity drops findings below it; empty keeps every finding
+	MinSeverity string `yaml:"min_severity,omitempty" json:"min_severity"`
+	// Muted lists repositories whose findings are left out
+	Muted []string `yaml:"muted,omitempty" json:"muted"`
+	// Unsubscribed stops report emails altogether
+	Unsubscribed bool `yaml:"unsubscribed,omitempty" json:"unsubscribed"`
+}
+
+// Default reports whether r changes nothing
+func (r Recipient) Default() bool {
+	return r.MinSeverity == "" && len(r.Muted) == 0 && !r.Unsubscribed
+}
+
+// Describe sums up what r filters, e.g. "Medium findings and above,
+// api muted"; empty when it filters nothing
+func (r Recipient) Describe() string {
+	var parts []string
+	if min, ok := domain.ParseSeverity(r.MinSeverity); ok && min != domain.SeverityLow {
+		parts = append(parts, string(min)+" findings and above")
+	}
+	if len(r.Muted) > 0 {
+		parts = append(parts, strings.Join(r.Muted, ", ")+" muted")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Store is the preferences file: every recipient's preferences and the
+// secret signing their manage links
+type Store struct {
+	store      state.Store
+	doc        state.Doc
+	Secret     string               `yaml:"secret,omitempty"`
+	Recipients map[string]Recipient `yaml:"recipients,omitempty"`
+	// Sent lists, by recipient, the repositories of the reports emailed to
+	// them: those their manage page offers to mute
+	Sent map[string][]string `yaml:"sent,omitempty"`
+}
+
+// Load reads the preferences file at path. A missing file is a store
+// without preferences.
+func Load(st state.Store, path string) (*Store, error) {
+	store := &Store{store: st, doc: state.Doc{Key: "preferences", Path: path, Perm: 0600}}
+
+	data, err := st.Read(store.doc)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return store, nil
+		}
+		return nil, fmt.Errorf("reading preferences: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// Save writes the store back to its file, readable only by its owner
+// since it holds the signing secret
+func (s *Store) Save() error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err := s.store.Write(s.doc, data); err != nil {
+		return fmt.Errorf("writing preferences: %w", err)
+	}
+	return nil
+}
+
+// For returns the preferences of address, the defaults when it set none
+func (s *Store) For(address string) Recipient {
+	return s.Recipients[key(address)]
+}
+
+// Set stores the preferences of address, forgetting them when they are
+// the defaults
+func (s *Store) Set(address string, r Recipient) {
+	if r.Default() {
+		delete(s.Recipients, key(address))
+		return
+	}
+	if s.Recipients == nil {
+		s.Recipients = make(map[string]Recipient)
+	}
+	r.Muted = slices.Compact(slices.Sorted(slices.Values(r.Muted)))
+	s.Recipients[key(address)] = r
+}
+
+// RecordSent adds repos to the repositories of the reports emailed to
+// address, reporting whether any was new so the caller saves the store
+func (s *Store) RecordSent(address string, repos []string) bool {
+	k := key(address)
+	known := s.Sent[k]
+	added := false
+	for _, repo := range repos {
+		if !slices.Contains(known, repo) {
+			known = append(known, repo)
+			added = true
+		}
+	}
+	if !added {
+		return false
+	}
+	if s.Sent == nil {
+		s.Sent = make(map[string][]string)
+	}
+	slices.Sort(known)
+	s.Sent[k] = known
+	return true
+}
+
+// SentRepos returns the repositories of the reports emailed to address
+func (s *Store) SentRepos(address string) []string {
+	return slices.Clone(s.Sent[key(address)])
+}
+
+// Token returns the token of the manage link of address, creating the
+// signing secret on first use; created tells the caller to save the store.
+func (s *Store) Token(address string) (token string, created bool, err error) {
+	if s.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return "", false, fmt.Errorf("creating preferences secret: %w", err)
+		}
+		s.Secret = hex.EncodeToString(secret)
+		created = true
+	}
+	mac := hmac.New(sha256.New, []byte(s.Secret))
+	mac.Write([]byte(key(address)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), created, nil
+}
+
+// Verify reports whether token is the manage link token of address
+func (s *Store) Verify(address, token string) bool {
+	if s.Secret == "" || address == "" {
+		return false
+	}
+	want, _, err := s.Token(address)
+	return err == nil && hmac.Equal([]byte(want), []byte(token))
+}
+
+// Link returns the manage link of address on the dashboard at base
+func Link(base, address, token string) string {
+	q := url.Values{"email": {address}, "token": {token}}
+	return strings.TrimSuffix(base, "/") + "/manage?" + q.Encode()
+}
+
+// Apply returns the part of rpt r wants: without the findings, failures
+// and exclusions of muted repositories, nor findings below MinSeverity.
+// rpt is returned as is when r filters nothing.
+func Apply(rpt *domain.Report, r Recipient) *domain.Report {
+	min, _ := domain.ParseSeverity(r.MinSeverity)
+	if len(r.Muted) == 0 && min.Rank() <= domain.SeverityLow.Rank() {
+		return rpt
+	}
+
+	v := *rpt
+	v.Repositories = slices.DeleteFunc(slices.Clone(rpt.Repositories), func(repo string) bool {
+		return slices.Contains(r.Muted, repo)
+	})
+	v.Findings = slices.DeleteFunc(slices.Clone(rpt.Findings), func(f domain.Finding) bool {
+		return slices.Contains(r.Muted, f.RepoName) || f.Severity.Rank() < min.Rank()
+	})
+	v.Failures = slices.DeleteFunc(slices.Clone(rpt.Failures), func(f domain.ReviewFailure) bool {
+		return !slices.ContainsFunc(f.Repos, func(repo string) bool { return !slices.Contains(r.Muted, repo) })
+	})
+	v.Exclusions = slices.DeleteFunc(slices.Clone(rpt.Exclusions), func(e domain.Exclusion) bool {
+		return slices.Contains(r.Muted, e.Repo)
+	})
+	v.Diffs = slices.DeleteFunc(slices.Clone(rpt.Diffs), func(d domain.ReportDiff) bool {
+		return slices.Contains(r.Muted, d.Repo)
+	})
+	v.NothingToNote = len(v.Findings) == 0
+	return &v
+}
+
+// key normalizes an email address, matched ignoring case
+func key(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
@@ -0,0 +1,60 @@
+package prefs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/juparave/codereviewer/internal/state"
+)
+
+func TestVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "preferences.yaml")
+	store, err := Load(state.Files, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Verify("ana@example.com", "anything") {
+		t.Error("verified a token before any was issued")
+	}
+
+	token, created, err := store.Token("ana@example.com")
+	if err != nil || !created {
+		t.Fatalf("Token = %q, %v, %v; want a new secret", token, created, err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := store.Token("ben@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tokens survive reloading the store
+	store, err = Load(state.Files, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := []byte(token)
+	tampered[0] ^= 1
+
+	tests := []struct {
+		name    string
+		address string
+		token   string
+		want    bool
+	}{
+		{"valid", "ana@example.com", token, true},
+		{"address case and spaces", " Ana@Example.com ", token, true},
+		{"tampered", "ana@example.com", string(tampered), false},
+		{"truncated", "ana@example.com", token[:len(token)-1], false},
+		{"empty", "ana@example.com", "", false},
+		{"another recipient's", "ana@example.com", other, false},
+		{"for another recipient", "ben@example.com", token, false},
+		{"no address", "", token, false},
+	}
+	for _, tt := range tests {
+		if got := store.Verify(tt.address, tt.token); got != tt.want {
+			t.Errorf("%s: Verify(%q, %q) = %v, want %v", tt.name, tt.address, tt.token, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Package progress emits structured progress events of a review run as
+// NDJSON, for GUI wrappers and editor plugins that render live progress
+// without parsing the logs.
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Stages of a review run, in order
+const (
+	StageScan    = "scan"    // Finding repositories
+	StageCommits = "commits" // Listing the commits in the window, per repository
+	StageDiffs   = "diffs"   // Extracting the diffs, per commit
+	StageReview  = "review"  // Reviewing, per request to the model
+	StageReport  = "report"  // Writing the report
+	StageDeliver = "deliver" // Emailing the report and alerts
+	StageDone    = "done"    // Finished; the message sums up the report
+	StageFailed  = "failed"  // Stopped; the message is the error
+)
+
+// stageSpans is the part of the run, in percent, each stage covers
+var stageSpans = map[string][2]int{
+	StageScan:    {0, 5},
+	StageCommits: {5, 15},
+	StageDiffs:   {15, 30},
+	StageReview:  {30, 90},
+	StageReport:  {90, 95},
+	StageDeliver: {95, 100},
+	StageDone:    {100, 100},
+	StageFailed:  {100, 100},
+}
+
+// Event is one line of progress output
+type Event struct {
+	Event   string    `json:"event"` // Always "progress", telling events apart from JSON logs
+	Time    time.Time `json:"time"`
+	Stage   string    `json:"stage"`
+	Repo    string    `json:"repo,omitempty"`
+	Percent int       `json:"percent"` // Of the whole run
+	Message string    `json:"message,omitempty"`
+}
+
+// Reporter writes events as NDJSON. A nil Reporter discards them, so
+// callers report progress without checking whether anyone listens.
+type Reporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSON returns a Reporter writing one JSON event per line to w
+func NewJSON(w io.Writer) *Reporter {
+	return &Reporter{enc: json.NewEncoder(w)}
+}
+
+// Stage reports the start of stage
+func (p *Reporter) Stage(stage, message string) {
+	p.Step(stage, "", 0, 1, message)
+}
+
+// Step reports that done of the total items of stage are finished, repo
+// being the repository of the current one when known
+func (p *Reporter) Step(stage, repo string, done, total int, message string) {
+	if p == nil {
+		return
+	}
+	span := stageSpans[stage]
+	percent := span[0]
+	if total > 0 {
+		percent += (span[1] - span[0]) * min(done, total) / total
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// Progress is best effort; a closed stderr must not fail the run
+	_ = p.enc.Encode(Event{
+		Event:   "progress",
+		Time:    time.Now(),
+		Stage:   stage,
+		Repo:    repo,
+		Percent: percent,
+		Message: message,
+	})
+}
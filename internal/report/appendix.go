@@ -0,0 +1,138 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"slices"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// appendixCSS styles the diffs of the appendix
+const appendixCSS = "pre.diff { background: #f9fafb; border: 1px solid #e5e7eb; padding: 12px; overflow-x: auto; font-size: 12px; line-height: 1.4; }\n" +
+	"pre.diff .add { color: #166534; background: #dcfce7; }\n" +
+	"pre.diff .del { color: #991b1b; background: #fee2e2; }\n" +
+	"pre.diff .hunk { color: #6b7280; }\n"
+
+// writeDiffs stores the reviewed diffs of the run for date, replacing
+// those of an earlier run that day even when there are none
+func (f *Formatter) writeDiffs(date string, diffs []domain.ReportDiff) error {
+	data, err := json.Marshal(diffs)
+	if err != nil {
+		return fmt.Errorf("encoding reviewed diffs: %w", err)
+	}
+	if _, err := f.store.Put(date, ArtifactDiffs, data); err != nil {
+		return fmt.Errorf("writing reviewed diffs: %w", err)
+	}
+	return nil
+}
+
+// LoadDiffs adds the reviewed diffs stored with rpt to it, for the
+// appendix of its HTML page or email, keeping those of the repositories
+// rpt lists. Reports stored without them are left as they are.
+func (f *Formatter) LoadDiffs(rpt *domain.Report) error {
+	if !f.appendix.Enabled() {
+		return nil
+	}
+	date := rpt.Date.Format(DateLayout)
+	data, err := f.store.Get(date, ArtifactDiffs)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var diffs []domain.ReportDiff
+	if err := json.Unmarshal(data, &diffs); err != nil {
+		return fmt.Errorf("parsing reviewed diffs of %s: %w", date, err)
+	}
+	rpt.Diffs = slices.DeleteFunc(diffs, func(d domain.ReportDiff) bool {
+		return !slices.Contains(rpt.Repositories, d.Repo)
+	})
+	return nil
+}
+
+// diffKey identifies the diffs of a file in a repository
+func diffKey(repo, path string) string {
+	return repo + "\x00" + path
+}
+
+// diffAnchors names the appendix section of each file in diffs, by
+// diffKey. Anchors are derived from the repository and path, so links
+// to them keep working across renders of the same report.
+func diffAnchors(diffs []domain.ReportDiff) map[string]string {
+	anchors := make(map[string]string)
+	for _, d := range diffs {
+		key := diffKey(d.Repo, d.Path)
+		if _, ok := anchors[key]; !ok {
+			sum := sha256.Sum256([]byte(key))
+			anchors[key] = "diff-" + hex.EncodeToString(sum[:6])
+		}
+	}
+	return anchors
+}
+
+// writeAppendixHTML renders the reviewed diffs, one section per file in
+// the order they were reviewed, each commit's diff of it in turn
+func writeAppendixHTML(sb *strings.Builder, diffs []domain.ReportDiff, anchors map[string]string) {
+	sb.WriteString("<h2 id='appendix'>Appendix: Reviewed Diffs</h2>\n")
+	sb.WriteString(fmt.Sprintf("<p>The %d file diffs exactly as sent for review, truncation included.</p>\n", len(diffs)))
+
+	var order []string
+	byFile := make(map[string][]domain.ReportDiff)
+	for _, d := range diffs {
+		key := diffKey(d.Repo, d.Path)
+		if _, ok := byFile[key]; !ok {
+			order = append(order, key)
+		}
+		byFile[key] = append(byFile[key], d)
+	}
+
+	for _, key := range order {
+		first := byFile[key][0]
+		sb.WriteString(fmt.Sprintf("<h3 id='%s'>%s: <code>%s</code></h3>\n",
+			anchors[key], html.EscapeString(first.Repo), html.EscapeString(first.Path)))
+		for _, d := range byFile[key] {
+			var about []string
+			if d.Commit != "" {
+				about = append(about, fmt.Sprintf("Commit <code>%s</code>", html.EscapeString(domain.ShortHash(d.Commit))))
+			}
+			if d.OldPath != "" {
+				about = append(about, fmt.Sprintf("renamed from <code>%s</code>", html.EscapeString(d.OldPath)))
+			}
+			if len(about) > 0 {
+				sb.WriteString("<p>" + strings.Join(about, ", ") + "</p>\n")
+			}
+			sb.WriteString("<pre class='diff'>")
+			writeDiffLines(sb, d.Content)
+			sb.WriteString("</pre>\n")
+		}
+	}
+}
+
+// writeDiffLines writes the lines of a diff, escaped, marking added and
+// removed lines and hunk headers
+func writeDiffLines(sb *strings.Builder, content string) {
+	for line := range strings.SplitSeq(strings.TrimSuffix(content, "\n"), "\n") {
+		class := ""
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			class = "hunk"
+		case strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++"):
+			class = "add"
+		case strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---"):
+			class = "del"
+		}
+		if class == "" {
+			sb.WriteString(html.EscapeString(line) + "\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("<span class='%s'>%s</span>\n", class, html.EscapeString(line)))
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/state"
+)
+
+// Storage backends, selected with reports.backend
+const (
+	BackendFiles    = "files"    // Content-addressed files in reports.output_dir
+	BackendSQLite   = "sqlite"   // A SQLite database file (default)
+	BackendBolt     = "bbolt"    // A bbolt key/value file
+	BackendPostgres = "postgres" // A shared PostgreSQL database, e.g. for a team hub
+)
+
+// Backend stores the artifacts of each run, see Artifacts
+type Backend interface {
+	// Put stores data as the kind artifact of the run for date
+	// (YYYY-MM-DD), replacing the previous one, and returns where
+	Put(date, kind string, data []byte) (string, error)
+	// Get returns the kind artifact of the run for date. A missing
+	// artifact wraps fs.ErrNotExist.
+	Get(date, kind string) ([]byte, error)
+	// Path returns where the kind artifact of date is stored: a file for
+	// the files backend, a location such as sqlite:<file>#<date>/<kind>
+	// for the others
+	Path(date, kind string) (string, error)
+	// Dates returns the dates with a stored kind artifact, oldest first
+	Dates(kind string) ([]string, error)
+	// Verify checks every stored artifact matches its hash, and counts
+	// stored data no run refers to
+	Verify() ([]Problem, int, error)
+	// Prune removes the runs dated before date (empty removes none) and
+	// stored data no remaining run refers to; dryRun only reports
+	Prune(before string, dryRun bool) (*PruneResult, error)
+}
+
+// Migrator is a backend that can take in reports stored some other way:
+// the files backend moves in reports written before it was
+// content-addressed, the database backends copy in the reports directory
+type Migrator interface {
+	Migrate() (int, error)
+}
+
+// openBackends shares one connection per backend and location across the
+// process
+var (
+	openMu       sync.Mutex
+	openBackends = make(map[string]Backend)
+)
+
+// OpenBackend returns the backend selected by cfg. A sqlite or bbolt file
+// created by the call takes in the reports found in reports.output_dir,
+// so switching to it, or upgrading to the sqlite default, keeps the
+// history.
+func OpenBackend(cfg config.ReportsConfig) (Backend, error) {
+	backend, dsn := cfg.Backend, cfg.DSN
+	switch backend {
+	case BackendFiles:
+		return NewStore(cfg.OutputDir), nil
+	case "", BackendSQLite:
+		backend = BackendSQLite
+		if dsn == "" {
+			dsn = filepath.Join(cfg.OutputDir, "cra.db")
+		}
+	case BackendBolt:
+		if dsn == "" {
+			dsn = filepath.Join(cfg.OutputDir, "cra.bolt")
+		}
+	case BackendPostgres:
+		if dsn == "" {
+			return nil, fmt.Errorf("reports.dsn is required for the postgres backend, e.g. postgres://cra@db/cra")
+		}
+	default:
+		return nil, fmt.Errorf("unknown reports.backend %q", backend)
+	}
+
+	openMu.Lock()
+	defer openMu.Unlock()
+	key := backend + "\x00" + dsn
+	if b, ok := openBackends[key]; ok {
+		return b, nil
+	}
+
+	_, statErr := os.Stat(dsn)
+	created := backend != BackendPostgres && errors.Is(statErr, fs.ErrNotExist)
+
+	var b Backend
+	var err error
+	switch backend {
+	case BackendBolt:
+		b, err = openBolt(dsn, cfg.OutputDir)
+	default:
+		b, err = openSQL(backend, dsn, cfg.OutputDir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening %s report storage: %w", backend, err)
+	}
+	if created {
+		if _, err := b.(Migrator).Migrate(); err != nil {
+			return nil, fmt.Errorf("copying the reports of %s into %s: %w", cfg.OutputDir, dsn, err)
+		}
+	}
+	openBackends[key] = b
+	return b, nil
+}
+
+// OpenState returns where the state documents of cfg's backend live: the
+// database of a database backend, their own files otherwise
+func OpenState(cfg config.ReportsConfig) state.Store {
+	b, err := OpenBackend(cfg)
+	if err != nil {
+		return brokenBackend{err}
+	}
+	if s, ok := b.(state.Store); ok {
+		return s
+	}
+	return state.Files
+}
+
+// copyFiles copies every run in the files store in dir into dst, oldest
+// first, and returns how many artifacts it copied
+func copyFiles(dst Backend, dir string) (int, error) {
+	src := NewStore(dir)
+	copied := 0
+	for _, kind := range Artifacts {
+		dates, err := src.Dates(kind)
+		if err != nil {
+			return copied, err
+		}
+		sort.Strings(dates)
+		for _, date := range dates {
+			data, err := src.Get(date, kind)
+			if err != nil {
+				return copied, err
+			}
+			if _, err := dst.Put(date, kind, data); err != nil {
+				return copied, err
+			}
+			copied++
+		}
+	}
+	return copied, nil
+}
+
+// brokenBackend reports the error that kept a backend from opening on
+// every call, so a Formatter can be created before storage is reachable
+type brokenBackend struct{ err error }
+
+func (b brokenBackend) Put(string, string, []byte) (string, error) { return "", b.err }
+func (b brokenBackend) Get(string, string) ([]byte, error)         { return nil, b.err }
+func (b brokenBackend) Path(string, string) (string, error)        { return "", b.err }
+func (b brokenBackend) Dates(string) ([]string, error)             { return nil, b.err }
+func (b brokenBackend) Verify() ([]Problem, int, error)            { return nil, 0, b.err }
+func (b brokenBackend) Prune(string, bool) (*PruneResult, error)   { return nil, b.err }
+func (b brokenBackend) Read(state.Doc) ([]byte, error)             { return nil, b.err }
+func (b brokenBackend) Write(state.Doc, []byte) error              { return b.err }
+func (b brokenBackend) Remove(state.Doc) error                     { return b.err }
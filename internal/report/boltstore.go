@@ -0,0 +1,232 @@
+package report
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltStore keeps run artifacts in a bbolt file: a bucket per kind, keyed
+// by date, each value the artifact's SHA-256 followed by its content
+type boltStore struct {
+	path      string
+	outputDir string // Migrated from
+}
+
+// boltLockTimeout is how long an operation waits for other processes
+// using the bbolt file before giving up
+const boltLockTimeout = 30 * time.Second
+
+// openBolt creates the bbolt file at path if needed
+func openBolt(path, outputDir string) (*boltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	s := &boltStore{path: path, outputDir: outputDir}
+	return s, s.update(func(*bolt.Tx) error { return nil })
+}
+
+// open opens the file for one operation. bbolt locks it for as long as it
+// is open, shared for reads and exclusive for writes, so it is never held
+// between operations: processes such as serve and a scheduled run take
+// turns instead of the first shutting the others out.
+func (s *boltStore) open(readOnly bool) (*bolt.DB, error) {
+	db, err := bolt.Open(s.path, 0644, &bolt.Options{Timeout: boltLockTimeout, ReadOnly: readOnly})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("%s stayed locked by another process for %s", s.path, boltLockTimeout)
+	}
+	return db, err
+}
+
+// view runs fn in a read transaction
+func (s *boltStore) view(fn func(*bolt.Tx) error) error {
+	db, err := s.open(true)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+// update runs fn in a write transaction
+func (s *boltStore) update(fn func(*bolt.Tx) error) error {
+	db, err := s.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
+
+func (s *boltStore) Put(date, kind string, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	err := s.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(kind))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(date), append(sum[:], data...))
+	})
+	if err != nil {
+		return "", fmt.Errorf("storing %s artifact: %w", kind, err)
+	}
+	return s.locate(date, kind), nil
+}
+
+func (s *boltStore) Get(date, kind string) ([]byte, error) {
+	var data []byte
+	var problem string
+	err := s.view(func(tx *bolt.Tx) error {
+		value := s.value(tx, date, kind)
+		if value == nil {
+			return fmt.Errorf("no %s stored for %s: %w", kind, date, fs.ErrNotExist)
+		}
+		problem = checkValue(value)
+		data = bytes.Clone(value[min(len(value), sha256.Size):])
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if problem != "" {
+		return nil, fmt.Errorf("%s of %s is corrupt: %s, run `review storage verify`", kind, date, problem)
+	}
+	return data, nil
+}
+
+func (s *boltStore) Path(date, kind string) (string, error) {
+	err := s.view(func(tx *bolt.Tx) error {
+		if s.value(tx, date, kind) == nil {
+			return fmt.Errorf("no %s stored for %s: %w", kind, date, fs.ErrNotExist)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return s.locate(date, kind), nil
+}
+
+func (s *boltStore) Dates(kind string) ([]string, error) {
+	var dates []string
+	err := s.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(kind))
+		if b == nil {
+			return nil
+		}
+		// Keys are kept sorted, so dates come out oldest first
+		return b.ForEach(func(k, _ []byte) error {
+			dates = append(dates, string(k))
+			return nil
+		})
+	})
+	return dates, err
+}
+
+// Verify checks every value against its hash. Values are self-contained,
+// so nothing is ever orphaned.
+func (s *boltStore) Verify() ([]Problem, int, error) {
+	var problems []Problem
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(kind []byte, b *bolt.Bucket) error {
+			if string(kind) == stateBucket {
+				return nil
+			}
+			return b.ForEach(func(date, value []byte) error {
+				if problem := checkValue(value); problem != "" {
+					problems = append(problems, Problem{string(date), string(kind), problem})
+				}
+				return nil
+			})
+		})
+	})
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].Date < problems[j].Date })
+	return problems, 0, err
+}
+
+func (s *boltStore) Prune(before string, dryRun bool) (*PruneResult, error) {
+	result := &PruneResult{}
+	if before == "" {
+		return result, nil
+	}
+
+	runs := make(map[string]bool)
+	prune := func(tx *bolt.Tx) error {
+		return tx.ForEach(func(kind []byte, b *bolt.Bucket) error {
+			if string(kind) == stateBucket {
+				return nil
+			}
+			var old [][]byte
+			c := b.Cursor()
+			for k, v := c.First(); k != nil && string(k) < before; k, v = c.Next() {
+				old = append(old, bytes.Clone(k))
+				runs[string(k)] = true
+				result.Objects++
+				result.Bytes += int64(max(len(v)-sha256.Size, 0))
+			}
+			if dryRun {
+				return nil
+			}
+			for _, k := range old {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	var err error
+	if dryRun {
+		err = s.view(prune)
+	} else {
+		err = s.update(prune)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("removing runs: %w", err)
+	}
+	for date := range runs {
+		result.Runs = append(result.Runs, date)
+	}
+	sort.Strings(result.Runs)
+	return result, nil
+}
+
+// Migrate copies the reports in reports.output_dir into the bbolt file
+func (s *boltStore) Migrate() (int, error) {
+	return copyFiles(s, s.outputDir)
+}
+
+// value returns the stored value of date in the kind bucket, valid only
+// during tx
+func (s *boltStore) value(tx *bolt.Tx, date, kind string) []byte {
+	b := tx.Bucket([]byte(kind))
+	if b == nil {
+		return nil
+	}
+	return b.Get([]byte(date))
+}
+
+func (s *boltStore) locate(date, kind string) string {
+	return "bbolt:" + s.path + "#" + date + "/" + kind
+}
+
+// checkValue describes what is wrong with a stored value, empty when its
+// content matches its hash
+func checkValue(value []byte) string {
+	if len(value) < sha256.Size {
+		return "value is truncated"
+	}
+	if sum := sha256.Sum256(value[sha256.Size:]); !bytes.Equal(sum[:], value[:sha256.Size]) {
+		return "content doesn't match its hash"
+	}
+	return ""
+}
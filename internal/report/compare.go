@@ -0,0 +1,186 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// Comparison sorts the findings of two reports by what happened to them
+// between the runs, matched by fingerprint
+type Comparison struct {
+	From       *domain.Report
+	To         *domain.Report
+	New        []domain.Finding // In To only
+	Resolved   []domain.Finding // Of From, gone from its files' code since
+	Persisting []domain.Finding // Of From and still open, as last reported
+	Suppressed []domain.Finding // Of either, in the suppressions or baseline
+}
+
+// Compare compares the findings of from with those of a later report to.
+// between holds the reports of the runs in between, oldest first; each of
+// those and to need their reviewed diffs (see LoadDiffs). suppressed tells
+// whether a finding ID is suppressed or in the baseline.
+//
+// A run only reviews the changes of its window, so a finding of from that
+// to doesn't report is resolved only when a later run reviewed a change to
+// its files without reporting it again; while its code isn't touched it
+// persists. Each list is sorted by severity, highest first.
+func Compare(from, to *domain.Report, between []*domain.Report, suppressed func(id string) bool) *Comparison {
+	c := &Comparison{From: from, To: to}
+
+	// The findings of from, as last reported and whether resolved since
+	type tracked struct {
+		finding  domain.Finding
+		resolved bool
+	}
+	open := make(map[string]*tracked, len(from.Findings))
+	for _, f := range from.Findings {
+		open[f.Fingerprint()] = &tracked{finding: f}
+	}
+	for _, rpt := range append(slices.Clone(between), to) {
+		reported := make(map[string]domain.Finding, len(rpt.Findings))
+		for _, f := range rpt.Findings {
+			reported[f.Fingerprint()] = f
+		}
+		reviewed := reviewedFiles(rpt)
+		for id, t := range open {
+			if f, ok := reported[id]; ok {
+				t.finding, t.resolved = f, false
+				continue
+			}
+			for _, file := range t.finding.Files {
+				if reviewed[t.finding.RepoName+"\x00"+file] {
+					t.resolved = true
+				}
+			}
+		}
+	}
+
+	for _, f := range from.Findings {
+		t := open[f.Fingerprint()]
+		switch {
+		case suppressed(f.Fingerprint()):
+			c.Suppressed = append(c.Suppressed, t.finding)
+		case t.resolved:
+			c.Resolved = append(c.Resolved, t.finding)
+		default:
+			c.Persisting = append(c.Persisting, t.finding)
+		}
+	}
+	for _, f := range to.Findings {
+		switch {
+		case open[f.Fingerprint()] != nil:
+		case suppressed(f.Fingerprint()):
+			c.Suppressed = append(c.Suppressed, f)
+		default:
+			c.New = append(c.New, f)
+		}
+	}
+
+	for _, list := range [][]domain.Finding{c.New, c.Resolved, c.Persisting, c.Suppressed} {
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].Severity.Rank() > list[j].Severity.Rank()
+		})
+	}
+	return c
+}
+
+// reviewedFiles returns the files whose changes rpt reviewed, keyed by
+// repository and path, old paths of renames included
+func reviewedFiles(rpt *domain.Report) map[string]bool {
+	files := make(map[string]bool, len(rpt.Diffs))
+	for _, d := range rpt.Diffs {
+		files[d.Repo+"\x00"+d.Path] = true
+		if d.OldPath != "" {
+			files[d.Repo+"\x00"+d.OldPath] = true
+		}
+	}
+	return files
+}
+
+// comparisonSection is one list of a comparison with its heading
+type comparisonSection struct {
+	title    string
+	findings []domain.Finding
+}
+
+// sections returns the lists of c in the order they are rendered
+func (c *Comparison) sections() []comparisonSection {
+	return []comparisonSection{
+		{"New", c.New},
+		{"Resolved", c.Resolved},
+		{"Persisting", c.Persisting},
+		{"Suppressed", c.Suppressed},
+	}
+}
+
+// FormatComparison renders a comparison as plain text for the terminal
+func (f *Formatter) FormatComparison(c *Comparison) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s → %s: %d new, %d resolved, %d persisting, %d suppressed\n",
+		c.From.Date.Format(DateLayout), c.To.Date.Format(DateLayout),
+		len(c.New), len(c.Resolved), len(c.Persisting), len(c.Suppressed)))
+
+	for _, section := range c.sections() {
+		if len(section.findings) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n%s (%d)\n", section.title, len(section.findings)))
+		for _, finding := range section.findings {
+			sb.WriteString(fmt.Sprintf("  %s %-8s %s\n", f.Emoji(finding.Severity), f.Label(finding.Severity), finding.Title))
+			sb.WriteString(fmt.Sprintf("           %s: %s  ID: %s\n", finding.RepoName, strings.Join(finding.Files, ", "), finding.Fingerprint()))
+		}
+	}
+	return sb.String()
+}
+
+// ComparisonHTML renders a comparison as a standalone HTML page
+func (f *Formatter) ComparisonHTML(c *Comparison) string {
+	var sb strings.Builder
+
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset='utf-8'>\n")
+	sb.WriteString("<style>\n")
+	sb.WriteString("body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; max-width: 800px; margin: 0 auto; padding: 20px; }\n")
+	sb.WriteString("h1 { color: #1a1a1a; border-bottom: 2px solid #667eea; padding-bottom: 10px; }\n")
+	sb.WriteString(f.SeverityCSS())
+	sb.WriteString("table { border-collapse: collapse; width: 100%; }\n")
+	sb.WriteString("td, th { border: 1px solid #e5e7eb; padding: 6px 12px; text-align: left; }\n")
+	sb.WriteString("code { background: #f3f4f6; padding: 2px 6px; border-radius: 4px; font-size: 14px; }\n")
+	sb.WriteString(".new h2 { color: #b91c1c; } .resolved h2 { color: #15803d; } .persisting h2 { color: #6b7280; } .suppressed h2 { color: #9ca3af; }\n")
+	sb.WriteString("</style>\n</head>\n<body>\n")
+
+	sb.WriteString(fmt.Sprintf("<h1>Code Review Comparison - %s to %s</h1>\n",
+		c.From.Date.Format("January 2, 2006"), c.To.Date.Format("January 2, 2006")))
+	sb.WriteString("<table>\n<tr><th></th><th>Findings</th><th>New</th><th>Resolved</th><th>Persisting</th><th>Suppressed</th></tr>\n")
+	sb.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%d (%s)</td><td rowspan='2'>%d</td><td rowspan='2'>%d</td><td rowspan='2'>%d</td><td rowspan='2'>%d</td></tr>\n",
+		c.From.Date.Format(DateLayout), c.From.TotalFindings(), f.countsHTML(c.From), len(c.New), len(c.Resolved), len(c.Persisting), len(c.Suppressed)))
+	sb.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%d (%s)</td></tr>\n</table>\n",
+		c.To.Date.Format(DateLayout), c.To.TotalFindings(), f.countsHTML(c.To)))
+
+	for _, section := range c.sections() {
+		sb.WriteString(fmt.Sprintf("<div class='%s'>\n<h2>%s (%d)</h2>\n", strings.ToLower(section.title), section.title, len(section.findings)))
+		if len(section.findings) == 0 {
+			sb.WriteString("<p>None.</p>\n</div>\n")
+			continue
+		}
+		sb.WriteString("<table>\n<tr><th>Severity</th><th>Finding</th><th>Repository</th><th>Files</th></tr>\n")
+		for _, finding := range section.findings {
+			files := make([]string, len(finding.Files))
+			for i, file := range finding.Files {
+				files[i] = "<code>" + html.EscapeString(file) + "</code>"
+			}
+			sb.WriteString(fmt.Sprintf("<tr><td><span class='%s'>%s %s</span></td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+				severityClass(finding.Severity), f.Emoji(finding.Severity), html.EscapeString(f.Label(finding.Severity)),
+				html.EscapeString(finding.Title), html.EscapeString(finding.RepoName), strings.Join(files, ", ")))
+		}
+		sb.WriteString("</table>\n</div>\n")
+	}
+
+	sb.WriteString("</body>\n</html>")
+	return sb.String()
+}
@@ -0,0 +1,64 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+func TestCompare(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	finding := func(title, file string) domain.Finding {
+		return domain.Finding{RepoName: "api", Title: title, Files: []string{file}, Severity: domain.SeverityMedium}
+	}
+	fixed := finding("Fixed", "fixed.go")
+	untouched := finding("Untouched", "untouched.go")
+	again := finding("Reported again", "again.go")
+	ignored := finding("Suppressed", "ignored.go")
+	added := finding("Added", "added.go")
+
+	from := &domain.Report{Date: day(2), Findings: []domain.Finding{fixed, untouched, again, ignored}}
+	between := []*domain.Report{{Date: day(3), Findings: []domain.Finding{again}, Diffs: []domain.ReportDiff{
+		{Repo: "api", Path: "fixed.go"}, {Repo: "api", Path: "again.go"}, {Repo: "api", Path: "ignored.go"},
+	}}}
+	to := &domain.Report{Date: day(4), Findings: []domain.Finding{added}, Diffs: []domain.ReportDiff{
+		{Repo: "api", Path: "added.go"},
+	}}
+	suppressed := func(id string) bool { return id == ignored.Fingerprint() }
+
+	c := Compare(from, to, between, suppressed)
+	check := func(name string, got []domain.Finding, want ...domain.Finding) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("%s = %d findings, want %d", name, len(got), len(want))
+		}
+		for i := range want {
+			if got[i].Title != want[i].Title {
+				t.Errorf("%s[%d] = %q, want %q", name, i, got[i].Title, want[i].Title)
+			}
+		}
+	}
+	check("New", c.New, added)
+	check("Resolved", c.Resolved, fixed)
+	check("Persisting", c.Persisting, untouched, again)
+	check("Suppressed", c.Suppressed, ignored)
+}
+
+func TestComparisonHTMLSeverityClass(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 0, 0, 0, 0, time.UTC) }
+	hostile := domain.Finding{RepoName: "api", Title: "Odd", Files: []string{"a.go"}, Severity: "x' onmouseover='alert(1)"}
+	high := domain.Finding{RepoName: "api", Title: "Bad", Files: []string{"b.go"}, Severity: "high"}
+
+	none := func(string) bool { return false }
+	f := NewFormatter(config.ReportsConfig{OutputDir: t.TempDir()})
+	out := f.ComparisonHTML(Compare(&domain.Report{Date: day(2)}, &domain.Report{Date: day(3), Findings: []domain.Finding{hostile, high}}, nil, none))
+	if strings.Contains(out, "onmouseover='") {
+		t.Errorf("the severity broke out of its class attribute:\n%s", out)
+	}
+	if !strings.Contains(out, "<span class='unknown'>") || !strings.Contains(out, "<span class='high'>") {
+		t.Errorf("want the classes unknown and high:\n%s", out)
+	}
+}
@@ -2,51 +2,111 @@ package report
 
 import (
 	"fmt"
+	"html"
+	"net/url"
 	"os"
-	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/juparave/codereviewer/internal/config"
 	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/state"
 )
 
 // Formatter generates Markdown reports
 type Formatter struct {
 	outputDir string
+	store     Backend
+	styles    map[domain.Severity]config.SeverityStyle
+	appendix  config.AppendixConfig
 }
 
 // NewFormatter creates a new Formatter
-func NewFormatter(outputDir string) *Formatter {
-	return &Formatter{outputDir: outputDir}
+func NewFormatter(cfg config.ReportsConfig) *Formatter {
+	// A backend that can't be opened fails each use, with its reason
+	store, err := OpenBackend(cfg)
+	if err != nil {
+		store = brokenBackend{err}
+	}
+	return &Formatter{
+		outputDir: cfg.OutputDir,
+		store:     store,
+		styles:    resolveStyles(cfg.Severity),
+		appendix:  cfg.Appendix,
+	}
 }
 
-// Write generates and saves a Markdown report
+// State returns where the run state lives, see OpenState
+func (f *Formatter) State() state.Store {
+	if s, ok := f.store.(state.Store); ok {
+		return s
+	}
+	return state.Files
+}
+
+// Write stores a Markdown report, along with its HTML, a JSON copy of the
+// report data used by the history commands and, with
+// reports.appendix.html, the reviewed diffs, and returns the Markdown
+// file's path
 func (f *Formatter) Write(report *domain.Report) (string, error) {
-	// Ensure output directory exists
-	if err := os.MkdirAll(f.outputDir, 0755); err != nil {
-		return "", fmt.Errorf("creating output directory: %w", err)
+	date := report.Date.Format(DateLayout)
+	path, err := f.store.Put(date, ArtifactMarkdown, []byte(f.Markdown(report)))
+	if err != nil {
+		return "", fmt.Errorf("writing report: %w", err)
 	}
 
-	// Generate filename
-	filename := report.Date.Format("2006-01-02") + ".md"
-	filepath := filepath.Join(f.outputDir, filename)
+	if err := f.writeMetadata(date, report); err != nil {
+		return "", err
+	}
+	if _, err := f.store.Put(date, ArtifactHTML, []byte(f.ToHTML(report))); err != nil {
+		return "", fmt.Errorf("writing HTML report: %w", err)
+	}
+	if f.appendix.HTML {
+		if err := f.writeDiffs(date, report.Diffs); err != nil {
+			return "", err
+		}
+	}
 
-	// Generate content
-	content := f.format(report)
+	return path, nil
+}
 
-	// Write file
-	if err := os.WriteFile(filepath, []byte(content), 0644); err != nil {
-		return "", fmt.Errorf("writing report: %w", err)
+// Store returns the storage backend of the report history
+func (f *Formatter) Store() Backend {
+	return f.store
+}
+
+// CheckWritable verifies the output directory can be created and written
+// to or, with a database backend, that the database can be read
+func (f *Formatter) CheckWritable() error {
+	if _, ok := f.store.(*Store); !ok {
+		_, err := f.store.Dates(ArtifactReport)
+		return err
+	}
+	if err := os.MkdirAll(f.outputDir, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
 	}
 
-	return filepath, nil
+	probe, err := os.CreateTemp(f.outputDir, ".cra-write-check-*")
+	if err != nil {
+		return fmt.Errorf("writing to output directory: %w", err)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
 }
 
-func (f *Formatter) format(report *domain.Report) string {
+// Markdown renders the report as Markdown, as saved by Write
+func (f *Formatter) Markdown(report *domain.Report) string {
 	var sb strings.Builder
 
 	// Header
 	sb.WriteString(fmt.Sprintf("# Code Review Report - %s\n\n", report.Date.Format("January 2, 2006")))
+	for _, note := range report.UserNotes {
+		sb.WriteString(fmt.Sprintf("> 📝 **Note:** %s\n", note))
+	}
+	if len(report.UserNotes) > 0 {
+		sb.WriteString("\n")
+	}
 
 	// Summary
 	sb.WriteString("## Summary\n\n")
@@ -60,64 +120,114 @@ func (f *Formatter) format(report *domain.Report) string {
 	}
 	// Add model name
 	sb.WriteString(fmt.Sprintf("**Model:** %s\n\n", report.Model))
+	if report.Usage != nil {
+		sb.WriteString(fmt.Sprintf("**LLM usage:** %s\n\n", usageLine(*report.Usage)))
+	}
+
+	// Repositories skipped or only partially reviewed
+	for _, note := range report.Notes {
+		sb.WriteString(fmt.Sprintf("> ⚠️ %s\n", note))
+	}
+	if len(report.Notes) > 0 {
+		sb.WriteString("\n")
+	}
+
+	// Chunks the reviewer gave up on
+	if len(report.Failures) > 0 {
+		sb.WriteString("## Review Errors\n\n")
+		sb.WriteString("These changes could not be reviewed and may hide issues:\n\n")
+		for _, failure := range report.Failures {
+			sb.WriteString(fmt.Sprintf("- **%s:** `%s`: %s\n",
+				strings.Join(failure.Repos, ", "), strings.Join(failure.Files, "`, `"), failure.Error))
+		}
+		sb.WriteString("\n")
+	}
 
 	// No findings case
 	if !report.HasFindings() {
-		sb.WriteString("✅ **No issues found.** Great work!\n")
+		sb.WriteString("✅ **No issues found.** Great work!\n\n")
+		sb.WriteString(fmt.Sprintf("*%s*\n", provenance(report)))
 		return sb.String()
 	}
 
 	// Findings count by severity
-	sb.WriteString(fmt.Sprintf("**Findings:** %d total (%d High, %d Medium, %d Low)\n\n",
-		report.TotalFindings(), report.HighCount(), report.MediumCount(), report.LowCount()))
+	sb.WriteString(fmt.Sprintf("**Findings:** %d total (%s)\n\n",
+		report.TotalFindings(), f.countsLine(report.HighCount(), report.MediumCount(), report.LowCount())))
 
-	// Findings grouped by severity
+	// Findings grouped by severity, under each day when backfilled
 	sb.WriteString("---\n\n")
-	sb.WriteString("## Findings\n\n")
-
-	// High severity first
-	for _, finding := range report.Findings {
-		if finding.Severity == domain.SeverityHigh {
-			f.writeFinding(&sb, finding)
+	for _, day := range findingDays(report) {
+		if day.date.IsZero() {
+			sb.WriteString("## Findings\n\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("## Findings - %s (%d commits)\n\n", day.date.Format("January 2, 2006"), day.commits))
 		}
-	}
-
-	// Medium severity
-	for _, finding := range report.Findings {
-		if finding.Severity == domain.SeverityMedium {
-			f.writeFinding(&sb, finding)
-		}
-	}
-
-	// Low severity
-	for _, finding := range report.Findings {
-		if finding.Severity == domain.SeverityLow {
+		for _, finding := range bySeverity(day.findings) {
 			f.writeFinding(&sb, finding)
 		}
 	}
 
 	// Footer
 	sb.WriteString("---\n\n")
-	sb.WriteString(fmt.Sprintf("*Generated by Code Review Agent at %s*\n",
-		time.Now().Format("15:04 MST")))
+	sb.WriteString(fmt.Sprintf("*%s*\n", provenance(report)))
 
 	return sb.String()
 }
 
-func (f *Formatter) writeFinding(sb *strings.Builder, finding domain.Finding) {
-	// Severity badge
-	var badge string
-	switch finding.Severity {
-	case domain.SeverityHigh:
-		badge = "🔴"
-	case domain.SeverityMedium:
-		badge = "🟡"
-	case domain.SeverityLow:
-		badge = "🟢"
+// dayFindings are the findings of one day of a report
+type dayFindings struct {
+	date     time.Time // Zero when the report isn't split by day
+	commits  int
+	findings []domain.Finding
+}
+
+// findingDays groups the findings of a report that backfilled missed days
+// by day, oldest first, leaving out days without findings. Other reports
+// are one group.
+func findingDays(report *domain.Report) []dayFindings {
+	if len(report.Days) == 0 {
+		return []dayFindings{{findings: report.Findings}}
 	}
+	var days []dayFindings
+	for _, day := range report.Days {
+		group := dayFindings{commits: day.Commits}
+		group.date, _ = time.ParseInLocation(DateLayout, day.Date, report.Date.Location())
+		for _, finding := range report.Findings {
+			if finding.Day == day.Date {
+				group.findings = append(group.findings, finding)
+			}
+		}
+		if len(group.findings) > 0 {
+			days = append(days, group)
+		}
+	}
+	return days
+}
 
-	sb.WriteString(fmt.Sprintf("### %s %s\n\n", badge, finding.Title))
-	sb.WriteString(fmt.Sprintf("**Severity:** %s | **Repository:** %s\n\n", finding.Severity, finding.RepoName))
+// bySeverity returns findings ordered High, Medium, then Low, keeping the
+// order within each severity
+func bySeverity(findings []domain.Finding) []domain.Finding {
+	sorted := slices.Clone(findings)
+	slices.SortStableFunc(sorted, func(a, b domain.Finding) int {
+		return b.Severity.Rank() - a.Severity.Rank()
+	})
+	return sorted
+}
+
+func (f *Formatter) writeFinding(sb *strings.Builder, finding domain.Finding) {
+	sb.WriteString(fmt.Sprintf("### %s %s\n\n", f.Emoji(finding.Severity), finding.Title))
+	sb.WriteString(fmt.Sprintf("**Severity:** %s | **Repository:** %s", f.Label(finding.Severity), finding.RepoName))
+	if finding.Category != "" {
+		sb.WriteString(fmt.Sprintf(" | **Category:** %s", finding.Category))
+	}
+	if finding.PRNumber > 0 {
+		sb.WriteString(fmt.Sprintf(" | **Pull Request:** [#%d](%s)", finding.PRNumber, finding.PRURL))
+	}
+	if finding.RemovedIn != "" {
+		sb.WriteString(fmt.Sprintf(" | **Code removed** in `%s`", domain.ShortHash(finding.RemovedIn)))
+	}
+	sb.WriteString(fmt.Sprintf(" | **ID:** `%s`", finding.Fingerprint()))
+	sb.WriteString("\n\n")
 
 	if len(finding.Files) > 0 {
 		sb.WriteString("**Files:**\n")
@@ -134,11 +244,76 @@ func (f *Formatter) writeFinding(sb *strings.Builder, finding domain.Finding) {
 	sb.WriteString("**Suggested Action:**\n")
 	sb.WriteString(finding.Action)
 	sb.WriteString("\n\n")
+
+	for _, name := range finding.Extensions.Names() {
+		sb.WriteString(fmt.Sprintf("**%s:** %s\n\n", name, finding.Extensions[name]))
+	}
+}
+
+// usageLine describes LLM calls and prompt cache hits, e.g. "3 calls,
+// 12000 input tokens, 4000 from prompt cache (33%)"
+func usageLine(u domain.Usage) string {
+	line := fmt.Sprintf("%d calls, %d input tokens, %d from prompt cache (%.0f%%)",
+		u.Calls, u.InputTokens, u.CachedTokens, 100*u.CacheHitRate())
+	if u.Errors > 0 {
+		line += fmt.Sprintf(", %d failed calls", u.Errors)
+	}
+	if u.Reused > 0 {
+		line += fmt.Sprintf(", %d answers reused from cache", u.Reused)
+	}
+	return line
+}
+
+// provenance describes the build and time that produced the report, e.g.
+// "Generated by Code Review Agent 1.2.0 (abc123) using googleai at 08:00 UTC"
+func provenance(report *domain.Report) string {
+	p := report.Provenance
+	at := p.GeneratedAt
+	if at.IsZero() {
+		at = time.Now() // Reports written before provenance was recorded
+	}
+
+	s := "Generated by Code Review Agent"
+	if p.Version != "" {
+		s += " " + p.Version
+		if p.Commit != "" {
+			s += " (" + p.Commit + ")"
+		}
+	}
+	if p.Provider != "" {
+		s += " using " + p.Provider
+	}
+	return s + " at " + at.Format("15:04 MST")
 }
 
-// ToHTML converts markdown report content to basic HTML for email
+// FormatFinding renders a single finding as Markdown, e.g. for a PR comment
+func (f *Formatter) FormatFinding(finding domain.Finding) string {
+	var sb strings.Builder
+	f.writeFinding(&sb, finding)
+	return sb.String()
+}
+
+// ToHTML renders the report as HTML for its pages in the dashboard and
+// from generate-report, ending with the reviewed diffs when the report
+// carries them and reports.appendix.html is set
 func (f *Formatter) ToHTML(report *domain.Report) string {
-	// Simple HTML version for email
+	return f.html(report, f.appendix.HTML)
+}
+
+// EmailHTML renders the report as HTML for email, with the reviewed diffs
+// only when reports.appendix.email is set
+func (f *Formatter) EmailHTML(report *domain.Report) string {
+	return f.html(report, f.appendix.Email)
+}
+
+// html renders the report as basic HTML, with the appendix of reviewed
+// diffs when appendix is set and the report carries them
+func (f *Formatter) html(report *domain.Report, appendix bool) string {
+	var anchors map[string]string
+	if appendix {
+		anchors = diffAnchors(report.Diffs)
+	}
+
 	var sb strings.Builder
 
 	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
@@ -146,57 +321,120 @@ func (f *Formatter) ToHTML(report *domain.Report) string {
 	sb.WriteString("body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; max-width: 800px; margin: 0 auto; padding: 20px; }\n")
 	sb.WriteString("h1 { color: #1a1a1a; border-bottom: 2px solid #667eea; padding-bottom: 10px; }\n")
 	sb.WriteString("h3 { margin-top: 24px; }\n")
-	sb.WriteString(".high { color: #dc2626; }\n")
-	sb.WriteString(".medium { color: #d97706; }\n")
-	sb.WriteString(".low { color: #059669; }\n")
 	sb.WriteString(".finding { background: #f9fafb; border-left: 4px solid #667eea; padding: 16px; margin: 16px 0; }\n")
-	sb.WriteString(".finding-high { border-left-color: #dc2626; }\n")
-	sb.WriteString(".finding-medium { border-left-color: #d97706; }\n")
-	sb.WriteString(".finding-low { border-left-color: #059669; }\n")
+	sb.WriteString(f.SeverityCSS())
 	sb.WriteString("code { background: #f3f4f6; padding: 2px 6px; border-radius: 4px; font-size: 14px; }\n")
+	if len(anchors) > 0 {
+		sb.WriteString(appendixCSS)
+	}
 	sb.WriteString("</style>\n</head>\n<body>\n")
 
 	sb.WriteString(fmt.Sprintf("<h1>Code Review Report - %s</h1>\n", report.Date.Format("January 2, 2006")))
-	sb.WriteString(fmt.Sprintf("<p>%s</p>\n", report.Summary))
+	for _, note := range report.UserNotes {
+		sb.WriteString(fmt.Sprintf("<p>📝 <strong>Note:</strong> %s</p>\n", html.EscapeString(note)))
+	}
+	sb.WriteString(fmt.Sprintf("<p>%s</p>\n", html.EscapeString(report.Summary)))
 
 	if report.CommitCount > 0 {
 		sb.WriteString(fmt.Sprintf("<p><strong>Reviewed:</strong> %d commits across %d files in %d repositories</p>\n",
 			report.CommitCount, report.FileCount, len(report.Repositories)))
 	}
 
+	for _, note := range report.Notes {
+		sb.WriteString(fmt.Sprintf("<p>⚠️ %s</p>\n", html.EscapeString(note)))
+	}
+
+	if len(report.Failures) > 0 {
+		sb.WriteString("<h2>Review Errors</h2>\n<p>These changes could not be reviewed and may hide issues:</p>\n<ul>\n")
+		for _, failure := range report.Failures {
+			sb.WriteString(fmt.Sprintf("<li><strong>%s:</strong> <code>%s</code>: %s</li>\n",
+				html.EscapeString(strings.Join(failure.Repos, ", ")),
+				html.EscapeString(strings.Join(failure.Files, ", ")),
+				html.EscapeString(failure.Error)))
+		}
+		sb.WriteString("</ul>\n")
+	}
+
 	if !report.HasFindings() {
 		sb.WriteString("<p>✅ <strong>No issues found.</strong> Great work!</p>\n")
 	} else {
-		sb.WriteString(fmt.Sprintf("<p><strong>Findings:</strong> %d total (<span class='high'>%d High</span>, <span class='medium'>%d Medium</span>, <span class='low'>%d Low</span>)</p>\n",
-			report.TotalFindings(), report.HighCount(), report.MediumCount(), report.LowCount()))
+		sb.WriteString(fmt.Sprintf("<p><strong>Findings:</strong> %d total (%s)</p>\n",
+			report.TotalFindings(), f.countsHTML(report)))
 
-		for _, finding := range report.Findings {
-			severityClass := strings.ToLower(string(finding.Severity))
-			sb.WriteString(fmt.Sprintf("<div class='finding finding-%s'>\n", severityClass))
-			sb.WriteString(fmt.Sprintf("<h3>%s</h3>\n", finding.Title))
-			sb.WriteString(fmt.Sprintf("<p><strong>Severity:</strong> <span class='%s'>%s</span> | <strong>Repository:</strong> %s</p>\n",
-				severityClass, finding.Severity, finding.RepoName))
-
-			if len(finding.Files) > 0 {
-				sb.WriteString("<p><strong>Files:</strong> ")
-				for i, file := range finding.Files {
-					if i > 0 {
-						sb.WriteString(", ")
-					}
-					sb.WriteString(fmt.Sprintf("<code>%s</code>", file))
-				}
-				sb.WriteString("</p>\n")
+		for _, day := range findingDays(report) {
+			if !day.date.IsZero() {
+				sb.WriteString(fmt.Sprintf("<h2>%s (%d commits)</h2>\n", day.date.Format("January 2, 2006"), day.commits))
+			}
+			for _, finding := range day.findings {
+				f.writeFindingHTML(&sb, finding, anchors)
 			}
-
-			sb.WriteString(fmt.Sprintf("<p><strong>Issue:</strong> %s</p>\n", finding.Explanation))
-			sb.WriteString(fmt.Sprintf("<p><strong>Suggested Action:</strong> %s</p>\n", finding.Action))
-			sb.WriteString("</div>\n")
 		}
 	}
+	if len(anchors) > 0 {
+		writeAppendixHTML(&sb, report.Diffs, anchors)
+	}
 
-	sb.WriteString(fmt.Sprintf("<p style='color: #6b7280; font-size: 12px; margin-top: 40px;'>Generated by Code Review Agent at %s</p>\n",
-		time.Now().Format("15:04 MST")))
+	sb.WriteString(fmt.Sprintf("<p style='color: #6b7280; font-size: 12px; margin-top: 40px;'>%s</p>\n", html.EscapeString(provenance(report))))
 	sb.WriteString("</body>\n</html>")
 
 	return sb.String()
 }
+
+// writeFindingHTML renders one finding of the HTML report, its files
+// linking to their diffs in the appendix through anchors, keyed by
+// diffKey
+func (f *Formatter) writeFindingHTML(sb *strings.Builder, finding domain.Finding, anchors map[string]string) {
+	// Findings come from the model and stored JSON, so every field is
+	// escaped
+	class := severityClass(finding.Severity)
+	sb.WriteString(fmt.Sprintf("<div class='finding finding-%s'>\n", class))
+	sb.WriteString(fmt.Sprintf("<h3>%s %s</h3>\n", f.Emoji(finding.Severity), html.EscapeString(finding.Title)))
+	sb.WriteString(fmt.Sprintf("<p><strong>Severity:</strong> <span class='%s'>%s</span> | <strong>Repository:</strong> %s",
+		class, html.EscapeString(f.Label(finding.Severity)), html.EscapeString(finding.RepoName)))
+	if finding.Category != "" {
+		sb.WriteString(fmt.Sprintf(" | <strong>Category:</strong> %s", html.EscapeString(string(finding.Category))))
+	}
+	if finding.PRNumber > 0 {
+		if u := webURL(finding.PRURL); u != "" {
+			sb.WriteString(fmt.Sprintf(" | <strong>Pull Request:</strong> <a href='%s'>#%d</a>", html.EscapeString(u), finding.PRNumber))
+		} else {
+			sb.WriteString(fmt.Sprintf(" | <strong>Pull Request:</strong> #%d", finding.PRNumber))
+		}
+	}
+	if finding.RemovedIn != "" {
+		sb.WriteString(fmt.Sprintf(" | <strong>Code removed</strong> in <code>%s</code>", domain.ShortHash(finding.RemovedIn)))
+	}
+	sb.WriteString("</p>\n")
+
+	if len(finding.Files) > 0 {
+		sb.WriteString("<p><strong>Files:</strong> ")
+		for i, file := range finding.Files {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			if anchor, ok := anchors[diffKey(finding.RepoName, file)]; ok {
+				sb.WriteString(fmt.Sprintf("<a href='#%s'><code>%s</code></a>", anchor, html.EscapeString(file)))
+			} else {
+				sb.WriteString(fmt.Sprintf("<code>%s</code>", html.EscapeString(file)))
+			}
+		}
+		sb.WriteString("</p>\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("<p><strong>Issue:</strong> %s</p>\n", html.EscapeString(finding.Explanation)))
+	sb.WriteString(fmt.Sprintf("<p><strong>Suggested Action:</strong> %s</p>\n", html.EscapeString(finding.Action)))
+	for _, name := range finding.Extensions.Names() {
+		sb.WriteString(fmt.Sprintf("<p><strong>%s:</strong> %s</p>\n", html.EscapeString(name), html.EscapeString(finding.Extensions[name])))
+	}
+	sb.WriteString("</div>\n")
+}
+
+// webURL returns u when it is an http or https URL, so a link taken from
+// stored data can't run script, empty otherwise
+func webURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ""
+	}
+	return u
+}
@@ -0,0 +1,21 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+func TestHTMLEscapesNotes(t *testing.T) {
+	f := NewFormatter(config.ReportsConfig{OutputDir: t.TempDir()})
+	out := f.ToHTML(&domain.Report{Date: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Notes: []string{"<script>alert(1)</script>"}})
+	if strings.Contains(out, "<script>") {
+		t.Fatalf("note not escaped:\n%s", out)
+	}
+	if !strings.Contains(out, "&lt;script&gt;") {
+		t.Errorf("escaped note missing:\n%s", out)
+	}
+}
@@ -0,0 +1,168 @@
+package report
+
+import (
+	"slices"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// HealthWindow is how many of the latest report days the recent provider
+// figures cover
+const HealthWindow = 7
+
+// CallStats tallies LLM calls
+type CallStats struct {
+	Requests  int   `json:"requests"`
+	Errors    int   `json:"errors"`
+	LatencyMS int64 `json:"latency_ms"`
+}
+
+func (c *CallStats) add(m domain.ModelUsage) {
+	c.Requests += m.Requests()
+	c.Errors += m.Errors
+	c.LatencyMS += m.LatencyMS
+}
+
+// ErrorRate is the share of requests that failed, 0 to 1
+func (c CallStats) ErrorRate() float64 {
+	if c.Requests == 0 {
+		return 0
+	}
+	return float64(c.Errors) / float64(c.Requests)
+}
+
+// AvgLatency is the average time a request took
+func (c CallStats) AvgLatency() time.Duration {
+	if c.Requests == 0 {
+		return 0
+	}
+	return time.Duration(c.LatencyMS/int64(c.Requests)) * time.Millisecond
+}
+
+// ProviderDay is one report day's calls to a provider
+type ProviderDay struct {
+	Date string `json:"date"`
+	CallStats
+}
+
+// ProviderHealth summarizes the calls to one LLM provider across the
+// report history
+type ProviderHealth struct {
+	Provider string    `json:"provider"`
+	Models   []string  `json:"models"`
+	Runs     int       `json:"runs"`
+	Failed   int       `json:"failed_runs"` // Runs that failed before writing a report
+	LastUsed time.Time `json:"last_used"`
+	CallStats
+	// ByModel splits the calls by model, in Models order
+	ByModel []ModelHealth `json:"by_model"`
+	// Recent covers the provider's last HealthWindow report days
+	Recent CallStats     `json:"recent"`
+	Days   []ProviderDay `json:"days"` // Oldest first
+}
+
+// ModelHealth tallies the calls to one model of a provider
+type ModelHealth struct {
+	Model string `json:"model"`
+	CallStats
+}
+
+// providerRun is the usage of one run, report or failed, by a provider
+type providerRun struct {
+	date   time.Time
+	failed bool
+	models []domain.ModelUsage
+}
+
+// ComputeProviderHealth tallies the LLM calls recorded in reports and
+// failed runs (both oldest first) by provider, most recently used first.
+// Calls are credited to the provider and model that made them; reports
+// written before usage was recorded per model credit theirs to the
+// report's provider and model. Reports without usage or provenance, e.g.
+// heuristic reviews, are left out.
+func ComputeProviderHealth(reports []*domain.Report, failed []domain.FailedRun) []ProviderHealth {
+	var runs []providerRun
+	for _, rpt := range reports {
+		if rpt.Usage == nil {
+			continue
+		}
+		runs = append(runs, providerRun{date: rpt.Date, models: modelUsage(*rpt.Usage, rpt.Provenance.Provider, rpt.Model)})
+	}
+	for _, run := range failed {
+		runs = append(runs, providerRun{date: run.Time, failed: true, models: run.Usage.Models})
+	}
+	slices.SortStableFunc(runs, func(a, b providerRun) int {
+		return a.date.Compare(b.date)
+	})
+
+	var health []ProviderHealth
+	index := make(map[string]int)
+	for _, run := range runs {
+		counted := make(map[string]bool)
+		for _, m := range run.models {
+			if m.Provider == "" || m.Requests() == 0 {
+				continue
+			}
+			i, ok := index[m.Provider]
+			if !ok {
+				i = len(health)
+				index[m.Provider] = i
+				health = append(health, ProviderHealth{Provider: m.Provider})
+			}
+			h := &health[i]
+			if !counted[m.Provider] {
+				counted[m.Provider] = true
+				if run.failed {
+					h.Failed++
+				} else {
+					h.Runs++
+				}
+			}
+			h.add(m)
+			if m.Model != "" {
+				j := slices.Index(h.Models, m.Model)
+				if j < 0 {
+					j = len(h.Models)
+					h.Models = append(h.Models, m.Model)
+					h.ByModel = append(h.ByModel, ModelHealth{Model: m.Model})
+				}
+				h.ByModel[j].add(m)
+			}
+			if run.date.After(h.LastUsed) {
+				h.LastUsed = run.date
+			}
+
+			date := run.date.Format(DateLayout)
+			if n := len(h.Days); n > 0 && h.Days[n-1].Date == date {
+				h.Days[n-1].add(m)
+			} else {
+				day := ProviderDay{Date: date}
+				day.add(m)
+				h.Days = append(h.Days, day)
+			}
+		}
+	}
+
+	for i := range health {
+		h := &health[i]
+		for _, day := range h.Days[max(0, len(h.Days)-HealthWindow):] {
+			h.Recent.Requests += day.Requests
+			h.Recent.Errors += day.Errors
+			h.Recent.LatencyMS += day.LatencyMS
+		}
+	}
+	slices.SortStableFunc(health, func(a, b ProviderHealth) int {
+		return b.LastUsed.Compare(a.LastUsed)
+	})
+	return health
+}
+
+// modelUsage returns the per-model usage of a report, all of it credited
+// to its provider and model when it predates per-model records
+func modelUsage(u domain.Usage, provider, model string) []domain.ModelUsage {
+	if len(u.Models) > 0 {
+		return u.Models
+	}
+	return []domain.ModelUsage{{Provider: provider, Model: model, Calls: u.Calls, Errors: u.Errors, LatencyMS: u.LatencyMS}}
+}
@@ -0,0 +1,59 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+func TestComputeProviderHealth(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 8, 0, 0, 0, time.UTC) }
+	reports := []*domain.Report{
+		// Before per-model usage: credited to the report's provider
+		{Date: day(1), Model: "gemini-2.0-flash", Provenance: domain.Provenance{Provider: "googleai"},
+			Usage: &domain.Usage{Calls: 3, Errors: 1, LatencyMS: 400}},
+		{Date: day(2), Model: "gemini-2.0-flash", Provenance: domain.Provenance{Provider: "googleai"},
+			Usage: &domain.Usage{Calls: 3, Models: []domain.ModelUsage{
+				{Provider: "googleai", Model: "googleai/gemini-2.0-flash", Calls: 1, LatencyMS: 100},
+				{Provider: "googleai", Model: "googleai/gemini-2.5-pro", Calls: 1, LatencyMS: 300},
+				{Provider: "openai", Model: "openai/glm-4.7", Calls: 1, LatencyMS: 200},
+			}}},
+		// Heuristic review
+		{Date: day(3), Model: "heuristics"},
+	}
+	failed := []domain.FailedRun{
+		{Time: day(4), Error: "reviewing code: quota exceeded", Usage: domain.Usage{Errors: 3, Models: []domain.ModelUsage{
+			{Provider: "openai", Model: "openai/glm-4.7", Errors: 3, LatencyMS: 900},
+		}}},
+	}
+
+	health := ComputeProviderHealth(reports, failed)
+	if len(health) != 2 {
+		t.Fatalf("got %d providers, want 2", len(health))
+	}
+
+	// Most recently used first
+	openai, google := health[0], health[1]
+	if openai.Provider != "openai" || google.Provider != "googleai" {
+		t.Fatalf("providers = %s, %s; want openai, googleai", openai.Provider, google.Provider)
+	}
+	if openai.Runs != 1 || openai.Failed != 1 || openai.Requests != 4 || openai.Errors != 3 {
+		t.Errorf("openai = %d runs, %d failed, %d requests, %d errors; want 1, 1, 4, 3", openai.Runs, openai.Failed, openai.Requests, openai.Errors)
+	}
+	if !openai.LastUsed.Equal(day(4)) {
+		t.Errorf("openai last used %v, want the failed run's %v", openai.LastUsed, day(4))
+	}
+	if google.Runs != 2 || google.Failed != 0 || google.Requests != 6 || google.Errors != 1 {
+		t.Errorf("googleai = %d runs, %d failed, %d requests, %d errors; want 2, 0, 6, 1", google.Runs, google.Failed, google.Requests, google.Errors)
+	}
+	if len(google.ByModel) != 3 {
+		t.Fatalf("googleai models = %v, want 3", google.Models)
+	}
+	if pro := google.ByModel[2]; pro.Model != "googleai/gemini-2.5-pro" || pro.Requests != 1 || pro.AvgLatency() != 300*time.Millisecond {
+		t.Errorf("strong model = %+v", pro)
+	}
+	if len(google.Days) != 2 || google.Recent.Requests != 6 {
+		t.Errorf("googleai days = %v, recent = %+v", google.Days, google.Recent)
+	}
+}
@@ -0,0 +1,166 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// DateLayout is the date format used in report file names
+const DateLayout = "2006-01-02"
+
+// writeMetadata stores the report data as JSON alongside the Markdown
+// report. The diffs aren't part of it, as the history commands read every
+// report's data.
+func (f *Formatter) writeMetadata(date string, report *domain.Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding report metadata: %w", err)
+	}
+
+	if _, err := f.store.Put(date, ArtifactReport, data); err != nil {
+		return fmt.Errorf("writing report metadata: %w", err)
+	}
+	return nil
+}
+
+// History loads the metadata of every stored report, oldest first.
+// Reports written before metadata was recorded are skipped.
+func (f *Formatter) History() ([]*domain.Report, error) {
+	dates, err := f.store.Dates(ArtifactReport)
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []*domain.Report
+	for _, date := range dates {
+		rpt, err := f.Load(date)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, rpt)
+	}
+	return reports, nil
+}
+
+// Load returns the stored report data for a date (YYYY-MM-DD)
+func (f *Formatter) Load(date string) (*domain.Report, error) {
+	data, err := f.store.Get(date, ArtifactReport)
+	if err != nil {
+		return nil, fmt.Errorf("reading report metadata: %w", err)
+	}
+
+	var rpt domain.Report
+	if err := json.Unmarshal(data, &rpt); err != nil {
+		return nil, fmt.Errorf("parsing report data of %s: %w", date, err)
+	}
+	return &rpt, nil
+}
+
+// LoadMarkdown returns the stored Markdown report for a date (YYYY-MM-DD)
+func (f *Formatter) LoadMarkdown(date string) ([]byte, error) {
+	return f.store.Get(date, ArtifactMarkdown)
+}
+
+// MarkdownPath returns the path of the Markdown report for a date
+// (YYYY-MM-DD), empty when there is none
+func (f *Formatter) MarkdownPath(date string) string {
+	path, err := f.store.Path(date, ArtifactMarkdown)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// SaveResponses stores the raw model answers of the run for a date
+// (YYYY-MM-DD), see review.save_responses
+func (f *Formatter) SaveResponses(date string, data []byte) (string, error) {
+	return f.store.Put(date, ArtifactResponses, data)
+}
+
+// SavePrompts stores the prompts sent to the model during the run for a
+// date (YYYY-MM-DD), see review.save_responses
+func (f *Formatter) SavePrompts(date string, data []byte) (string, error) {
+	return f.store.Put(date, ArtifactPrompts, data)
+}
+
+// LoadResponses returns the raw model answers stored for a date
+// (YYYY-MM-DD)
+func (f *Formatter) LoadResponses(date string) ([]byte, error) {
+	return f.store.Get(date, ArtifactResponses)
+}
+
+// SaveWindow stores the review window of the run for a date (YYYY-MM-DD)
+func (f *Formatter) SaveWindow(date string, data []byte) error {
+	_, err := f.store.Put(date, ArtifactWindow, data)
+	return err
+}
+
+// LatestWindow returns the most recently dated review window stored, nil
+// when there is none
+func (f *Formatter) LatestWindow() ([]byte, error) {
+	dates, err := f.store.Dates(ArtifactWindow)
+	if err != nil || len(dates) == 0 {
+		return nil, err
+	}
+	return f.store.Get(dates[len(dates)-1], ArtifactWindow)
+}
+
+// LatestDate returns the date of the most recent stored report
+func (f *Formatter) LatestDate() (string, error) {
+	dates, err := f.store.Dates(ArtifactMarkdown)
+	if err != nil {
+		return "", err
+	}
+	if len(dates) == 0 {
+		return "", fmt.Errorf("no reports in %s", f.outputDir)
+	}
+	return dates[len(dates)-1], nil
+}
+
+// SaveFailedRun adds run to the failed runs stored for a date
+// (YYYY-MM-DD)
+func (f *Formatter) SaveFailedRun(date string, run domain.FailedRun) error {
+	var runs []domain.FailedRun
+	data, err := f.store.Get(date, ArtifactFailed)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &runs); err != nil {
+			return fmt.Errorf("parsing failed runs of %s: %w", date, err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	data, err = json.MarshalIndent(append(runs, run), "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding failed runs: %w", err)
+	}
+	_, err = f.store.Put(date, ArtifactFailed, data)
+	return err
+}
+
+// FailedRuns loads every stored failed run, oldest first
+func (f *Formatter) FailedRuns() ([]domain.FailedRun, error) {
+	dates, err := f.store.Dates(ArtifactFailed)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []domain.FailedRun
+	for _, date := range dates {
+		data, err := f.store.Get(date, ArtifactFailed)
+		if err != nil {
+			return nil, err
+		}
+		var day []domain.FailedRun
+		if err := json.Unmarshal(data, &day); err != nil {
+			return nil, fmt.Errorf("parsing failed runs of %s: %w", date, err)
+		}
+		runs = append(runs, day...)
+	}
+	return runs, nil
+}
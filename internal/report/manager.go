@@ -0,0 +1,206 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// MaxTopRisks caps the findings listed in the management summary
+const MaxTopRisks = 5
+
+// repoCount is the number of findings in one repository
+type repoCount struct {
+	Repo  string
+	Count int
+	High  int
+}
+
+// FormatManager renders the condensed management summary of a report as
+// Markdown. previous is the prior report used for the trend line and may
+// be nil. File paths and code suggestions are left out.
+func (f *Formatter) FormatManager(report, previous *domain.Report) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("# Code Review Summary - %s\n\n", report.Date.Format("January 2, 2006")))
+	sb.WriteString(report.Summary)
+	sb.WriteString("\n\n")
+
+	sb.WriteString(fmt.Sprintf("- **Activity:** %d commits, %d files, %d repositories\n",
+		report.CommitCount, report.FileCount, len(report.Repositories)))
+	sb.WriteString(fmt.Sprintf("- **Findings:** %d total (%s)\n",
+		report.TotalFindings(), f.countsLine(report.HighCount(), report.MediumCount(), report.LowCount())))
+	if trend := f.trend(report, previous); trend != "" {
+		sb.WriteString(fmt.Sprintf("- **Trend:** %s\n", trend))
+	}
+	for _, note := range report.UserNotes {
+		sb.WriteString(fmt.Sprintf("- 📝 %s\n", note))
+	}
+	for _, note := range report.Notes {
+		sb.WriteString(fmt.Sprintf("- ⚠️ %s\n", note))
+	}
+	if n := len(report.Failures); n > 0 {
+		sb.WriteString(fmt.Sprintf("- ⚠️ %s\n", failuresNote(n)))
+	}
+	sb.WriteString("\n")
+
+	if !report.HasFindings() {
+		sb.WriteString("✅ **No issues found.**\n")
+		return sb.String()
+	}
+
+	sb.WriteString("## Top Risks\n\n")
+	for _, finding := range topRisks(report.Findings) {
+		sb.WriteString(fmt.Sprintf("- %s **%s** — %s (%s)\n", f.Emoji(finding.Severity), f.Label(finding.Severity), finding.Title, finding.RepoName))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("## By Repository\n\n")
+	sb.WriteString(fmt.Sprintf("| Repository | Findings | %s |\n|---|---|---|\n", f.Label(domain.SeverityHigh)))
+	for _, rc := range countByRepo(report.Findings) {
+		sb.WriteString(fmt.Sprintf("| %s | %d | %d |\n", rc.Repo, rc.Count, rc.High))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(fmt.Sprintf("*%s*\n", provenance(report)))
+	return sb.String()
+}
+
+// ManagerHTML renders the management summary as HTML for email
+func (f *Formatter) ManagerHTML(report, previous *domain.Report) string {
+	var sb strings.Builder
+
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	sb.WriteString("<style>\n")
+	sb.WriteString("body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; max-width: 800px; margin: 0 auto; padding: 20px; }\n")
+	sb.WriteString("h1 { color: #1a1a1a; border-bottom: 2px solid #667eea; padding-bottom: 10px; }\n")
+	sb.WriteString(f.SeverityCSS())
+	sb.WriteString("table { border-collapse: collapse; }\n")
+	sb.WriteString("td, th { border: 1px solid #e5e7eb; padding: 6px 12px; text-align: left; }\n")
+	sb.WriteString("</style>\n</head>\n<body>\n")
+
+	sb.WriteString(fmt.Sprintf("<h1>Code Review Summary - %s</h1>\n", report.Date.Format("January 2, 2006")))
+	sb.WriteString(fmt.Sprintf("<p>%s</p>\n", html.EscapeString(report.Summary)))
+
+	sb.WriteString("<ul>\n")
+	sb.WriteString(fmt.Sprintf("<li><strong>Activity:</strong> %d commits, %d files, %d repositories</li>\n",
+		report.CommitCount, report.FileCount, len(report.Repositories)))
+	sb.WriteString(fmt.Sprintf("<li><strong>Findings:</strong> %d total (%s)</li>\n",
+		report.TotalFindings(), f.countsHTML(report)))
+	if trend := f.trend(report, previous); trend != "" {
+		sb.WriteString(fmt.Sprintf("<li><strong>Trend:</strong> %s</li>\n", html.EscapeString(trend)))
+	}
+	for _, note := range report.UserNotes {
+		sb.WriteString(fmt.Sprintf("<li>📝 %s</li>\n", html.EscapeString(note)))
+	}
+	for _, note := range report.Notes {
+		sb.WriteString(fmt.Sprintf("<li>⚠️ %s</li>\n", html.EscapeString(note)))
+	}
+	if n := len(report.Failures); n > 0 {
+		sb.WriteString(fmt.Sprintf("<li>⚠️ %s</li>\n", failuresNote(n)))
+	}
+	sb.WriteString("</ul>\n")
+
+	if !report.HasFindings() {
+		sb.WriteString("<p>✅ <strong>No issues found.</strong></p>\n")
+	} else {
+		sb.WriteString("<h2>Top Risks</h2>\n<ul>\n")
+		for _, finding := range topRisks(report.Findings) {
+			class := severityClass(finding.Severity)
+			sb.WriteString(fmt.Sprintf("<li><span class='%s'>%s %s</span> — %s (%s)</li>\n",
+				class, f.Emoji(finding.Severity), html.EscapeString(f.Label(finding.Severity)),
+				html.EscapeString(finding.Title), html.EscapeString(finding.RepoName)))
+		}
+		sb.WriteString("</ul>\n")
+
+		sb.WriteString(fmt.Sprintf("<h2>By Repository</h2>\n<table>\n<tr><th>Repository</th><th>Findings</th><th>%s</th></tr>\n",
+			html.EscapeString(f.Label(domain.SeverityHigh))))
+		for _, rc := range countByRepo(report.Findings) {
+			sb.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%d</td><td>%d</td></tr>\n", html.EscapeString(rc.Repo), rc.Count, rc.High))
+		}
+		sb.WriteString("</table>\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("<p style='color: #6b7280; font-size: 12px; margin-top: 40px;'>%s</p>\n", html.EscapeString(provenance(report))))
+	sb.WriteString("</body>\n</html>")
+
+	return sb.String()
+}
+
+// Previous returns the most recent stored report dated before date, or nil
+func (f *Formatter) Previous(date time.Time) *domain.Report {
+	reports, err := f.History()
+	if err != nil {
+		return nil
+	}
+
+	day := date.Format(DateLayout)
+	for i := len(reports) - 1; i >= 0; i-- {
+		if reports[i].Date.Format(DateLayout) < day {
+			return reports[i]
+		}
+	}
+	return nil
+}
+
+// trend describes the change in findings since the previous report
+func (f *Formatter) trend(report, previous *domain.Report) string {
+	if previous == nil {
+		return ""
+	}
+
+	since := previous.Date.Format("Jan 2")
+	total := report.TotalFindings() - previous.TotalFindings()
+	high := report.HighCount() - previous.HighCount()
+	if total == 0 && high == 0 {
+		return fmt.Sprintf("unchanged since %s", since)
+	}
+	return fmt.Sprintf("%+d findings (%+d %s) since %s", total, high, f.Label(domain.SeverityHigh), since)
+}
+
+// topRisks returns the most severe findings, highest first
+func topRisks(findings []domain.Finding) []domain.Finding {
+	sorted := make([]domain.Finding, len(findings))
+	copy(sorted, findings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Severity.Rank() > sorted[j].Severity.Rank()
+	})
+	if len(sorted) > MaxTopRisks {
+		sorted = sorted[:MaxTopRisks]
+	}
+	return sorted
+}
+
+// countByRepo tallies findings per repository, most findings first
+func countByRepo(findings []domain.Finding) []repoCount {
+	index := make(map[string]int)
+	var counts []repoCount
+	for _, finding := range findings {
+		i, ok := index[finding.RepoName]
+		if !ok {
+			i = len(counts)
+			index[finding.RepoName] = i
+			counts = append(counts, repoCount{Repo: finding.RepoName})
+		}
+		counts[i].Count++
+		if finding.Severity == domain.SeverityHigh {
+			counts[i].High++
+		}
+	}
+	sort.SliceStable(counts, func(i, j int) bool {
+		return counts[i].Count > counts[j].Count
+	})
+	return counts
+}
+
+// failuresNote summarizes unreviewed changes without naming files
+func failuresNote(n int) string {
+	if n == 1 {
+		return "1 batch of changes could not be reviewed; see the engineer report."
+	}
+	return fmt.Sprintf("%d batches of changes could not be reviewed; see the engineer report.", n)
+}
@@ -0,0 +1,72 @@
+package report
+
+import (
+	"strings"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// Query filters historical findings. Zero fields match everything.
+type Query struct {
+	Repo        string          // Repository name, case-insensitive
+	MinSeverity domain.Severity // Drop findings below this severity
+	From        time.Time       // First report date included
+	To          time.Time       // Last report date included
+	Text        string          // Case-insensitive match on title, explanation, action or files
+}
+
+// Record is a finding together with the date of the report it appeared in
+// and its fingerprint, which `review suppress` accepts
+type Record struct {
+	ID   string    `json:"id"`
+	Date time.Time `json:"date"`
+	domain.Finding
+}
+
+// Findings returns the stored findings matching q, oldest first
+func (f *Formatter) Findings(q Query) ([]Record, error) {
+	reports, err := f.History()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []Record
+	for _, rpt := range reports {
+		day := rpt.Date.Format(DateLayout)
+		if !q.From.IsZero() && day < q.From.Format(DateLayout) {
+			continue
+		}
+		if !q.To.IsZero() && day > q.To.Format(DateLayout) {
+			continue
+		}
+
+		for _, finding := range rpt.Findings {
+			if q.matches(finding) {
+				records = append(records, Record{ID: finding.Fingerprint(), Date: rpt.Date, Finding: finding})
+			}
+		}
+	}
+	return records, nil
+}
+
+func (q Query) matches(f domain.Finding) bool {
+	if q.Repo != "" && !strings.EqualFold(f.RepoName, q.Repo) {
+		return false
+	}
+	if q.MinSeverity != "" && f.Severity.Rank() < q.MinSeverity.Rank() {
+		return false
+	}
+	if q.Text == "" {
+		return true
+	}
+
+	text := strings.ToLower(q.Text)
+	fields := append([]string{f.Title, f.Explanation, f.Action}, f.Files...)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// DecodeJSON reads an exported report: the output of --format json, or
+// a run manifest written with --output, whose summary is left empty
+func DecodeJSON(data []byte) (*domain.Report, error) {
+	var probe struct {
+		Version int    `json:"version"`
+		Status  string `json:"status"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("parsing report JSON: %w", err)
+	}
+	if probe.Version == 0 || probe.Status == "" {
+		var rpt domain.Report
+		if err := json.Unmarshal(data, &rpt); err != nil {
+			return nil, fmt.Errorf("parsing report JSON: %w", err)
+		}
+		return &rpt, nil
+	}
+
+	var m domain.Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing manifest JSON: %w", err)
+	}
+	if m.Version > domain.ManifestVersion {
+		return nil, fmt.Errorf("manifest version %d is newer than this build reads (%d)", m.Version, domain.ManifestVersion)
+	}
+	if m.ReportDate == "" {
+		return nil, fmt.Errorf("the manifest has no report: the run was %s", m.Status)
+	}
+	return manifestReport(&m)
+}
+
+// manifestReport rebuilds the report recorded in a manifest
+func manifestReport(m *domain.Manifest) (*domain.Report, error) {
+	date, err := time.ParseInLocation(DateLayout, m.ReportDate, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("manifest report_date: %w", err)
+	}
+	rpt := &domain.Report{
+		Date:          date,
+		Findings:      m.Findings,
+		CommitCount:   len(m.Commits),
+		FileCount:     len(m.Diffs),
+		NothingToNote: len(m.Findings) == 0,
+		Model:         m.Model,
+		Failures:      m.Failures,
+		Exclusions:    m.Exclusions,
+		Usage:         m.Usage,
+		Sampling:      m.Sampling,
+		Provenance:    m.Provenance,
+	}
+	for _, repo := range m.Repos {
+		rpt.Repositories = append(rpt.Repositories, repo.Name)
+	}
+	return rpt, nil
+}
+
+// browsers are the headless browsers PDF looks for on PATH
+var browsers = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable", "chrome", "msedge"}
+
+// pageCSP keeps a printed page from running script or loading anything
+// but inline styles and images: reports carry model output
+const pageCSP = "default-src 'none'; style-src 'unsafe-inline'; img-src data:"
+
+// PDF prints an HTML page to PDF with a headless Chrome or Chromium. The
+// page is served from a one-off loopback address rather than a file, so it
+// can't reach local files, and with script disabled.
+func PDF(ctx context.Context, page string) ([]byte, error) {
+	var browser string
+	for _, name := range browsers {
+		if path, err := exec.LookPath(name); err == nil {
+			browser = path
+			break
+		}
+	}
+	if browser == "" {
+		return nil, fmt.Errorf("rendering PDF needs Chrome or Chromium on PATH")
+	}
+
+	dir, err := os.MkdirTemp("", "cra-pdf-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "report.pdf")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("serving the page to print: %w", err)
+	}
+	token := filepath.Base(dir) // Unguessable, so other local users can't read the page
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/"+token {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Content-Security-Policy", pageCSP)
+			w.Write([]byte(page))
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	cmd := exec.CommandContext(ctx, browser, "--headless", "--disable-gpu",
+		"--no-pdf-header-footer", "--user-data-dir="+filepath.Join(dir, "profile"),
+		"--blink-settings=scriptEnabled=false",
+		"--print-to-pdf="+out, "http://"+ln.Addr().String()+"/"+token)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", filepath.Base(browser), err, output)
+	}
+	return os.ReadFile(out)
+}
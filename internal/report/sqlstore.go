@@ -0,0 +1,198 @@
+package report
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // postgres driver "pgx"
+	_ "modernc.org/sqlite"             // sqlite driver "sqlite"
+)
+
+// sqlStore keeps run artifacts in a SQLite or PostgreSQL table, one row
+// per run and kind with its SHA-256, and the hub's user accounts
+type sqlStore struct {
+	db        *sql.DB
+	location  string // For Path, without credentials
+	outputDir string // Migrated from
+}
+
+// openSQL connects to the database and creates the table if needed
+func openSQL(backend, dsn, outputDir string) (*sqlStore, error) {
+	driver, blob, location := "pgx", "BYTEA", "postgres"
+	if backend == BackendSQLite {
+		if err := os.MkdirAll(filepath.Dir(dsn), 0755); err != nil {
+			return nil, err
+		}
+		driver, blob, location = "sqlite", "BLOB", "sqlite:"+dsn
+		if !strings.Contains(dsn, "busy_timeout") {
+			// serve and scheduled runs share the file: wait for the other
+			// writer instead of failing with "database is locked"
+			sep := "?"
+			if strings.Contains(dsn, "?") {
+				sep = "&"
+			}
+			dsn += sep + "_pragma=busy_timeout(30000)"
+		}
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if backend == BackendSQLite {
+		// One writer at a time; more connections only contend for the lock
+		db.SetMaxOpenConns(1)
+	}
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS cra_artifacts (
+		run_date TEXT NOT NULL,
+		kind     TEXT NOT NULL,
+		sha256   TEXT NOT NULL,
+		data     ` + blob + ` NOT NULL,
+		PRIMARY KEY (run_date, kind)
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	s := &sqlStore{db: db, location: location, outputDir: outputDir}
+	if err := s.createUsersTable(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := s.createStateTable(blob); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *sqlStore) Put(date, kind string, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	_, err := s.db.Exec(`INSERT INTO cra_artifacts (run_date, kind, sha256, data) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (run_date, kind) DO UPDATE SET sha256 = excluded.sha256, data = excluded.data`,
+		date, kind, hex.EncodeToString(sum[:]), data)
+	if err != nil {
+		return "", fmt.Errorf("storing %s artifact: %w", kind, err)
+	}
+	return s.locate(date, kind), nil
+}
+
+func (s *sqlStore) Get(date, kind string) ([]byte, error) {
+	var hash string
+	var data []byte
+	err := s.db.QueryRow(`SELECT sha256, data FROM cra_artifacts WHERE run_date = $1 AND kind = $2`, date, kind).Scan(&hash, &data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("no %s stored for %s: %w", kind, date, fs.ErrNotExist)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s of %s: %w", kind, date, err)
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != hash {
+		return nil, fmt.Errorf("%s of %s is corrupt: content doesn't match its hash, run `review storage verify`", kind, date)
+	}
+	return data, nil
+}
+
+func (s *sqlStore) Path(date, kind string) (string, error) {
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM cra_artifacts WHERE run_date = $1 AND kind = $2`, date, kind).Scan(&n); err != nil {
+		return "", err
+	}
+	if n == 0 {
+		return "", fmt.Errorf("no %s stored for %s: %w", kind, date, fs.ErrNotExist)
+	}
+	return s.locate(date, kind), nil
+}
+
+func (s *sqlStore) locate(date, kind string) string {
+	return s.location + "#" + date + "/" + kind
+}
+
+func (s *sqlStore) Dates(kind string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT run_date FROM cra_artifacts WHERE kind = $1 ORDER BY run_date`, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dates []string
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		dates = append(dates, date)
+	}
+	return dates, rows.Err()
+}
+
+// Verify checks every row against its hash. Rows are self-contained, so
+// nothing is ever orphaned.
+func (s *sqlStore) Verify() ([]Problem, int, error) {
+	rows, err := s.db.Query(`SELECT run_date, kind, sha256, data FROM cra_artifacts ORDER BY run_date, kind`)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var problems []Problem
+	for rows.Next() {
+		var date, kind, hash string
+		var data []byte
+		if err := rows.Scan(&date, &kind, &hash, &data); err != nil {
+			return nil, 0, err
+		}
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != hash {
+			problems = append(problems, Problem{date, kind, "content doesn't match its hash"})
+		}
+	}
+	return problems, 0, rows.Err()
+}
+
+func (s *sqlStore) Prune(before string, dryRun bool) (*PruneResult, error) {
+	result := &PruneResult{}
+	if before == "" {
+		return result, nil
+	}
+
+	rows, err := s.db.Query(`SELECT run_date, COUNT(*), CAST(SUM(LENGTH(data)) AS BIGINT) FROM cra_artifacts WHERE run_date < $1 GROUP BY run_date ORDER BY run_date`, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var date string
+		var count int
+		var size int64
+		if err := rows.Scan(&date, &count, &size); err != nil {
+			return nil, err
+		}
+		result.Runs = append(result.Runs, date)
+		result.Objects += count
+		result.Bytes += size
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// Free the connection for the delete; SQLite has only one
+	rows.Close()
+
+	if !dryRun && len(result.Runs) > 0 {
+		if _, err := s.db.Exec(`DELETE FROM cra_artifacts WHERE run_date < $1`, before); err != nil {
+			return nil, fmt.Errorf("removing runs: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// Migrate copies the reports in reports.output_dir into the database
+func (s *sqlStore) Migrate() (int, error) {
+	return copyFiles(s, s.outputDir)
+}
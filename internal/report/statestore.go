@@ -0,0 +1,106 @@
+package report
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/juparave/codereviewer/internal/state"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Database backends also keep the state documents, so every process of a
+// team hub sees the same pause window, notes, suppressions and preferences.
+// A document the database doesn't hold yet is read from its file, which
+// carries state kept in files over until it's first written; a removed one
+// is stored empty so the file isn't read again.
+
+// stateDoc interprets a document read from a database
+func stateDoc(doc state.Doc, data []byte, found bool) ([]byte, error) {
+	if !found {
+		return state.Files.Read(doc)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%s was removed: %w", doc.Key, fs.ErrNotExist)
+	}
+	return data, nil
+}
+
+// createStateTable adds the state table next to cra_artifacts
+func (s *sqlStore) createStateTable(blob string) error {
+	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS cra_state (
+		name TEXT PRIMARY KEY,
+		data ` + blob + ` NOT NULL
+	)`)
+	return err
+}
+
+func (s *sqlStore) Read(doc state.Doc) ([]byte, error) {
+	var data []byte
+	err := s.db.QueryRow(`SELECT data FROM cra_state WHERE name = $1`, doc.Key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return stateDoc(doc, nil, false)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", doc.Key, err)
+	}
+	return stateDoc(doc, data, true)
+}
+
+func (s *sqlStore) Write(doc state.Doc, data []byte) error {
+	if data == nil {
+		data = []byte{}
+	}
+	_, err := s.db.Exec(`INSERT INTO cra_state (name, data) VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET data = excluded.data`, doc.Key, data)
+	if err != nil {
+		return fmt.Errorf("storing %s: %w", doc.Key, err)
+	}
+	return nil
+}
+
+func (s *sqlStore) Remove(doc state.Doc) error {
+	return s.Write(doc, nil)
+}
+
+// stateBucket holds the state documents; Verify and Prune skip it
+const stateBucket = "cra_state"
+
+func (s *boltStore) Read(doc state.Doc) ([]byte, error) {
+	var data []byte
+	found := false
+	err := s.view(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(stateBucket)); b != nil {
+			if v := b.Get([]byte(doc.Key)); v != nil {
+				data, found = append([]byte(nil), v...), true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", doc.Key, err)
+	}
+	return stateDoc(doc, data, found)
+}
+
+func (s *boltStore) Write(doc state.Doc, data []byte) error {
+	if data == nil {
+		data = []byte{}
+	}
+	err := s.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(stateBucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(doc.Key), data)
+	})
+	if err != nil {
+		return fmt.Errorf("storing %s: %w", doc.Key, err)
+	}
+	return nil
+}
+
+func (s *boltStore) Remove(doc state.Doc) error {
+	return s.Write(doc, nil)
+}
@@ -0,0 +1,44 @@
+package report
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/state"
+)
+
+func TestDatabaseState(t *testing.T) {
+	for _, backend := range []string{BackendSQLite, BackendBolt} {
+		t.Run(backend, func(t *testing.T) {
+			dir := t.TempDir()
+			doc := state.Doc{Key: "pause", Path: filepath.Join(dir, "pause.yaml"), Perm: 0644}
+			if err := os.WriteFile(doc.Path, []byte("from file"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			st := OpenState(config.ReportsConfig{OutputDir: dir, Backend: backend})
+
+			// Until written, the file is read
+			if data, err := st.Read(doc); err != nil || string(data) != "from file" {
+				t.Fatalf("Read before Write = %q, %v; want the file", data, err)
+			}
+			if err := st.Write(doc, []byte("from database")); err != nil {
+				t.Fatal(err)
+			}
+			if data, err := st.Read(doc); err != nil || string(data) != "from database" {
+				t.Fatalf("Read after Write = %q, %v; want the database", data, err)
+			}
+
+			// Removed stays removed even though the file remains
+			if err := st.Remove(doc); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := st.Read(doc); !errors.Is(err, fs.ErrNotExist) {
+				t.Fatalf("Read after Remove: %v, want fs.ErrNotExist", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package report
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// Period groups report history into buckets
+type Period string
+
+const (
+	PeriodDay   Period = "day"
+	PeriodWeek  Period = "week"
+	PeriodMonth Period = "month"
+)
+
+// HeatMapRows is the number of files and directories kept in the heat map
+const HeatMapRows = 15
+
+// Counts tallies findings by severity
+type Counts struct {
+	Total  int `json:"total"`
+	High   int `json:"high"`
+	Medium int `json:"medium"`
+	Low    int `json:"low"`
+}
+
+func (c *Counts) add(s domain.Severity) {
+	c.Total++
+	switch s {
+	case domain.SeverityHigh:
+		c.High++
+	case domain.SeverityMedium:
+		c.Medium++
+	case domain.SeverityLow:
+		c.Low++
+	}
+}
+
+// Bucket holds finding counts for one day or ISO week
+type Bucket struct {
+	Label string `json:"label"` // 2006-01-02, 2006-W01 or 2006-01
+	Counts
+}
+
+// HotSpot is a repository ranked by its findings across the history
+type HotSpot struct {
+	Repo string `json:"repo"`
+	Counts
+}
+
+// HeatRow is a file (repo/path) or directory (repo/dir/) ranked by the
+// findings that named it, with its count in each bucket
+type HeatRow struct {
+	Path    string `json:"path"`
+	Total   int    `json:"total"`
+	Buckets []int  `json:"buckets"` // Aligned with Stats.Buckets
+}
+
+// Stats aggregates findings across stored reports
+type Stats struct {
+	Reports  int       `json:"reports"`
+	Severity Counts    `json:"severity"`
+	Buckets  []Bucket  `json:"buckets"`
+	HotSpots []HotSpot `json:"hot_spots"`
+	// HeatMap lists the files and directories with the most findings,
+	// hottest first
+	HeatMap []HeatRow `json:"heat_map"`
+	// Resolved counts findings that stopped being reported; the average
+	// is measured from the first report that contained them to the first
+	// later report that didn't
+	Resolved         int           `json:"resolved"`
+	Open             int           `json:"open"`
+	AvgTimeToResolve time.Duration `json:"avg_time_to_resolve"`
+	// Providers tracks the health of the LLM providers the reviews used
+	Providers []ProviderHealth `json:"providers,omitempty"`
+}
+
+// ComputeStats aggregates reports (oldest first) into trend statistics,
+// with the failed runs counting toward provider health
+func ComputeStats(reports []*domain.Report, failed []domain.FailedRun, period Period) *Stats {
+	stats := &Stats{Reports: len(reports)}
+
+	bucketIndex := make(map[string]int)
+	repoIndex := make(map[string]int)
+	heat := make(map[string]map[int]int)
+	for _, rpt := range reports {
+		label := bucketLabel(rpt.Date, period)
+		i, ok := bucketIndex[label]
+		if !ok {
+			i = len(stats.Buckets)
+			bucketIndex[label] = i
+			stats.Buckets = append(stats.Buckets, Bucket{Label: label})
+		}
+
+		for _, f := range rpt.Findings {
+			stats.Severity.add(f.Severity)
+			stats.Buckets[i].add(f.Severity)
+
+			j, ok := repoIndex[f.RepoName]
+			if !ok {
+				j = len(stats.HotSpots)
+				repoIndex[f.RepoName] = j
+				stats.HotSpots = append(stats.HotSpots, HotSpot{Repo: f.RepoName})
+			}
+			stats.HotSpots[j].add(f.Severity)
+
+			for _, p := range heatPaths(f) {
+				if heat[p] == nil {
+					heat[p] = make(map[int]int)
+				}
+				heat[p][i]++
+			}
+		}
+	}
+
+	sort.SliceStable(stats.HotSpots, func(i, j int) bool {
+		a, b := stats.HotSpots[i], stats.HotSpots[j]
+		if a.High != b.High {
+			return a.High > b.High
+		}
+		return a.Total > b.Total
+	})
+
+	stats.HeatMap = heatMap(heat, len(stats.Buckets))
+	stats.Resolved, stats.Open, stats.AvgTimeToResolve = resolution(reports)
+	stats.Providers = ComputeProviderHealth(reports, failed)
+	return stats
+}
+
+// resolution tracks each finding from the first report that contains it to
+// the first later report without it. A report for a day with no reviewed
+// commits says nothing about open findings, so it is skipped.
+func resolution(reports []*domain.Report) (resolved, open int, avg time.Duration) {
+	firstSeen := make(map[string]time.Time)
+	var total time.Duration
+
+	for _, rpt := range reports {
+		if rpt.NothingToNote {
+			continue
+		}
+
+		present := make(map[string]bool)
+		for _, f := range rpt.Findings {
+			key := f.Fingerprint()
+			present[key] = true
+			if _, ok := firstSeen[key]; !ok {
+				firstSeen[key] = rpt.Date
+			}
+		}
+
+		for key, seen := range firstSeen {
+			if !present[key] {
+				resolved++
+				total += rpt.Date.Sub(seen)
+				delete(firstSeen, key)
+			}
+		}
+	}
+
+	if resolved > 0 {
+		avg = total / time.Duration(resolved)
+	}
+	return resolved, len(firstSeen), avg
+}
+
+// heatPaths returns the files a finding names and their directories, each
+// once, prefixed with the repository
+func heatPaths(f domain.Finding) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, file := range f.Files {
+		keys := []string{f.RepoName + "/" + file}
+		if dir := path.Dir(file); dir != "." && dir != "/" {
+			keys = append(keys, f.RepoName+"/"+dir+"/")
+		}
+		for _, k := range keys {
+			if !seen[k] {
+				seen[k] = true
+				paths = append(paths, k)
+			}
+		}
+	}
+	return paths
+}
+
+// heatMap ranks the counted paths and keeps the HeatMapRows hottest
+func heatMap(heat map[string]map[int]int, buckets int) []HeatRow {
+	rows := make([]HeatRow, 0, len(heat))
+	for p, counts := range heat {
+		row := HeatRow{Path: p, Buckets: make([]int, buckets)}
+		for i, n := range counts {
+			row.Buckets[i] = n
+			row.Total += n
+		}
+		rows = append(rows, row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Total != rows[j].Total {
+			return rows[i].Total > rows[j].Total
+		}
+		return rows[i].Path < rows[j].Path
+	})
+	if len(rows) > HeatMapRows {
+		rows = rows[:HeatMapRows]
+	}
+	return rows
+}
+
+func bucketLabel(date time.Time, period Period) string {
+	switch period {
+	case PeriodWeek:
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case PeriodMonth:
+		return date.Format("2006-01")
+	}
+	return date.Format(DateLayout)
+}
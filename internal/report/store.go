@@ -0,0 +1,550 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/filelock"
+)
+
+// Artifact kinds stored for a run
+const (
+	ArtifactMarkdown  = "markdown"  // The report as Markdown
+	ArtifactReport    = "report"    // The report data as JSON, read by the history commands
+	ArtifactResponses = "responses" // Raw model answers, see review.save_responses
+	ArtifactWindow    = "window"    // The review window and the patch IDs it reviewed, see overlap
+	ArtifactDiffs     = "diffs"     // The diffs as sent for review, see reports.appendix
+	ArtifactHTML      = "html"      // The report as HTML, as the dashboard shows it
+	ArtifactPrompts   = "prompts"   // The prompts sent to the model, see review.save_responses
+	ArtifactFailed    = "failed"    // Runs of the day that failed after calling the LLM, for provider health
+)
+
+// Artifacts lists every artifact kind, in the order they're copied
+var Artifacts = []string{ArtifactMarkdown, ArtifactReport, ArtifactHTML, ArtifactResponses, ArtifactPrompts, ArtifactWindow, ArtifactDiffs, ArtifactFailed}
+
+// readableNames are the files each artifact kind is linked to in its
+// run's directory
+var readableNames = map[string]string{
+	ArtifactMarkdown:  "report.md",
+	ArtifactReport:    "report.json",
+	ArtifactHTML:      "report.html",
+	ArtifactResponses: "responses.json",
+	ArtifactPrompts:   "prompts.json",
+	ArtifactWindow:    "window.json",
+	ArtifactDiffs:     "diffs.json",
+	ArtifactFailed:    "failed.json",
+}
+
+// lockName is the lock file serializing writes to the store
+const lockName = ".lock"
+
+// Store is the files backend. It keeps the artifacts of each run
+// content-addressed under the reports directory:
+//
+//	objects/ab/cdef…   artifact contents, named by their SHA-256
+//	runs/<date>.json   the run's manifest: artifact kind to hash and size
+//	<date>/report.md   readable links to the run's objects, see readableNames
+//
+// Identical artifacts are stored once, reads check the hash, and pruning
+// a run only drops the objects no other run refers to. Writes hold the
+// lock file, so runs in several processes don't lose each other's
+// manifest entries. Reports written
+// before the store existed (<date>.md, <date>.json, responses/<date>.json)
+// are still read until `review storage migrate` moves them in.
+type Store struct {
+	dir string
+}
+
+// RunManifest lists the artifacts stored for one run
+type RunManifest struct {
+	Date      string              `json:"date"`
+	Artifacts map[string]Artifact `json:"artifacts"`
+}
+
+// Artifact points at a stored object
+type Artifact struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// NewStore returns the store in the reports directory dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Put stores data as the kind artifact of the run for date (YYYY-MM-DD),
+// replacing the previous one, and returns the path of its readable link
+func (s *Store) Put(date, kind string, data []byte) (string, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	path := s.objectPath(hash)
+
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		if err := writeAtomic(path, data); err != nil {
+			return "", fmt.Errorf("storing %s artifact: %w", kind, err)
+		}
+	} else if err != nil {
+		return "", fmt.Errorf("storing %s artifact: %w", kind, err)
+	}
+
+	run, err := s.Run(date)
+	if err != nil {
+		return "", err
+	}
+	run.Artifacts[kind] = Artifact{SHA256: hash, Size: int64(len(data))}
+	if err := s.writeRun(run); err != nil {
+		return "", err
+	}
+
+	// The store copy supersedes a report written before the store existed
+	if legacy := s.legacyPath(date, kind); legacy != "" {
+		os.Remove(legacy)
+	}
+	return s.link(date, kind, path)
+}
+
+// link points the readable file of the kind artifact of date at its
+// object, returning the readable path. Filesystems without hard links get
+// a copy.
+func (s *Store) link(date, kind, object string) (string, error) {
+	name, ok := readableNames[kind]
+	if !ok {
+		return object, nil
+	}
+	path := s.readablePath(date, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("linking %s artifact: %w", kind, err)
+	}
+	tmp := filepath.Join(filepath.Dir(path), ".tmp-"+name)
+	os.Remove(tmp)
+	if err := os.Link(object, tmp); err == nil {
+		if err := os.Rename(tmp, path); err != nil {
+			os.Remove(tmp)
+			return "", fmt.Errorf("linking %s artifact: %w", kind, err)
+		}
+		return path, nil
+	}
+	data, err := os.ReadFile(object)
+	if err == nil {
+		err = writeAtomic(path, data)
+	}
+	if err != nil {
+		return "", fmt.Errorf("linking %s artifact: %w", kind, err)
+	}
+	return path, nil
+}
+
+// Get returns the kind artifact of the run for date, failing when it was
+// changed since it was stored. A missing artifact wraps fs.ErrNotExist.
+func (s *Store) Get(date, kind string) ([]byte, error) {
+	path, hash, err := s.locate(date, kind)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s of %s: %w", kind, date, err)
+	}
+	if hash != "" {
+		if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != hash {
+			return nil, fmt.Errorf("%s of %s is corrupt: content doesn't match its hash, run `review storage verify`", kind, date)
+		}
+	}
+	return data, nil
+}
+
+// Path returns the file holding the kind artifact of the run for date:
+// its readable link when there is one, else its object
+func (s *Store) Path(date, kind string) (string, error) {
+	path, hash, err := s.locate(date, kind)
+	if err != nil || hash == "" {
+		return path, err
+	}
+	if name, ok := readableNames[kind]; ok {
+		if _, err := os.Stat(s.readablePath(date, name)); err == nil {
+			return s.readablePath(date, name), nil
+		}
+	}
+	return path, nil
+}
+
+// locate finds the kind artifact of date in the store, or in the layout
+// used before it, returning its path and expected hash (empty for
+// unmigrated files)
+func (s *Store) locate(date, kind string) (string, string, error) {
+	run, err := s.Run(date)
+	if err != nil {
+		return "", "", err
+	}
+	if a, ok := run.Artifacts[kind]; ok {
+		return s.objectPath(a.SHA256), a.SHA256, nil
+	}
+	if legacy := s.legacyPath(date, kind); legacy != "" {
+		if _, err := os.Stat(legacy); err == nil {
+			return legacy, "", nil
+		}
+	}
+	return "", "", fmt.Errorf("no %s stored for %s: %w", kind, date, fs.ErrNotExist)
+}
+
+// Run returns the manifest of the run for date, empty when nothing is
+// stored for it
+func (s *Store) Run(date string) (*RunManifest, error) {
+	data, err := os.ReadFile(s.runPath(date))
+	if errors.Is(err, fs.ErrNotExist) {
+		return &RunManifest{Date: date, Artifacts: make(map[string]Artifact)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading run manifest: %w", err)
+	}
+	var run RunManifest
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("parsing run manifest %s: %w", filepath.Base(s.runPath(date)), err)
+	}
+	if run.Artifacts == nil {
+		run.Artifacts = make(map[string]Artifact)
+	}
+	return &run, nil
+}
+
+// Dates returns the dates with a stored kind artifact, oldest first
+func (s *Store) Dates(kind string) ([]string, error) {
+	seen := make(map[string]bool)
+	runs, err := filepath.Glob(filepath.Join(s.dir, "runs", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range runs {
+		date := strings.TrimSuffix(filepath.Base(path), ".json")
+		run, err := s.Run(date)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := run.Artifacts[kind]; ok {
+			seen[date] = true
+		}
+	}
+
+	legacy, err := s.legacyFiles(kind)
+	if err != nil {
+		return nil, err
+	}
+	for date := range legacy {
+		seen[date] = true
+	}
+
+	dates := make([]string, 0, len(seen))
+	for date := range seen {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return dates, nil
+}
+
+// Problem is an integrity problem found by Verify
+type Problem struct {
+	Date   string
+	Kind   string
+	Detail string
+}
+
+// Verify checks that every artifact of every run is present and matches
+// its hash, and counts the objects no run refers to
+func (s *Store) Verify() ([]Problem, int, error) {
+	runs, err := s.runs()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var problems []Problem
+	referenced := make(map[string]bool)
+	for _, run := range runs {
+		kinds := make([]string, 0, len(run.Artifacts))
+		for kind := range run.Artifacts {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			a := run.Artifacts[kind]
+			referenced[a.SHA256] = true
+			data, err := os.ReadFile(s.objectPath(a.SHA256))
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				problems = append(problems, Problem{run.Date, kind, "object missing"})
+			case err != nil:
+				problems = append(problems, Problem{run.Date, kind, err.Error()})
+			default:
+				if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != a.SHA256 {
+					problems = append(problems, Problem{run.Date, kind, "content doesn't match its hash"})
+				}
+			}
+		}
+	}
+
+	objects, err := s.objects()
+	if err != nil {
+		return nil, 0, err
+	}
+	orphans := 0
+	for hash := range objects {
+		if !referenced[hash] {
+			orphans++
+		}
+	}
+	return problems, orphans, nil
+}
+
+// PruneResult is what Prune removed, or would remove
+type PruneResult struct {
+	Runs    []string // Dates whose runs were removed
+	Objects int
+	Bytes   int64
+}
+
+// Prune removes the runs dated before date (YYYY-MM-DD; empty removes
+// none) and every object no remaining run refers to. With dryRun set it
+// only reports what it would remove.
+func (s *Store) Prune(before string, dryRun bool) (*PruneResult, error) {
+	if !dryRun {
+		unlock, err := s.lock()
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+
+	runs, err := s.runs()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PruneResult{}
+	referenced := make(map[string]bool)
+	for _, run := range runs {
+		if before != "" && run.Date < before {
+			result.Runs = append(result.Runs, run.Date)
+			if !dryRun {
+				if err := os.Remove(s.runPath(run.Date)); err != nil {
+					return nil, fmt.Errorf("removing run %s: %w", run.Date, err)
+				}
+				if err := os.RemoveAll(filepath.Join(s.dir, run.Date)); err != nil {
+					return nil, fmt.Errorf("removing run %s: %w", run.Date, err)
+				}
+			}
+			continue
+		}
+		for _, a := range run.Artifacts {
+			referenced[a.SHA256] = true
+		}
+	}
+
+	objects, err := s.objects()
+	if err != nil {
+		return nil, err
+	}
+	for hash, size := range objects {
+		if referenced[hash] {
+			continue
+		}
+		result.Objects++
+		result.Bytes += size
+		if !dryRun {
+			if err := os.Remove(s.objectPath(hash)); err != nil {
+				return nil, fmt.Errorf("removing object %s: %w", hash, err)
+			}
+			// Drop the fan-out directory once empty
+			os.Remove(filepath.Dir(s.objectPath(hash)))
+		}
+	}
+	return result, nil
+}
+
+// Migrate moves reports written before the store existed into it and
+// returns how many files it moved
+func (s *Store) Migrate() (int, error) {
+	moved := 0
+	for _, kind := range []string{ArtifactMarkdown, ArtifactReport, ArtifactResponses} {
+		files, err := s.legacyFiles(kind)
+		if err != nil {
+			return moved, err
+		}
+		dates := make([]string, 0, len(files))
+		for date := range files {
+			dates = append(dates, date)
+		}
+		sort.Strings(dates)
+
+		for _, date := range dates {
+			data, err := os.ReadFile(files[date])
+			if err != nil {
+				return moved, fmt.Errorf("reading %s: %w", files[date], err)
+			}
+			// Put removes the file once stored
+			if _, err := s.Put(date, kind, data); err != nil {
+				return moved, err
+			}
+			moved++
+		}
+	}
+	os.Remove(filepath.Join(s.dir, "responses")) // Only succeeds when empty
+	return moved, nil
+}
+
+// runs reads every run manifest, oldest first
+func (s *Store) runs() ([]*RunManifest, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "runs", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	runs := make([]*RunManifest, 0, len(paths))
+	for _, path := range paths {
+		run, err := s.Run(strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// objects lists the stored objects and their sizes by hash
+func (s *Store) objects() (map[string]int64, error) {
+	objects := make(map[string]int64)
+	root := filepath.Join(s.dir, "objects")
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects[filepath.Base(filepath.Dir(path))+d.Name()] = info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing objects: %w", err)
+	}
+	return objects, nil
+}
+
+func (s *Store) writeRun(run *RunManifest) error {
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding run manifest: %w", err)
+	}
+	if err := writeAtomic(s.runPath(run.Date), data); err != nil {
+		return fmt.Errorf("writing run manifest: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) objectPath(hash string) string {
+	return filepath.Join(s.dir, "objects", hash[:2], hash[2:])
+}
+
+func (s *Store) runPath(date string) string {
+	return filepath.Join(s.dir, "runs", date+".json")
+}
+
+func (s *Store) readablePath(date, name string) string {
+	return filepath.Join(s.dir, date, name)
+}
+
+// lock takes the store's lock file exclusively, returning the function
+// releasing it
+func (s *Store) lock() (func(), error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating reports directory: %w", err)
+	}
+	unlock, err := filelock.Lock(filepath.Join(s.dir, lockName), true)
+	if err != nil {
+		return nil, fmt.Errorf("locking reports directory: %w", err)
+	}
+	return unlock, nil
+}
+
+// legacyPath is where the kind artifact of date was written before the
+// store existed
+func (s *Store) legacyPath(date, kind string) string {
+	switch kind {
+	case ArtifactMarkdown:
+		return filepath.Join(s.dir, date+".md")
+	case ArtifactReport:
+		return filepath.Join(s.dir, date+".json")
+	case ArtifactResponses:
+		return filepath.Join(s.dir, "responses", date+".json")
+	}
+	return ""
+}
+
+// legacyFiles lists the kind artifacts written before the store existed,
+// by date
+func (s *Store) legacyFiles(kind string) (map[string]string, error) {
+	pattern := s.legacyPath("*", kind)
+	if pattern == "" {
+		return nil, nil
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string, len(paths))
+	for _, path := range paths {
+		date := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		// Other files, e.g. a run manifest written with --output
+		if _, err := time.Parse(DateLayout, date); err == nil {
+			files[date] = path
+		}
+	}
+	return files, nil
+}
+
+// writeAtomic writes data to path through a temporary file so readers
+// never see a partial file
+func writeAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
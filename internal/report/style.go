@@ -0,0 +1,95 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// defaultStyles are used for any severity field not overridden in config
+var defaultStyles = map[domain.Severity]config.SeverityStyle{
+	domain.SeverityHigh:   {Label: "High", Emoji: "🔴", Color: "#dc2626"},
+	domain.SeverityMedium: {Label: "Medium", Emoji: "🟡", Color: "#d97706"},
+	domain.SeverityLow:    {Label: "Low", Emoji: "🟢", Color: "#059669"},
+}
+
+// resolveStyles merges configured severity styles over the defaults
+func resolveStyles(overrides map[string]config.SeverityStyle) map[domain.Severity]config.SeverityStyle {
+	styles := make(map[domain.Severity]config.SeverityStyle, len(defaultStyles))
+	for sev, style := range defaultStyles {
+		styles[sev] = style
+	}
+
+	for name, override := range overrides {
+		sev, ok := domain.ParseSeverity(name)
+		if !ok {
+			continue
+		}
+		style := styles[sev]
+		if override.Label != "" {
+			style.Label = override.Label
+		}
+		if override.Emoji != "" {
+			style.Emoji = override.Emoji
+		}
+		if override.Color != "" {
+			style.Color = override.Color
+		}
+		styles[sev] = style
+	}
+	return styles
+}
+
+// Label returns the display label of a severity
+func (f *Formatter) Label(s domain.Severity) string {
+	if style, ok := f.styles[s]; ok {
+		return style.Label
+	}
+	return string(s)
+}
+
+// Emoji returns the badge shown next to findings of a severity
+func (f *Formatter) Emoji(s domain.Severity) string {
+	return f.styles[s].Emoji
+}
+
+// SeverityCSS returns CSS rules coloring the high, medium and low classes
+// and the matching finding borders
+func (f *Formatter) SeverityCSS() string {
+	var sb strings.Builder
+	for _, sev := range []domain.Severity{domain.SeverityHigh, domain.SeverityMedium, domain.SeverityLow} {
+		class := severityClass(sev)
+		color := f.styles[sev].Color
+		sb.WriteString(fmt.Sprintf(".%s { color: %s; }\n", class, color))
+		sb.WriteString(fmt.Sprintf(".finding-%s { border-left-color: %s; }\n", class, color))
+	}
+	return sb.String()
+}
+
+// severityClass is the CSS class of sev, e.g. high. Severities come from
+// the model, so one that isn't known gets the class unknown rather than
+// its text.
+func severityClass(sev domain.Severity) string {
+	if known, ok := domain.ParseSeverity(string(sev)); ok {
+		return strings.ToLower(string(known))
+	}
+	return "unknown"
+}
+
+// countsLine renders "N High, N Medium, N Low" with the configured labels
+func (f *Formatter) countsLine(high, medium, low int) string {
+	return fmt.Sprintf("%d %s, %d %s, %d %s",
+		high, f.Label(domain.SeverityHigh),
+		medium, f.Label(domain.SeverityMedium),
+		low, f.Label(domain.SeverityLow))
+}
+
+// countsHTML renders the severity counts of a report as colored spans
+func (f *Formatter) countsHTML(report *domain.Report) string {
+	return fmt.Sprintf("<span class='high'>%d %s</span>, <span class='medium'>%d %s</span>, <span class='low'>%d %s</span>",
+		report.HighCount(), f.Label(domain.SeverityHigh),
+		report.MediumCount(), f.Label(domain.SeverityMedium),
+		report.LowCount(), f.Label(domain.SeverityLow))
+}
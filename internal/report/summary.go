@@ -0,0 +1,113 @@
+package report
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// ansiColors are the terminal colors of severities whose configured color
+// isn't a #rrggbb value the terminal can show as is
+var ansiColors = map[domain.Severity]string{
+	domain.SeverityHigh:   "41", // Red background
+	domain.SeverityMedium: "43", // Yellow
+	domain.SeverityLow:    "42", // Green
+}
+
+// repoHeat counts the findings of one repository by severity
+type repoHeat struct {
+	repo   string
+	counts map[domain.Severity]int
+}
+
+// weight orders repositories by their worst findings first
+func (h repoHeat) weight() []int {
+	return []int{h.counts[domain.SeverityHigh], h.counts[domain.SeverityMedium], h.counts[domain.SeverityLow]}
+}
+
+// TerminalSummary renders the end of a CLI run: one line of severity
+// badges per repository with findings, hottest first, then SummaryLine.
+// color uses ANSI colors, for terminals that support them.
+func (f *Formatter) TerminalSummary(report *domain.Report, color bool) string {
+	heat := make(map[string]*repoHeat)
+	var heats []*repoHeat
+	for _, finding := range report.Findings {
+		h, ok := heat[finding.RepoName]
+		if !ok {
+			h = &repoHeat{repo: finding.RepoName, counts: make(map[domain.Severity]int)}
+			heat[finding.RepoName] = h
+			heats = append(heats, h)
+		}
+		h.counts[finding.Severity]++
+	}
+	slices.SortStableFunc(heats, func(a, b *repoHeat) int {
+		if c := slices.Compare(b.weight(), a.weight()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.repo, b.repo)
+	})
+
+	width := 0
+	for _, h := range heats {
+		width = max(width, len(h.repo))
+	}
+
+	var sb strings.Builder
+	for _, h := range heats {
+		sb.WriteString(fmt.Sprintf("  %-*s ", width, h.repo))
+		for _, sev := range []domain.Severity{domain.SeverityHigh, domain.SeverityMedium, domain.SeverityLow} {
+			if n := h.counts[sev]; n > 0 {
+				sb.WriteString(" " + f.badge(sev, fmt.Sprintf("%d %s", n, f.Label(sev)), color))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	if clean := len(report.Repositories) - len(heats); clean > 0 && len(heats) > 0 {
+		sb.WriteString(fmt.Sprintf("  %d more repositories without findings\n", clean))
+	}
+	sb.WriteString(f.SummaryLine(report))
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// SummaryLine sums up a report in one line, e.g. "Code review of October
+// 17, 2026: 7 findings (2 High, 1 Medium, 4 Low) in 12 commits across 3
+// repositories"
+func (f *Formatter) SummaryLine(report *domain.Report) string {
+	scope := fmt.Sprintf("%d commits across %d repositories", report.CommitCount, len(report.Repositories))
+	date := report.Date.Format("January 2, 2006")
+	if !report.HasFindings() {
+		return fmt.Sprintf("Code review of %s: no findings in %s", date, scope)
+	}
+	return fmt.Sprintf("Code review of %s: %d findings (%s) in %s", date, report.TotalFindings(),
+		f.countsLine(report.HighCount(), report.MediumCount(), report.LowCount()), scope)
+}
+
+// badge renders text as a badge of severity s: white on the severity's
+// color, or in brackets without color
+func (f *Formatter) badge(s domain.Severity, text string, color bool) string {
+	if !color {
+		return "[" + text + "]"
+	}
+	background := ansiColors[s]
+	if r, g, b, ok := parseHexColor(f.styles[s].Color); ok {
+		background = fmt.Sprintf("48;2;%d;%d;%d", r, g, b)
+	}
+	return fmt.Sprintf("\x1b[1;97;%sm %s \x1b[0m", background, text)
+}
+
+// parseHexColor parses a #rrggbb CSS color
+func parseHexColor(css string) (r, g, b uint8, ok bool) {
+	hex, found := strings.CutPrefix(strings.TrimSpace(css), "#")
+	if !found || len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(v >> 16), uint8(v >> 8), uint8(v), true
+}
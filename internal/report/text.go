@@ -0,0 +1,78 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// Text renders the report as plain text for a terminal
+func (f *Formatter) Text(report *domain.Report) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Code Review Report - %s\n\n", report.Date.Format("January 2, 2006")))
+	for _, note := range report.UserNotes {
+		sb.WriteString(fmt.Sprintf("Note: %s\n", note))
+	}
+	if len(report.UserNotes) > 0 {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(report.Summary)
+	sb.WriteString("\n\n")
+
+	if report.CommitCount > 0 {
+		sb.WriteString(fmt.Sprintf("Reviewed %d commits across %d files in %d repositories\n",
+			report.CommitCount, report.FileCount, len(report.Repositories)))
+	}
+	if report.Usage != nil {
+		sb.WriteString(fmt.Sprintf("LLM usage: %s\n", usageLine(*report.Usage)))
+	}
+	for _, note := range report.Notes {
+		sb.WriteString(fmt.Sprintf("Note: %s\n", note))
+	}
+	for _, failure := range report.Failures {
+		sb.WriteString(fmt.Sprintf("Not reviewed: %s: %s\n", strings.Join(failure.Files, ", "), failure.Error))
+	}
+
+	if !report.HasFindings() {
+		sb.WriteString("No issues found.\n")
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("Findings: %d total (%s)\n",
+		report.TotalFindings(), f.countsLine(report.HighCount(), report.MediumCount(), report.LowCount())))
+
+	for _, day := range findingDays(report) {
+		if !day.date.IsZero() {
+			sb.WriteString(fmt.Sprintf("\n== %s (%d commits) ==\n", day.date.Format("January 2, 2006"), day.commits))
+		}
+		for _, finding := range day.findings {
+			f.writeFindingText(&sb, finding)
+		}
+	}
+	return sb.String()
+}
+
+// writeFindingText renders one finding of the plain text report
+func (f *Formatter) writeFindingText(sb *strings.Builder, finding domain.Finding) {
+	sb.WriteString(fmt.Sprintf("\n%s [%s] %s\n", f.Emoji(finding.Severity), f.Label(finding.Severity), finding.Title))
+	sb.WriteString(fmt.Sprintf("  Repository: %s  ID: %s\n", finding.RepoName, finding.Fingerprint()))
+	if finding.Category != "" {
+		sb.WriteString(fmt.Sprintf("  Category: %s\n", finding.Category))
+	}
+	if finding.PRNumber > 0 {
+		sb.WriteString(fmt.Sprintf("  Pull request: #%d %s\n", finding.PRNumber, finding.PRURL))
+	}
+	if finding.RemovedIn != "" {
+		sb.WriteString(fmt.Sprintf("  Code removed in %s\n", domain.ShortHash(finding.RemovedIn)))
+	}
+	if len(finding.Files) > 0 {
+		sb.WriteString(fmt.Sprintf("  Files: %s\n", strings.Join(finding.Files, ", ")))
+	}
+	sb.WriteString(fmt.Sprintf("  Issue: %s\n", finding.Explanation))
+	sb.WriteString(fmt.Sprintf("  Fix: %s\n", finding.Action))
+	for _, name := range finding.Extensions.Names() {
+		sb.WriteString(fmt.Sprintf("  %s: %s\n", name, finding.Extensions[name]))
+	}
+}
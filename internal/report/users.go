@@ -0,0 +1,254 @@
+package report
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/util"
+)
+
+// User roles in a team hub
+const (
+	RoleLead     = "lead"     // Sees every repository and can trigger reviews
+	RoleEngineer = "engineer" // Sees only the repositories matching their patterns
+)
+
+// tokenPrefix marks hub API tokens, so they're easy to spot in a leak
+const tokenPrefix = "cra_"
+
+// User is an account of the team hub served by `review serve`
+type User struct {
+	Name    string    `json:"name"`
+	Role    string    `json:"role"`
+	Repos   []string  `json:"repos,omitempty"` // Patterns of the repositories an engineer sees
+	Created time.Time `json:"created"`
+}
+
+// Lead reports whether u sees everything
+func (u *User) Lead() bool {
+	return u.Role == RoleLead
+}
+
+// Users stores the accounts of the team hub and their API tokens. Only the
+// SHA-256 of each token is kept.
+type Users interface {
+	// AddUser creates u and returns its API token
+	AddUser(u User) (string, error)
+	// Users returns every account, by name
+	Users() ([]User, error)
+	// RemoveUser deletes the account name and its token
+	RemoveUser(name string) error
+	// RotateToken replaces the token of name and returns the new one
+	RotateToken(name string) (string, error)
+	// Authenticate returns the account of token. An unknown token wraps
+	// fs.ErrNotExist.
+	Authenticate(token string) (*User, error)
+}
+
+// OpenUsers returns the account store of the backend selected by cfg. Only
+// the sqlite and postgres backends keep accounts.
+func OpenUsers(cfg config.ReportsConfig) (Users, error) {
+	backend, err := OpenBackend(cfg)
+	if err != nil {
+		return nil, err
+	}
+	users, ok := backend.(Users)
+	if !ok {
+		return nil, fmt.Errorf("user accounts need reports.backend postgres or sqlite")
+	}
+	return users, nil
+}
+
+// newToken returns a random API token and its hash
+func newToken() (token, hash string, err error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = tokenPrefix + hex.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// createUsersTable adds the accounts table next to cra_artifacts
+func (s *sqlStore) createUsersTable() error {
+	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS cra_users (
+		name       TEXT PRIMARY KEY,
+		role       TEXT NOT NULL,
+		repos      TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		created    TEXT NOT NULL
+	)`)
+	return err
+}
+
+func (s *sqlStore) AddUser(u User) (string, error) {
+	if u.Role != RoleLead && u.Role != RoleEngineer {
+		return "", fmt.Errorf("invalid role %q, expected %s or %s", u.Role, RoleLead, RoleEngineer)
+	}
+	if u.Role == RoleEngineer && len(u.Repos) == 0 {
+		return "", fmt.Errorf("an engineer needs repository patterns to see anything")
+	}
+	if _, err := util.CompilePatterns(u.Repos, true); err != nil {
+		return "", fmt.Errorf("repository patterns: %w", err)
+	}
+	token, hash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	if u.Created.IsZero() {
+		u.Created = time.Now()
+	}
+
+	res, err := s.db.Exec(`INSERT INTO cra_users (name, role, repos, token_hash, created) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (name) DO NOTHING`,
+		u.Name, u.Role, strings.Join(u.Repos, "\n"), hash, u.Created.UTC().Format(time.RFC3339))
+	if err != nil {
+		return "", fmt.Errorf("adding user %s: %w", u.Name, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return "", fmt.Errorf("user %s already exists", u.Name)
+	}
+	return token, nil
+}
+
+func (s *sqlStore) Users() ([]User, error) {
+	rows, err := s.db.Query(`SELECT name, role, repos, created FROM cra_users ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
+
+func (s *sqlStore) RemoveUser(name string) error {
+	res, err := s.db.Exec(`DELETE FROM cra_users WHERE name = $1`, name)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("no user %s: %w", name, fs.ErrNotExist)
+	}
+	return nil
+}
+
+func (s *sqlStore) RotateToken(name string) (string, error) {
+	token, hash, err := newToken()
+	if err != nil {
+		return "", err
+	}
+	res, err := s.db.Exec(`UPDATE cra_users SET token_hash = $1 WHERE name = $2`, hash, name)
+	if err != nil {
+		return "", err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return "", fmt.Errorf("no user %s: %w", name, fs.ErrNotExist)
+	}
+	return token, nil
+}
+
+func (s *sqlStore) Authenticate(token string) (*User, error) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return nil, fmt.Errorf("unknown token: %w", fs.ErrNotExist)
+	}
+	u, err := scanUser(s.db.QueryRow(`SELECT name, role, repos, created FROM cra_users WHERE token_hash = $1`, hashToken(token)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("unknown token: %w", fs.ErrNotExist)
+	}
+	return u, err
+}
+
+// scanUser reads the name, role, repos and created columns
+func scanUser(row interface{ Scan(...any) error }) (*User, error) {
+	var u User
+	var repos, created string
+	if err := row.Scan(&u.Name, &u.Role, &repos, &created); err != nil {
+		return nil, err
+	}
+	if repos != "" {
+		u.Repos = strings.Split(repos, "\n")
+	}
+	u.Created, _ = time.Parse(time.RFC3339, created)
+	return &u, nil
+}
+
+// ViewFor returns the part of rpt that u may see: everything for a lead,
+// and for an engineer the findings, failures and exclusions of the
+// repositories matching their patterns. The summary and notes, which may
+// mention any repository, are left out of a partial view. ok is false
+// when none of the report's repositories are visible.
+func ViewFor(rpt *domain.Report, u *User) (view *domain.Report, ok bool) {
+	if u == nil || u.Lead() {
+		return rpt, true
+	}
+	patterns, err := util.CompilePatterns(u.Repos, true)
+	if err != nil || patterns.Empty() {
+		// Validated when the user was added; never fall back to everything
+		return nil, false
+	}
+
+	v := *rpt
+	v.Repositories = nil
+	for _, repo := range rpt.Repositories {
+		if patterns.Match(repo) {
+			v.Repositories = append(v.Repositories, repo)
+		}
+	}
+	if len(v.Repositories) == 0 {
+		return nil, false
+	}
+	if len(v.Repositories) == len(rpt.Repositories) {
+		return rpt, true
+	}
+
+	v.Findings = nil
+	for _, f := range rpt.Findings {
+		if patterns.Match(f.RepoName) {
+			v.Findings = append(v.Findings, f)
+		}
+	}
+	v.Failures = nil
+	for _, failure := range rpt.Failures {
+		if patterns.Match(failure.Repos...) {
+			v.Failures = append(v.Failures, failure)
+		}
+	}
+	v.Exclusions = nil
+	for _, e := range rpt.Exclusions {
+		if patterns.Match(e.Repo) {
+			v.Exclusions = append(v.Exclusions, e)
+		}
+	}
+	v.Diffs = nil
+	for _, d := range rpt.Diffs {
+		if patterns.Match(d.Repo) {
+			v.Diffs = append(v.Diffs, d)
+		}
+	}
+	v.Summary = fmt.Sprintf("Showing %d of %d repositories.", len(v.Repositories), len(rpt.Repositories))
+	v.Notes = nil
+	v.NothingToNote = len(v.Findings) == 0
+	return &v, true
+}
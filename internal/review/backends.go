@@ -0,0 +1,147 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/genkit"
+	oai "github.com/firebase/genkit/go/plugins/compat_oai/openai"
+	"github.com/firebase/genkit/go/plugins/googlegenai"
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/errs"
+	"github.com/openai/openai-go/option"
+)
+
+// OllamaBaseURL is the OpenAI-compatible API of a local Ollama server,
+// used when an ollama backend sets no base_url
+const OllamaBaseURL = "http://localhost:11434/v1"
+
+// model is a model of an initialized LLM backend
+type model struct {
+	backend  string // Name in review.backends; empty for review.provider
+	provider string // Its provider, e.g. googleai
+	genkit   *genkit.Genkit
+	id       string // Genkit model name, e.g. googleai/gemini-2.0-flash
+}
+
+// key tells models apart across backends using the same model name
+func (m model) key() string {
+	return m.backend + "\x00" + m.id
+}
+
+// backendModels are the models of one backend: the default, and those
+// reviewing critical and experimental repositories
+type backendModels struct {
+	model  model
+	strong model
+	triage model
+}
+
+// initBackend starts the Genkit plugin of cfg's provider, name being its
+// review.backends entry, empty for review.provider
+func initBackend(ctx context.Context, name string, cfg config.ReviewConfig) (backendModels, error) {
+	var g *genkit.Genkit
+	var prefix, modelID string
+
+	switch cfg.Provider {
+	case "openai", config.ProviderOllama:
+		// OpenAI-compatible API (Zhipu AI, Ollama, etc.)
+		apiKey := cfg.ResolveAPIKey()
+		prefix = "openai"
+		modelID = cfg.Model
+		if modelID == "" {
+			modelID = "glm-4.7"
+		}
+		baseURL := cfg.BaseURL
+		if cfg.Provider == config.ProviderOllama {
+			if cfg.Model == "" {
+				return backendModels{}, errs.Config(fmt.Errorf("%s: ollama needs a model", backendField(name)), "set model to a model pulled with `ollama pull`, e.g. qwen2.5-coder")
+			}
+			if baseURL == "" {
+				baseURL = OllamaBaseURL
+			}
+			// Ollama ignores the key, but the client wants one
+			apiKey = config.ProviderOllama
+		}
+
+		// Build options for custom base URL
+		var opts []option.RequestOption
+		if baseURL != "" {
+			opts = append(opts, option.WithBaseURL(baseURL))
+		}
+
+		plugin := &oai.OpenAI{
+			APIKey: apiKey,
+			Opts:   opts,
+		}
+
+		modelID = qualifyModel(prefix, modelID)
+		g = genkit.Init(ctx,
+			genkit.WithDefaultModel(modelID),
+			genkit.WithPlugins(plugin),
+		)
+
+	case "googleai":
+		fallthrough
+	default:
+		// Google AI (Gemini)
+		apiKey := cfg.ResolveAPIKey()
+
+		prefix = "googleai"
+		modelID = cfg.Model
+		if modelID == "" {
+			modelID = "gemini-2.0-flash"
+		}
+		modelID = qualifyModel(prefix, modelID)
+
+		g = genkit.Init(ctx,
+			genkit.WithDefaultModel(modelID),
+			genkit.WithPlugins(&googlegenai.GoogleAI{
+				APIKey: apiKey,
+			}),
+		)
+	}
+
+	models := backendModels{model: model{backend: name, provider: cfg.Provider, genkit: g, id: modelID}}
+	models.strong, models.triage = models.model, models.model
+	if cfg.StrongModel != "" {
+		models.strong.id = qualifyModel(prefix, cfg.StrongModel)
+	}
+	if cfg.TriageModel != "" {
+		models.triage.id = qualifyModel(prefix, cfg.TriageModel)
+	}
+	return models, nil
+}
+
+// backendField names a backend's settings in messages
+func backendField(name string) string {
+	if name == "" {
+		return "review"
+	}
+	return "review.backends." + name
+}
+
+// qualifyModel prefixes model with the Genkit provider unless it names one
+func qualifyModel(provider, model string) string {
+	if model == "" || strings.Contains(model, "/") {
+		return model
+	}
+	return provider + "/" + model
+}
+
+// modelFor returns the model reviewing repo: that of its risk profile on
+// its backend
+func (r *Reviewer) modelFor(repo string) model {
+	models := r.models
+	if b, ok := r.backends[r.config.RepoBackend[repo]]; ok {
+		models = b
+	}
+	switch r.risk(repo) {
+	case config.RiskCritical:
+		return models.strong
+	case config.RiskExperimental:
+		return models.triage
+	}
+	return models.model
+}
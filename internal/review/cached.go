@@ -0,0 +1,42 @@
+package review
+
+import (
+	"github.com/juparave/codereviewer/internal/cache"
+)
+
+// SetCache reuses the model's answers to prompts sent before, kept in
+// store, as when the same changes are reviewed again; nil doesn't
+func (r *Reviewer) SetCache(store *cache.Store) {
+	r.cache = store
+}
+
+// responseKey names the cached answer of m to a system message and prompt.
+// The endpoint is part of it, as OpenAI-compatible servers share model
+// names.
+func (r *Reviewer) responseKey(m model, system, prompt string) string {
+	return cache.Key(m.id, r.config.Backend(m.backend).BaseURL, system, prompt) + ".txt"
+}
+
+// cachedAnswer returns the answer of m to system and prompt cached by an
+// earlier call, in this process or another
+func (r *Reviewer) cachedAnswer(m model, system, prompt string) (string, bool) {
+	if r.cache == nil {
+		return "", false
+	}
+	data, err := r.cache.Get(cache.Responses, r.responseKey(m, system, prompt))
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// remember caches the answer of m to system and prompt. Only answers that
+// parsed are, so a retry asks the model again.
+func (r *Reviewer) remember(m model, system, prompt, answer string) {
+	if r.cache == nil {
+		return
+	}
+	if err := r.cache.Put(cache.Responses, r.responseKey(m, system, prompt), []byte(answer)); err != nil {
+		r.logger.Debug("caching the model answer failed", "err", err)
+	}
+}
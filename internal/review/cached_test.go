@@ -0,0 +1,65 @@
+package review
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/juparave/codereviewer/internal/cache"
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/logging"
+)
+
+func TestCachedAnswerNotWrittenBack(t *testing.T) {
+	cfg := config.DefaultConfig().Review
+	templates, err := loadPromptTemplates("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	store, err := cache.Open(config.CacheConfig{Dir: dir}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Reviewer{config: cfg, logger: logging.OrDefault(nil), templates: templates}
+	r.SetCache(store)
+
+	diffs := []domain.Diff{{RepoName: "api", FilePath: "main.go", Language: "go", Content: "+func main() {}\n"}}
+	r.promptData = r.newPromptData(diffs)
+	// No model is set up: a call to it would fail the test
+	c := chunk{model: model{id: "test/model"}, diffs: diffs}
+	system, prompt := r.systemMessage(), r.userMessage(diffs, "")
+
+	answer := `{"summary": "Fine", "findings": [{"title": "Bug", "severity": "High", "repo_name": "api", "files": ["main.go"]}]}`
+	r.remember(c.model, system, prompt, answer)
+	entry := filepath.Join(dir, cache.Responses, r.responseKey(c.model, system, prompt))
+	before, err := os.Stat(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result Result
+	output, err := r.reviewChunk(context.Background(), Response{}, c, "", &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Summary != "Fine" || len(output.Findings) != 1 || result.Usage.Reused != 1 || result.Usage.Calls != 0 {
+		t.Fatalf("got %+v with usage %+v, want the cached answer reused", output, result.Usage)
+	}
+
+	// Entries are replaced through a new file, so an answer written back
+	// would be another file
+	after, err := os.Stat(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(before, after) {
+		t.Error("the reused answer was written back to the cache")
+	}
+	r.remember(c.model, system, prompt, answer)
+	if again, err := os.Stat(entry); err != nil || os.SameFile(after, again) {
+		t.Errorf("remember didn't replace the entry (%v), the check above proves nothing", err)
+	}
+}
@@ -0,0 +1,58 @@
+package review
+
+import (
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// MaxChunkTokens is the approximate input budget for a single LLM call.
+// Diffs beyond this are split across several calls.
+const MaxChunkTokens = 30000
+
+// MaxCarryoverTokens caps the rolling context passed from earlier chunks
+const MaxCarryoverTokens = 1000
+
+// ExpectedOutputTokens is the typical size of one structured review response
+const ExpectedOutputTokens = 1000
+
+// EstimateTokens approximates the token count of text using the common
+// ~4 characters per token heuristic
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// diffTokens estimates the prompt tokens contributed by a single diff,
+// including its repository/file headers
+func diffTokens(d domain.Diff) int {
+	return EstimateTokens(d.Content) + EstimateTokens(d.Context) + EstimateTokens(d.RepoName+d.FilePath+d.Language) + 20
+}
+
+// ChunkDiffs groups diffs into chunks that fit within MaxChunkTokens once the
+// fixed prompt overhead is included. A diff larger than the budget gets a
+// chunk of its own rather than being dropped.
+func ChunkDiffs(diffs []domain.Diff) [][]domain.Diff {
+	budget := MaxChunkTokens - promptOverheadTokens() - MaxCarryoverTokens
+
+	var chunks [][]domain.Diff
+	var current []domain.Diff
+	used := 0
+	for _, d := range diffs {
+		tokens := diffTokens(d)
+		if len(current) > 0 && used+tokens > budget {
+			chunks = append(chunks, current)
+			current = nil
+			used = 0
+		}
+		current = append(current, d)
+		used += tokens
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+
+	return chunks
+}
+
+// promptOverheadTokens is the size of the instructions sent with every chunk
+func promptOverheadTokens() int {
+	return EstimateTokens(systemPrompt) + EstimateTokens(outputInstructions)
+}
@@ -0,0 +1,46 @@
+package review
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+func TestChunkDiffs(t *testing.T) {
+	small := func(name string) domain.Diff {
+		return domain.Diff{RepoName: "api", FilePath: name, Content: "+x\n"}
+	}
+	huge := domain.Diff{RepoName: "api", FilePath: "huge.go", Content: strings.Repeat("x", MaxChunkTokens*4*2)}
+	half := domain.Diff{RepoName: "api", FilePath: "half.go", Content: strings.Repeat("x", MaxChunkTokens*4/2)}
+
+	tests := []struct {
+		name  string
+		diffs []domain.Diff
+		sizes []int
+	}{
+		{"empty", nil, nil},
+		{"small diffs share a chunk", []domain.Diff{small("a.go"), small("b.go"), small("c.go")}, []int{3}},
+		{"oversized diff gets its own chunk", []domain.Diff{small("a.go"), huge, small("b.go")}, []int{1, 1, 1}},
+		{"budget splits diffs", []domain.Diff{half, half, half}, []int{1, 1, 1}},
+		{"fills before splitting", []domain.Diff{half, small("a.go"), half}, []int{2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := ChunkDiffs(tt.diffs)
+			if len(chunks) != len(tt.sizes) {
+				t.Fatalf("got %d chunks, want %d", len(chunks), len(tt.sizes))
+			}
+			total := 0
+			for i, chunk := range chunks {
+				if len(chunk) != tt.sizes[i] {
+					t.Errorf("chunk %d has %d diffs, want %d", i, len(chunk), tt.sizes[i])
+				}
+				total += len(chunk)
+			}
+			if total != len(tt.diffs) {
+				t.Errorf("chunks hold %d diffs, want %d", total, len(tt.diffs))
+			}
+		})
+	}
+}
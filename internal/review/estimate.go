@@ -0,0 +1,90 @@
+package review
+
+import (
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/logging"
+)
+
+// Estimate describes the expected LLM usage of reviewing a set of diffs
+type Estimate struct {
+	Chunks       int
+	InputTokens  int
+	OutputTokens int
+}
+
+// ModelPrice is the list price of a model in USD per million tokens
+type ModelPrice struct {
+	Provider string
+	Model    string
+	Input    float64
+	Output   float64
+}
+
+// Pricing holds approximate list prices for commonly used models. Prices
+// change often; treat the resulting costs as an order-of-magnitude check.
+var Pricing = []ModelPrice{
+	{Provider: "googleai", Model: "gemini-2.0-flash", Input: 0.10, Output: 0.40},
+	{Provider: "googleai", Model: "gemini-2.5-flash", Input: 0.30, Output: 2.50},
+	{Provider: "googleai", Model: "gemini-2.5-pro", Input: 1.25, Output: 10.00},
+	{Provider: "openai", Model: "gpt-4o-mini", Input: 0.15, Output: 0.60},
+	{Provider: "openai", Model: "gpt-4o", Input: 2.50, Output: 10.00},
+	{Provider: "openai", Model: "glm-4.7", Input: 0.60, Output: 2.20},
+}
+
+// EstimateUsage computes chunk and token counts for diffs without calling the LLM
+func EstimateUsage(diffs []domain.Diff) Estimate {
+	chunks := ChunkDiffs(diffs)
+
+	est := Estimate{Chunks: len(chunks)}
+	for _, chunk := range chunks {
+		est.InputTokens += promptOverheadTokens()
+		for _, d := range chunk {
+			est.InputTokens += diffTokens(d)
+		}
+		est.OutputTokens += ExpectedOutputTokens
+	}
+
+	// Later chunks carry earlier context and a final call merges summaries
+	if est.Chunks > 1 {
+		est.InputTokens += (est.Chunks - 1) * MaxCarryoverTokens // Carried context
+		est.InputTokens += MaxCarryoverTokens                    // Synthesis prompt
+		est.OutputTokens += 100
+	}
+
+	return est
+}
+
+// Cost returns the estimated cost in USD of the usage at the given price
+func (e Estimate) Cost(price ModelPrice) float64 {
+	return float64(e.InputTokens)/1e6*price.Input + float64(e.OutputTokens)/1e6*price.Output
+}
+
+// Prompt is the prompt one chunk of a review would send to the LLM
+type Prompt struct {
+	Diffs  []domain.Diff
+	Text   string
+	Tokens int
+}
+
+// Prompts builds the prompt for every chunk of diffs without calling the
+// LLM. In a real run, prompts after the first also carry the summary and
+// findings of earlier chunks, which aren't known until the LLM answers.
+func Prompts(cfg config.ReviewConfig, diffs []domain.Diff) ([]Prompt, error) {
+	templates, err := loadPromptTemplates(cfg.SystemPromptFile, cfg.OutputInstructionsFile)
+	if err != nil {
+		return nil, err
+	}
+	languages, err := loadLanguagePrompts(cfg.LanguagePromptsDir, cfg.LanguagePrompts)
+	if err != nil {
+		return nil, err
+	}
+	r := &Reviewer{config: cfg, logger: logging.OrDefault(nil), templates: templates, languages: languages}
+	r.promptData = r.newPromptData(diffs)
+	var prompts []Prompt
+	for _, chunk := range r.chunks(diffs) {
+		text := r.buildPrompt(chunk.diffs, "")
+		prompts = append(prompts, Prompt{Diffs: chunk.diffs, Text: text, Tokens: EstimateTokens(text)})
+	}
+	return prompts, nil
+}
@@ -0,0 +1,87 @@
+package review
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+)
+
+// languageGuidance points the model at the pitfalls of languages a generic
+// prompt misses, keyed by language label. review.language_prompts and
+// review.language_prompts_dir replace it per language.
+var languageGuidance = map[string]string{
+	"go": "- Goroutines: look for data races on shared maps and slices, goroutines that can block forever or outlive their request, and loops capturing variables in closures.\n" +
+		"- Errors: returned errors dropped or compared with == instead of errors.Is, and panics on paths that should return an error.\n" +
+		"- Resources: deferred Close calls in loops, unclosed response bodies and contexts not passed down.",
+	"sql": "- Migrations: check they can run on a live table without long locks (adding NOT NULL columns without defaults, rewriting indexes without CONCURRENTLY) and that rolling back is possible.\n" +
+		"- Data: destructive statements without a WHERE clause, and changed column types or constraints that existing rows may violate.",
+	"dart": "- Widgets: setState or BuildContext used after an await without checking mounted, and controllers, streams or animation controllers never disposed.\n" +
+		"- Builds: expensive work or object creation inside build methods, and missing const constructors on static widgets.",
+	"typescript": "- Types: any or non-null assertions (!) hiding real nulls, and casts that skip validation of external data.\n" +
+		"- Async: promises neither awaited nor handled, and async callbacks passed where errors get lost (forEach, event handlers).",
+}
+
+// loadLanguagePrompts merges the built-in guidance with the files of dir
+// and then inline, keyed by lowercase language label; empty texts remove a
+// language. Reading dir fails with a config error.
+func loadLanguagePrompts(dir string, inline map[string]string) (map[string]string, error) {
+	prompts := make(map[string]string, len(languageGuidance))
+	for lang, text := range languageGuidance {
+		prompts[lang] = text
+	}
+
+	set := func(lang, text string) {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if text = strings.TrimSpace(text); text == "" {
+			delete(prompts, lang)
+		} else {
+			prompts[lang] = text
+		}
+	}
+
+	if dir != "" {
+		paths, err := filepath.Glob(filepath.Join(dir, "*.md"))
+		if err == nil && len(paths) == 0 {
+			_, err = os.Stat(dir)
+		}
+		if err != nil {
+			return nil, errs.Config(fmt.Errorf("reading language prompts: %w", err), "point review.language_prompts_dir at a directory of <language>.md files")
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, errs.Config(fmt.Errorf("reading language prompts: %w", err), "point review.language_prompts_dir at a directory of <language>.md files")
+			}
+			set(strings.TrimSuffix(filepath.Base(path), ".md"), string(data))
+		}
+	}
+	for lang, text := range inline {
+		set(lang, text)
+	}
+	return prompts, nil
+}
+
+// languageSection holds the guidance for the languages of the files in
+// diffs, in the order they first appear
+func (r *Reviewer) languageSection(diffs []domain.Diff) string {
+	var sb strings.Builder
+	var seen []string
+	for _, d := range diffs {
+		lang := strings.ToLower(d.Language)
+		guidance, ok := r.languages[lang]
+		if !ok || slices.Contains(seen, lang) {
+			continue
+		}
+		seen = append(seen, lang)
+		sb.WriteString(fmt.Sprintf("### %s\n\n%s\n\n", lang, guidance))
+	}
+	if len(seen) == 0 {
+		return ""
+	}
+	return "## Language Guidance\n\nApply this guidance only to files in the language it is given for.\n\n" + sb.String()
+}
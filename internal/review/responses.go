@@ -0,0 +1,154 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// Response is a raw model answer to one request of a review
+type Response struct {
+	// Chunk is the 1-based chunk the answer reviews; 0 is the summary
+	// synthesis
+	Chunk   int `json:"chunk"`
+	Attempt int `json:"attempt,omitempty"`
+	// Files are the files of the chunk, used to map shortened repository
+	// names back when replaying
+	Files []FileRef `json:"files,omitempty"`
+	Text  string    `json:"text"`
+	// Error is why the request failed or its answer couldn't be parsed
+	Error string `json:"error,omitempty"`
+	// Verify marks the verifier pass over the findings of critical
+	// repositories, whose titles Checked lists in the order numbered in
+	// the prompt
+	Verify  bool     `json:"verify,omitempty"`
+	Checked []string `json:"checked,omitempty"`
+}
+
+// Request is a prompt sent to the model during a review, stored with the
+// responses so a report can be traced back to what the model saw
+type Request struct {
+	Chunk   int    `json:"chunk"` // As in Response
+	Attempt int    `json:"attempt,omitempty"`
+	Verify  bool   `json:"verify,omitempty"`
+	System  string `json:"system,omitempty"`
+	Text    string `json:"text"`
+}
+
+// addPrompt records the system message and prompt of the request answered
+// by resp
+func (res *Result) addPrompt(resp Response, system, prompt string) {
+	res.Requests = append(res.Requests, Request{Chunk: resp.Chunk, Attempt: resp.Attempt, Verify: resp.Verify, System: system, Text: prompt})
+}
+
+// EncodeRequests encodes the requests of a review as JSON for storage
+func EncodeRequests(requests []Request) ([]byte, error) {
+	data, err := json.MarshalIndent(requests, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding prompts: %w", err)
+	}
+	return data, nil
+}
+
+// FileRef names a reviewed file
+type FileRef struct {
+	Repo string `json:"repo"`
+	Path string `json:"path"`
+}
+
+// Transcript is what `review replay` needs to rebuild a report: the raw
+// answers of a run and the findings found without the LLM
+type Transcript struct {
+	Responses []Response       `json:"responses"`
+	Local     []domain.Finding `json:"local_findings,omitempty"` // e.g. duplicated changes
+}
+
+// EncodeTranscript encodes t as JSON for storage
+func EncodeTranscript(t *Transcript) ([]byte, error) {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding responses: %w", err)
+	}
+	return data, nil
+}
+
+// DecodeTranscript decodes a transcript encoded by EncodeTranscript
+func DecodeTranscript(data []byte) (*Transcript, error) {
+	var t Transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parsing responses: %w", err)
+	}
+	return &t, nil
+}
+
+// Replay rebuilds a review result from saved responses without calling
+// the LLM. Each chunk's last answer is parsed again, so parsing changes
+// apply; a chunk whose answer still doesn't parse is reported as a failure.
+// The findings a verifier pass rejected stay out.
+func Replay(responses []Response) *Result {
+	last := make(map[int]Response)
+	var order []int
+	var verified []Response
+	for _, resp := range responses {
+		if resp.Verify {
+			verified = append(verified, resp)
+			continue
+		}
+		if _, ok := last[resp.Chunk]; !ok && resp.Chunk > 0 {
+			order = append(order, resp.Chunk)
+		}
+		last[resp.Chunk] = resp
+	}
+
+	r := &Reviewer{}
+	result := &Result{}
+	var summaries []string
+	var diffs []domain.Diff
+	for _, chunk := range order {
+		resp := last[chunk]
+		var chunkDiffs []domain.Diff
+		for _, file := range resp.Files {
+			chunkDiffs = append(chunkDiffs, domain.Diff{RepoName: file.Repo, FilePath: file.Path})
+		}
+		diffs = append(diffs, chunkDiffs...)
+
+		if resp.Text == "" && resp.Error != "" {
+			// The model never answered; replaying can't help
+			result.Failures = append(result.Failures, chunkFailure(chunkDiffs, errors.New(resp.Error)))
+			continue
+		}
+		output, err := r.parseResponse(resp.Text)
+		if err != nil {
+			result.Failures = append(result.Failures, chunkFailure(chunkDiffs, fmt.Errorf("chunk %d: parsing response: %w", chunk, err)))
+			continue
+		}
+		result.Findings = append(result.Findings, output.Findings...)
+		if output.Summary != "" {
+			summaries = append(summaries, output.Summary)
+		}
+	}
+	normalizeRepoNames(result.Findings, diffs)
+	for _, verify := range verified {
+		if rejected, err := parseVerdicts(verify.Text); err == nil && verify.Error == "" {
+			result.Findings = rejectFindings(result.Findings, verify.Checked, rejected)
+		}
+	}
+
+	result.Summary = strings.Join(summaries, " ")
+	if summary, ok := last[0]; ok && strings.TrimSpace(summary.Text) != "" {
+		result.Summary = strings.TrimSpace(summary.Text)
+	}
+	return result
+}
+
+// chunkFiles lists the files of a chunk
+func chunkFiles(diffs []domain.Diff) []FileRef {
+	files := make([]FileRef, len(diffs))
+	for i, d := range diffs {
+		files[i] = FileRef{Repo: d.RepoName, Path: d.FilePath}
+	}
+	return files
+}
@@ -4,17 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
-	oai "github.com/firebase/genkit/go/plugins/compat_oai/openai"
-	"github.com/firebase/genkit/go/plugins/googlegenai"
+	"github.com/juparave/codereviewer/internal/cache"
 	"github.com/juparave/codereviewer/internal/config"
 	"github.com/juparave/codereviewer/internal/domain"
-	"github.com/openai/openai-go/option"
+	"github.com/juparave/codereviewer/internal/logging"
 )
 
 // ReviewOutput is the structured output from the LLM
@@ -25,141 +26,589 @@ type ReviewOutput struct {
 
 // Reviewer performs code review using an LLM
 type Reviewer struct {
-	config  config.ReviewConfig
-	logger  *log.Logger
-	genkit  *genkit.Genkit
-	modelID string
+	config config.ReviewConfig
+	logger *slog.Logger
+	// models are those of review.provider, backends those of each
+	// review.backends entry by name
+	models    backendModels
+	backends  map[string]backendModels
+	templates promptTemplates
+	// languages maps language labels to their guidance
+	languages map[string]string
+
+	// promptData describes the review in progress to prompt templates
+	promptData *PromptData
+	// progress is told of each request finished; see SetProgress
+	progress func(done, total int)
+	// cache holds the answers to prompts sent before; see SetCache
+	cache *cache.Store
 }
 
-// NewReviewer creates a new Reviewer
-func NewReviewer(cfg config.ReviewConfig, logger *log.Logger) (*Reviewer, error) {
+// SetProgress calls fn with the number of requests finished and their
+// total as a review goes
+func (r *Reviewer) SetProgress(fn func(done, total int)) {
+	r.progress = fn
+}
+
+// NewReviewer creates a new Reviewer, initializing review.provider and
+// every review.backends entry
+func NewReviewer(cfg config.ReviewConfig, logger *slog.Logger) (*Reviewer, error) {
 	ctx := context.Background()
 
-	var g *genkit.Genkit
-	var modelID string
-
-	switch cfg.Provider {
-	case "openai":
-		// OpenAI-compatible API (Zhipu AI, etc.)
-		apiKey := cfg.APIKey
-		if apiKey == "" {
-			apiKey = os.Getenv("ZHIPU_API_KEY")
-			if apiKey == "" {
-				apiKey = os.Getenv("OPENAI_API_KEY")
-			}
+	models, err := initBackend(ctx, "", cfg)
+	if err != nil {
+		return nil, err
+	}
+	backends := make(map[string]backendModels, len(cfg.Backends))
+	for name := range cfg.Backends {
+		if backends[name], err = initBackend(ctx, name, cfg.Backend(name)); err != nil {
+			return nil, err
 		}
+	}
 
-		// Build options for custom base URL
-		var opts []option.RequestOption
-		if cfg.BaseURL != "" {
-			opts = append(opts, option.WithBaseURL(cfg.BaseURL))
-		}
+	templates, err := loadPromptTemplates(cfg.SystemPromptFile, cfg.OutputInstructionsFile)
+	if err != nil {
+		return nil, err
+	}
+	languages, err := loadLanguagePrompts(cfg.LanguagePromptsDir, cfg.LanguagePrompts)
+	if err != nil {
+		return nil, err
+	}
 
-		plugin := &oai.OpenAI{
-			APIKey: apiKey,
-			Opts:   opts,
-		}
+	return &Reviewer{
+		config:    cfg,
+		logger:    logging.OrDefault(logger),
+		models:    models,
+		backends:  backends,
+		templates: templates,
+		languages: languages,
+	}, nil
+}
+
+// Result is the outcome of a review
+type Result struct {
+	Findings []domain.Finding
+	Summary  string
+	// Failures lists the chunks that couldn't be reviewed; their findings
+	// are missing from the result
+	Failures []domain.ReviewFailure
+	Usage    domain.Usage
+	// Responses are the raw model answers, kept for `review replay`
+	Responses []Response
+	// Requests are the prompts the answers reply to
+	Requests []Request
+}
+
+// Review analyzes diffs and returns findings. Diffs that don't fit in a
+// single prompt are reviewed in chunks whose results are combined. A chunk
+// that still fails after retries is recorded in Result.Failures and the
+// remaining chunks are reviewed; an error is returned only when every
+// chunk fails, with a result carrying only the failures and usage.
+// Nothing about one backend's repositories reaches another.
+func (r *Reviewer) Review(ctx context.Context, diffs []domain.Diff) (*Result, error) {
+	if len(diffs) == 0 {
+		return &Result{Summary: "No changes to review."}, nil
+	}
+
+	chunks := r.chunks(diffs)
+	byBackend := make(map[string][]domain.Diff)
+	for _, c := range chunks {
+		byBackend[c.model.backend] = append(byBackend[c.model.backend], c.diffs...)
+	}
 
-		modelID = cfg.Model
-		if modelID == "" {
-			modelID = "glm-4.7"
+	result := &Result{}
+	var summaries []string
+	// What each backend's chunks found, carried to its later chunks
+	backendSummaries := make(map[string][]string)
+	backendFindings := make(map[string][]domain.Finding)
+	var lastErr error
+	for i, chunk := range chunks {
+		if r.progress != nil {
+			r.progress(i, len(chunks))
 		}
-		// Prefix with openai/ for Genkit
-		if !strings.Contains(modelID, "/") {
-			modelID = "openai/" + modelID
+		if len(chunks) > 1 {
+			r.logger.Info("reviewing chunk", "chunk", i+1, "chunks", len(chunks), "files", len(chunk.diffs), "model", chunk.model.id)
 		}
+		backend := chunk.model.backend
+		r.promptData = r.newPromptData(byBackend[backend])
 
-		g = genkit.Init(ctx,
-			genkit.WithDefaultModel(modelID),
-			genkit.WithPlugins(plugin),
-		)
+		// Later chunks see what earlier ones covered so the model can relate
+		// changes across chunks and avoid repeating findings
+		carry := carryover(backendSummaries[backend], backendFindings[backend])
 
-	case "googleai":
-		fallthrough
-	default:
-		// Google AI (Gemini)
-		apiKey := cfg.APIKey
-		if apiKey == "" {
-			apiKey = os.Getenv("GEMINI_API_KEY")
-			if apiKey == "" {
-				apiKey = os.Getenv("GOOGLE_API_KEY")
+		output, err := r.reviewChunkWithRetry(ctx, i+1, chunk, carry, result)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
 			}
+			r.logger.Warn("chunk failed, continuing", "chunk", i+1, "chunks", len(chunks), "err", err)
+			result.Failures = append(result.Failures, chunkFailure(chunk.diffs, err))
+			lastErr = err
+			continue
 		}
 
-		modelID = cfg.Model
-		if modelID == "" {
-			modelID = "gemini-2.0-flash"
-		}
-		// Prefix with googleai/ for Genkit
-		if !strings.Contains(modelID, "/") {
-			modelID = "googleai/" + modelID
+		result.Findings = append(result.Findings, output.Findings...)
+		backendFindings[backend] = append(backendFindings[backend], output.Findings...)
+		if output.Summary != "" {
+			summaries = append(summaries, output.Summary)
+			backendSummaries[backend] = append(backendSummaries[backend], output.Summary)
 		}
+	}
 
-		g = genkit.Init(ctx,
-			genkit.WithDefaultModel(modelID),
-			genkit.WithPlugins(&googlegenai.GoogleAI{
-				APIKey: apiKey,
-			}),
-		)
+	if len(result.Failures) == len(chunks) {
+		// The usage still counts toward provider health
+		return &Result{Failures: result.Failures, Usage: result.Usage}, lastErr
 	}
 
-	return &Reviewer{
-		config:  cfg,
-		logger:  logger,
-		genkit:  g,
-		modelID: modelID,
-	}, nil
+	normalizeRepoNames(result.Findings, diffs)
+	normalizeCategories(result.Findings)
+	r.keepFindingFields(result.Findings)
+	result.Findings = r.applyStrictness(result.Findings)
+	result.Findings = r.verify(ctx, result.Findings, diffs, result)
+
+	result.Summary = strings.Join(summaries, " ")
+	// A summary of several backends' repositories would show each to the
+	// synthesizing model
+	if len(summaries) > 1 && len(byBackend) == 1 {
+		synthesized, err := r.synthesize(ctx, chunks[0].model, summaries, result)
+		if err != nil {
+			r.logger.Warn("summary synthesis failed, using chunk summaries", "err", err)
+		} else {
+			result.Summary = synthesized
+		}
+	}
+
+	if result.Usage.Calls > 1 {
+		r.promptData = r.newPromptData(diffs)
+		result.Usage.RepeatedTokens = EstimateTokens(r.systemMessage()) * (result.Usage.Calls - 1)
+	}
+	return result, nil
 }
 
-// Review analyzes diffs and returns findings
-func (r *Reviewer) Review(ctx context.Context, diffs []domain.Diff) ([]domain.Finding, string, error) {
-	if len(diffs) == 0 {
-		return nil, "No changes to review.", nil
+// MaxChunkAttempts is how many times a chunk's LLM call is tried
+const MaxChunkAttempts = 3
+
+// reviewChunkWithRetry calls reviewChunk, backing off between attempts
+func (r *Reviewer) reviewChunkWithRetry(ctx context.Context, n int, chunk chunk, carry string, result *Result) (*ReviewOutput, error) {
+	var lastErr error
+	for attempt := 1; attempt <= MaxChunkAttempts; attempt++ {
+		output, err := r.reviewChunk(ctx, Response{Chunk: n, Attempt: attempt, Files: chunkFiles(chunk.diffs)}, chunk, carry, result)
+		if err == nil {
+			return output, nil
+		}
+		lastErr = err
+
+		if attempt < MaxChunkAttempts {
+			r.logger.Warn("review attempt failed", "chunk", n, "attempt", attempt, "err", err)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(attempt*attempt) * time.Second):
+			}
+		}
 	}
+	return nil, fmt.Errorf("failed after %d attempts: %w", MaxChunkAttempts, lastErr)
+}
 
-	// Build the prompt
-	prompt := r.buildPrompt(diffs)
+// chunkFailure records which repositories and files a failed chunk covered
+func chunkFailure(diffs []domain.Diff, err error) domain.ReviewFailure {
+	failure := domain.ReviewFailure{Error: err.Error()}
+	seen := make(map[string]bool)
+	for _, d := range diffs {
+		if !seen[d.RepoName] {
+			seen[d.RepoName] = true
+			failure.Repos = append(failure.Repos, d.RepoName)
+		}
+		failure.Files = append(failure.Files, d.FilePath)
+	}
+	return failure
+}
 
-	// Generate response
-	answer, err := genkit.GenerateText(ctx, r.genkit,
-		ai.WithModelName(r.modelID),
-		ai.WithPrompt(prompt),
-	)
+// reviewChunk sends one prompt to the LLM and parses its response, adding
+// the answer to result.Responses as resp
+func (r *Reviewer) reviewChunk(ctx context.Context, resp Response, chunk chunk, carry string, result *Result) (*ReviewOutput, error) {
+	system, prompt := r.systemMessage(), r.userMessage(chunk.diffs, carry)
+	result.addPrompt(resp, system, prompt)
+	answer, cached, err := r.generate(ctx, chunk.model, system, prompt, &result.Usage)
 	if err != nil {
-		return nil, "", fmt.Errorf("generating review: %w", err)
+		err = fmt.Errorf("generating review: %w", err)
+		resp.Error = err.Error()
+		result.Responses = append(result.Responses, resp)
+		return nil, err
 	}
 
 	// Parse the response
+	resp.Text = answer
 	output, err := r.parseResponse(answer)
 	if err != nil {
-		return nil, "", fmt.Errorf("parsing response: %w", err)
+		resp.Error = err.Error()
+	}
+	result.Responses = append(result.Responses, resp)
+	if err != nil {
+		return nil, fmt.Errorf("parsing response: %w", err)
+	}
+	if !cached {
+		r.remember(chunk.model, system, prompt, answer)
 	}
 
-	return output.Findings, output.Summary, nil
+	return output, nil
 }
 
-func (r *Reviewer) buildPrompt(diffs []domain.Diff) string {
+// synthesize merges per-chunk summaries into one coherent report summary
+// with m
+func (r *Reviewer) synthesize(ctx context.Context, m model, summaries []string, result *Result) (string, error) {
 	var sb strings.Builder
+	sb.WriteString(synthesisPrompt)
+	sb.WriteString("\n\n## Partial Summaries\n\n")
+	for i, s := range summaries {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, s))
+	}
+	if len(result.Findings) > 0 {
+		sb.WriteString("\n## Findings\n\n")
+		for _, f := range result.Findings {
+			sb.WriteString(fmt.Sprintf("- [%s] %s (%s)\n", f.Severity, f.Title, f.RepoName))
+		}
+	}
+
+	prompt := sb.String()
+	result.addPrompt(Response{}, "", prompt)
+	answer, cached, err := r.generate(ctx, m, "", prompt, &result.Usage)
+	if err != nil {
+		return "", fmt.Errorf("generating summary: %w", err)
+	}
+	result.Responses = append(result.Responses, Response{Text: answer})
+
+	summary := strings.TrimSpace(answer)
+	if summary == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	if !cached {
+		r.remember(m, "", prompt, answer)
+	}
+	return summary, nil
+}
+
+// generate sends a system message, when set, and a user prompt to m
+// and adds the call to usage. The system message is identical for
+// every chunk so providers with prompt caching (Gemini implicit caching,
+// OpenAI automatic caching) can serve it from cache on later calls. An
+// answer cached by an earlier call with the same messages is reused
+// without calling m, and reported as cached; callers remember the fresh
+// answers they could use.
+func (r *Reviewer) generate(ctx context.Context, m model, system, prompt string, usage *domain.Usage) (answer string, cached bool, err error) {
+	if answer, ok := r.cachedAnswer(m, system, prompt); ok {
+		usage.Reused++
+		r.logger.Debug("reused cached model answer", "model", m.id)
+		return answer, true, nil
+	}
+
+	// The text is passed as an argument: WithPrompt and WithSystem treat
+	// their first parameter as a format string, and diffs contain %
+	opts := []ai.GenerateOption{
+		ai.WithModelName(m.id),
+		ai.WithPrompt("%s", prompt),
+	}
+	if system != "" {
+		opts = append(opts, ai.WithSystem("%s", system))
+	}
+
+	start := time.Now()
+	resp, err := genkit.Generate(ctx, m.genkit, opts...)
+	perModel := usage.Model(m.provider, m.id)
+	usage.LatencyMS += time.Since(start).Milliseconds()
+	perModel.LatencyMS += time.Since(start).Milliseconds()
+	if err != nil {
+		usage.Errors++
+		perModel.Errors++
+		r.logger.Debug("LLM call failed", "model", m.id, "duration", time.Since(start), "err", err)
+		return "", false, err
+	}
+	usage.Calls++
+	perModel.Calls++
+	attrs := []any{"model", m.id, "duration", time.Since(start)}
+	if u := resp.Usage; u != nil {
+		usage.InputTokens += u.InputTokens
+		usage.CachedTokens += u.CachedContentTokens
+		usage.OutputTokens += u.OutputTokens
+		perModel.InputTokens += u.InputTokens
+		perModel.OutputTokens += u.OutputTokens
+		attrs = append(attrs, "input_tokens", u.InputTokens, "cached_tokens", u.CachedContentTokens, "output_tokens", u.OutputTokens)
+	}
+	r.logger.Debug("LLM call", attrs...)
+	return resp.Text(), false, nil
+}
+
+// carryover builds the rolling context passed to later chunks: the
+// summaries and finding titles produced so far, capped at MaxCarryoverTokens
+// by dropping the oldest entries first
+func carryover(summaries []string, findings []domain.Finding) string {
+	if len(summaries) == 0 && len(findings) == 0 {
+		return ""
+	}
+
+	var lines []string
+	for _, s := range summaries {
+		lines = append(lines, "- "+s)
+	}
+	for _, f := range findings {
+		lines = append(lines, fmt.Sprintf("- Already reported: [%s] %s (%s)", f.Severity, f.Title, f.RepoName))
+	}
+
+	for len(lines) > 1 && EstimateTokens(strings.Join(lines, "\n")) > MaxCarryoverTokens {
+		lines = lines[1:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// normalizeRepoNames maps each finding's repository back to the display
+// name used in the prompt. Models occasionally shorten "services/api" to
+// "api", which would make same-named repositories indistinguishable.
+// Repositories are told apart by name and file path together: among those
+// that changed the finding's files, the one whose name ends in the name
+// the model gave wins, or the only one when there is a single candidate.
+func normalizeRepoNames(findings []domain.Finding, diffs []domain.Diff) {
+	type repoFile struct{ repo, file string }
+	known := make(map[string]bool)
+	changed := make(map[repoFile]bool)
+	var repos []string
+	for _, d := range diffs {
+		if !known[d.RepoName] {
+			known[d.RepoName] = true
+			repos = append(repos, d.RepoName)
+		}
+		changed[repoFile{d.RepoName, d.FilePath}] = true
+	}
+
+	for i := range findings {
+		f := &findings[i]
+		if known[f.RepoName] {
+			continue
+		}
+		var candidates, matching []string
+		for _, repo := range repos {
+			if !slices.ContainsFunc(f.Files, func(file string) bool { return changed[repoFile{repo, file}] }) {
+				continue
+			}
+			candidates = append(candidates, repo)
+			if f.RepoName != "" && strings.HasSuffix(repo, "/"+f.RepoName) {
+				matching = append(matching, repo)
+			}
+		}
+		switch {
+		case len(matching) == 1:
+			f.RepoName = matching[0]
+		case len(candidates) == 1:
+			f.RepoName = candidates[0]
+		}
+	}
+}
 
-	sb.WriteString(systemPrompt)
+// findingFieldsSection asks for the review.finding_fields of every finding
+func (r *Reviewer) findingFieldsSection() string {
+	if len(r.config.FindingFields) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n\nAlso give every finding an \"extensions\" object with these string fields:\n\n")
+	for _, f := range r.config.FindingFields {
+		sb.WriteString(fmt.Sprintf("- \"%s\": %s\n", f.Name, f.Description))
+	}
+	return sb.String()
+}
+
+// keepFindingFields drops the extensions review.finding_fields doesn't
+// declare, which models sometimes invent
+func (r *Reviewer) keepFindingFields(findings []domain.Finding) {
+	declared := make(map[string]bool)
+	for _, f := range r.config.FindingFields {
+		declared[f.Name] = true
+	}
+	for i := range findings {
+		for name := range findings[i].Extensions {
+			if !declared[name] {
+				delete(findings[i].Extensions, name)
+			}
+		}
+		if len(findings[i].Extensions) == 0 {
+			findings[i].Extensions = nil
+		}
+	}
+}
+
+// normalizeCategories lowercases the categories models return and clears
+// unknown ones
+func normalizeCategories(findings []domain.Finding) {
+	for i := range findings {
+		findings[i].Category, _ = domain.ParseCategory(string(findings[i].Category))
+	}
+}
+
+// Ping sends a tiny generation request to the provider and to every
+// backend to verify they are reachable and the credentials are accepted
+func (r *Reviewer) Ping(ctx context.Context) error {
+	models := []model{r.models.model}
+	for _, name := range slices.Sorted(maps.Keys(r.backends)) {
+		models = append(models, r.backends[name].model)
+	}
+	for _, m := range models {
+		_, err := genkit.GenerateText(ctx, m.genkit,
+			ai.WithModelName(m.id),
+			ai.WithPrompt("Reply with the single word OK."),
+		)
+		if err != nil {
+			return fmt.Errorf("%s: test generation with %s: %w", backendField(m.backend), m.id, err)
+		}
+	}
+	return nil
+}
+
+// buildPrompt is the full text of one chunk's request: the system message
+// followed by the user message
+func (r *Reviewer) buildPrompt(diffs []domain.Diff, carry string) string {
+	return r.systemMessage() + "\n\n" + r.userMessage(diffs, carry)
+}
+
+// systemMessage holds the instructions shared by every chunk. It must not
+// depend on the chunk, or prompt caching stops matching.
+func (r *Reviewer) systemMessage() string {
+	var sb strings.Builder
+
+	sb.WriteString(r.render(r.templates.system, systemPrompt))
 	sb.WriteString("\n\n")
+
+	if level, ok := strictnessLevels[r.config.Strictness]; ok {
+		sb.WriteString("## Strictness\n\n")
+		sb.WriteString(level.prompt())
+		sb.WriteString("\n\n")
+	}
+
+	if len(r.config.Focus) > 0 {
+		sb.WriteString("## Focus\n\n")
+		sb.WriteString("Review only for these areas: " + strings.Join(r.config.Focus, ", ") + ". ")
+		sb.WriteString("Leave out issues in any other area, however important.\n\n")
+	}
+
+	if r.config.PromptAddendum != "" {
+		sb.WriteString("## Additional Guidance\n\n")
+		sb.WriteString(r.config.PromptAddendum)
+		sb.WriteString("\n\n")
+	}
+
+	if len(r.config.RunNotes) > 0 {
+		sb.WriteString("## Notes From the Author for This Review\n\n")
+		sb.WriteString("The author described circumstances of today's changes. Take them into account, e.g. expect the churn they describe, but still report real issues.\n\n")
+		for _, note := range r.config.RunNotes {
+			sb.WriteString("- " + note + "\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(r.render(r.templates.output, outputInstructions))
+	sb.WriteString(r.findingFieldsSection())
+
+	return sb.String()
+}
+
+// userMessage holds what is specific to one chunk: per-repository
+// strictness and guidance, context from earlier chunks and the diffs
+func (r *Reviewer) userMessage(diffs []domain.Diff, carry string) string {
+	var sb strings.Builder
+
+	sb.WriteString(r.strictnessSection(diffs))
+	sb.WriteString(r.guidanceSection(diffs))
+	sb.WriteString(r.languageSection(diffs))
+
+	if carry != "" {
+		sb.WriteString("## Context From Earlier Parts of This Review\n\n")
+		sb.WriteString("The changes below continue a review split into several parts. ")
+		sb.WriteString("Earlier parts covered the following; do not repeat findings already reported.\n\n")
+		sb.WriteString(carry)
+		sb.WriteString("\n\n")
+	}
+
 	sb.WriteString("## Code Changes to Review\n\n")
 
 	for _, d := range diffs {
 		sb.WriteString(fmt.Sprintf("### Repository: %s\n", d.RepoName))
 		sb.WriteString(fmt.Sprintf("### File: %s (%s)\n", d.FilePath, d.Language))
+		if d.Merge {
+			sb.WriteString(mergeNote)
+		}
 		sb.WriteString("```diff\n")
 		sb.WriteString(d.Content)
 		sb.WriteString("\n```\n\n")
+		if d.Context != "" {
+			sb.WriteString("Whole file after the change, for context only; review the diff above:\n")
+			sb.WriteString("```" + d.Language + "\n")
+			sb.WriteString(d.Context)
+			sb.WriteString("\n```\n\n")
+		}
 	}
 
-	sb.WriteString(outputInstructions)
-
 	return sb.String()
 }
 
+// mergeNote explains the combined diff format of a merge commit's
+// conflict resolutions
+const mergeNote = "Conflict resolution of a merge commit, as a combined diff against both parents: " +
+	"the first column compares with the first parent, the second with the second. " +
+	"Lines marked in both columns were written by hand while resolving the conflict; " +
+	"check they keep the intent of both sides.\n"
+
+// strictnessSection lists the per-repository strictness overrides for the
+// repositories in diffs; review.strictness is in the system message
+func (r *Reviewer) strictnessSection(diffs []domain.Diff) string {
+	var sb strings.Builder
+	seen := make(map[string]bool)
+	for _, d := range diffs {
+		level, ok := r.config.RepoStrictness[d.RepoName]
+		if !ok || level == r.config.Strictness || seen[d.RepoName] {
+			continue
+		}
+		if strictness, ok := strictnessLevels[level]; ok {
+			seen[d.RepoName] = true
+			sb.WriteString(fmt.Sprintf("- In %s: %s\n", d.RepoName, strictness.prompt()))
+		}
+	}
+	if len(seen) == 0 {
+		return ""
+	}
+	return "## Repository Strictness\n\nThese repositories override the strictness above.\n\n" + sb.String() + "\n"
+}
+
+// guidanceSection holds the guidance the repositories in diffs give in
+// their .cra.yaml
+func (r *Reviewer) guidanceSection(diffs []domain.Diff) string {
+	var sb strings.Builder
+	seen := make(map[string]bool)
+	for _, d := range diffs {
+		guidance := r.config.RepoGuidance[d.RepoName]
+		if guidance == "" || seen[d.RepoName] {
+			continue
+		}
+		seen[d.RepoName] = true
+		sb.WriteString(fmt.Sprintf("### %s\n\n%s\n\n", d.RepoName, strings.TrimSpace(guidance)))
+	}
+	if len(seen) == 0 {
+		return ""
+	}
+	return "## Repository Guidance\n\nApply this guidance only to changes in the repository it is given for.\n\n" + sb.String()
+}
+
 func (r *Reviewer) parseResponse(text string) (*ReviewOutput, error) {
-	// Try to find JSON in the response
+	text = trimCodeFence(text)
+
+	var output ReviewOutput
+	if err := json.Unmarshal([]byte(text), &output); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w\nResponse was: %s", err, text)
+	}
+
+	return &output, nil
+}
+
+// trimCodeFence returns the JSON of an answer, without the markdown code
+// block models sometimes wrap it in
+func trimCodeFence(text string) string {
 	text = strings.TrimSpace(text)
 
 	// Handle markdown code blocks
@@ -175,14 +624,7 @@ func (r *Reviewer) parseResponse(text string) (*ReviewOutput, error) {
 		}
 	}
 
-	text = strings.TrimSpace(text)
-
-	var output ReviewOutput
-	if err := json.Unmarshal([]byte(text), &output); err != nil {
-		return nil, fmt.Errorf("failed to parse JSON: %w\nResponse was: %s", err, text)
-	}
-
-	return &output, nil
+	return strings.TrimSpace(text)
 }
 
 const systemPrompt = `You are a senior software engineer performing a daily code review. Your role is to identify meaningful issues that matter for production code quality.
@@ -211,6 +653,10 @@ const systemPrompt = `You are a senior software engineer performing a daily code
 - Speculative future problems
 - Style preferences`
 
+const synthesisPrompt = `You are a senior software engineer finishing a daily code review that was performed in several parts. Combine the partial summaries below into one coherent summary of the day's changes in two or three sentences, mentioning the most important risks if there are findings.
+
+Respond with the summary text only, no headings, lists or JSON.`
+
 const outputInstructions = `
 ## Required Output Format
 
@@ -222,6 +668,7 @@ Respond with a JSON object in this exact format:
     {
       "title": "Brief issue title",
       "severity": "High|Medium|Low",
+      "category": "security|bugs|data|design|performance|maintainability",
       "repo_name": "repository-name",
       "files": ["file1.go", "file2.go"],
       "explanation": "Why this is a problem and what could go wrong",
@@ -230,6 +677,8 @@ Respond with a JSON object in this exact format:
   ]
 }
 
+The category is the area the issue belongs to: data for data integrity, maintainability for anything outside the other areas.
+
 If no meaningful issues are found, return:
 {
   "summary": "Summary of changes reviewed",
@@ -0,0 +1,40 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+func TestNormalizeRepoNames(t *testing.T) {
+	diffs := []domain.Diff{
+		{RepoName: "services/api", RepoPath: "/src/services/api", FilePath: "main.go"},
+		{RepoName: "tools/api", RepoPath: "/src/tools/api", FilePath: "main.go"},
+		{RepoName: "tools/api", RepoPath: "/src/tools/api", FilePath: "cli.go"},
+		{RepoName: "web", RepoPath: "/src/web", FilePath: "app.ts"},
+		{RepoName: "web/admin", RepoPath: "/src/web/admin", FilePath: "main.go"},
+	}
+
+	tests := []struct {
+		name  string
+		repo  string
+		files []string
+		want  string
+	}{
+		{"known name", "web", []string{"app.ts"}, "web"},
+		{"shortened name, one repository changed the file", "api", []string{"cli.go"}, "tools/api"},
+		{"shortened name, the file changed in both", "api", []string{"main.go"}, "api"},
+		{"suffix picks among repositories changing the file", "admin", []string{"main.go"}, "web/admin"},
+		{"other name, single candidate", "frontend", []string{"app.ts"}, "web"},
+		{"unknown file", "api", []string{"other.go"}, "api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := []domain.Finding{{RepoName: tt.repo, Files: tt.files}}
+			normalizeRepoNames(findings, diffs)
+			if findings[0].RepoName != tt.want {
+				t.Errorf("got %q, want %q", findings[0].RepoName, tt.want)
+			}
+		})
+	}
+}
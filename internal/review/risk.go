@@ -0,0 +1,205 @@
+package review
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// chunk is a part of a review sent in one request, with the model that
+// reviews it
+type chunk struct {
+	model model
+	diffs []domain.Diff
+}
+
+// risk returns the risk profile of a repository, normal when unset or
+// unknown, so a repository with a mistyped risk is still reviewed
+func (r *Reviewer) risk(repo string) string {
+	if risk, ok := r.config.RepoRisk[repo]; ok && slices.Contains(config.RiskProfiles, risk) {
+		return risk
+	}
+	return config.RiskNormal
+}
+
+// chunks groups diffs by the model reviewing their repository, most
+// critical first, and splits each group into chunks. Without risk
+// profiles, backends or extra models, this is ChunkDiffs with the default
+// model.
+func (r *Reviewer) chunks(diffs []domain.Diff) []chunk {
+	var models []model
+	groups := make(map[string][]domain.Diff)
+	for _, risk := range config.RiskProfiles {
+		for _, d := range diffs {
+			if r.risk(d.RepoName) != risk {
+				continue
+			}
+			m := r.modelFor(d.RepoName)
+			if _, ok := groups[m.key()]; !ok {
+				models = append(models, m)
+			}
+			groups[m.key()] = append(groups[m.key()], d)
+		}
+	}
+
+	var chunks []chunk
+	for _, m := range models {
+		for _, c := range ChunkDiffs(groups[m.key()]) {
+			chunks = append(chunks, chunk{model: m, diffs: c})
+		}
+	}
+	return chunks
+}
+
+// verify has the models of critical repositories check their findings
+// against the diffs, a second opinion that drops false positives before
+// they reach the report. Each model only sees its own repositories, and
+// the findings are kept as they are when its pass fails.
+func (r *Reviewer) verify(ctx context.Context, findings []domain.Finding, diffs []domain.Diff, result *Result) []domain.Finding {
+	var models []model
+	checked := make(map[string][]domain.Finding)
+	files := make(map[string]bool)
+	for _, f := range findings {
+		if r.risk(f.RepoName) != config.RiskCritical {
+			continue
+		}
+		m := r.modelFor(f.RepoName)
+		if _, ok := checked[m.key()]; !ok {
+			models = append(models, m)
+		}
+		checked[m.key()] = append(checked[m.key()], f)
+		for _, file := range f.Files {
+			files[f.RepoName+"/"+file] = true
+		}
+	}
+
+	for _, m := range models {
+		var evidence []domain.Diff
+		for _, d := range diffs {
+			if files[d.RepoName+"/"+d.FilePath] && r.modelFor(d.RepoName).key() == m.key() {
+				evidence = append(evidence, d)
+			}
+		}
+		findings = r.verifyWith(ctx, m, findings, checked[m.key()], evidence, result)
+	}
+	return findings
+}
+
+// verifyWith runs the verifier pass of checked, some of findings, on m
+func (r *Reviewer) verifyWith(ctx context.Context, m model, findings, checked []domain.Finding, evidence []domain.Diff, result *Result) []domain.Finding {
+	resp := Response{Verify: true}
+	for _, f := range checked {
+		resp.Checked = append(resp.Checked, f.Title)
+	}
+	prompt := verifyMessage(checked, evidence)
+	result.addPrompt(resp, "", prompt)
+	answer, cached, err := r.generate(ctx, m, "", prompt, &result.Usage)
+	if err != nil {
+		resp.Error = fmt.Sprintf("generating verification: %v", err)
+		result.Responses = append(result.Responses, resp)
+		r.logger.Warn("verifier pass failed, keeping findings", "model", m.id, "err", err)
+		return findings
+	}
+	resp.Text = answer
+	rejected, err := parseVerdicts(answer)
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	result.Responses = append(result.Responses, resp)
+	if err != nil {
+		r.logger.Warn("verifier pass failed, keeping findings", "model", m.id, "err", err)
+		return findings
+	}
+	if !cached {
+		r.remember(m, "", prompt, answer)
+	}
+
+	kept := rejectFindings(findings, resp.Checked, rejected)
+	r.logger.Info("verified findings of critical repositories", "model", m.id, "checked", len(checked), "rejected", len(findings)-len(kept))
+	return kept
+}
+
+// verifyMessage asks for a verdict on each finding, numbered from 1, with
+// the diffs they point at
+func verifyMessage(findings []domain.Finding, diffs []domain.Diff) string {
+	var sb strings.Builder
+	sb.WriteString(verifyPrompt)
+	sb.WriteString("\n\n## Findings\n\n")
+	for i, f := range findings {
+		sb.WriteString(fmt.Sprintf("%d. [%s] %s (%s: %s)\n   %s\n", i+1, f.Severity, f.Title, f.RepoName, strings.Join(f.Files, ", "), f.Explanation))
+	}
+
+	// Keep to one request's worth of evidence
+	budget := MaxChunkTokens - EstimateTokens(sb.String())
+	sb.WriteString("\n## Code Changes\n\n")
+	for _, d := range diffs {
+		if budget -= diffTokens(d); budget < 0 {
+			break
+		}
+		sb.WriteString(fmt.Sprintf("### %s: %s (%s)\n", d.RepoName, d.FilePath, d.Language))
+		sb.WriteString("```diff\n" + d.Content + "\n```\n")
+		if d.Context != "" {
+			sb.WriteString("Whole file after the change:\n```\n" + d.Context + "\n```\n")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// parseVerdicts returns the numbers of the findings a verification answer
+// rejects
+func parseVerdicts(text string) ([]int, error) {
+	var output struct {
+		Verdicts []struct {
+			Finding int    `json:"finding"`
+			Valid   bool   `json:"valid"`
+			Reason  string `json:"reason"`
+		} `json:"verdicts"`
+	}
+	if err := json.Unmarshal([]byte(trimCodeFence(text)), &output); err != nil {
+		return nil, fmt.Errorf("parsing verification: %w", err)
+	}
+	var rejected []int
+	for _, v := range output.Verdicts {
+		if !v.Valid {
+			rejected = append(rejected, v.Finding)
+		}
+	}
+	return rejected, nil
+}
+
+// rejectFindings drops the findings whose titles are at the 1-based
+// positions rejected of checked
+func rejectFindings(findings []domain.Finding, checked []string, rejected []int) []domain.Finding {
+	drop := make(map[string]bool)
+	for _, n := range rejected {
+		if n >= 1 && n <= len(checked) {
+			drop[checked[n-1]] = true
+		}
+	}
+	if len(drop) == 0 {
+		return findings
+	}
+	return slices.DeleteFunc(slices.Clone(findings), func(f domain.Finding) bool {
+		return drop[f.Title]
+	})
+}
+
+const verifyPrompt = `You are a senior software engineer double-checking findings another reviewer reported on changes to a critical repository. False positives cost the team's trust, and missing a real issue costs more.
+
+For each finding, check it against the code changes below: the issue must be visible in the code and the explanation must be right. Reject findings that misread the code, that the change already handles, or that are speculation the code doesn't support.
+
+Respond with a JSON object in this exact format:
+
+{
+  "verdicts": [
+    {"finding": 1, "valid": true, "reason": "One sentence"}
+  ]
+}
+
+Give a verdict for every finding, by its number. When unsure, keep the finding: "valid": true.`
@@ -0,0 +1,77 @@
+package review
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// strictness is what a review.strictness level reports
+type strictness struct {
+	guidance    string          // What to flag, told to the model
+	minSeverity domain.Severity // Findings below are dropped
+	maxFindings int             // Per repository, most severe first; 0 for no cap
+}
+
+// strictnessLevels maps each review.strictness value to its behavior
+var strictnessLevels = map[string]strictness{
+	"low": {
+		guidance:    "Report only High severity issues: bugs, security problems and data loss. Leave out everything else.",
+		minSeverity: domain.SeverityHigh,
+		maxFindings: 5,
+	},
+	"medium": {
+		guidance:    "Report issues of any severity that meet the principles above, but leave out style nitpicks and personal preferences.",
+		minSeverity: domain.SeverityLow,
+	},
+	"high": {
+		guidance:    "Report every meaningful issue, including Low severity maintainability, readability and test coverage concerns.",
+		minSeverity: domain.SeverityLow,
+	},
+}
+
+// prompt is the level's instruction to the model
+func (s strictness) prompt() string {
+	if s.maxFindings == 0 {
+		return s.guidance
+	}
+	return fmt.Sprintf("%s Report at most %d findings per repository, the most important ones.", s.guidance, s.maxFindings)
+}
+
+// applyStrictness enforces each repository's strictness on findings,
+// whatever the model returned: it drops those below the level's severity
+// and keeps the most severe up to its cap
+func (r *Reviewer) applyStrictness(findings []domain.Finding) []domain.Finding {
+	// Most severe first, so the cap keeps them
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity.Rank() > findings[j].Severity.Rank()
+	})
+
+	kept := findings[:0]
+	counts := make(map[string]int)
+	dropped := 0
+	for _, f := range findings {
+		level, ok := strictnessLevels[r.repoStrictness(f.RepoName)]
+		// Unknown severities are kept rather than lost silently
+		below := f.Severity.Rank() > 0 && f.Severity.Rank() < level.minSeverity.Rank()
+		if ok && (below || level.maxFindings > 0 && counts[f.RepoName] >= level.maxFindings) {
+			dropped++
+			continue
+		}
+		counts[f.RepoName]++
+		kept = append(kept, f)
+	}
+	if dropped > 0 {
+		r.logger.Debug("dropped findings beyond the strictness", "findings", dropped)
+	}
+	return kept
+}
+
+// repoStrictness returns the strictness applying to a repository
+func (r *Reviewer) repoStrictness(repo string) string {
+	if level, ok := r.config.RepoStrictness[repo]; ok {
+		return level
+	}
+	return r.config.Strictness
+}
@@ -0,0 +1,86 @@
+package review
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/logging"
+)
+
+func TestPromptPerStrictness(t *testing.T) {
+	diffs := []domain.Diff{{RepoName: "api", FilePath: "main.go", Language: "go", Content: "+func main() {}\n"}}
+
+	texts := make(map[string]string)
+	for level, want := range strictnessLevels {
+		cfg := config.DefaultConfig().Review
+		cfg.Strictness = level
+		prompts, err := Prompts(cfg, diffs)
+		if err != nil {
+			t.Fatalf("%s: %v", level, err)
+		}
+		if len(prompts) != 1 {
+			t.Fatalf("%s: got %d prompts, want 1", level, len(prompts))
+		}
+		text := prompts[0].Text
+		if !strings.Contains(text, want.guidance) {
+			t.Errorf("%s: prompt lacks the level's guidance", level)
+		}
+		texts[level] = text
+	}
+
+	if texts["low"] == texts["medium"] || texts["medium"] == texts["high"] || texts["low"] == texts["high"] {
+		t.Error("prompts don't change with the strictness")
+	}
+	if !strings.Contains(texts["low"], "at most 5 findings") {
+		t.Error("low prompt lacks its cap")
+	}
+	if strings.Contains(texts["medium"], "at most") {
+		t.Error("medium, the default, must not cap findings")
+	}
+}
+
+func TestApplyStrictness(t *testing.T) {
+	findings := func(severities ...domain.Severity) []domain.Finding {
+		var out []domain.Finding
+		for _, s := range severities {
+			out = append(out, domain.Finding{RepoName: "api", Severity: s})
+		}
+		return out
+	}
+	many := make([]domain.Severity, 20)
+	for i := range many {
+		many[i] = domain.SeverityLow
+	}
+
+	tests := []struct {
+		level    string
+		findings []domain.Finding
+		want     int
+	}{
+		{"low", findings(domain.SeverityHigh, domain.SeverityMedium, domain.SeverityLow), 1},
+		{"low", findings(many[:0]...), 0},
+		{"medium", findings(domain.SeverityHigh, domain.SeverityMedium, domain.SeverityLow), 3},
+		{"medium", findings(many...), 20},
+		{"high", findings(many...), 20},
+		{"low", findings("Unknown"), 1},
+	}
+	for _, tt := range tests {
+		cfg := config.DefaultConfig().Review
+		cfg.Strictness = tt.level
+		r := &Reviewer{config: cfg, logger: logging.OrDefault(nil)}
+		if got := r.applyStrictness(tt.findings); len(got) != tt.want {
+			t.Errorf("%s with %d findings: kept %d, want %d", tt.level, len(tt.findings), len(got), tt.want)
+		}
+	}
+
+	// Per-repository strictness wins over the global one
+	cfg := config.DefaultConfig().Review
+	cfg.RepoStrictness = map[string]string{"api": "low"}
+	r := &Reviewer{config: cfg, logger: logging.OrDefault(nil)}
+	got := r.applyStrictness(findings(domain.SeverityLow, domain.SeverityHigh))
+	if len(got) != 1 || got[0].Severity != domain.SeverityHigh {
+		t.Errorf("repository strictness: got %v, want only the High finding", got)
+	}
+}
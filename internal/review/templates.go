@@ -0,0 +1,148 @@
+package review
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/errs"
+)
+
+// PromptData is what review.system_prompt_file and
+// review.output_instructions_file templates see. It describes the whole
+// review rather than one chunk, so every chunk shares the same system
+// message and prompt caching keeps working.
+type PromptData struct {
+	// Default is the built-in text the template replaces, for templates
+	// that only add to it: {{.Default}}
+	Default      string
+	Date         string // Of the review, 2006-01-02
+	Strictness   string
+	Focus        []string
+	Repositories []PromptRepo
+	Languages    []string // Of every file reviewed, sorted
+	Files        int
+	Lines        int
+}
+
+// PromptRepo describes one repository of a review to prompt templates
+type PromptRepo struct {
+	Name      string
+	Languages []string // Sorted
+	Files     []string
+	Lines     int
+}
+
+// promptTemplates are the user-supplied replacements of the built-in
+// prompts; nil keeps the built-in one
+type promptTemplates struct {
+	system *template.Template
+	output *template.Template
+}
+
+// loadPromptTemplates parses the templates the config names, failing
+// with a config error
+func loadPromptTemplates(systemFile, outputFile string) (promptTemplates, error) {
+	var t promptTemplates
+	var err error
+	if t.system, err = ParsePromptTemplate(systemFile); err != nil {
+		return t, errs.Config(err, "fix review.system_prompt_file; {{.Default}} is the built-in text")
+	}
+	if t.output, err = ParsePromptTemplate(outputFile); err != nil {
+		return t, errs.Config(err, "fix review.output_instructions_file; {{.Default}} is the built-in text")
+	}
+	return t, nil
+}
+
+// ParsePromptTemplate parses a prompt template file as a Go text/template
+// executed with PromptData; an empty path returns nil
+func ParsePromptTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading prompt template: %w", err)
+	}
+	tmpl, err := template.New(path).Funcs(config.PromptFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing prompt template: %w", err)
+	}
+
+	// Catch fields PromptData doesn't have before the first review
+	if err := tmpl.Execute(&strings.Builder{}, samplePromptData); err != nil {
+		return nil, fmt.Errorf("prompt template %s: %w", path, err)
+	}
+	return tmpl, nil
+}
+
+// samplePromptData checks templates when they are loaded
+var samplePromptData = PromptData{
+	Default:      "Built-in instructions",
+	Date:         "2006-01-02",
+	Strictness:   "medium",
+	Repositories: []PromptRepo{{Name: "example", Languages: []string{"go"}, Files: []string{"main.go"}, Lines: 1}},
+	Languages:    []string{"go"},
+	Files:        1,
+	Lines:        1,
+}
+
+// newPromptData describes the review of diffs to prompt templates
+func (r *Reviewer) newPromptData(diffs []domain.Diff) *PromptData {
+	data := &PromptData{
+		Date:       time.Now().Format("2006-01-02"),
+		Strictness: r.config.Strictness,
+		Focus:      r.config.Focus,
+		Files:      len(diffs),
+	}
+
+	index := make(map[string]int)
+	for _, d := range diffs {
+		i, ok := index[d.RepoName]
+		if !ok {
+			i = len(data.Repositories)
+			index[d.RepoName] = i
+			data.Repositories = append(data.Repositories, PromptRepo{Name: d.RepoName})
+		}
+		repo := &data.Repositories[i]
+		repo.Files = append(repo.Files, d.FilePath)
+		repo.Lines += d.LineCount
+		data.Lines += d.LineCount
+		if d.Language != "" && !slices.Contains(repo.Languages, d.Language) {
+			repo.Languages = append(repo.Languages, d.Language)
+		}
+		if d.Language != "" && !slices.Contains(data.Languages, d.Language) {
+			data.Languages = append(data.Languages, d.Language)
+		}
+	}
+	for i := range data.Repositories {
+		slices.Sort(data.Repositories[i].Languages)
+	}
+	slices.Sort(data.Languages)
+	return data
+}
+
+// render executes tmpl with the review's data, falling back to the
+// built-in text when there is no template or it fails
+func (r *Reviewer) render(tmpl *template.Template, builtin string) string {
+	if tmpl == nil {
+		return builtin
+	}
+	data := PromptData{}
+	if r.promptData != nil {
+		data = *r.promptData
+	}
+	data.Default = builtin
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		r.logger.Warn("prompt template failed, using the built-in prompt", "template", tmpl.Name(), "err", err)
+		return builtin
+	}
+	return sb.String()
+}
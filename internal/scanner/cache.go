@@ -0,0 +1,141 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/juparave/codereviewer/internal/cache"
+)
+
+// scanCacheVersion changes when the cache format, or what a walk records,
+// does
+const scanCacheVersion = 1
+
+// scanCache records what the walk of each subtree of a root path found,
+// with the modification times of the directories walked. A directory's
+// time changes when entries are added, removed or renamed in it, so a
+// subtree none of whose directories changed needs no new walk.
+type scanCache struct {
+	cache    *cache.Store
+	name     string                    // Entry in the scan namespace
+	Version  int                       `json:"version"`
+	Key      string                    `json:"key"` // Scanner settings the results depend on
+	Subtrees map[string]*cachedSubtree `json:"subtrees"`
+}
+
+// cachedSubtree is the result of walking one subtree
+type cachedSubtree struct {
+	Dirs      map[string]int64 `json:"dirs"` // Modification time in Unix nanoseconds, by path
+	Repos     []cachedRepo     `json:"repos,omitempty"`
+	TooDeep   []string         `json:"too_deep,omitempty"`
+	StoppedAt string           `json:"stopped_at,omitempty"`
+	Links     []string         `json:"links,omitempty"`
+}
+
+type cachedRepo struct {
+	Path   string `json:"path"`
+	Common string `json:"common"` // Common git dir, telling worktrees apart
+}
+
+// cacheKey identifies the scanner settings a cached walk is valid for
+func (s *Scanner) cacheKey() string {
+	c := s.config
+	key := fmt.Sprintf("nested=%t depth=%d repos=%d symlinks=%t", c.IncludeNested, c.MaxDepth, c.MaxRepos, c.FollowSymlinks)
+	if c.MaxRepos > 0 && s.countsKey != "" {
+		// Where the walk stopped depends on which repositories counted
+		key += " counted=" + s.countsKey
+	}
+	return key
+}
+
+// loadCache reads the scan cache of rootPath, returning nil when caching
+// is off. A missing, unreadable or outdated cache, or --rescan, gives an
+// empty one, to be filled by this scan.
+func (s *Scanner) loadCache(rootPath string) *scanCache {
+	if !s.config.Cache || s.cache == nil {
+		return nil
+	}
+	scans := &scanCache{
+		cache:   s.cache,
+		name:    cache.Key(realPath(rootPath)) + ".json",
+		Version: scanCacheVersion,
+		Key:     s.cacheKey(),
+	}
+	if s.config.Rescan {
+		return scans
+	}
+
+	data, err := s.cache.Get(cache.Scan, scans.name)
+	if err != nil {
+		return scans
+	}
+	var stored scanCache
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != scanCacheVersion || stored.Key != scans.Key {
+		s.logger.Debug("ignoring outdated scan cache", "root", rootPath)
+		return scans
+	}
+	scans.Subtrees = stored.Subtrees
+	return scans
+}
+
+// lookup returns the cached walk of the subtree at dir, nil when there is
+// none or a directory in it changed since
+func (c *scanCache) lookup(s *Scanner, rootPath, dir string) *walker {
+	if c == nil {
+		return nil
+	}
+	cached, ok := c.Subtrees[dir]
+	if !ok {
+		return nil
+	}
+	for path, mtime := range cached.Dirs {
+		info, err := os.Stat(path)
+		if err != nil || info.ModTime().UnixNano() != mtime {
+			return nil
+		}
+	}
+
+	w := s.newWalker(rootPath)
+	for _, repo := range cached.Repos {
+		w.repos = append(w.repos, s.repository(rootPath, repo.Path))
+		w.commons = append(w.commons, repo.Common)
+	}
+	w.tooDeep, w.stoppedAt, w.links = cached.TooDeep, cached.StoppedAt, cached.Links
+	return w
+}
+
+// store records the walk of the subtree at dir
+func (c *scanCache) store(dir string, w *walker) {
+	if c == nil {
+		return
+	}
+	cached := &cachedSubtree{Dirs: w.dirs, TooDeep: w.tooDeep, StoppedAt: w.stoppedAt, Links: w.links}
+	for i, repo := range w.repos {
+		cached.Repos = append(cached.Repos, cachedRepo{Path: repo.Path, Common: w.commons[i]})
+	}
+	if c.Subtrees == nil {
+		c.Subtrees = make(map[string]*cachedSubtree)
+	}
+	c.Subtrees[dir] = cached
+}
+
+// save writes the cache, keeping only the subtrees of this scan
+func (c *scanCache) save(subtrees []string) error {
+	if c == nil {
+		return nil
+	}
+	kept := make(map[string]*cachedSubtree, len(subtrees))
+	for _, dir := range subtrees {
+		if cached, ok := c.Subtrees[dir]; ok {
+			kept[dir] = cached
+		}
+	}
+	c.Subtrees = kept
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return c.cache.Put(cache.Scan, c.name, data)
+}
@@ -0,0 +1,93 @@
+package scanner
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/cache"
+	"github.com/juparave/codereviewer/internal/config"
+)
+
+// makeRepo creates a repository at path, as far as the scanner can tell
+func makeRepo(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(path, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// scan finds the repositories under root, returning their names
+func scan(t *testing.T, cfg config.ScannerConfig, store *cache.Store, root string) []string {
+	t.Helper()
+	s := New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.SetCache(store)
+	repos, err := s.FindRepositories(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, repo := range repos {
+		names = append(names, repo.Name)
+	}
+	return names
+}
+
+func TestScanCache(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		root := t.TempDir()
+		store, err := cache.Open(config.CacheConfig{Dir: t.TempDir()}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		makeRepo(t, filepath.Join(root, "a", "api"))
+		if err := os.MkdirAll(filepath.Join(root, "b", "team"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg := config.ScannerConfig{Cache: true, Workers: workers}
+		check := func(step string, want ...string) {
+			t.Helper()
+			if got := scan(t, cfg, store, root); !slices.Equal(got, want) {
+				t.Errorf("workers %d, %s: found %q, want %q", workers, step, got, want)
+			}
+		}
+		check("first scan", "a/api")
+
+		// A repository added in a nested directory changes that directory's
+		// time. Filesystems with coarse timestamps may not tell it apart
+		// within a second, so the time is moved on explicitly.
+		web := filepath.Join(root, "b", "team", "web")
+		makeRepo(t, web)
+		later := time.Now().Add(time.Minute)
+		if err := os.Chtimes(filepath.Dir(web), later, later); err != nil {
+			t.Fatal(err)
+		}
+		check("after adding a repository", "a/api", "b/team/web")
+
+		// A subtree whose directories kept their times isn't walked again:
+		// the cache still reports a repository gone without a trace
+		api := filepath.Join(root, "a", "api")
+		info, err := os.Stat(api)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.RemoveAll(filepath.Join(api, ".git")); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(api, info.ModTime(), info.ModTime()); err != nil {
+			t.Fatal(err)
+		}
+		check("unchanged subtree", "a/api", "b/team/web")
+
+		// --rescan walks everything
+		cfg.Rescan = true
+		check("rescan", "b/team/web")
+		cfg.Rescan = false
+		check("after rescan", "b/team/web")
+	}
+}
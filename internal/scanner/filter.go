@@ -0,0 +1,87 @@
+package scanner
+
+import (
+	"fmt"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/util"
+)
+
+// Filter restricts a run to repositories matching a set of patterns
+type Filter struct {
+	patterns *util.Patterns
+}
+
+// NewFilter compiles repository patterns. A pattern is a glob ("api-*")
+// or, when wrapped in slashes, a regular expression ("/^svc-(a|b)$/").
+// Patterns starting with "!" exclude matching repositories. A repository
+// is kept when it matches an include pattern (or there are none) and no
+// exclude pattern. Patterns are tested against the repository's display
+// name and its directory name.
+func NewFilter(patterns []string) (*Filter, error) {
+	compiled, err := util.CompilePatterns(patterns, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository filter: %w", err)
+	}
+	return &Filter{patterns: compiled}, nil
+}
+
+// Empty reports whether the filter keeps every repository
+func (f *Filter) Empty() bool {
+	return f.patterns.Empty()
+}
+
+// Match reports whether repo passes the filter
+func (f *Filter) Match(repo domain.Repository) bool {
+	return f.patterns.Match(repo.Name, GetRepoName(repo.Path))
+}
+
+// Apply returns the repositories that pass the filter
+func (f *Filter) Apply(repos []domain.Repository) []domain.Repository {
+	if f.Empty() {
+		return repos
+	}
+
+	var kept []domain.Repository
+	for _, repo := range repos {
+		if f.Match(repo) {
+			kept = append(kept, repo)
+		}
+	}
+	return kept
+}
+
+// ListFilter applies the permanent repos.include and repos.exclude lists,
+// which --repos doesn't override
+type ListFilter struct {
+	include *util.Patterns
+	exclude *util.Patterns
+}
+
+// NewListFilter compiles the include and exclude lists. Both take the
+// patterns of NewFilter; a repository is kept when it matches include (or
+// include is empty) and doesn't match exclude.
+func NewListFilter(include, exclude []string) (*ListFilter, error) {
+	in, err := util.CompilePatterns(include, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repos.include: %w", err)
+	}
+	ex, err := util.CompilePatterns(exclude, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repos.exclude: %w", err)
+	}
+	return &ListFilter{include: in, exclude: ex}, nil
+}
+
+// Reason returns the list that leaves repo out, repos.include or
+// repos.exclude, or empty when repo is kept
+func (f *ListFilter) Reason(repo domain.Repository) string {
+	names := []string{repo.Name, GetRepoName(repo.Path)}
+	switch {
+	case !f.include.Match(names...):
+		return "repos.include"
+	case !f.exclude.Empty() && f.exclude.Match(names...):
+		return "repos.exclude"
+	}
+	return ""
+}
@@ -1,10 +1,20 @@
 package scanner
 
 import (
-	"log"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+
+	"github.com/juparave/codereviewer/internal/cache"
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/logging"
 )
 
 // ExcludedDirs are directories to skip during scanning
@@ -21,52 +31,379 @@ var ExcludedDirs = map[string]bool{
 
 // Scanner finds Git repositories in a directory tree
 type Scanner struct {
-	logger *log.Logger
+	config config.ScannerConfig
+	logger *slog.Logger
+	cache  *cache.Store // Where scans are cached; nil not to
+
+	// counts reports whether a repository counts toward max_repos; nil
+	// counts every one. countsKey describes it for the scan cache.
+	counts    func(domain.Repository) bool
+	countsKey string
 }
 
 // New creates a new Scanner
-func New(logger *log.Logger) *Scanner {
-	return &Scanner{logger: logger}
+func New(cfg config.ScannerConfig, logger *slog.Logger) *Scanner {
+	return &Scanner{config: cfg, logger: logging.OrDefault(logger)}
 }
 
-// FindRepositories recursively finds all Git repositories under rootPath
-func (s *Scanner) FindRepositories(rootPath string) ([]string, error) {
-	var repos []string
+// SetCounted has only the repositories match accepts count toward
+// scanner.max_repos, e.g. those the repository filters keep, so filtered
+// out ones don't use up the cap. key describes match for the scan cache;
+// a nil match counts every repository.
+func (s *Scanner) SetCounted(match func(domain.Repository) bool, key string) {
+	s.counts, s.countsKey = match, key
+}
 
-	err := filepath.WalkDir(rootPath, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return nil // Skip directories we can't access
-		}
+// SetCache keeps scans in store, with scanner.cache; nil doesn't
+func (s *Scanner) SetCache(store *cache.Store) {
+	s.cache = store
+}
+
+// FindRepositories recursively finds all Git repositories under rootPath.
+// Once a repository is found its working tree is not descended into unless
+// nested repositories are enabled, in which case paths ignored by the outer
+// repository (.gitignore, info/exclude and the global excludes file) are not
+// walked, although an ignored directory that is itself a repository is kept.
+// Checked-out submodules are reported either way. Worktrees sharing one git
+// directory are reported once, preferring the main worktree. Directories
+// deeper than scanner.max_depth aren't searched, and the walk stops at
+// scanner.max_repos repositories; both are logged when they cut it short.
+// With scanner.follow_symlinks, symlinked directories are walked too, once,
+// unless they lead back into a tree already walked. With scanner.cache,
+// subtrees whose directories all kept their modification times since the
+// last scan aren't walked again.
+func (s *Scanner) FindRepositories(rootPath string) ([]domain.Repository, error) {
+	subtrees := s.subtrees(rootPath)
+	scans := s.loadCache(rootPath)
 
-		// Skip hidden directories (except .git which we're looking for)
-		name := d.Name()
-		if strings.HasPrefix(name, ".") && name != ".git" {
-			if d.IsDir() {
-				return filepath.SkipDir
+	// Walk the subtrees not cached at once, then merge them in order so the
+	// result doesn't depend on which finished first
+	results := make([]*walker, len(subtrees))
+	errs := make([]error, len(subtrees))
+	sem := make(chan struct{}, s.workers())
+	var wg sync.WaitGroup
+	walked := 0
+	for i, dir := range subtrees {
+		if results[i] = scans.lookup(s, rootPath, dir); results[i] != nil {
+			continue
+		}
+		walked++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			sub := s.newWalker(rootPath)
+			sub.deferLinks = true
+			if scans != nil {
+				sub.dirs = make(map[string]int64)
 			}
-			return nil
+			errs[i] = sub.walk(dir, dir)
+			results[i] = sub
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if scans != nil {
+		s.logger.Debug("scanned for repositories", "subtrees", len(subtrees), "walked", walked, "cached", len(subtrees)-walked)
+		for i, dir := range subtrees {
+			if results[i].dirs != nil {
+				scans.store(dir, results[i])
+			}
+		}
+		if err := scans.save(subtrees); err != nil {
+			s.logger.Warn("saving the scan cache failed", "err", err)
+		}
+	}
+
+	w := s.newWalker(rootPath)
+	for _, sub := range results {
+		w.merge(sub)
+	}
+	// Symlinks last, so which tree a repository is found through doesn't
+	// depend on timing either; their trees are walked every time
+	for _, link := range w.links {
+		if err := w.follow(link); err != nil {
+			return nil, err
 		}
+	}
+
+	if len(w.tooDeep) > 0 {
+		s.logger.Warn("scanner.max_depth reached, directories below these were not searched",
+			"max_depth", s.config.MaxDepth, "directories", len(w.tooDeep), "first", w.tooDeep[:min(len(w.tooDeep), 5)])
+		for _, dir := range w.tooDeep {
+			s.logger.Debug("not searched below max_depth", "path", dir)
+		}
+	}
+	if w.stoppedAt != "" {
+		s.logger.Warn("scanner.max_repos reached, the rest of the tree was not scanned",
+			"max_repos", s.config.MaxRepos, "stopped_at", w.stoppedAt)
+	}
+	return w.repos, nil
+}
+
+// DefaultScanWorkers is how many subtrees of root_path are walked at once
+// when scanner.workers is 0
+const DefaultScanWorkers = 8
+
+func (s *Scanner) workers() int {
+	if s.config.Workers > 0 {
+		return s.config.Workers
+	}
+	return DefaultScanWorkers
+}
 
-		// Skip excluded directories
-		if d.IsDir() && ExcludedDirs[name] {
-			return filepath.SkipDir
+// subtrees lists the top-level directories of rootPath, and its symlinks,
+// to walk in parallel, or just rootPath when the tree is walked in one go:
+// with a single worker, or when rootPath is a repository itself
+func (s *Scanner) subtrees(rootPath string) []string {
+	whole := []string{rootPath}
+	if s.workers() <= 1 {
+		return whole
+	}
+	if gitDir, _ := resolveGitDir(rootPath); gitDir != "" {
+		return whole
+	}
+	entries, err := os.ReadDir(rootPath)
+	if err != nil {
+		return whole
+	}
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Type()&os.ModeSymlink != 0 {
+			dirs = append(dirs, filepath.Join(rootPath, entry.Name()))
 		}
+	}
+	return dirs
+}
 
-		// Check if this is a .git directory
-		if d.IsDir() && name == ".git" {
-			repoPath := filepath.Dir(path)
-			repos = append(repos, repoPath)
-			return filepath.SkipDir // Don't descend into .git
+// repository returns the repository at path, named as configured
+func (s *Scanner) repository(rootPath, path string) domain.Repository {
+	return domain.Repository{Path: path, Name: s.displayName(rootPath, path)}
+}
+
+// symlinkTarget returns the real path of the directory the symlink at path
+// points to, or an empty string when it isn't a directory or leads into a
+// tree already walked, as a symlink loop does
+func (s *Scanner) symlinkTarget(path string, walked []string) string {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		s.logger.Debug("skipping broken symlink", "path", path, "err", err)
+		return ""
+	}
+	if info, err := os.Stat(target); err != nil || !info.IsDir() {
+		return ""
+	}
+	for _, tree := range walked {
+		if rel, err := filepath.Rel(tree, target); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			s.logger.Debug("not following symlink into a tree already walked", "path", path, "target", target)
+			return ""
 		}
+	}
+	return target
+}
 
+// realPath resolves the symlinks in path, returning it cleaned when they
+// can't be resolved
+func realPath(path string) string {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		return real
+	}
+	return filepath.Clean(path)
+}
+
+// depth returns how many directory levels path is below rootPath
+func depth(rootPath, path string) int {
+	rel, err := filepath.Rel(rootPath, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// skipBroken logs a directory whose .git file doesn't lead to a git
+// directory, e.g. a worktree whose main repository was moved; err is nil
+// when there is no .git at all
+func (s *Scanner) skipBroken(path string, err error) {
+	if err != nil {
+		s.logger.Warn("skipping repository with a broken .git file", "path", path, "err", err)
+	}
+}
+
+// resolveGitDir returns the git directory of the working tree at dir: its
+// .git directory, or the directory a .git file points at, as linked
+// worktrees and submodules have. It returns an empty string when dir has no
+// .git, and an error too when a .git file is unreadable or points nowhere.
+func resolveGitDir(dir string) (string, error) {
+	path := filepath.Join(dir, ".git")
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", nil
+	}
+	if info.IsDir() {
+		return path, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return "", fmt.Errorf("%s has no gitdir line", path)
+	}
+	target = strings.TrimSpace(target)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+	if info, err := os.Stat(target); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("git directory %s not found", target)
+	}
+	return target, nil
+}
+
+// isMainWorktree reports whether dir holds the repository's git directory
+// rather than being a linked worktree
+func isMainWorktree(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil && info.IsDir()
+}
+
+// commonDir returns the git directory shared by all worktrees of the
+// repository whose git directory is gitDir. A linked worktree's git
+// directory names it in its commondir file; a submodule's is its own.
+func commonDir(gitDir string) string {
+	common := gitDir
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		if target := strings.TrimSpace(string(data)); filepath.IsAbs(target) {
+			common = target
+		} else {
+			common = filepath.Join(gitDir, target)
+		}
+	}
+	if resolved, err := filepath.EvalSymlinks(common); err == nil {
+		return resolved
+	}
+	return filepath.Clean(common)
+}
+
+// submodules lists the paths of the submodules (gitlinks) in the index of
+// the repository at repoPath, leaving out those in hidden or excluded
+// directories as the walk does
+func submodules(repoPath string) []string {
+	if _, err := os.Stat(filepath.Join(repoPath, ".gitmodules")); err != nil {
 		return nil
-	})
+	}
+	cmd := exec.Command("git", "ls-files", "--stage", "-z")
+	cmd.Dir = repoPath
 
+	output, err := cmd.Output()
 	if err != nil {
-		return nil, err
+		return nil
 	}
 
-	return repos, nil
+	var paths []string
+	for _, entry := range bytes.Split(output, []byte{0}) {
+		// <mode> <object> <stage>\t<path>
+		meta, path, ok := strings.Cut(string(entry), "\t")
+		if !ok || !strings.HasPrefix(meta, "160000 ") || skippedPath(path) {
+			continue
+		}
+		paths = append(paths, filepath.Join(repoPath, filepath.FromSlash(path)))
+	}
+	return paths
+}
+
+// skippedPath reports whether the walk would skip a directory of the
+// slash-separated relative path
+func skippedPath(path string) bool {
+	for _, name := range strings.Split(path, "/") {
+		if strings.HasPrefix(name, ".") || ExcludedDirs[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredDirs lists the directories git ignores in the repository at repoPath
+func ignoredDirs(repoPath string) []string {
+	cmd := exec.Command("git", "ls-files",
+		"--others",
+		"--ignored",
+		"--exclude-standard",
+		"--directory",
+		"-z",
+	)
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var dirs []string
+	for _, entry := range bytes.Split(output, []byte{0}) {
+		rel := string(entry)
+		if !strings.HasSuffix(rel, "/") {
+			continue
+		}
+		dirs = append(dirs, filepath.Join(repoPath, filepath.FromSlash(strings.TrimSuffix(rel, "/"))))
+	}
+	return dirs
+}
+
+// displayName labels a repository according to the configured naming style
+func (s *Scanner) displayName(rootPath, repoPath string) string {
+	switch s.config.RepoNames {
+	case "base":
+		return GetRepoName(repoPath)
+	case "remote":
+		if name := remoteName(repoPath); name != "" {
+			return name
+		}
+	}
+	return RelativeName(rootPath, repoPath)
+}
+
+// RelativeName returns the repository path relative to rootPath using
+// forward slashes, or the directory name when the root is the repository
+func RelativeName(rootPath, repoPath string) string {
+	rel, err := filepath.Rel(rootPath, repoPath)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return GetRepoName(repoPath)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// remoteName derives "owner/name" from the origin remote URL, returning an
+// empty string when the repository has no origin
+func remoteName(repoPath string) string {
+	cmd := exec.Command("git", "config", "--get", "remote.origin.url")
+	cmd.Dir = repoPath
+
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return NameFromRemoteURL(strings.TrimSpace(string(output)))
+}
+
+// NameFromRemoteURL extracts "owner/name" from an SSH or HTTPS remote URL
+func NameFromRemoteURL(url string) string {
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+
+	// scp-like syntax: git@github.com:owner/name
+	if i := strings.Index(url, ":"); i != -1 && !strings.Contains(url, "://") {
+		url = url[i+1:]
+	}
+
+	parts := strings.Split(url, "/")
+	if len(parts) < 2 {
+		return url
+	}
+	return parts[len(parts)-2] + "/" + parts[len(parts)-1]
 }
 
 // GetRepoName extracts the repository name from its path
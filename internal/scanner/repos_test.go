@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/juparave/codereviewer/internal/config"
+)
+
+// makeWorktree creates a linked worktree at path of the repository at main
+func makeWorktree(t *testing.T, main, path string) {
+	t.Helper()
+	gitDir := filepath.Join(main, ".git", "worktrees", filepath.Base(path))
+	if err := os.MkdirAll(gitDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(gitDir, "commondir"), []byte("../..\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, ".git"), []byte("gitdir: "+gitDir+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindRepositoriesWorkers(t *testing.T) {
+	root := t.TempDir()
+	for _, repo := range []string{
+		"main",
+		"beta/nested/one",
+		"beta/nested/two",
+		"delta",
+		"delta/inner",
+		"zeta/deep/deeper/repo",
+		"node_modules/pkg",
+		".hidden/repo",
+	} {
+		makeRepo(t, filepath.Join(root, repo))
+	}
+	// Found before the main worktree, which it gives way to when the walk
+	// gets that far
+	makeWorktree(t, filepath.Join(root, "main"), filepath.Join(root, "a-wt"))
+
+	tests := []struct {
+		name string
+		cfg  config.ScannerConfig
+		want []string
+	}{
+		{"everything", config.ScannerConfig{}, []string{"main", "beta/nested/one", "beta/nested/two", "delta", "zeta/deep/deeper/repo"}},
+		{"nested", config.ScannerConfig{IncludeNested: true}, []string{"main", "beta/nested/one", "beta/nested/two", "delta", "delta/inner", "zeta/deep/deeper/repo"}},
+		{"max_depth", config.ScannerConfig{MaxDepth: 2}, []string{"main", "delta"}},
+		{"max_repos 1", config.ScannerConfig{MaxRepos: 1}, []string{"a-wt"}},
+		{"max_repos 2", config.ScannerConfig{MaxRepos: 2}, []string{"a-wt", "beta/nested/one"}},
+		{"max_repos 4", config.ScannerConfig{MaxRepos: 4}, []string{"a-wt", "beta/nested/one", "beta/nested/two", "delta"}},
+		{"max_repos and nested", config.ScannerConfig{MaxRepos: 5, IncludeNested: true}, []string{"a-wt", "beta/nested/one", "beta/nested/two", "delta", "delta/inner"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, workers := range []int{1, 2, 8} {
+				cfg := tt.cfg
+				cfg.Workers = workers
+				if got := scan(t, cfg, nil, root); !slices.Equal(got, tt.want) {
+					t.Errorf("workers %d: found %q, want %q", workers, got, tt.want)
+				}
+			}
+		})
+	}
+}
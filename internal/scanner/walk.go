@@ -0,0 +1,201 @@
+package scanner
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+)
+
+// walker holds the state of one walk for repositories: of the whole tree,
+// or of one top-level subtree when scanning in parallel
+type walker struct {
+	s        *Scanner
+	rootPath string
+
+	repos   []domain.Repository
+	counted int            // Of repos, those counting toward max_repos
+	commons []string       // Common git dir of each of repos
+	seen    map[string]int // Common git dir -> index in repos
+	ignored map[string]bool
+
+	// Real paths of the trees walked: root_path and the targets of the
+	// symlinks followed, which aren't followed again into any of them
+	walked []string
+	// deferLinks records symlinks in links instead of following them, for
+	// the merge of parallel walks to follow them in order
+	deferLinks bool
+	links      []string
+
+	tooDeep   []string // Directories at max_depth not descended into
+	stoppedAt string   // First path left unscanned at max_repos
+
+	// dirs records the modification time of each directory walked, for the
+	// scan cache, when not nil
+	dirs map[string]int64
+}
+
+func (s *Scanner) newWalker(rootPath string) *walker {
+	return &walker{
+		s:        s,
+		rootPath: rootPath,
+		seen:     make(map[string]int),
+		ignored:  make(map[string]bool),
+		walked:   []string{realPath(rootPath)},
+	}
+}
+
+// full reports whether scanner.max_repos repositories counting toward it
+// were found
+func (w *walker) full() bool {
+	return w.s.config.MaxRepos > 0 && w.counted >= w.s.config.MaxRepos
+}
+
+// add records the repository at path, reporting false when it is another
+// worktree of one already found
+func (w *walker) add(path, gitDir string) bool {
+	return w.addRepo(w.s.repository(w.rootPath, path), commonDir(gitDir))
+}
+
+func (w *walker) addRepo(repo domain.Repository, common string) bool {
+	if i, ok := w.seen[common]; ok {
+		// The same repository again through a symlink keeps its first path
+		if isMainWorktree(repo.Path) && !isMainWorktree(w.repos[i].Path) {
+			w.repos[i] = repo
+		}
+		return false
+	}
+	w.seen[common] = len(w.repos)
+	w.repos = append(w.repos, repo)
+	if w.s.counts == nil || w.s.counts(repo) {
+		w.counted++
+	}
+	w.commons = append(w.commons, common)
+	return true
+}
+
+// addSubmodules adds the checked-out submodules of the repository at path,
+// and theirs
+func (w *walker) addSubmodules(path string) {
+	for _, sub := range submodules(path) {
+		if w.full() {
+			w.stoppedAt = cmp.Or(w.stoppedAt, sub)
+			return
+		}
+		gitDir, err := resolveGitDir(sub)
+		if gitDir == "" {
+			w.s.skipBroken(sub, err)
+			continue
+		}
+		if w.add(sub, gitDir) {
+			w.s.logger.Debug("found submodule", "path", sub)
+			w.addSubmodules(sub)
+		}
+	}
+}
+
+// merge adds what the walk of a subtree found, in its order
+func (w *walker) merge(sub *walker) {
+	for i, repo := range sub.repos {
+		if w.full() {
+			w.stoppedAt = cmp.Or(w.stoppedAt, repo.Path)
+			break
+		}
+		w.addRepo(repo, sub.commons[i])
+	}
+	w.tooDeep = append(w.tooDeep, sub.tooDeep...)
+	w.stoppedAt = cmp.Or(w.stoppedAt, sub.stoppedAt)
+	w.links = append(w.links, sub.links...)
+}
+
+// follow walks the directory the symlink at path points to, unless it
+// leads into a tree already walked
+func (w *walker) follow(path string) error {
+	target := w.s.symlinkTarget(path, w.walked)
+	if target == "" {
+		return nil
+	}
+	w.walked = append(w.walked, target)
+	return w.walk(path, target)
+}
+
+// walk walks the tree at dir, whose real path is real when dir is a
+// followed symlink
+func (w *walker) walk(dir, real string) error {
+	s := w.s
+	return filepath.WalkDir(real, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip directories we can't access
+		}
+		if real != dir {
+			// Report paths under the symlink, as the user laid them out
+			path = filepath.Join(dir, strings.TrimPrefix(path, real))
+		}
+
+		// Skip hidden directories, including .git itself
+		name := filepath.Base(path)
+		if path != w.rootPath && strings.HasPrefix(name, ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.Type()&os.ModeSymlink != 0 && s.config.FollowSymlinks && !ExcludedDirs[name] {
+			if w.deferLinks {
+				w.links = append(w.links, path)
+				return nil
+			}
+			return w.follow(path)
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		// Skip excluded directories
+		if ExcludedDirs[name] {
+			return filepath.SkipDir
+		}
+
+		if w.full() {
+			w.stoppedAt = cmp.Or(w.stoppedAt, path)
+			return filepath.SkipAll
+		}
+
+		if w.dirs != nil && real == dir {
+			if info, err := d.Info(); err == nil {
+				w.dirs[path] = info.ModTime().UnixNano()
+			}
+		}
+
+		gitDir, err := resolveGitDir(path)
+		if gitDir == "" {
+			s.skipBroken(path, err)
+			if w.ignored[path] {
+				return filepath.SkipDir
+			}
+			if s.config.MaxDepth > 0 && depth(w.rootPath, path) >= s.config.MaxDepth {
+				w.tooDeep = append(w.tooDeep, path)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !w.add(path, gitDir) {
+			return filepath.SkipDir // Another worktree of a repository already found
+		}
+
+		if !s.config.IncludeNested || w.ignored[path] {
+			// Submodules are tracked, so the nested walk finds them itself
+			w.addSubmodules(path)
+			return filepath.SkipDir // Don't descend into the working tree
+		}
+
+		for _, dir := range ignoredDirs(path) {
+			w.ignored[dir] = true
+		}
+		return nil
+	})
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/report"
+)
+
+// tokenCookie carries the API token of a dashboard session
+const tokenCookie = "cra_token"
+
+type userKey struct{}
+
+// userFrom returns the signed-in user of a request, nil when server.auth
+// is off
+func userFrom(ctx context.Context) *report.User {
+	u, _ := ctx.Value(userKey{}).(*report.User)
+	return u
+}
+
+// requireUser authenticates every request but the login and manage pages
+// by its bearer token or session cookie. API requests without a valid
+// token get 401; dashboard pages redirect to the login page.
+func (s *Server) requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" || isManagePath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" {
+			if c, err := r.Cookie(tokenCookie); err == nil {
+				token = c.Value
+			}
+		}
+
+		var u *report.User
+		var err error
+		if token == "" {
+			err = fs.ErrNotExist
+		} else {
+			u, err = s.users.Authenticate(token)
+		}
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				s.logger.Error("authenticating", "err", err)
+			}
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="cra"`)
+				writeError(w, http.StatusUnauthorized, errors.New("a valid API token is required"))
+			} else {
+				http.Redirect(w, r, "/login", http.StatusSeeOther)
+			}
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, u)))
+	})
+}
+
+// requireLead rejects users who aren't leads
+func (s *Server) requireLead(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if u := userFrom(r.Context()); u != nil && !u.Lead() {
+			writeError(w, http.StatusForbidden, errors.New("only leads can trigger reviews"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+var loginTmpl = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>Code Review Agent</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; max-width: 480px; margin: 80px auto; padding: 20px; }
+input { width: 100%; padding: 8px; margin: 8px 0; box-sizing: border-box; }
+.error { color: #b91c1c; }
+</style>
+</head>
+<body>
+<h1>Code Review Agent</h1>
+{{if .}}<p class="error">{{.}}</p>{{end}}
+<form method="post" action="/login">
+<label for="token">API token</label>
+<input id="token" name="token" type="password" autocomplete="current-password" autofocus>
+<button type="submit">Sign in</button>
+</form>
+<p>Ask a lead for a token; they're created with <code>review user add</code>.</p>
+</body>
+</html>`))
+
+func (s *Server) handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	loginTmpl.Execute(w, "")
+}
+
+// handleLogin checks the submitted token and keeps it in a session cookie
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimSpace(r.FormValue("token"))
+	if _, err := s.users.Authenticate(token); err != nil {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusUnauthorized)
+		loginTmpl.Execute(w, "Unknown token.")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     tokenCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: tokenCookie, Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, userFrom(r.Context()))
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/juparave/codereviewer/internal/report"
+)
+
+func TestRequireUser(t *testing.T) {
+	s, token := newTestServer(t, true)
+	handler := s.Handler()
+
+	tests := []struct {
+		name     string
+		path     string
+		bearer   string
+		cookie   string
+		want     int
+		location string
+	}{
+		{"API without token", "/api/me", "", "", http.StatusUnauthorized, ""},
+		{"page without token", "/", "", "", http.StatusSeeOther, "/login"},
+		{"API with wrong token", "/api/me", "cra_wrong", "", http.StatusUnauthorized, ""},
+		{"wrong cookie", "/api/me", "", "cra_wrong", http.StatusUnauthorized, ""},
+		{"bearer token", "/api/me", token, "", http.StatusOK, ""},
+		{"cookie", "/api/me", "", token, http.StatusOK, ""},
+		{"login page", "/login", "", "", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: tokenCookie, Value: tt.cookie})
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if got := rec.Header().Get("Location"); got != tt.location {
+				t.Errorf("Location = %q, want %q", got, tt.location)
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+			if tt.path == "/api/me" && tt.want == http.StatusOK {
+				var u report.User
+				if err := json.NewDecoder(rec.Body).Decode(&u); err != nil || u.Name != "ana" {
+					t.Errorf("/api/me = %+v, %v; want ana", u, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoginLogout(t *testing.T) {
+	s, token := newTestServer(t, true)
+	handler := s.Handler()
+	login := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(url.Values{"token": {token}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := login("cra_wrong"); rec.Code != http.StatusUnauthorized || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("wrong token: status %d, cookies %v; want 401 and none", rec.Code, rec.Result().Cookies())
+	}
+
+	rec := login(token)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/" {
+		t.Fatalf("login: status %d to %q, want 303 to /", rec.Code, rec.Header().Get("Location"))
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != tokenCookie || cookies[0].Value != token || !cookies[0].HttpOnly {
+		t.Fatalf("login cookies = %v, want an HttpOnly %s holding the token", cookies, tokenCookie)
+	}
+
+	// The session cookie signs in the next request, and logging out
+	// expires it
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/login" {
+		t.Fatalf("logout: status %d to %q, want 303 to /login", rec.Code, rec.Header().Get("Location"))
+	}
+	cookies = rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != tokenCookie || cookies[0].MaxAge >= 0 {
+		t.Errorf("logout cookies = %v, want %s expired", cookies, tokenCookie)
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// csrfHeader carries the CSRF token of API requests; the dashboard's
+// forms send it as the csrf_token field
+const csrfHeader = "X-CSRF-Token"
+
+// newCSRFToken returns a random token, made once per server
+func newCSRFToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// requireCSRF rejects requests that don't carry the server's CSRF token,
+// so another site can't trigger reviews through a visitor's browser,
+// whether or not server.auth is on. Requests carrying a valid server.auth
+// bearer token are let through: browsers don't add that header by
+// themselves. Without server.auth a bearer header proves nothing, and the
+// CSRF token is required.
+func (s *Server) requireCSRF(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && s.users != nil {
+			if _, err := s.users.Authenticate(bearer); err == nil {
+				next(w, r)
+				return
+			}
+		}
+		token := r.Header.Get(csrfHeader)
+		if token == "" {
+			token = r.FormValue("csrf_token")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.csrf)) != 1 {
+			writeError(w, http.StatusForbidden, errors.New("missing or invalid CSRF token, see the "+csrfHeader+" header of GET /api/runs/current"))
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/report"
+)
+
+// newTestServer returns a server storing reports in a temporary sqlite
+// database. With auth, it has a lead whose token is returned.
+func newTestServer(t *testing.T, auth bool) (*Server, string) {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	cfg.Reports.OutputDir = t.TempDir()
+	cfg.Server.Auth = auth
+	s, err := New(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !auth {
+		return s, ""
+	}
+	token, err := s.users.AddUser(report.User{Name: "ana", Role: report.RoleLead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, token
+}
+
+func TestRequireCSRF(t *testing.T) {
+	s, token := newTestServer(t, true)
+	open, _ := newTestServer(t, false)
+
+	tests := []struct {
+		name   string
+		server *Server
+		header map[string]string
+		form   url.Values
+		want   int
+	}{
+		{"missing token", s, nil, nil, http.StatusForbidden},
+		{"wrong header", s, map[string]string{csrfHeader: "nope"}, nil, http.StatusForbidden},
+		{"wrong form field", s, nil, url.Values{"csrf_token": {"nope"}}, http.StatusForbidden},
+		{"header", s, map[string]string{csrfHeader: s.csrf}, nil, http.StatusOK},
+		{"form field", s, nil, url.Values{"csrf_token": {s.csrf}}, http.StatusOK},
+		{"valid bearer", s, map[string]string{"Authorization": "Bearer " + token}, nil, http.StatusOK},
+		{"invalid bearer", s, map[string]string{"Authorization": "Bearer cra_forged"}, nil, http.StatusForbidden},
+		{"bearer without auth", open, map[string]string{"Authorization": "Bearer anything"}, nil, http.StatusForbidden},
+		{"token without auth", open, map[string]string{csrfHeader: open.csrf}, nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/runs", strings.NewReader(tt.form.Encode()))
+			if tt.form != nil {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			tt.server.requireCSRF(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
+
+// A forged bearer token is refused by authentication before the CSRF
+// check is reached
+func TestTriggerForgedBearer(t *testing.T) {
+	s, _ := newTestServer(t, true)
+	req := httptest.NewRequest(http.MethodPost, "/api/runs", nil)
+	req.Header.Set("Authorization", "Bearer cra_forged")
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if s.Status().Running {
+		t.Error("a review was started")
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/juparave/codereviewer/internal/report"
+)
+
+var dashboardTmpl = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"percent": func(rate float64) string { return fmt.Sprintf("%.1f%%", 100*rate) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>Code Review Agent</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; max-width: 960px; margin: 0 auto; padding: 20px; }
+h1 { color: #1a1a1a; border-bottom: 2px solid #667eea; padding-bottom: 10px; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 8px; border-bottom: 1px solid #e5e7eb; vertical-align: top; }
+{{.SeverityCSS}}.status { background: #f9fafb; border-left: 4px solid #667eea; padding: 12px 16px; margin: 16px 0; }
+</style>
+</head>
+<body>
+<h1>Code Review Agent</h1>
+{{with .User}}
+<p class="user">Signed in as {{.Name}} ({{.Role}}{{if not .Lead}}: {{range $i, $r := .Repos}}{{if $i}}, {{end}}{{$r}}{{end}}{{end}})
+<form method="post" action="/logout" style="display:inline"><button type="submit">Sign out</button></form></p>
+{{end}}
+
+<div class="status">
+{{if .Status.Running}}{{if .Status.Schedule}}Scheduled review ({{.Status.Schedule}}){{else}}Review{{end}} running since {{.Status.StartedAt.Format "15:04:05"}}&hellip;
+{{else}}
+{{if not .Status.FinishedAt.IsZero}}Last review finished at {{.Status.FinishedAt.Format "2006-01-02 15:04"}}{{if .Status.Error}} with error: {{.Status.Error}}{{end}}.{{else}}No review triggered since the server started.{{end}}
+{{if or (not .User) .User.Lead}}<form method="post" action="/run" style="display:inline"><input type="hidden" name="csrf_token" value="{{.CSRF}}"><button type="submit">Run review now</button></form>{{end}}
+{{end}}
+</div>
+
+{{if .Reports}}
+<table>
+<tr><th>Date</th><th>Findings</th><th>Commits</th><th>Repositories</th><th>Summary</th></tr>
+{{range .Reports}}
+<tr>
+<td><a href="/reports/{{.Date}}">{{.Date}}</a></td>
+<td>{{.Findings}} (<span class="high">{{.High}}</span>/<span class="medium">{{.Medium}}</span>/<span class="low">{{.Low}}</span>)</td>
+<td>{{.CommitCount}}</td>
+<td>{{range $i, $r := .Repositories}}{{if $i}}, {{end}}{{$r}}{{end}}</td>
+<td>{{.Summary}}</td>
+</tr>
+{{end}}
+</table>
+{{else}}
+<p>No reports yet.</p>
+{{end}}
+
+{{if .Providers}}
+<h2>Provider health</h2>
+<table>
+<tr><th>Provider</th><th>Models</th><th>Runs</th><th>Failed runs</th><th>Requests</th><th>Errors</th><th>Avg latency</th><th>Last {{.Window}} days</th><th>Last used</th></tr>
+{{range .Providers}}
+<tr>
+<td>{{.Provider}}</td>
+<td>{{range $i, $m := .ByModel}}{{if $i}}, {{end}}{{$m.Model}} ({{$m.Requests}} requests, {{percent $m.ErrorRate}} errors){{end}}</td>
+<td>{{.Runs}}</td>
+<td>{{.Failed}}</td>
+<td>{{.Requests}}</td>
+<td>{{.Errors}} ({{percent .ErrorRate}})</td>
+<td>{{.AvgLatency}}</td>
+<td>{{.Recent.Requests}} requests, {{percent .Recent.ErrorRate}} errors, {{.Recent.AvgLatency}}</td>
+<td>{{.LastUsed.Format "2006-01-02"}}</td>
+</tr>
+{{end}}
+</table>
+{{end}}
+</body>
+</html>`))
+
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	u := userFrom(r.Context())
+	reports, err := s.reports().History()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Provider health spans every repository, so only leads see it
+	var providers []report.ProviderHealth
+	if u == nil || u.Lead() {
+		failed, err := s.reports().FailedRuns()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		providers = report.ComputeProviderHealth(reports, failed)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	dashboardTmpl.Execute(w, struct {
+		Status      RunStatus
+		Reports     []ReportSummary
+		Providers   []report.ProviderHealth
+		Window      int
+		SeverityCSS template.CSS
+		User        *report.User
+		CSRF        string
+	}{s.Status(), summaries(reports, u), providers, report.HealthWindow, template.CSS(s.reports().SeverityCSS()), u, s.csrf})
+}
+
+// handleRunForm triggers a review from the dashboard button
+func (s *Server) handleRunForm(w http.ResponseWriter, r *http.Request) {
+	s.Trigger()
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (s *Server) handleReportPage(w http.ResponseWriter, r *http.Request) {
+	rpt, ok := s.loadReport(w, r)
+	if !ok {
+		return
+	}
+	if err := s.reports().LoadDiffs(rpt); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(s.reports().ToHTML(rpt)))
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/prefs"
+)
+
+// errBadManageLink rejects preference requests whose token doesn't match
+// their email address
+var errBadManageLink = errors.New("invalid or expired manage link, use the one in your latest report email")
+
+var manageTmpl = template.Must(template.New("manage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>Code Review Agent</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; max-width: 560px; margin: 40px auto; padding: 20px; }
+fieldset { border: 1px solid #e5e7eb; margin: 16px 0; }
+.saved { color: #15803d; }
+</style>
+</head>
+<body>
+<h1>Email preferences</h1>
+<p>For {{.Email}}</p>
+{{if .Saved}}<p class="saved">Saved. The next report email uses them.</p>{{end}}
+<form method="post" action="/manage">
+<input type="hidden" name="email" value="{{.Email}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<fieldset>
+<legend>Minimum severity</legend>
+<select name="min_severity">
+<option value=""{{if not .Prefs.MinSeverity}} selected{{end}}>Every finding</option>
+{{range .Severities}}<option value="{{.}}"{{if eq (print .) $.Prefs.MinSeverity}} selected{{end}}>{{.}} and above</option>
+{{end}}</select>
+</fieldset>
+{{if .Repos}}<fieldset>
+<legend>Muted repositories</legend>
+{{range .Repos}}<label><input type="checkbox" name="muted" value="{{.}}"{{if $.Muted .}} checked{{end}}> {{.}}</label><br>
+{{end}}</fieldset>{{end}}
+<fieldset>
+<label><input type="checkbox" name="unsubscribed" value="true"{{if .Prefs.Unsubscribed}} checked{{end}}> Don't email me reports</label>
+</fieldset>
+<button type="submit">Save</button>
+</form>
+</body>
+</html>`))
+
+// loadPrefs loads the preferences file and checks the manage link of
+// address, so the caller may read and change its preferences
+func (s *Server) loadPrefs(address, token string) (*prefs.Store, error) {
+	store, err := prefs.Load(s.reports().State(), s.currentConfig().Email.PreferencesFile)
+	if err != nil {
+		return nil, err
+	}
+	if !store.Verify(address, token) {
+		return nil, errBadManageLink
+	}
+	return store, nil
+}
+
+// setPrefs saves the preferences of address, serializing writers so
+// concurrent saves don't lose each other's changes, and returns the store
+func (s *Server) setPrefs(address, token string, p prefs.Recipient) (*prefs.Store, error) {
+	s.prefsMu.Lock()
+	defer s.prefsMu.Unlock()
+
+	store, err := s.loadPrefs(address, token)
+	if err != nil {
+		return nil, err
+	}
+	store.Set(address, p)
+	return store, store.Save()
+}
+
+// handleManagePage shows the preferences of the recipient of a manage link
+func (s *Server) handleManagePage(w http.ResponseWriter, r *http.Request) {
+	address, token := r.FormValue("email"), r.FormValue("token")
+	store, err := s.loadPrefs(address, token)
+	if err != nil {
+		s.prefsError(w, r, err)
+		return
+	}
+	s.renderManage(w, address, token, store.For(address), store.SentRepos(address), false)
+}
+
+// handleManage saves the preferences submitted from the manage page, or
+// unsubscribes the recipient for a one-click List-Unsubscribe request
+// (RFC 8058) from their mail client
+func (s *Server) handleManage(w http.ResponseWriter, r *http.Request) {
+	address, token := r.FormValue("email"), r.FormValue("token")
+	var p prefs.Recipient
+	if r.FormValue("List-Unsubscribe") == "One-Click" {
+		store, err := s.loadPrefs(address, token)
+		if err != nil {
+			s.prefsError(w, r, err)
+			return
+		}
+		p = store.For(address)
+		p.Unsubscribed = true
+	} else {
+		p = prefs.Recipient{
+			MinSeverity:  r.FormValue("min_severity"),
+			Muted:        r.Form["muted"],
+			Unsubscribed: r.FormValue("unsubscribed") == "true",
+		}
+	}
+	if err := checkPrefs(&p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	store, err := s.setPrefs(address, token, p)
+	if err != nil {
+		s.prefsError(w, r, err)
+		return
+	}
+	s.logger.Info("recipient changed email preferences", "email", address, "min_severity", p.MinSeverity, "muted", p.Muted, "unsubscribed", p.Unsubscribed)
+	s.renderManage(w, address, token, p, store.SentRepos(address), true)
+}
+
+// renderManage shows the manage page of address. Only repos, those of the
+// reports emailed to it, and the ones it muted are listed: a manage link
+// doesn't reveal the rest of the report history.
+func (s *Server) renderManage(w http.ResponseWriter, address, token string, p prefs.Recipient, repos []string, saved bool) {
+	for _, repo := range p.Muted {
+		if !slices.Contains(repos, repo) {
+			repos = append(repos, repo)
+		}
+	}
+	slices.Sort(repos)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	manageTmpl.Execute(w, struct {
+		Email, Token string
+		Prefs        prefs.Recipient
+		Severities   []domain.Severity
+		Repos        []string
+		Muted        func(string) bool
+		Saved        bool
+	}{address, token, p, []domain.Severity{domain.SeverityMedium, domain.SeverityHigh}, repos, func(repo string) bool {
+		return slices.Contains(p.Muted, repo)
+	}, saved})
+}
+
+// handleGetPrefs returns the preferences of the recipient of a manage link
+func (s *Server) handleGetPrefs(w http.ResponseWriter, r *http.Request) {
+	address := r.URL.Query().Get("email")
+	store, err := s.loadPrefs(address, r.URL.Query().Get("token"))
+	if err != nil {
+		s.prefsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, store.For(address))
+}
+
+// handlePutPrefs replaces the preferences of the recipient of a manage
+// link with the JSON body
+func (s *Server) handlePutPrefs(w http.ResponseWriter, r *http.Request) {
+	var p prefs.Recipient
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := checkPrefs(&p); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	address := r.URL.Query().Get("email")
+	if _, err := s.setPrefs(address, r.URL.Query().Get("token"), p); err != nil {
+		s.prefsError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// prefsError answers a failed preferences request: 403 for a bad link,
+// 500 otherwise, as JSON for the API and text for the page
+func (s *Server) prefsError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusForbidden
+	if !errors.Is(err, errBadManageLink) {
+		status = http.StatusInternalServerError
+		s.logger.Error("email preferences", "err", err)
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		writeError(w, status, err)
+		return
+	}
+	http.Error(w, err.Error(), status)
+}
+
+// checkPrefs checks submitted preferences, normalizing the severity
+func checkPrefs(p *prefs.Recipient) error {
+	if p.MinSeverity == "" {
+		return nil
+	}
+	severity, ok := domain.ParseSeverity(p.MinSeverity)
+	if !ok {
+		return errors.New("min_severity must be High, Medium or Low")
+	}
+	p.MinSeverity = string(severity)
+	return nil
+}
+
+// isManagePath reports whether path is authorized by manage link tokens
+// rather than API tokens
+func isManagePath(path string) bool {
+	return path == "/manage" || strings.HasPrefix(path, "/api/preferences")
+}
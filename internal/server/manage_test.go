@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/juparave/codereviewer/internal/prefs"
+)
+
+func TestManageLinkTokens(t *testing.T) {
+	s, _ := newTestServer(t, true)
+	s.config.Email.PreferencesFile = filepath.Join(t.TempDir(), "preferences.yaml")
+
+	store, err := prefs.Load(s.reports().State(), s.config.Email.PreferencesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ana, _, err := store.Token("ana@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ben, _, err := store.Token("ben@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	tampered := "A" + ana[1:]
+	if tampered == ana {
+		tampered = "B" + ana[1:]
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"valid", ana, http.StatusOK},
+		{"tampered", tampered, http.StatusForbidden},
+		{"signed for another recipient", ben, http.StatusForbidden},
+		{"missing", "", http.StatusForbidden},
+	}
+	handler := s.Handler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := url.Values{"email": {"ana@example.com"}, "token": {tt.token}}.Encode()
+
+			// Manage links work without an API token, even with server.auth
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/preferences?"+q, nil))
+			if rec.Code != tt.want {
+				t.Errorf("GET: status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+
+			rec = httptest.NewRecorder()
+			body := strings.NewReader(`{"min_severity": "high", "muted": ["api"]}`)
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/preferences?"+q, body))
+			if rec.Code != tt.want {
+				t.Errorf("PUT: status = %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/manage?"+q, nil))
+			if rec.Code != tt.want {
+				t.Errorf("manage page: status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	// Only the valid link changed Ana's preferences, and Ben's are untouched
+	store, err = prefs.Load(s.reports().State(), s.config.Email.PreferencesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := store.For("ana@example.com"); got.MinSeverity != "High" || len(got.Muted) != 1 {
+		t.Errorf("ana's preferences = %+v, want High with api muted", got)
+	}
+	if got := store.For("ben@example.com"); !got.Default() {
+		t.Errorf("ben's preferences = %+v, want the defaults", got)
+	}
+}
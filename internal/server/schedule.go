@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/config"
+)
+
+// RunSchedules starts a review whenever one of the config's schedules
+// matches, until ctx is cancelled. Schedules are read from the current
+// config every minute, so reloading it changes them. A schedule matching
+// while a review runs is skipped.
+func (s *Server) RunSchedules(ctx context.Context) {
+	s.logSchedules()
+	for {
+		now := time.Now()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(now.Truncate(time.Minute).Add(time.Minute).Sub(now)):
+		}
+		s.startDue(time.Now().Truncate(time.Minute))
+	}
+}
+
+// startDue starts a review for the schedules matching the minute of now.
+// Reviews don't overlap: when several match, the first starts it and the
+// others are logged as skipped.
+func (s *Server) startDue(now time.Time) {
+	cfg := s.currentConfig()
+	exprs, err := cfg.Schedule.Parse()
+	if err != nil {
+		// Validated on load; keep serving
+		s.logger.Error("invalid schedule", "err", err)
+		return
+	}
+	now = now.In(cfg.Location())
+	for i, expr := range exprs {
+		if !expr.Match(now) {
+			continue
+		}
+		schedule := cfg.Schedule[i]
+		if !s.start(&schedule) {
+			s.logger.Warn("skipping scheduled review, a review is already running", "schedule", schedule.Label())
+			continue
+		}
+		s.logger.Info("starting scheduled review", "schedule", schedule.Label(), "next", expr.Next(now))
+	}
+}
+
+// logSchedules logs when each schedule next starts a review
+func (s *Server) logSchedules() {
+	cfg := s.currentConfig()
+	exprs, err := cfg.Schedule.Parse()
+	if err != nil {
+		return
+	}
+	now := time.Now().In(cfg.Location())
+	for i, expr := range exprs {
+		s.logger.Info("scheduled reviews", "schedule", cfg.Schedule[i].Label(), "next", expr.Next(now))
+	}
+}
+
+// currentConfig returns the config reviews start with
+func (s *Server) currentConfig() *config.Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config
+}
@@ -0,0 +1,336 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/app"
+	"github.com/juparave/codereviewer/internal/config"
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/report"
+)
+
+// RunStatus describes the most recent review triggered through the server
+type RunStatus struct {
+	Running    bool      `json:"running"`
+	Schedule   string    `json:"schedule,omitempty"` // The schedule that started it, empty when triggered
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// ReportSummary is the listing entry for a stored report
+type ReportSummary struct {
+	Date         string   `json:"date"`
+	Summary      string   `json:"summary"`
+	Findings     int      `json:"findings"`
+	High         int      `json:"high"`
+	Medium       int      `json:"medium"`
+	Low          int      `json:"low"`
+	CommitCount  int      `json:"commit_count"`
+	Repositories []string `json:"repositories"`
+}
+
+// Server exposes reviews and stored reports over HTTP
+type Server struct {
+	config    *config.Config
+	logger    *slog.Logger
+	formatter *report.Formatter
+	users     report.Users // Set when server.auth is on
+	csrf      string       // Token of the forms and API requests triggering reviews
+
+	mu     sync.Mutex
+	status RunStatus
+
+	prefsMu sync.Mutex // Serializes writes to the preferences file
+}
+
+// New creates a new Server. With server.auth, it opens the user accounts
+// of the reports backend.
+func New(cfg *config.Config, logger *slog.Logger) (*Server, error) {
+	csrf, err := newCSRFToken()
+	if err != nil {
+		return nil, fmt.Errorf("creating CSRF token: %w", err)
+	}
+	s := &Server{
+		config:    cfg,
+		logger:    logger,
+		formatter: report.NewFormatter(cfg.Reports),
+		csrf:      csrf,
+	}
+	if cfg.Server.Auth {
+		users, err := report.OpenUsers(cfg.Reports)
+		if err != nil {
+			return nil, fmt.Errorf("server.auth: %w", err)
+		}
+		s.users = users
+	}
+	return s, nil
+}
+
+// Handler returns the HTTP routes served by the server
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /{$}", s.handleDashboard)
+	mux.HandleFunc("GET /reports/{date}", s.handleReportPage)
+	mux.HandleFunc("POST /run", s.requireCSRF(s.requireLead(s.handleRunForm)))
+
+	mux.HandleFunc("POST /api/runs", s.requireCSRF(s.requireLead(s.handleTrigger)))
+	mux.HandleFunc("GET /api/runs/current", s.handleStatus)
+	mux.HandleFunc("GET /api/reports", s.handleListReports)
+	mux.HandleFunc("GET /api/providers", s.requireLead(s.handleProviders))
+	mux.HandleFunc("GET /api/reports/{date}", s.handleGetReport)
+	mux.HandleFunc("GET /api/reports/{date}/findings", s.handleGetFindings)
+
+	// Recipients of report emails, authorized by their manage link
+	mux.HandleFunc("GET /manage", s.handleManagePage)
+	mux.HandleFunc("POST /manage", s.handleManage)
+	mux.HandleFunc("GET /api/preferences", s.handleGetPrefs)
+	mux.HandleFunc("PUT /api/preferences", s.handlePutPrefs)
+
+	if s.users == nil {
+		return mux
+	}
+	mux.HandleFunc("GET /login", s.handleLoginPage)
+	mux.HandleFunc("POST /login", s.handleLogin)
+	mux.HandleFunc("POST /logout", s.handleLogout)
+	mux.HandleFunc("GET /api/me", s.handleMe)
+	return s.requireUser(mux)
+}
+
+// ListenAndServe serves until ctx is cancelled, then shuts down gracefully
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	s.mu.Lock()
+	addr := s.config.Server.Addr
+	s.mu.Unlock()
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.logger.Info("serving dashboard", "url", "http://"+addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+// Trigger starts a review in the background. It returns false when a
+// review is already running.
+func (s *Server) Trigger() bool {
+	return s.start(nil)
+}
+
+// start starts a review in the background, with the window of schedule
+// when it started the review. It returns false when a review is already
+// running.
+func (s *Server) start(schedule *config.Schedule) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.status.Running {
+		return false
+	}
+	s.status = RunStatus{Running: true, StartedAt: time.Now()}
+	cfg := s.config
+	if schedule != nil {
+		s.status.Schedule = schedule.Label()
+		if schedule.Since != "" {
+			scheduled := *cfg
+			scheduled.Since = schedule.Since
+			cfg = &scheduled
+		}
+	}
+
+	go func() {
+		// Reviews outlive the request that triggered them
+		_, err := app.NewRunner(cfg).Run(context.Background())
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.status.Running = false
+		s.status.FinishedAt = time.Now()
+		if err != nil {
+			s.status.Error = err.Error()
+			s.logger.Error("review failed", "err", err)
+		}
+	}()
+
+	return true
+}
+
+// Reload switches the server to cfg: later reviews and pages use it, and a
+// running review finishes with the configuration it started with. The
+// listen address and server.auth only change on restart. An invalid cfg is
+// rejected and the server keeps its configuration.
+func (s *Server) Reload(cfg *config.Config) error {
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cfg.Server != s.config.Server {
+		s.logger.Warn("server settings changed, restart to apply them", "addr", cfg.Server.Addr, "auth", cfg.Server.Auth)
+		cfg.Server = s.config.Server
+	}
+	s.config = cfg
+	s.formatter = report.NewFormatter(cfg.Reports)
+	return nil
+}
+
+// reports returns the formatter reading stored reports
+func (s *Server) reports() *report.Formatter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.formatter
+}
+
+// Status returns the state of the most recent review
+func (s *Server) Status() RunStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.status
+}
+
+func (s *Server) handleTrigger(w http.ResponseWriter, r *http.Request) {
+	if !s.Trigger() {
+		writeError(w, http.StatusConflict, errors.New("a review is already running"))
+		return
+	}
+	writeJSON(w, http.StatusAccepted, s.Status())
+}
+
+// handleStatus returns the status of the latest review, and the CSRF
+// token POST /api/runs needs in its header
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(csrfHeader, s.csrf)
+	writeJSON(w, http.StatusOK, s.Status())
+}
+
+func (s *Server) handleListReports(w http.ResponseWriter, r *http.Request) {
+	reports, err := s.reports().History()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, summaries(reports, userFrom(r.Context())))
+}
+
+// handleProviders returns the health of the LLM providers used
+func (s *Server) handleProviders(w http.ResponseWriter, r *http.Request) {
+	reports, err := s.reports().History()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	failed, err := s.reports().FailedRuns()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	health := report.ComputeProviderHealth(reports, failed)
+	if health == nil {
+		health = []report.ProviderHealth{}
+	}
+	writeJSON(w, http.StatusOK, health)
+}
+
+func (s *Server) handleGetReport(w http.ResponseWriter, r *http.Request) {
+	rpt, ok := s.loadReport(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, rpt)
+}
+
+func (s *Server) handleGetFindings(w http.ResponseWriter, r *http.Request) {
+	rpt, ok := s.loadReport(w, r)
+	if !ok {
+		return
+	}
+	findings := rpt.Findings
+	if findings == nil {
+		findings = []domain.Finding{}
+	}
+	writeJSON(w, http.StatusOK, findings)
+}
+
+// summaries lists the reports (oldest first) u may see, newest first
+func summaries(reports []*domain.Report, u *report.User) []ReportSummary {
+	summaries := make([]ReportSummary, 0, len(reports))
+	for i := len(reports) - 1; i >= 0; i-- {
+		rpt, ok := report.ViewFor(reports[i], u)
+		if !ok {
+			continue
+		}
+		summaries = append(summaries, ReportSummary{
+			Date:         rpt.Date.Format(report.DateLayout),
+			Summary:      rpt.Summary,
+			Findings:     rpt.TotalFindings(),
+			High:         rpt.HighCount(),
+			Medium:       rpt.MediumCount(),
+			Low:          rpt.LowCount(),
+			CommitCount:  rpt.CommitCount,
+			Repositories: rpt.Repositories,
+		})
+	}
+	return summaries
+}
+
+// loadReport loads the stored report of the request's date as its user
+// may see it, writing an error response on failure
+func (s *Server) loadReport(w http.ResponseWriter, r *http.Request) (*domain.Report, bool) {
+	date := r.PathValue("date")
+	if _, err := time.Parse(report.DateLayout, date); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date))
+		return nil, false
+	}
+
+	rpt, err := s.reports().Load(date)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			writeError(w, http.StatusNotFound, fmt.Errorf("no report for %s", date))
+		} else {
+			writeError(w, http.StatusInternalServerError, err)
+		}
+		return nil, false
+	}
+	view, ok := report.ViewFor(rpt, userFrom(r.Context()))
+	if !ok {
+		// Not found rather than forbidden, so dates don't leak
+		writeError(w, http.StatusNotFound, fmt.Errorf("no report for %s", date))
+		return nil, false
+	}
+	return view, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
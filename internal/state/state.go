@@ -0,0 +1,52 @@
+// Package state keeps the small documents CRA updates as it runs, such as
+// the pause window, run notes, suppressions and recipient preferences:
+// in their own files, or in the reports database so every process of a
+// team hub shares them.
+package state
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Doc locates one state document
+type Doc struct {
+	Key  string      // Its name in a database, e.g. pause
+	Path string      // Its file, as configured
+	Perm fs.FileMode // Of the file, 0600 for documents holding secrets
+}
+
+// Store reads and writes state documents
+type Store interface {
+	// Read returns the document. A missing one wraps fs.ErrNotExist.
+	Read(doc Doc) ([]byte, error)
+	// Write replaces the document
+	Write(doc Doc, data []byte) error
+	// Remove deletes the document; a missing one isn't an error
+	Remove(doc Doc) error
+}
+
+// Files keeps each document in its file
+var Files Store = files{}
+
+type files struct{}
+
+func (files) Read(doc Doc) ([]byte, error) {
+	return os.ReadFile(doc.Path)
+}
+
+func (files) Write(doc Doc, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(doc.Path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(doc.Path, data, doc.Perm)
+}
+
+func (files) Remove(doc Doc) error {
+	if err := os.Remove(doc.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package suppress
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"github.com/juparave/codereviewer/internal/domain"
+	"github.com/juparave/codereviewer/internal/state"
+	"gopkg.in/yaml.v3"
+)
+
+// Entry records an accepted finding that should no longer be reported
+type Entry struct {
+	ID     string    `yaml:"id"` // domain.Finding.Fingerprint
+	Repo   string    `yaml:"repo"`
+	Title  string    `yaml:"title"`
+	Reason string    `yaml:"reason,omitempty"`
+	Added  time.Time `yaml:"added"`
+}
+
+// List is the set of suppressed findings stored in a YAML file
+type List struct {
+	store   state.Store
+	doc     state.Doc
+	Entries []Entry `yaml:"suppressions"`
+}
+
+// Load reads the suppression file at path. A missing file is an empty list.
+func Load(st state.Store, path string) (*List, error) {
+	list := &List{store: st, doc: state.Doc{Key: "suppressions", Path: path, Perm: 0644}}
+
+	data, err := st.Read(list.doc)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return list, nil
+		}
+		return nil, fmt.Errorf("reading suppressions: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, list); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return list, nil
+}
+
+// Save writes the list back to its file
+func (l *List) Save() error {
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	if err := l.store.Write(l.doc, data); err != nil {
+		return fmt.Errorf("writing suppressions: %w", err)
+	}
+	return nil
+}
+
+// Contains reports whether the fingerprint id is suppressed
+func (l *List) Contains(id string) bool {
+	for _, e := range l.Entries {
+		if e.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Add suppresses a finding. It returns false if it was already suppressed.
+func (l *List) Add(f domain.Finding, reason string) bool {
+	id := f.Fingerprint()
+	if l.Contains(id) {
+		return false
+	}
+	l.Entries = append(l.Entries, Entry{
+		ID:     id,
+		Repo:   f.RepoName,
+		Title:  f.Title,
+		Reason: reason,
+		Added:  time.Now(),
+	})
+	return true
+}
+
+// Remove lifts the suppression of id. It returns false if id wasn't suppressed.
+func (l *List) Remove(id string) bool {
+	for i, e := range l.Entries {
+		if e.ID == id {
+			l.Entries = append(l.Entries[:i], l.Entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Filter drops suppressed findings, returning the rest and how many were dropped
+func (l *List) Filter(findings []domain.Finding) ([]domain.Finding, int) {
+	if len(l.Entries) == 0 {
+		return findings, 0
+	}
+
+	var kept []domain.Finding
+	for _, f := range findings {
+		if !l.Contains(f.Fingerprint()) {
+			kept = append(kept, f)
+		}
+	}
+	return kept, len(findings) - len(kept)
+}
@@ -0,0 +1,116 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Ignore is a compiled list of gitignore-style path patterns. As in
+// .gitignore, a pattern without a slash matches a file or directory name
+// at any depth, one with a slash is anchored to the repository root, a
+// trailing slash matches directories only, "**" spans directories and a
+// leading "!" re-includes. The last matching pattern wins.
+type Ignore struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	pattern string // As written, for reporting
+	negate  bool
+	re      *regexp.Regexp
+}
+
+// CompileIgnore compiles patterns, skipping blank lines and # comments
+func CompileIgnore(patterns []string) (*Ignore, error) {
+	ig := &Ignore{}
+	for _, pattern := range patterns {
+		trimmed := strings.TrimSpace(pattern)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		negate := strings.HasPrefix(trimmed, "!")
+		re, err := ignoreRegexp(strings.TrimPrefix(trimmed, "!"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		ig.rules = append(ig.rules, ignoreRule{pattern: trimmed, negate: negate, re: re})
+	}
+	return ig, nil
+}
+
+// Match reports whether path, relative to the repository root with
+// forward slashes, is excluded, and the pattern deciding it
+func (ig *Ignore) Match(path string) (pattern string, excluded bool) {
+	if ig == nil {
+		return "", false
+	}
+	path = strings.TrimPrefix(path, "/")
+	for i := len(ig.rules) - 1; i >= 0; i-- {
+		if rule := ig.rules[i]; rule.re.MatchString(path) {
+			return rule.pattern, !rule.negate
+		}
+	}
+	return "", false
+}
+
+// ignoreRegexp translates one gitignore pattern. The expression matches a
+// file path when the pattern matches the file or one of its directories.
+func ignoreRegexp(pattern string) (*regexp.Regexp, error) {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if strings.Contains(pattern, "/") {
+		pattern = strings.TrimPrefix(pattern, "/")
+	} else {
+		sb.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "/**") && i+3 == len(pattern):
+			sb.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [")
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	if dirOnly {
+		// A directory, so something must follow it
+		sb.WriteString("/.*$")
+	} else {
+		sb.WriteString("(?:/.*)?$")
+	}
+	return regexp.Compile(sb.String())
+}
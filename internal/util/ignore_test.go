@@ -0,0 +1,55 @@
+package util
+
+import "testing"
+
+func TestIgnoreMatch(t *testing.T) {
+	ig, err := CompileIgnore([]string{
+		"# generated code",
+		"",
+		"*.pb.go",
+		"vendor/",
+		"/build",
+		"docs/**/*.md",
+		"!docs/keep.md",
+		"testdata",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		excluded bool
+		pattern  string
+	}{
+		{"api/user.pb.go", true, "*.pb.go"},
+		{"user.go", false, ""},
+		{"vendor/lib/a.go", true, "vendor/"},
+		{"src/vendor/lib/a.go", true, "vendor/"},
+		{"vendor", false, ""},
+		{"build/out.js", true, "/build"},
+		{"src/build/out.js", false, ""},
+		{"docs/guide/intro.md", true, "docs/**/*.md"},
+		{"docs/intro.md", true, "docs/**/*.md"},
+		{"docs/keep.md", false, "!docs/keep.md"},
+		{"pkg/testdata/in.txt", true, "testdata"},
+		{"/api/user.pb.go", true, "*.pb.go"},
+	}
+	for _, tt := range tests {
+		pattern, excluded := ig.Match(tt.path)
+		if excluded != tt.excluded || pattern != tt.pattern {
+			t.Errorf("Match(%q) = %q, %v; want %q, %v", tt.path, pattern, excluded, tt.pattern, tt.excluded)
+		}
+	}
+
+	var none *Ignore
+	if _, excluded := none.Match("a.go"); excluded {
+		t.Error("nil Ignore excluded a path")
+	}
+}
+
+func TestCompileIgnoreInvalid(t *testing.T) {
+	if _, err := CompileIgnore([]string{"[a-"}); err == nil {
+		t.Error("want an error for an unterminated class")
+	}
+}
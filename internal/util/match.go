@@ -0,0 +1,98 @@
+package util
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Patterns is a compiled include/exclude pattern list. A pattern is a glob
+// ("api-*") or, when wrapped in slashes, a regular expression
+// ("/^svc-(a|b)$/"); a leading "!" makes it an exclude pattern.
+type Patterns struct {
+	include []func(string) bool
+	exclude []func(string) bool
+}
+
+// CompilePatterns compiles patterns, ignoring blank entries. With
+// foldCase, globs match case-insensitively and regular expressions get
+// the (?i) flag.
+func CompilePatterns(patterns []string, foldCase bool) (*Patterns, error) {
+	p := &Patterns{}
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+
+		negate := strings.HasPrefix(pattern, "!")
+		m, err := compilePattern(strings.TrimPrefix(pattern, "!"), foldCase)
+		if err != nil {
+			return nil, err
+		}
+		if negate {
+			p.exclude = append(p.exclude, m)
+		} else {
+			p.include = append(p.include, m)
+		}
+	}
+	return p, nil
+}
+
+// Empty reports whether the list matches everything
+func (p *Patterns) Empty() bool {
+	return p == nil || len(p.include) == 0 && len(p.exclude) == 0
+}
+
+// Match reports whether any of names matches an include pattern (or there
+// are none) and none matches an exclude pattern
+func (p *Patterns) Match(names ...string) bool {
+	if p.Empty() {
+		return true
+	}
+
+	matchAny := func(matchers []func(string) bool) bool {
+		for _, m := range matchers {
+			for _, name := range names {
+				if m(name) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if len(p.include) > 0 && !matchAny(p.include) {
+		return false
+	}
+	return !matchAny(p.exclude)
+}
+
+func compilePattern(pattern string, foldCase bool) (func(string) bool, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		expr := pattern[1 : len(pattern)-1]
+		if foldCase {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+		return re.MatchString, nil
+	}
+
+	if foldCase {
+		pattern = strings.ToLower(pattern)
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return func(name string) bool {
+		if foldCase {
+			name = strings.ToLower(name)
+		}
+		ok, _ := path.Match(pattern, name)
+		return ok
+	}, nil
+}